@@ -123,6 +123,20 @@ func (c *Cache) Get(k string) (map[string]string, bool) {
 	return v, ok
 }
 
+// Range 遍历缓存的所有 pod 元数据 f 返回 false 时停止遍历
+//
+// 回调期间持有读锁 f 中不可修改 Cache
+func (c *Cache) Range(f func(ip string, meta map[string]string) bool) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	for ip, meta := range c.cache {
+		if !f(ip, meta) {
+			return
+		}
+	}
+}
+
 type podObject struct {
 	Action    string `json:"action"`
 	ClusterID string `json:"cluster"`
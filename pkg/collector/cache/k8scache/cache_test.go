@@ -71,4 +71,14 @@ func TestCache(t *testing.T) {
 
 	v, ok = c.Get("127.0.0.3")
 	assert.False(t, ok)
+
+	ips := make(map[string]string)
+	c.Range(func(ip string, meta map[string]string) bool {
+		ips[ip] = meta["k8s.pod.name"]
+		return true
+	})
+	assert.Equal(t, map[string]string{
+		"127.0.0.1": "bkm-statefulset-worker-0",
+		"127.0.0.2": "bkm-statefulset-worker-1",
+	}, ips)
 }
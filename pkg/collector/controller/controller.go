@@ -21,6 +21,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/debugtap"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/hook"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/wait"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pingserver"
@@ -441,10 +442,13 @@ loop:
 			start := time.Now()
 			rtype := task.Record().RecordType
 			token := task.Record().Token
+			tr := debugtap.Start(task.PipelineName(), task.Record())
 			for i := 0; i < task.StageCount(); i++ {
 				// 任务执行应该事务的 一旦中间某一环执行失败那就整体失败
 				stage := task.StageAt(i)
+				tr.Before(stage)
 				derivedRecord, err := c.pipelineMgr.GetProcessor(stage).Process(task.Record())
+				tr.After(stage, err)
 				if errors.Is(err, define.ErrSkipEmptyRecord) {
 					DefaultMetricMonitor.IncSkippedCounter(task.PipelineName(), rtype, token.GetDataID(rtype), stage, token.Original)
 					logger.Warnf("skip empty record '%s' at stage: %v, token: %+v, err: %v", rtype, stage, token, err)
@@ -470,6 +474,7 @@ loop:
 			DefaultMetricMonitor.ObserveHandledDuration(start, task.PipelineName(), rtype, token.GetDataID(rtype))
 
 			t0 := time.Now()
+			tr.Exported()
 			exporter.PublishRecord(task.Record())
 
 			// no processors
@@ -501,11 +506,14 @@ loop:
 			start := time.Now()
 			rtype := task.Record().RecordType
 			token := task.Record().Token
+			tr := debugtap.Start(task.PipelineName(), task.Record())
 			for i := 0; i < task.StageCount(); i++ {
 				// 任务执行应该事务的 一旦中间某一环执行失败那就整体失败
 				// 无需再关注是否为 derived 类型
 				stage := task.StageAt(i)
+				tr.Before(stage)
 				_, err := c.pipelineMgr.GetProcessor(stage).Process(task.Record())
+				tr.After(stage, err)
 				if errors.Is(err, define.ErrSkipEmptyRecord) {
 					logger.Warnf("skip empty record '%s' at stage: %v, token: %+v, err: %v", rtype, stage, token, err)
 					DefaultMetricMonitor.IncSkippedCounter(task.PipelineName(), rtype, token.GetDataID(rtype), stage, token.Original)
@@ -525,6 +533,7 @@ loop:
 			DefaultMetricMonitor.ObserveHandledDuration(start, task.PipelineName(), rtype, token.GetDataID(rtype))
 
 			t0 := time.Now()
+			tr.Exported()
 			exporter.PublishRecord(task.Record())
 
 			// no processors
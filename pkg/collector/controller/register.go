@@ -22,17 +22,21 @@ import (
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/probefilter"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/proxyvalidator"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/ratelimiter"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/redactor"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/resourcefilter"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/sampler"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/servicediscover"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/servicegraph"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/textspliter"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tokenchecker"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/beat"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/fta"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/jaeger"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/kafka"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/logpush"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/otlp"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/promscrape"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/pushgateway"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/pyroscope"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/remotewrite"
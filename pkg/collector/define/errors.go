@@ -10,7 +10,15 @@
 package define
 
 import (
+	"math"
+	"strconv"
+	"time"
+
 	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var (
@@ -18,3 +26,55 @@ var (
 	ErrSkipEmptyRecord   = errors.New("bk-collector: skip empty record")
 	ErrEndOfPipeline     = errors.New("bk-collector: end of pipeline")
 )
+
+// RetryAfterError 携带重试建议的错误
+//
+// Http 接口据此设置 Retry-After 头 gRPC 接口返回 ResourceExhausted 并附带 RetryInfo
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func NewRetryAfterError(err error, after time.Duration) error {
+	return &RetryAfterError{Err: err, After: after}
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus 实现 grpc status 接口 使 gRPC 服务端直接返回该错误时能够携带 RetryInfo
+func (e *RetryAfterError) GRPCStatus() *status.Status {
+	st := status.New(codes.ResourceExhausted, e.Error())
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(e.After)})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+// RetryAfter 返回错误链中携带的重试建议
+func RetryAfter(err error) (time.Duration, bool) {
+	var e *RetryAfterError
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	return e.After, true
+}
+
+// RetryAfterSeconds 返回 Retry-After 头取值 不足 1 秒按 1 秒计
+func RetryAfterSeconds(err error) (string, bool) {
+	after, ok := RetryAfter(err)
+	if !ok {
+		return "", false
+	}
+	seconds := int(math.Ceil(after.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds), true
+}
@@ -76,3 +76,11 @@ func (q *EventQueue) Push(events []Event) {
 func (q *EventQueue) Get() <-chan []Event {
 	return q.events
 }
+
+// Usage 返回队列使用率 [0, 1]
+func (q *EventQueue) Usage() float64 {
+	if cap(q.events) == 0 {
+		return 0
+	}
+	return float64(len(q.events)) / float64(cap(q.events))
+}
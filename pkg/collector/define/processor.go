@@ -27,4 +27,6 @@ const (
 	ProcessorTextSpliter     = "text_spliter"
 	ProcessorFieldNormalizer = "field_normalizer"
 	ProcessorMethodFilter    = "method_filter"
+	ProcessorRedactor        = "redactor"
+	ProcessorServiceGraph    = "service_graph"
 )
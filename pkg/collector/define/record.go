@@ -43,6 +43,8 @@ const (
 	SourceBeat        = "beat"
 	SourceTars        = "tars"
 	SourceLogPush     = "logpush"
+	SourcePromScrape  = "promscrape"
+	SourceKafka       = "kafka"
 
 	KeyToken        = "X-BK-TOKEN"
 	KeyDataID       = "X-BK-DATA-ID"
@@ -124,6 +126,7 @@ const (
 	RequestICMP    RequestType = "icmp"
 	RequestDerived RequestType = "derived"
 	RequestTars    RequestType = "tars"
+	RequestKafka   RequestType = "kafka"
 )
 
 type RequestClient struct {
@@ -285,6 +288,14 @@ func (q *RecordQueue) Get() <-chan *Record {
 	return q.records
 }
 
+// Usage 返回队列使用率 [0, 1]
+func (q *RecordQueue) Usage() float64 {
+	if cap(q.records) == 0 {
+		return 0
+	}
+	return float64(len(q.records)) / float64(cap(q.records))
+}
+
 const (
 	TokenAppName = "app_name"
 )
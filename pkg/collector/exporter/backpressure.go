@@ -0,0 +1,108 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package exporter
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/backpressure"
+)
+
+const (
+	backpressureSourceQueue = "exporter_queue"
+	backpressureSourceGse   = "gse_send"
+
+	defaultBackpressureInterval = 5 * time.Second
+	defaultSendLatencyLow       = 50 * time.Millisecond
+	defaultSendLatencyHigh      = time.Second
+
+	// sendLatencyAlpha 发送耗时 EWMA 平滑系数
+	sendLatencyAlpha = 0.2
+)
+
+// BackpressureConfig 背压上报配置
+//
+// 队列使用率直接作为压力值 发送耗时在 [send_latency_low, send_latency_high] 区间线性映射为 [0, 1]
+type BackpressureConfig struct {
+	Interval        time.Duration `config:"interval"`
+	SendLatencyLow  time.Duration `config:"send_latency_low"`
+	SendLatencyHigh time.Duration `config:"send_latency_high"`
+}
+
+func (c *BackpressureConfig) Validate() {
+	if c.Interval <= 0 {
+		c.Interval = defaultBackpressureInterval
+	}
+	if c.SendLatencyLow <= 0 {
+		c.SendLatencyLow = defaultSendLatencyLow
+	}
+	if c.SendLatencyHigh <= c.SendLatencyLow {
+		c.SendLatencyHigh = defaultSendLatencyHigh
+	}
+	if c.SendLatencyHigh <= c.SendLatencyLow {
+		c.SendLatencyHigh = c.SendLatencyLow * 2
+	}
+}
+
+// latencyEWMA 并发安全的发送耗时滑动平均（秒）
+type latencyEWMA struct {
+	bits uint64
+}
+
+func (l *latencyEWMA) observe(d time.Duration) {
+	v := d.Seconds()
+	for {
+		old := atomic.LoadUint64(&l.bits)
+		cur := math.Float64frombits(old)
+		next := v
+		if old != 0 {
+			next = cur + sendLatencyAlpha*(v-cur)
+		}
+		if atomic.CompareAndSwapUint64(&l.bits, old, math.Float64bits(next)) {
+			return
+		}
+	}
+}
+
+func (l *latencyEWMA) value() time.Duration {
+	return time.Duration(math.Float64frombits(atomic.LoadUint64(&l.bits)) * float64(time.Second))
+}
+
+func (e *Exporter) reportBackpressure() {
+	c := e.cfg.Backpressure
+	backpressure.Report(backpressureSourceQueue, math.Max(globalRecords.Usage(), globalEvents.Usage()))
+	backpressure.Report(backpressureSourceGse, backpressure.Ratio(
+		e.sendLatency.value().Seconds(),
+		c.SendLatencyLow.Seconds(),
+		c.SendLatencyHigh.Seconds(),
+	))
+}
+
+// loopBackpressure 调用方需先执行 e.wg.Add(1)
+func (e *Exporter) loopBackpressure() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.cfg.Backpressure.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.reportBackpressure()
+
+		case <-e.ctx.Done():
+			backpressure.Remove(backpressureSourceQueue)
+			backpressure.Remove(backpressureSourceGse)
+			return
+		}
+	}
+}
@@ -15,7 +15,10 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/converter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/kafka"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/otlp"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/queue"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/wal"
 )
 
 // 不同类型的数据大小不同 所以队列大小要单独调整
@@ -30,9 +33,13 @@ const (
 )
 
 type Config struct {
-	MaxMessageBytes int              `config:"max_message_bytes"`
-	Queue           queue.Config     `config:"queue"`
-	Converter       converter.Config `config:"converter"`
+	MaxMessageBytes int                `config:"max_message_bytes"`
+	Queue           queue.Config       `config:"queue"`
+	Converter       converter.Config   `config:"converter"`
+	Wal             wal.Config         `config:"wal"`
+	Otlp            otlp.Config        `config:"otlp"`
+	Kafka           kafka.Config       `config:"kafka"`
+	Backpressure    BackpressureConfig `config:"backpressure"`
 }
 
 func (c *Config) Validate() {
@@ -57,6 +64,9 @@ func (c *Config) Validate() {
 	if c.MaxMessageBytes <= 0 {
 		c.MaxMessageBytes = defaultMaxMessageBytes
 	}
+	c.Wal.Validate()
+	c.Otlp.Validate()
+	c.Backpressure.Validate()
 }
 
 type SubConfig struct {
@@ -17,7 +17,10 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/converter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/kafka"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/otlp"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/queue"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/wal"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/wait"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/libgse/beat"
@@ -35,8 +38,12 @@ type Exporter struct {
 	wg        sync.WaitGroup
 	converter converter.Converter
 	queue     queue.Queue
+	otlp      *otlp.Exporter
+	kafka     *kafka.Exporter
 	cfg       *Config
 	batches   map[string]queue.Config // 无并发读写 无需锁保护
+
+	sendLatency latencyEWMA
 }
 
 var globalRecords = define.NewRecordQueue(define.PushModeGuarantee)
@@ -78,17 +85,53 @@ func New(conf *confengine.Config) (*Exporter, error) {
 	exp.queue = queue.NewBatchQueue(c.Queue, func(s string) queue.Config {
 		return exp.batches[s]
 	})
+
+	// 开启磁盘缓冲后 事件先落盘再回放至 BatchQueue
+	if c.Wal.Enabled {
+		dq, err := wal.New(c.Wal, exp.queue)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		exp.queue = dq
+	}
+
+	if c.Otlp.Enabled {
+		otlpExp, err := otlp.New(c.Otlp)
+		if err != nil {
+			exp.queue.Close()
+			cancel()
+			return nil, err
+		}
+		exp.otlp = otlpExp
+	}
+
+	if c.Kafka.Enabled {
+		kafkaExp, err := kafka.New(&c.Kafka)
+		if err != nil {
+			exp.queue.Close()
+			cancel()
+			return nil, err
+		}
+		exp.kafka = kafkaExp
+	}
 	return exp, nil
 }
 
 func (e *Exporter) Start() error {
 	logger.Info("exporter start working...")
 
+	if e.otlp != nil {
+		e.otlp.Start()
+	}
+
 	for i := 0; i < define.Concurrency(); i++ {
 		go wait.Until(e.ctx, e.consumeRecords)
 		go wait.Until(e.ctx, e.consumeEvents)
 		go wait.Until(e.ctx, e.sendEvents)
 	}
+	e.wg.Add(1)
+	go e.loopBackpressure()
 	return nil
 }
 
@@ -123,7 +166,13 @@ func (e *Exporter) consumeRecords() {
 	for {
 		select {
 		case record := <-globalRecords.Get():
-			e.converter.Convert(record, PublishEvents)
+			if e.otlp == nil || !e.cfg.Otlp.DisableGse {
+				e.converter.Convert(record, PublishEvents)
+			}
+			// otlp 转发会移动 record 中的数据 因此须在 converter 之后执行
+			if e.otlp != nil {
+				e.otlp.Export(record)
+			}
 
 		case <-e.ctx.Done():
 			return
@@ -138,8 +187,23 @@ func (e *Exporter) sendEvents() {
 	for {
 		select {
 		case event := <-e.queue.Pop():
+			if e.kafka != nil {
+				sent := e.kafka.Send(event)
+				if e.cfg.Kafka.DisableGse {
+					if sent {
+						continue
+					}
+					// 关闭 gse 时发送失败的事件仅在开启 fallback_gse 时改为发送至 gse
+					if !e.cfg.Kafka.FallbackGse {
+						e.kafka.Drop(event)
+						continue
+					}
+				}
+			}
+
 			start := time.Now()
 			SentFunc(event)
+			e.sendLatency.observe(time.Since(start))
 			DefaultMetricMonitor.ObserveSentDuration(start)
 			DefaultMetricMonitor.IncSentCounter()
 
@@ -154,4 +218,10 @@ func (e *Exporter) Stop() {
 	e.converter.Clean()
 	e.cancel()
 	e.wg.Wait()
+	if e.otlp != nil {
+		e.otlp.Stop()
+	}
+	if e.kafka != nil {
+		e.kafka.Stop()
+	}
 }
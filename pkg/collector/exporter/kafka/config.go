@@ -0,0 +1,128 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/kafkaclient"
+)
+
+const (
+	// TopicDataIDPlaceholder topic 中的占位符 发送时替换为事件 dataid
+	TopicDataIDPlaceholder = "{dataid}"
+
+	defaultTopic           = "bk_collector_" + TopicDataIDPlaceholder
+	defaultRequiredAcks    = "leader"
+	defaultMaxMessageBytes = 10 * 1024 * 1024 // 10MB
+	defaultTimeout         = 10 * time.Second
+	defaultMaxRetries      = 3
+)
+
+// Config kafka 导出配置
+//
+// 开启后 exporter 批量队列输出的事件会以 json 编码写入 kafka topic 消息 key 为 dataid
+// disable_gse 为 true 时事件不再发送至 gse
+// 此时 kafka 发送失败（生产者已重试 max_retries 次）的事件会被丢弃并计数 fallback_gse 为 true 时改为发送至 gse
+type Config struct {
+	Enabled         bool               `config:"enabled"`
+	DisableGse      bool               `config:"disable_gse"`
+	FallbackGse     bool               `config:"fallback_gse"`
+	Client          kafkaclient.Config `config:"client"`
+	Topic           string             `config:"topic"`
+	RequiredAcks    string             `config:"required_acks"` // none/leader/all
+	Compression     string             `config:"compression"`   // none/gzip/snappy/lz4/zstd
+	MaxMessageBytes int                `config:"max_message_bytes"`
+	Timeout         time.Duration      `config:"timeout"`
+	MaxRetries      int                `config:"max_retries"`
+}
+
+func (c *Config) normalize() error {
+	if err := c.Client.Normalize(); err != nil {
+		return err
+	}
+	if c.Topic == "" {
+		c.Topic = defaultTopic
+	}
+	if c.RequiredAcks == "" {
+		c.RequiredAcks = defaultRequiredAcks
+	}
+	if _, err := requiredAcks(c.RequiredAcks); err != nil {
+		return err
+	}
+	if _, err := compressionCodec(c.Compression); err != nil {
+		return err
+	}
+	if c.MaxMessageBytes <= 0 {
+		c.MaxMessageBytes = defaultMaxMessageBytes
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = defaultMaxRetries
+	}
+	return nil
+}
+
+func requiredAcks(s string) (sarama.RequiredAcks, error) {
+	switch s {
+	case "none":
+		return sarama.NoResponse, nil
+	case "leader":
+		return sarama.WaitForLocal, nil
+	case "all":
+		return sarama.WaitForAll, nil
+	}
+	return sarama.NoResponse, errors.Errorf("unsupported required_acks '%s'", s)
+}
+
+func compressionCodec(s string) (sarama.CompressionCodec, error) {
+	switch s {
+	case "", "none":
+		return sarama.CompressionNone, nil
+	case "gzip":
+		return sarama.CompressionGZIP, nil
+	case "snappy":
+		return sarama.CompressionSnappy, nil
+	case "lz4":
+		return sarama.CompressionLZ4, nil
+	case "zstd":
+		return sarama.CompressionZSTD, nil
+	}
+	return sarama.CompressionNone, errors.Errorf("unsupported compression '%s'", s)
+}
+
+func (c *Config) saramaConfig() (*sarama.Config, error) {
+	sc, err := c.Client.SaramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	codec, err := compressionCodec(c.Compression)
+	if err != nil {
+		return nil, err
+	}
+	acks, err := requiredAcks(c.RequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.Producer.Return.Successes = true
+	sc.Producer.Return.Errors = true
+	sc.Producer.RequiredAcks = acks
+	sc.Producer.Compression = codec
+	sc.Producer.MaxMessageBytes = c.MaxMessageBytes
+	sc.Producer.Timeout = c.Timeout
+	sc.Producer.Retry.Max = c.MaxRetries
+	return sc, nil
+}
@@ -0,0 +1,109 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+// newSyncProducer 创建同步生产者 单测中替换为 sarama mocks 实现
+var newSyncProducer = func(c *Config) (sarama.SyncProducer, error) {
+	sc, err := c.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	return sarama.NewSyncProducer(c.Client.Brokers, sc)
+}
+
+// Exporter 将 exporter 队列输出的事件写入 kafka
+//
+// 使用同步生产者 调用方（sendEvents 协程）本身即为并发发送 无需额外的发送队列
+type Exporter struct {
+	config   *Config
+	producer sarama.SyncProducer
+}
+
+func New(c *Config) (*Exporter, error) {
+	if err := c.normalize(); err != nil {
+		return nil, err
+	}
+	producer, err := newSyncProducer(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "create kafka producer failed")
+	}
+	logger.Infof("kafka exporter connected to %v, topic=%s", c.Client.Brokers, c.Topic)
+	return &Exporter{config: c, producer: producer}, nil
+}
+
+func eventDataID(event common.MapStr) string {
+	v, ok := event["dataid"]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func (e *Exporter) topic(dataID string) string {
+	return strings.ReplaceAll(e.config.Topic, TopicDataIDPlaceholder, dataID)
+}
+
+// Send 发送单个事件 返回是否发送成功
+func (e *Exporter) Send(event common.MapStr) bool {
+	dataID := eventDataID(event)
+	topic := e.topic(dataID)
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		DefaultMetricMonitor.IncSentFailedCounter(topic)
+		logger.Errorf("kafka exporter failed to marshal event, dataid=%s: %v", dataID, err)
+		return false
+	}
+
+	start := time.Now()
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(b),
+	}
+	if dataID != "" {
+		msg.Key = sarama.StringEncoder(dataID)
+	}
+	if _, _, err = e.producer.SendMessage(msg); err != nil {
+		DefaultMetricMonitor.IncSentFailedCounter(topic)
+		logger.Warnf("kafka exporter failed to send message, topic=%s: %v", topic, err)
+		return false
+	}
+
+	DefaultMetricMonitor.ObserveSentDuration(topic, start)
+	DefaultMetricMonitor.IncSentCounter(topic, len(b))
+	return true
+}
+
+// Drop 记录发送失败且不再发送至 gse 的事件
+func (e *Exporter) Drop(event common.MapStr) {
+	dataID := eventDataID(event)
+	topic := e.topic(dataID)
+	DefaultMetricMonitor.IncDroppedCounter(topic)
+	logger.Warnf("kafka exporter dropped event, dataid=%s, topic=%s", dataID, topic)
+}
+
+func (e *Exporter) Stop() {
+	if err := e.producer.Close(); err != nil {
+		logger.Warnf("failed to close kafka producer: %v", err)
+	}
+}
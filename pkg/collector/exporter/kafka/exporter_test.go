@@ -0,0 +1,111 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/kafkaclient"
+)
+
+func newTestExporter(t *testing.T, c *Config) (*Exporter, *mocks.SyncProducer) {
+	producer := mocks.NewSyncProducer(t, nil)
+	newSyncProducer = func(*Config) (sarama.SyncProducer, error) {
+		return producer, nil
+	}
+	exp, err := New(c)
+	assert.NoError(t, err)
+	return exp, producer
+}
+
+func TestConfigNormalize(t *testing.T) {
+	client := kafkaclient.Config{Brokers: []string{"127.0.0.1:9092"}}
+
+	c := Config{Client: client}
+	assert.NoError(t, c.normalize())
+	assert.Equal(t, defaultTopic, c.Topic)
+	assert.Equal(t, defaultRequiredAcks, c.RequiredAcks)
+	assert.Equal(t, defaultMaxMessageBytes, c.MaxMessageBytes)
+
+	sc, err := c.saramaConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, sarama.WaitForLocal, sc.Producer.RequiredAcks)
+	assert.True(t, sc.Producer.Return.Successes)
+
+	c = Config{Client: client, Compression: "brotli"}
+	assert.Error(t, c.normalize())
+
+	c = Config{Client: client, RequiredAcks: "majority"}
+	assert.Error(t, c.normalize())
+
+	c = Config{}
+	assert.Error(t, c.normalize())
+}
+
+func TestExporterSend(t *testing.T) {
+	exp, producer := newTestExporter(t, &Config{
+		Client: kafkaclient.Config{Brokers: []string{"127.0.0.1:9092"}},
+	})
+
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		if msg.Topic != "bk_collector_1001" {
+			return errors.Errorf("unexpected topic %s", msg.Topic)
+		}
+		key, _ := msg.Key.Encode()
+		if string(key) != "1001" {
+			return errors.Errorf("unexpected key %s", key)
+		}
+		value, _ := msg.Value.Encode()
+		if string(value) != `{"data":[{"metrics":{"cpu":1}}],"dataid":1001}` {
+			return errors.Errorf("unexpected value %s", value)
+		}
+		return nil
+	})
+	assert.True(t, exp.Send(common.MapStr{
+		"dataid": int32(1001),
+		"data":   []common.MapStr{{"metrics": common.MapStr{"cpu": 1}}},
+	}))
+
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	assert.False(t, exp.Send(common.MapStr{"dataid": int32(1001)}))
+
+	dropped := droppedTotal.WithLabelValues("bk_collector_1001")
+	before := testutil.ToFloat64(dropped)
+	exp.Drop(common.MapStr{"dataid": int32(1001)})
+	assert.Equal(t, before+1, testutil.ToFloat64(dropped))
+
+	exp.Stop()
+}
+
+func TestExporterFixedTopic(t *testing.T) {
+	exp, producer := newTestExporter(t, &Config{
+		Client: kafkaclient.Config{Brokers: []string{"127.0.0.1:9092"}},
+		Topic:  "bk_collector_events",
+	})
+
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		if msg.Topic != "bk_collector_events" {
+			return errors.Errorf("unexpected topic %s", msg.Topic)
+		}
+		if msg.Key != nil {
+			return errors.New("unexpected key")
+		}
+		return nil
+	})
+	assert.True(t, exp.Send(common.MapStr{"foo": "bar"}))
+	exp.Stop()
+}
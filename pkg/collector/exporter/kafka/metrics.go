@@ -0,0 +1,88 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+var (
+	sentTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_kafka_sent_total",
+			Help:      "Exporter kafka sent total",
+		},
+		[]string{"topic"},
+	)
+
+	sentBytesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_kafka_sent_bytes_total",
+			Help:      "Exporter kafka sent bytes total",
+		},
+		[]string{"topic"},
+	)
+
+	sentFailedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_kafka_sent_failed_total",
+			Help:      "Exporter kafka sent failed total",
+		},
+		[]string{"topic"},
+	)
+
+	droppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_kafka_dropped_total",
+			Help:      "Exporter kafka dropped total",
+		},
+		[]string{"topic"},
+	)
+
+	sentDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_kafka_sent_duration_seconds",
+			Help:      "Exporter kafka sent duration seconds",
+			Buckets:   define.DefObserveDuration,
+		},
+		[]string{"topic"},
+	)
+)
+
+var DefaultMetricMonitor = &metricMonitor{}
+
+type metricMonitor struct{}
+
+func (m *metricMonitor) IncSentCounter(topic string, bytes int) {
+	sentTotal.WithLabelValues(topic).Inc()
+	sentBytesTotal.WithLabelValues(topic).Add(float64(bytes))
+}
+
+func (m *metricMonitor) IncSentFailedCounter(topic string) {
+	sentFailedTotal.WithLabelValues(topic).Inc()
+}
+
+func (m *metricMonitor) IncDroppedCounter(topic string) {
+	droppedTotal.WithLabelValues(topic).Inc()
+}
+
+func (m *metricMonitor) ObserveSentDuration(topic string, t time.Time) {
+	sentDuration.WithLabelValues(topic).Observe(time.Since(t).Seconds())
+}
@@ -0,0 +1,286 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+const (
+	pathTraces  = "/v1/traces"
+	pathMetrics = "/v1/metrics"
+	pathLogs    = "/v1/logs"
+)
+
+// Client 负责将批次数据发送至一个 OTLP 目标地址
+type Client interface {
+	Export(ctx context.Context, b *batch) error
+	Endpoint() string
+	Close() error
+}
+
+// permanentError 标记不可重试的错误 如数据格式错误或者鉴权失败
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var pe permanentError
+	return errors.As(err, &pe)
+}
+
+func newClient(conf ClientConfig) (Client, error) {
+	if conf.Endpoint == "" {
+		return nil, errors.New("empty otlp endpoint")
+	}
+
+	switch conf.Protocol {
+	case ProtocolHttp:
+		return newHttpClient(conf)
+	default:
+		return newGrpcClient(conf)
+	}
+}
+
+func loadTLSConfig(conf TLSConfig) (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+		ServerName:         conf.ServerName,
+	}
+
+	if conf.CaFile != "" {
+		b, err := os.ReadFile(conf.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf("failed to parse ca file: %s", conf.CaFile)
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConf, nil
+}
+
+type grpcClient struct {
+	conf    ClientConfig
+	conn    *grpc.ClientConn
+	traces  ptraceotlp.GRPCClient
+	metrics pmetricotlp.GRPCClient
+	logs    plogotlp.GRPCClient
+}
+
+func newGrpcClient(conf ClientConfig) (*grpcClient, error) {
+	creds := insecure.NewCredentials()
+	if conf.TLS.Enabled {
+		tlsConf, err := loadTLSConfig(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConf)
+	}
+
+	// Dial 不会阻塞等待连接建立 目标不可用时在 Export 阶段返回错误并重试
+	conn, err := grpc.Dial(conf.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcClient{
+		conf:    conf,
+		conn:    conn,
+		traces:  ptraceotlp.NewClient(conn),
+		metrics: pmetricotlp.NewClient(conn),
+		logs:    plogotlp.NewClient(conn),
+	}, nil
+}
+
+func (c *grpcClient) Endpoint() string {
+	return c.conf.Endpoint
+}
+
+func (c *grpcClient) Export(ctx context.Context, b *batch) error {
+	ctx, cancel := context.WithTimeout(ctx, c.conf.Timeout)
+	defer cancel()
+
+	md := metadata.New(c.conf.Headers)
+	if c.conf.ForwardToken && b.key.token != "" {
+		md.Set(define.KeyToken, b.key.token)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	var err error
+	switch data := b.data.(type) {
+	case ptrace.Traces:
+		_, err = c.traces.Export(ctx, ptraceotlp.NewRequestFromTraces(data))
+	case pmetric.Metrics:
+		_, err = c.metrics.Export(ctx, pmetricotlp.NewRequestFromMetrics(data))
+	case plog.Logs:
+		_, err = c.logs.Export(ctx, plogotlp.NewRequestFromLogs(data))
+	default:
+		return permanent(errors.Errorf("unsupported data type %T", b.data))
+	}
+
+	if err == nil {
+		return nil
+	}
+	if isRetryableCode(status.Code(err)) {
+		return err
+	}
+	return permanent(err)
+}
+
+// isRetryableCode 参考 OTLP 规范中可重试的 gRPC 状态码
+func isRetryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Canceled, codes.DeadlineExceeded, codes.Aborted, codes.OutOfRange,
+		codes.Unavailable, codes.DataLoss, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func (c *grpcClient) Close() error {
+	return c.conn.Close()
+}
+
+type httpClient struct {
+	conf    ClientConfig
+	baseURL string
+	client  *http.Client
+}
+
+func newHttpClient(conf ClientConfig) (*httpClient, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	scheme := "http://"
+	if conf.TLS.Enabled {
+		tlsConf, err := loadTLSConfig(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConf
+		scheme = "https://"
+	}
+
+	baseURL := strings.TrimSuffix(conf.Endpoint, "/")
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = scheme + baseURL
+	}
+
+	return &httpClient{
+		conf:    conf,
+		baseURL: baseURL,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   conf.Timeout,
+		},
+	}, nil
+}
+
+func (c *httpClient) Endpoint() string {
+	return c.conf.Endpoint
+}
+
+func (c *httpClient) Export(ctx context.Context, b *batch) error {
+	var path string
+	var body []byte
+	var err error
+
+	switch data := b.data.(type) {
+	case ptrace.Traces:
+		path = pathTraces
+		body, err = ptraceotlp.NewRequestFromTraces(data).MarshalProto()
+	case pmetric.Metrics:
+		path = pathMetrics
+		body, err = pmetricotlp.NewRequestFromMetrics(data).MarshalProto()
+	case plog.Logs:
+		path = pathLogs
+		body, err = plogotlp.NewRequestFromLogs(data).MarshalProto()
+	default:
+		return permanent(errors.Errorf("unsupported data type %T", b.data))
+	}
+	if err != nil {
+		return permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set(define.ContentType, define.ContentTypeProtobuf)
+	for k, v := range c.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	if c.conf.ForwardToken && b.key.token != "" {
+		req.Header.Set(define.KeyToken, b.key.token)
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return nil
+	}
+
+	err = errors.Errorf("otlp http export failed, code=%d", rsp.StatusCode)
+	switch rsp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return err
+	}
+	return permanent(err)
+}
+
+func (c *httpClient) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,117 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"time"
+)
+
+const (
+	ProtocolGrpc = "grpc"
+	ProtocolHttp = "http"
+)
+
+const (
+	defaultBatchSize       = 500
+	defaultFlushInterval   = 3 * time.Second
+	defaultTimeout         = 10 * time.Second
+	defaultWorkers         = 4
+	defaultQueueSize       = 128
+	defaultMaxAttempts     = 5
+	defaultInitialInterval = 500 * time.Millisecond
+	defaultMaxInterval     = 10 * time.Second
+)
+
+// Config OTLP 导出配置
+//
+// 开启后 Record 会以 OTLP 协议转发至指定地址 可通过 routes 为不同 token 指定不同的目标
+// disable_gse 为 true 时数据不再发送至 gse 此时 collector 仅作为中继使用
+type Config struct {
+	Enabled       bool          `config:"enabled"`
+	DisableGse    bool          `config:"disable_gse"`
+	BatchSize     int           `config:"batch_size"`
+	FlushInterval time.Duration `config:"flush_interval"`
+	Workers       int           `config:"workers"`
+	QueueSize     int           `config:"queue_size"`
+	Retry         RetryConfig   `config:"retry"`
+	Client        ClientConfig  `config:"client"`
+	Routes        []RouteConfig `config:"routes"`
+}
+
+// ClientConfig 描述一个 OTLP 目标地址
+type ClientConfig struct {
+	Protocol string            `config:"protocol"`
+	Endpoint string            `config:"endpoint"`
+	Headers  map[string]string `config:"headers"`
+	Timeout  time.Duration     `config:"timeout"`
+	// ForwardToken 为 true 时将原始 token 以 X-BK-TOKEN 头透传 下游为 bk-collector 时使用
+	ForwardToken bool      `config:"forward_token"`
+	TLS          TLSConfig `config:"tls"`
+}
+
+type TLSConfig struct {
+	Enabled            bool   `config:"enabled"`
+	InsecureSkipVerify bool   `config:"insecure_skip_verify"`
+	CaFile             string `config:"ca_file"`
+	CertFile           string `config:"cert_file"`
+	KeyFile            string `config:"key_file"`
+	ServerName         string `config:"server_name"`
+}
+
+// RouteConfig 指定 tokens 使用的目标地址 未命中任何路由的数据使用默认 Client
+type RouteConfig struct {
+	Tokens []string     `config:"tokens"`
+	Client ClientConfig `config:"client"`
+}
+
+type RetryConfig struct {
+	Disabled        bool          `config:"disabled"`
+	MaxAttempts     int           `config:"max_attempts"`
+	InitialInterval time.Duration `config:"initial_interval"`
+	MaxInterval     time.Duration `config:"max_interval"`
+}
+
+func (c *ClientConfig) Validate() {
+	if c.Protocol != ProtocolHttp {
+		c.Protocol = ProtocolGrpc
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+}
+
+func (c *Config) Validate() {
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultFlushInterval
+	}
+	if c.Workers <= 0 {
+		c.Workers = defaultWorkers
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultQueueSize
+	}
+	if c.Retry.MaxAttempts <= 0 {
+		c.Retry.MaxAttempts = defaultMaxAttempts
+	}
+	if c.Retry.InitialInterval <= 0 {
+		c.Retry.InitialInterval = defaultInitialInterval
+	}
+	if c.Retry.MaxInterval <= 0 {
+		c.Retry.MaxInterval = defaultMaxInterval
+	}
+
+	c.Client.Validate()
+	for i := 0; i < len(c.Routes); i++ {
+		c.Routes[i].Client.Validate()
+	}
+}
@@ -0,0 +1,345 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	reasonUnsupported = "unsupported"
+	reasonNoRoute     = "no_route"
+	reasonEmpty       = "empty"
+)
+
+type batchKey struct {
+	client int
+	token  string
+	rtype  define.RecordType
+}
+
+// batch 相同目标地址 token 以及数据类型的 Record 会合并成一个批次发送
+type batch struct {
+	key   batchKey
+	data  any
+	count int
+}
+
+func newBatch(key batchKey) *batch {
+	b := &batch{key: key}
+	switch key.rtype {
+	case define.RecordTraces:
+		b.data = ptrace.NewTraces()
+	case define.RecordMetrics:
+		b.data = pmetric.NewMetrics()
+	case define.RecordLogs:
+		b.data = plog.NewLogs()
+	}
+	return b
+}
+
+// merge 将 data 移动至批次中 调用后 data 不可再被使用
+func (b *batch) merge(data any) {
+	switch dst := b.data.(type) {
+	case ptrace.Traces:
+		src := data.(ptrace.Traces)
+		b.count += src.SpanCount()
+		src.ResourceSpans().MoveAndAppendTo(dst.ResourceSpans())
+	case pmetric.Metrics:
+		src := data.(pmetric.Metrics)
+		b.count += src.DataPointCount()
+		src.ResourceMetrics().MoveAndAppendTo(dst.ResourceMetrics())
+	case plog.Logs:
+		src := data.(plog.Logs)
+		b.count += src.LogRecordCount()
+		src.ResourceLogs().MoveAndAppendTo(dst.ResourceLogs())
+	}
+}
+
+// normalizeRecordType 将 Record 类型归一为 OTLP 的三种信号类型
+//
+// profiles 在当前 pdata 版本中没有对应的 OTLP 信号 暂不支持转发
+func normalizeRecordType(record *define.Record) define.RecordType {
+	switch record.RecordType {
+	case define.RecordTraces, define.RecordTracesDerived:
+		if _, ok := record.Data.(ptrace.Traces); ok {
+			return define.RecordTraces
+		}
+	case define.RecordMetrics, define.RecordMetricsDerived:
+		if _, ok := record.Data.(pmetric.Metrics); ok {
+			return define.RecordMetrics
+		}
+	case define.RecordLogs, define.RecordLogsDerived:
+		if _, ok := record.Data.(plog.Logs); ok {
+			return define.RecordLogs
+		}
+	}
+	return define.RecordUndefined
+}
+
+func countOf(data any) int {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		return d.SpanCount()
+	case pmetric.Metrics:
+		return d.DataPointCount()
+	case plog.Logs:
+		return d.LogRecordCount()
+	}
+	return 0
+}
+
+// Exporter 将 Record 以 OTLP 协议转发至其他后端
+type Exporter struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	flushWg sync.WaitGroup
+	conf    Config
+	mut     sync.Mutex
+	clients []Client
+	routes  map[string]int
+	batches map[batchKey]*batch
+	ch      chan *batch
+	done    chan struct{}
+}
+
+// New 创建 Exporter 未配置默认地址时仅转发命中 routes 的数据
+func New(conf Config) (*Exporter, error) {
+	conf.Validate()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Exporter{
+		ctx:     ctx,
+		cancel:  cancel,
+		conf:    conf,
+		routes:  make(map[string]int),
+		batches: make(map[batchKey]*batch),
+		ch:      make(chan *batch, conf.QueueSize),
+		done:    make(chan struct{}),
+	}
+
+	// clients[0] 为默认地址 可能为空
+	var defaultClient Client
+	if conf.Client.Endpoint != "" {
+		c, err := newClient(conf.Client)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		defaultClient = c
+	}
+	e.clients = append(e.clients, defaultClient)
+
+	for _, route := range conf.Routes {
+		c, err := newClient(route.Client)
+		if err != nil {
+			e.closeClients()
+			cancel()
+			return nil, errors.Wrapf(err, "failed to create client for route %v", route.Tokens)
+		}
+		e.clients = append(e.clients, c)
+		for _, token := range route.Tokens {
+			e.routes[token] = len(e.clients) - 1
+		}
+	}
+	return e, nil
+}
+
+func (e *Exporter) closeClients() {
+	for _, c := range e.clients {
+		if c == nil {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			logger.Warnf("otlp exporter: failed to close client %s: %v", c.Endpoint(), err)
+		}
+	}
+}
+
+func (e *Exporter) Start() {
+	for i := 0; i < e.conf.Workers; i++ {
+		e.wg.Add(1)
+		go e.sendLoop()
+	}
+
+	e.flushWg.Add(1)
+	go e.flushLoop()
+}
+
+func (e *Exporter) route(token string) (int, bool) {
+	if idx, ok := e.routes[token]; ok {
+		return idx, true
+	}
+	return 0, e.clients[0] != nil
+}
+
+// Export 将 Record 加入批次 批次满时投递至发送队列
+func (e *Exporter) Export(record *define.Record) {
+	rtype := normalizeRecordType(record)
+	if rtype == define.RecordUndefined {
+		DefaultMetricMonitor.IncSkippedCounter(record.RecordType, reasonUnsupported)
+		return
+	}
+
+	idx, ok := e.route(record.Token.Original)
+	if !ok {
+		DefaultMetricMonitor.IncSkippedCounter(rtype, reasonNoRoute)
+		return
+	}
+	if countOf(record.Data) == 0 {
+		DefaultMetricMonitor.IncSkippedCounter(rtype, reasonEmpty)
+		return
+	}
+
+	key := batchKey{client: idx, token: record.Token.Original, rtype: rtype}
+
+	e.mut.Lock()
+	b, ok := e.batches[key]
+	if !ok {
+		b = newBatch(key)
+		e.batches[key] = b
+	}
+	b.merge(record.Data)
+
+	var full *batch
+	if b.count >= e.conf.BatchSize {
+		full = b
+		delete(e.batches, key)
+	}
+	e.mut.Unlock()
+
+	if full != nil {
+		e.enqueue(full)
+	}
+}
+
+// enqueue 投递批次至发送队列 队列已满时直接丢弃
+//
+// Export 运行在 exporter 主流程中 OTLP 后端异常时不能阻塞 gse 发送
+func (e *Exporter) enqueue(b *batch) {
+	select {
+	case e.ch <- b:
+	default:
+		logger.Warnf("otlp exporter: queue is full, drop %d %s, token=%s", b.count, b.key.rtype, b.key.token)
+		DefaultMetricMonitor.AddDroppedCounter(b.key.rtype, b.count)
+	}
+}
+
+func (e *Exporter) takeBatches() []*batch {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	batches := make([]*batch, 0, len(e.batches))
+	for _, b := range e.batches {
+		batches = append(batches, b)
+	}
+	e.batches = make(map[batchKey]*batch)
+	return batches
+}
+
+func (e *Exporter) flushLoop() {
+	defer e.flushWg.Done()
+
+	ticker := time.NewTicker(e.conf.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, b := range e.takeBatches() {
+				e.enqueue(b)
+			}
+
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (e *Exporter) sendLoop() {
+	defer e.wg.Done()
+
+	for b := range e.ch {
+		e.send(b)
+	}
+}
+
+// send 发送批次 可重试错误按照指数退避重试
+func (e *Exporter) send(b *batch) {
+	c := e.clients[b.key.client]
+	endpoint := c.Endpoint()
+	interval := e.conf.Retry.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := c.Export(e.ctx, b)
+		DefaultMetricMonitor.ObserveSentDuration(endpoint, start)
+		if err == nil {
+			DefaultMetricMonitor.IncSentCounter(endpoint, b.key.rtype, b.count)
+			return
+		}
+
+		if e.conf.Retry.Disabled || isPermanent(err) || attempt >= e.conf.Retry.MaxAttempts {
+			logger.Errorf("otlp exporter: failed to send %s to %s, token=%s, attempts=%d: %v", b.key.rtype, endpoint, b.key.token, attempt, err)
+			DefaultMetricMonitor.IncSentFailedCounter(endpoint, b.key.rtype)
+			return
+		}
+
+		DefaultMetricMonitor.IncRetriedCounter(endpoint, b.key.rtype)
+		select {
+		case <-time.After(interval):
+		case <-e.ctx.Done():
+			DefaultMetricMonitor.IncSentFailedCounter(endpoint, b.key.rtype)
+			return
+		}
+
+		interval *= 2
+		if interval > e.conf.Retry.MaxInterval {
+			interval = e.conf.Retry.MaxInterval
+		}
+	}
+}
+
+// Stop 发送剩余批次后退出 调用方需保证 Stop 之后不再调用 Export
+func (e *Exporter) Stop() {
+	close(e.done)
+	e.flushWg.Wait()
+
+	for _, b := range e.takeBatches() {
+		e.ch <- b
+	}
+	close(e.ch)
+
+	// 最多等待一个发送超时周期 超时后取消仍在退避重试中的批次
+	stopped := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(e.conf.Client.Timeout):
+		e.cancel()
+		<-stopped
+	}
+	e.cancel()
+	e.closeClients()
+}
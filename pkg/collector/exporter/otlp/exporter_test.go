@@ -0,0 +1,226 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+)
+
+type testTracesServer struct {
+	mut    sync.Mutex
+	spans  int
+	tokens []string
+}
+
+func (s *testTracesServer) Export(ctx context.Context, req ptraceotlp.Request) (ptraceotlp.Response, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.spans += req.Traces().SpanCount()
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.tokens = append(s.tokens, md.Get(define.KeyToken)...)
+	return ptraceotlp.NewResponse(), nil
+}
+
+func (s *testTracesServer) SpanCount() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.spans
+}
+
+func newTracesRecord(token string, spanCount int) *define.Record {
+	g := generator.NewTracesGenerator(define.TracesOptions{
+		SpanCount: spanCount,
+		SpanKind:  1,
+	})
+	return &define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{Original: token},
+		Data:       g.Generate(),
+	}
+}
+
+func TestExporterGrpc(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := &testTracesServer{}
+	server := grpc.NewServer()
+	ptraceotlp.RegisterServer(server, srv)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	exp, err := New(Config{
+		BatchSize:     20,
+		FlushInterval: time.Minute,
+		Client: ClientConfig{
+			Protocol:     ProtocolGrpc,
+			Endpoint:     lis.Addr().String(),
+			ForwardToken: true,
+		},
+	})
+	assert.NoError(t, err)
+	exp.Start()
+
+	for i := 0; i < 5; i++ {
+		exp.Export(newTracesRecord("token1", 10))
+	}
+	exp.Stop()
+
+	assert.Equal(t, 50, srv.SpanCount())
+	assert.Equal(t, []string{"token1", "token1", "token1"}, srv.tokens)
+}
+
+func TestExporterHttpRoutes(t *testing.T) {
+	var mut sync.Mutex
+	paths := make(map[string]int)
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			req := ptraceotlp.NewRequest()
+			assert.NoError(t, req.UnmarshalProto(b))
+
+			mut.Lock()
+			paths[name+r.URL.Path] += req.Traces().SpanCount()
+			mut.Unlock()
+		}))
+	}
+	defaultSvr := newServer("default")
+	defer defaultSvr.Close()
+	routeSvr := newServer("route")
+	defer routeSvr.Close()
+
+	exp, err := New(Config{
+		FlushInterval: 100 * time.Millisecond,
+		Client: ClientConfig{
+			Protocol: ProtocolHttp,
+			Endpoint: defaultSvr.URL,
+		},
+		Routes: []RouteConfig{
+			{
+				Tokens: []string{"token2"},
+				Client: ClientConfig{Protocol: ProtocolHttp, Endpoint: routeSvr.URL},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	exp.Start()
+
+	exp.Export(newTracesRecord("token1", 10))
+	exp.Export(newTracesRecord("token2", 5))
+	time.Sleep(500 * time.Millisecond)
+	exp.Stop()
+
+	assert.Equal(t, map[string]int{
+		"default/v1/traces": 10,
+		"route/v1/traces":   5,
+	}, paths)
+}
+
+func TestExporterHttpRetry(t *testing.T) {
+	var mut sync.Mutex
+	var calls int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer svr.Close()
+
+	exp, err := New(Config{
+		BatchSize: 1,
+		Retry: RetryConfig{
+			InitialInterval: 10 * time.Millisecond,
+		},
+		Client: ClientConfig{
+			Protocol: ProtocolHttp,
+			Endpoint: svr.URL,
+		},
+	})
+	assert.NoError(t, err)
+	exp.Start()
+	exp.Export(newTracesRecord("token1", 1))
+	exp.Stop()
+
+	assert.Equal(t, 3, calls)
+}
+
+func TestExporterSkipped(t *testing.T) {
+	exp, err := New(Config{})
+	assert.NoError(t, err)
+	exp.Start()
+	defer exp.Stop()
+
+	// 没有默认地址且未命中路由
+	exp.Export(newTracesRecord("token1", 1))
+	// 不支持的数据类型
+	exp.Export(&define.Record{RecordType: define.RecordProfiles})
+	assert.Len(t, exp.takeBatches(), 0)
+}
+
+func TestExporterQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer svr.Close()
+
+	exp, err := New(Config{
+		BatchSize: 1,
+		Workers:   1,
+		QueueSize: 1,
+		Retry:     RetryConfig{Disabled: true},
+		Client: ClientConfig{
+			Protocol: ProtocolHttp,
+			Endpoint: svr.URL,
+		},
+	})
+	assert.NoError(t, err)
+	exp.Start()
+
+	dropped := testutil.ToFloat64(droppedTotal.WithLabelValues(define.RecordTraces.S()))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// 1 个批次发送中 1 个批次在队列中 其余批次被丢弃
+		for i := 0; i < 10; i++ {
+			exp.Export(newTracesRecord("token1", 1))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("export blocked by otlp backend")
+	}
+	assert.GreaterOrEqual(t, testutil.ToFloat64(droppedTotal.WithLabelValues(define.RecordTraces.S()))-dropped, float64(8))
+
+	close(release)
+	exp.Stop()
+}
@@ -0,0 +1,114 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+var (
+	sentTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_sent_total",
+			Help:      "Exporter otlp sent total",
+		},
+		[]string{"endpoint", "record_type"},
+	)
+
+	sentItemsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_sent_items_total",
+			Help:      "Exporter otlp sent items total",
+		},
+		[]string{"endpoint", "record_type"},
+	)
+
+	sentFailedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_sent_failed_total",
+			Help:      "Exporter otlp sent failed total",
+		},
+		[]string{"endpoint", "record_type"},
+	)
+
+	retriedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_retried_total",
+			Help:      "Exporter otlp retried total",
+		},
+		[]string{"endpoint", "record_type"},
+	)
+
+	droppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_dropped_total",
+			Help:      "Exporter otlp dropped items total",
+		},
+		[]string{"record_type"},
+	)
+
+	skippedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_skipped_total",
+			Help:      "Exporter otlp skipped records total",
+		},
+		[]string{"record_type", "reason"},
+	)
+
+	sentDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_sent_duration_seconds",
+			Help:      "Exporter otlp sent duration seconds",
+			Buckets:   define.DefObserveDuration,
+		},
+		[]string{"endpoint"},
+	)
+)
+
+var DefaultMetricMonitor = &metricMonitor{}
+
+type metricMonitor struct{}
+
+func (m *metricMonitor) IncSentCounter(endpoint string, rtype define.RecordType, items int) {
+	sentTotal.WithLabelValues(endpoint, rtype.S()).Inc()
+	sentItemsTotal.WithLabelValues(endpoint, rtype.S()).Add(float64(items))
+}
+
+func (m *metricMonitor) IncSentFailedCounter(endpoint string, rtype define.RecordType) {
+	sentFailedTotal.WithLabelValues(endpoint, rtype.S()).Inc()
+}
+
+func (m *metricMonitor) IncRetriedCounter(endpoint string, rtype define.RecordType) {
+	retriedTotal.WithLabelValues(endpoint, rtype.S()).Inc()
+}
+
+func (m *metricMonitor) AddDroppedCounter(rtype define.RecordType, items int) {
+	droppedTotal.WithLabelValues(rtype.S()).Add(float64(items))
+}
+
+func (m *metricMonitor) IncSkippedCounter(rtype define.RecordType, reason string) {
+	skippedTotal.WithLabelValues(rtype.S(), reason).Inc()
+}
+
+func (m *metricMonitor) ObserveSentDuration(endpoint string, t time.Time) {
+	sentDuration.WithLabelValues(endpoint).Observe(time.Since(t).Seconds())
+}
@@ -0,0 +1,54 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package wal
+
+import (
+	"time"
+)
+
+const (
+	defaultPath              = "./data/wal"
+	defaultMaxSegmentBytes   = 64 * 1024 * 1024       // 64MB
+	defaultMaxBytesPerDataID = 2 * 1024 * 1024 * 1024 // 2GB
+	defaultMaxAge            = 6 * time.Hour
+	defaultSyncInterval      = time.Second
+)
+
+// Config 磁盘缓冲配置
+//
+// 开启后 exporter 会先将事件写入按 dataid 划分的分段日志 再由后台协程回放至内存队列
+// 当 gse 发送阻塞时数据会积压在磁盘中 进程重启后从 checkpoint 处继续回放
+// checkpoint 在数据进入内存队列后推进 进程崩溃时内存队列中尚未发送的数据会丢失
+type Config struct {
+	Enabled           bool          `config:"enabled" mapstructure:"enabled"`
+	Path              string        `config:"path" mapstructure:"path"`
+	MaxSegmentBytes   int64         `config:"max_segment_bytes" mapstructure:"max_segment_bytes"`
+	MaxBytesPerDataID int64         `config:"max_bytes_per_dataid" mapstructure:"max_bytes_per_dataid"`
+	MaxAge            time.Duration `config:"max_age" mapstructure:"max_age"`
+	SyncInterval      time.Duration `config:"sync_interval" mapstructure:"sync_interval"`
+}
+
+func (c *Config) Validate() {
+	if c.Path == "" {
+		c.Path = defaultPath
+	}
+	if c.MaxSegmentBytes <= 0 {
+		c.MaxSegmentBytes = defaultMaxSegmentBytes
+	}
+	if c.MaxBytesPerDataID <= 0 {
+		c.MaxBytesPerDataID = defaultMaxBytesPerDataID
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = defaultMaxAge
+	}
+	if c.SyncInterval <= 0 {
+		c.SyncInterval = defaultSyncInterval
+	}
+}
@@ -0,0 +1,104 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package wal
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+var (
+	walBacklogBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_wal_backlog_bytes",
+			Help:      "Exporter wal backlog bytes",
+		},
+		[]string{"id"},
+	)
+
+	walSegments = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_wal_segments",
+			Help:      "Exporter wal segments count",
+		},
+		[]string{"id"},
+	)
+
+	walWrittenBytesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_wal_written_bytes_total",
+			Help:      "Exporter wal written bytes total",
+		},
+		[]string{"id"},
+	)
+
+	walReplayedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_wal_replayed_total",
+			Help:      "Exporter wal replayed entries total",
+		},
+		[]string{"id"},
+	)
+
+	walDroppedBytesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_wal_dropped_bytes_total",
+			Help:      "Exporter wal dropped bytes total",
+		},
+		[]string{"id", "reason"},
+	)
+
+	walFailedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_wal_failed_total",
+			Help:      "Exporter wal failed total",
+		},
+		[]string{"id"},
+	)
+)
+
+var DefaultMetricMonitor = &metricMonitor{}
+
+type metricMonitor struct{}
+
+func (m *metricMonitor) SetBacklog(dataId int32, bytes int64, segments int) {
+	id := strconv.Itoa(int(dataId))
+	walBacklogBytes.WithLabelValues(id).Set(float64(bytes))
+	walSegments.WithLabelValues(id).Set(float64(segments))
+}
+
+func (m *metricMonitor) AddWrittenBytes(dataId int32, n int) {
+	walWrittenBytesTotal.WithLabelValues(strconv.Itoa(int(dataId))).Add(float64(n))
+}
+
+func (m *metricMonitor) IncReplayedCounter(dataId int32) {
+	walReplayedTotal.WithLabelValues(strconv.Itoa(int(dataId))).Inc()
+}
+
+func (m *metricMonitor) AddDroppedBytes(dataId int32, reason string, n int64) {
+	if n <= 0 {
+		return
+	}
+	walDroppedBytesTotal.WithLabelValues(strconv.Itoa(int(dataId)), reason).Add(float64(n))
+}
+
+func (m *metricMonitor) IncFailedCounter(dataId int32) {
+	walFailedTotal.WithLabelValues(strconv.Itoa(int(dataId))).Inc()
+}
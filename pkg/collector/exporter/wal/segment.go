@@ -0,0 +1,393 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package wal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	segmentSuffix  = ".seg"
+	checkpointFile = "checkpoint"
+	headerSize     = 8 // 4 bytes 长度 + 4 bytes crc32
+)
+
+var errEmpty = errors.New("segment log empty")
+
+type segment struct {
+	seq     uint64
+	size    int64
+	modTime time.Time
+}
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%020d%s", seq, segmentSuffix)
+}
+
+// position 描述读取位置 用于确认消费后再推进 checkpoint
+type position struct {
+	seq    uint64
+	offset int64
+}
+
+// segmentLog 单个 dataid 的分段日志
+//
+// 目录结构：
+// <dir>/00000000000000000001.seg
+// <dir>/00000000000000000002.seg
+// <dir>/checkpoint
+//
+// 每条 entry 格式为 [length(4B)][crc32(4B)][payload]
+// 写入永远追加到最后一个 segment 读取从 checkpoint 记录的位置开始
+type segmentLog struct {
+	mut             sync.Mutex
+	dir             string
+	maxSegmentBytes int64
+	segments        []*segment
+	writer          *os.File
+	reader          *os.File
+	read            position
+	notify          chan struct{}
+}
+
+func openSegmentLog(dir string, maxSegmentBytes int64) (*segmentLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	l := &segmentLog{
+		dir:             dir,
+		maxSegmentBytes: maxSegmentBytes,
+		notify:          make(chan struct{}, 1),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		l.segments = append(l.segments, &segment{seq: seq, size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(l.segments, func(i, j int) bool {
+		return l.segments[i].seq < l.segments[j].seq
+	})
+
+	l.read = l.loadCheckpoint()
+	l.trimConsumed()
+
+	// 重启后总是新建 segment 写入 避免追加到上次进程退出时可能写坏的文件末尾
+	if err := l.rotate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *segmentLog) loadCheckpoint() position {
+	var pos position
+	b, err := os.ReadFile(filepath.Join(l.dir, checkpointFile))
+	if err != nil {
+		return pos
+	}
+	if _, err := fmt.Sscanf(string(b), "%d %d", &pos.seq, &pos.offset); err != nil {
+		logger.Warnf("wal: invalid checkpoint in %s: %v", l.dir, err)
+		return position{}
+	}
+	return pos
+}
+
+// trimConsumed 删除 checkpoint 之前已经消费完毕的 segment
+func (l *segmentLog) trimConsumed() {
+	idx := 0
+	for idx < len(l.segments) && l.segments[idx].seq < l.read.seq {
+		l.removeFile(l.segments[idx].seq)
+		idx++
+	}
+	l.segments = l.segments[idx:]
+
+	if len(l.segments) > 0 && l.segments[0].seq != l.read.seq {
+		l.read = position{seq: l.segments[0].seq}
+	}
+}
+
+func (l *segmentLog) removeFile(seq uint64) {
+	if err := os.Remove(filepath.Join(l.dir, segmentName(seq))); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("wal: remove segment %d in %s failed: %v", seq, l.dir, err)
+	}
+}
+
+func (l *segmentLog) lastSegment() *segment {
+	if len(l.segments) == 0 {
+		return nil
+	}
+	return l.segments[len(l.segments)-1]
+}
+
+func (l *segmentLog) rotate() error {
+	if l.writer != nil {
+		if err := l.writer.Sync(); err != nil {
+			logger.Warnf("wal: sync segment in %s failed: %v", l.dir, err)
+		}
+		_ = l.writer.Close()
+		l.writer = nil
+	}
+
+	var seq uint64 = 1
+	if last := l.lastSegment(); last != nil {
+		seq = last.seq + 1
+	}
+	f, err := os.OpenFile(filepath.Join(l.dir, segmentName(seq)), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	l.writer = f
+	l.segments = append(l.segments, &segment{seq: seq, modTime: time.Now()})
+	if len(l.segments) == 1 {
+		l.read = position{seq: seq}
+	}
+	return nil
+}
+
+// Append 追加 payload 到最后一个 segment
+func (l *segmentLog) Append(payload []byte) error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if l.writer == nil {
+		return errors.New("segment log closed")
+	}
+
+	if last := l.lastSegment(); last.size > 0 && last.size+int64(headerSize+len(payload)) > l.maxSegmentBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[headerSize:], payload)
+
+	n, err := l.writer.Write(buf)
+	last := l.lastSegment()
+	last.size += int64(n)
+	last.modTime = time.Now()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Next 读取下一条 entry 但不推进读取位置 调用方需在确认消费后调用 Commit
+func (l *segmentLog) Next() ([]byte, position, error) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	for {
+		if len(l.segments) == 0 {
+			return nil, l.read, errEmpty
+		}
+
+		seg := l.segments[0]
+		isLast := seg == l.lastSegment()
+		if l.reader == nil {
+			f, err := os.Open(filepath.Join(l.dir, segmentName(seg.seq)))
+			if err != nil {
+				return nil, l.read, err
+			}
+			l.reader = f
+		}
+
+		payload, err := l.readAt(seg)
+		if err == nil {
+			return payload, position{seq: seg.seq, offset: l.read.offset + int64(headerSize+len(payload))}, nil
+		}
+		if isLast {
+			return nil, l.read, errEmpty
+		}
+
+		// 非写入中的 segment 读到末尾（或者文件尾部损坏）则切换到下一个 segment
+		if !errors.Is(err, errEmpty) {
+			logger.Warnf("wal: segment %d in %s broken at offset %d: %v", seg.seq, l.dir, l.read.offset, err)
+		}
+		l.dropFirst()
+	}
+}
+
+func (l *segmentLog) readAt(seg *segment) ([]byte, error) {
+	if l.read.offset+headerSize > seg.size {
+		return nil, errEmpty
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := l.reader.ReadAt(header, l.read.offset); err != nil {
+		return nil, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if l.read.offset+headerSize+length > seg.size {
+		return nil, errors.Errorf("truncated entry, length=%d", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := l.reader.ReadAt(payload, l.read.offset+headerSize); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, errors.New("checksum mismatch")
+	}
+	return payload, nil
+}
+
+// dropFirst 删除第一个 segment 并将读取位置移动到下一个 segment 开头
+// 调用方需持有锁 且保证第一个 segment 不是正在写入的 segment
+func (l *segmentLog) dropFirst() int64 {
+	seg := l.segments[0]
+	remain := seg.size - l.read.offset
+	if l.reader != nil {
+		_ = l.reader.Close()
+		l.reader = nil
+	}
+	l.removeFile(seg.seq)
+
+	l.segments = l.segments[1:]
+	if len(l.segments) > 0 {
+		l.read = position{seq: l.segments[0].seq}
+	}
+	if remain < 0 {
+		remain = 0
+	}
+	return remain
+}
+
+// Commit 推进读取位置
+func (l *segmentLog) Commit(pos position) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	// 期间 segment 可能已被淘汰
+	if pos.seq != l.read.seq || pos.offset < l.read.offset {
+		return
+	}
+	l.read = pos
+}
+
+// Backlog 返回尚未消费的字节数以及 segment 数量
+func (l *segmentLog) Backlog() (int64, int) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	var total int64
+	for _, seg := range l.segments {
+		total += seg.size
+	}
+	return total - l.read.offset, len(l.segments)
+}
+
+// Enforce 按照容量以及时间淘汰最旧的 segment 返回淘汰的未消费字节数
+func (l *segmentLog) Enforce(maxBytes int64, maxAge time.Duration) (int64, int64) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	var total int64
+	for _, seg := range l.segments {
+		total += seg.size
+	}
+	total -= l.read.offset
+
+	var bySize, byAge int64
+	now := time.Now()
+	for len(l.segments) > 1 {
+		seg := l.segments[0]
+		switch {
+		case maxBytes > 0 && total > maxBytes:
+			n := l.dropFirst()
+			bySize += n
+			total -= n
+		case maxAge > 0 && now.Sub(seg.modTime) > maxAge:
+			n := l.dropFirst()
+			byAge += n
+			total -= n
+		default:
+			return bySize, byAge
+		}
+	}
+	return bySize, byAge
+}
+
+// Sync 刷盘并保存 checkpoint
+func (l *segmentLog) Sync() error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	return l.sync()
+}
+
+func (l *segmentLog) sync() error {
+	if l.writer != nil {
+		if err := l.writer.Sync(); err != nil {
+			return err
+		}
+	}
+
+	tmp := filepath.Join(l.dir, checkpointFile+".tmp")
+	content := fmt.Sprintf("%d %d", l.read.seq, l.read.offset)
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(l.dir, checkpointFile))
+}
+
+// Close 关闭所有文件句柄并保存 checkpoint
+func (l *segmentLog) Close() error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	err := l.sync()
+	if l.writer != nil {
+		_ = l.writer.Close()
+		l.writer = nil
+	}
+	if l.reader != nil {
+		_ = l.reader.Close()
+		l.reader = nil
+	}
+	return err
+}
@@ -0,0 +1,297 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package wal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/queue"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	reasonSize = "size"
+	reasonAge  = "age"
+)
+
+// entry 是写入磁盘的数据单元 对应一次 Put 调用
+type entry struct {
+	DataID     int32             `json:"dataid"`
+	RecordType define.RecordType `json:"record_type"`
+	Token      define.Token      `json:"token"`
+	Data       []common.MapStr   `json:"data"`
+}
+
+type replayEvent struct {
+	define.CommonEvent
+	rtype define.RecordType
+}
+
+func (e replayEvent) RecordType() define.RecordType {
+	return e.rtype
+}
+
+func encodeEvents(events []define.Event) ([]byte, error) {
+	first := events[0]
+	ent := entry{
+		DataID:     first.DataId(),
+		RecordType: first.RecordType(),
+		Token:      first.Token(),
+		Data:       make([]common.MapStr, 0, len(events)),
+	}
+	for _, event := range events {
+		ent.Data = append(ent.Data, event.Data())
+	}
+	return json.Marshal(ent)
+}
+
+func decodeEvents(b []byte) ([]define.Event, error) {
+	var ent entry
+	// 使用 json.Number 避免大整数（如纳秒时间戳）精度丢失
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&ent); err != nil {
+		return nil, err
+	}
+
+	events := make([]define.Event, 0, len(ent.Data))
+	for _, data := range ent.Data {
+		events = append(events, replayEvent{
+			CommonEvent: define.NewCommonEvent(ent.Token, ent.DataID, data),
+			rtype:       ent.RecordType,
+		})
+	}
+	return events, nil
+}
+
+// DiskQueue 在 BatchQueue 前面增加磁盘缓冲
+//
+// Put 将事件写入 dataid 对应的 segmentLog 后立即返回 每个 dataid 拥有独立的回放协程
+// 负责将事件按顺序投递到下游队列 下游阻塞时数据积压在磁盘中而不是内存中
+//
+// 读取位置在事件投递到下游内存队列后即推进 而不是在 gse 确认发送之后
+// beat.Send 为异步发布 没有确认回调 因此已推进但仍在 BatchQueue 及 libbeat 内存队列中的数据
+// 在进程崩溃时会丢失 丢失量上限为内存队列容量 磁盘缓冲保证的是下游阻塞期间的积压不丢失
+// 正常退出时若下游未接收数据则不推进读取位置 下次启动重新回放 可能出现少量重复数据
+type DiskQueue struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	mut    sync.Mutex
+	conf   Config
+	next   queue.Queue
+	logs   map[int32]*segmentLog
+}
+
+var _ queue.Queue = (*DiskQueue)(nil)
+
+// New 创建 DiskQueue 并回放磁盘中遗留的数据
+func New(conf Config, next queue.Queue) (*DiskQueue, error) {
+	conf.Validate()
+	if err := os.MkdirAll(conf.Path, 0o755); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dq := &DiskQueue{
+		ctx:    ctx,
+		cancel: cancel,
+		conf:   conf,
+		next:   next,
+		logs:   make(map[int32]*segmentLog),
+	}
+
+	entries, err := os.ReadDir(conf.Path)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			continue
+		}
+		dataID, err := strconv.Atoi(ent.Name())
+		if err != nil {
+			continue
+		}
+		if _, err := dq.getOrCreateLog(int32(dataID)); err != nil {
+			logger.Errorf("wal: failed to open segment log of dataid %d: %v", dataID, err)
+			continue
+		}
+		logger.Infof("wal: replay segment log of dataid %d", dataID)
+	}
+
+	dq.wg.Add(1)
+	go dq.maintain()
+	return dq, nil
+}
+
+func (dq *DiskQueue) getOrCreateLog(dataID int32) (*segmentLog, error) {
+	dq.mut.Lock()
+	defer dq.mut.Unlock()
+
+	if l, ok := dq.logs[dataID]; ok {
+		return l, nil
+	}
+	if dq.ctx.Err() != nil {
+		return nil, dq.ctx.Err()
+	}
+
+	dir := filepath.Join(dq.conf.Path, strconv.Itoa(int(dataID)))
+	l, err := openSegmentLog(dir, dq.conf.MaxSegmentBytes)
+	if err != nil {
+		return nil, err
+	}
+	dq.logs[dataID] = l
+
+	dq.wg.Add(1)
+	go dq.replay(dataID, l)
+	return l, nil
+}
+
+func (dq *DiskQueue) snapshot() map[int32]*segmentLog {
+	dq.mut.Lock()
+	defer dq.mut.Unlock()
+
+	logs := make(map[int32]*segmentLog, len(dq.logs))
+	for k, v := range dq.logs {
+		logs[k] = v
+	}
+	return logs
+}
+
+// Put 写入磁盘 写入失败时降级为直接投递到下游队列
+func (dq *DiskQueue) Put(events ...define.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	dataID := events[0].DataId()
+	fallback := func(err error) {
+		logger.Errorf("wal: failed to write dataid %d, fallback to memory queue: %v", dataID, err)
+		DefaultMetricMonitor.IncFailedCounter(dataID)
+		dq.next.Put(events...)
+	}
+
+	l, err := dq.getOrCreateLog(dataID)
+	if err != nil {
+		fallback(err)
+		return
+	}
+
+	b, err := encodeEvents(events)
+	if err != nil {
+		fallback(err)
+		return
+	}
+	if err := l.Append(b); err != nil {
+		fallback(err)
+		return
+	}
+	DefaultMetricMonitor.AddWrittenBytes(dataID, len(b))
+}
+
+func (dq *DiskQueue) replay(dataID int32, l *segmentLog) {
+	defer dq.wg.Done()
+
+	for {
+		b, pos, err := l.Next()
+		if err != nil {
+			if err != errEmpty {
+				logger.Errorf("wal: failed to read dataid %d: %v", dataID, err)
+			}
+			select {
+			case <-l.notify:
+			case <-time.After(dq.conf.SyncInterval):
+			case <-dq.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		events, err := decodeEvents(b)
+		if err != nil {
+			logger.Errorf("wal: failed to decode dataid %d entry: %v", dataID, err)
+			l.Commit(pos)
+			continue
+		}
+
+		if len(events) > 0 {
+			dq.next.Put(events...)
+		}
+
+		// 此处仅代表数据进入内存队列 见 DiskQueue 注释中的丢失窗口说明
+		// 退出过程中下游可能未真正接收数据 不推进读取位置 下次启动重新回放
+		if dq.ctx.Err() != nil {
+			return
+		}
+		l.Commit(pos)
+		DefaultMetricMonitor.IncReplayedCounter(dataID)
+	}
+}
+
+// maintain 周期性刷盘 淘汰超限数据并上报积压指标
+func (dq *DiskQueue) maintain() {
+	defer dq.wg.Done()
+
+	ticker := time.NewTicker(dq.conf.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for dataID, l := range dq.snapshot() {
+				bySize, byAge := l.Enforce(dq.conf.MaxBytesPerDataID, dq.conf.MaxAge)
+				DefaultMetricMonitor.AddDroppedBytes(dataID, reasonSize, bySize)
+				DefaultMetricMonitor.AddDroppedBytes(dataID, reasonAge, byAge)
+
+				if err := l.Sync(); err != nil {
+					logger.Warnf("wal: failed to sync dataid %d: %v", dataID, err)
+				}
+				n, segments := l.Backlog()
+				DefaultMetricMonitor.SetBacklog(dataID, n, segments)
+			}
+
+		case <-dq.ctx.Done():
+			return
+		}
+	}
+}
+
+func (dq *DiskQueue) Pop() <-chan common.MapStr {
+	return dq.next.Pop()
+}
+
+// Close 停止回放并保存 checkpoint 可重复调用
+func (dq *DiskQueue) Close() {
+	dq.once.Do(func() {
+		dq.cancel()
+		// 下游队列关闭后阻塞在 Put 的回放协程才能退出
+		dq.next.Close()
+		dq.wg.Wait()
+
+		for dataID, l := range dq.snapshot() {
+			if err := l.Close(); err != nil {
+				logger.Warnf("wal: failed to close dataid %d: %v", dataID, err)
+			}
+		}
+	})
+}
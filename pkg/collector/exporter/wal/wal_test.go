@@ -0,0 +1,180 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package wal
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/queue"
+)
+
+type testTracesEvent struct {
+	define.CommonEvent
+}
+
+func (t testTracesEvent) RecordType() define.RecordType {
+	return define.RecordTraces
+}
+
+func newTestBatchQueue() queue.Queue {
+	conf := queue.Config{
+		TracesBatchSize: 1000,
+		FlushInterval:   100 * time.Millisecond,
+	}
+	return queue.NewBatchQueue(conf, func(s string) queue.Config {
+		return queue.Config{}
+	})
+}
+
+func newTestEvents(dataID int32, n int) []define.Event {
+	events := make([]define.Event, 0, n)
+	for i := 0; i < n; i++ {
+		events = append(events, testTracesEvent{
+			CommonEvent: define.NewCommonEvent(define.Token{Original: "token1"}, dataID, common.MapStr{"count": i}),
+		})
+	}
+	return events
+}
+
+func popItems(t *testing.T, q queue.Queue, expected int) int {
+	var total int
+	timeout := time.After(5 * time.Second)
+	for total < expected {
+		select {
+		case ms := <-q.Pop():
+			v, err := ms.GetValue("items")
+			assert.NoError(t, err)
+			total += len(v.([]common.MapStr))
+		case <-timeout:
+			return total
+		}
+	}
+	return total
+}
+
+func TestEncodeDecodeEvents(t *testing.T) {
+	events := []define.Event{
+		testTracesEvent{
+			CommonEvent: define.NewCommonEvent(define.Token{Original: "token1"}, 1001, common.MapStr{
+				"start_time": int64(1700000000123456789),
+			}),
+		},
+	}
+
+	b, err := encodeEvents(events)
+	assert.NoError(t, err)
+
+	decoded, err := decodeEvents(b)
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 1)
+	assert.Equal(t, int32(1001), decoded[0].DataId())
+	assert.Equal(t, define.RecordTraces, decoded[0].RecordType())
+	assert.Equal(t, "token1", decoded[0].Token().Original)
+	assert.Equal(t, json.Number("1700000000123456789"), decoded[0].Data()["start_time"])
+}
+
+func TestDiskQueuePutPop(t *testing.T) {
+	dq, err := New(Config{Path: t.TempDir(), SyncInterval: 100 * time.Millisecond}, newTestBatchQueue())
+	assert.NoError(t, err)
+	defer dq.Close()
+
+	for i := 0; i < 10; i++ {
+		dq.Put(newTestEvents(1001, 10)...)
+		dq.Put(newTestEvents(1002, 10)...)
+	}
+	assert.Equal(t, 200, popItems(t, dq, 200))
+}
+
+func TestDiskQueueReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	// 模拟 gse 阻塞 数据只写入磁盘
+	l, err := openSegmentLog(dir+"/1001", defaultMaxSegmentBytes)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		b, err := encodeEvents(newTestEvents(1001, 10))
+		assert.NoError(t, err)
+		assert.NoError(t, l.Append(b))
+	}
+	assert.NoError(t, l.Close())
+
+	dq, err := New(Config{Path: dir, SyncInterval: 100 * time.Millisecond}, newTestBatchQueue())
+	assert.NoError(t, err)
+	assert.Equal(t, 50, popItems(t, dq, 50))
+
+	// 等待 checkpoint 推进后关闭 再次启动不应重复回放
+	time.Sleep(300 * time.Millisecond)
+	dq.Close()
+
+	l, err = openSegmentLog(dir+"/1001", defaultMaxSegmentBytes)
+	assert.NoError(t, err)
+	defer l.Close()
+	_, _, err = l.Next()
+	assert.Equal(t, errEmpty, err)
+}
+
+func TestSegmentLogRotateAndCommit(t *testing.T) {
+	l, err := openSegmentLog(t.TempDir(), 64)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, l.Append([]byte("0123456789012345678901234567890123456789")))
+	}
+	n, segments := l.Backlog()
+	assert.Equal(t, int64(480), n)
+	assert.Equal(t, 10, segments)
+
+	var count int
+	for {
+		b, pos, err := l.Next()
+		if err != nil {
+			assert.Equal(t, errEmpty, err)
+			break
+		}
+		assert.Len(t, b, 40)
+		l.Commit(pos)
+		count++
+	}
+	assert.Equal(t, 10, count)
+
+	n, segments = l.Backlog()
+	assert.Equal(t, int64(0), n)
+	assert.Equal(t, 1, segments)
+}
+
+func TestSegmentLogEnforce(t *testing.T) {
+	l, err := openSegmentLog(t.TempDir(), 64)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, l.Append([]byte("0123456789012345678901234567890123456789")))
+	}
+
+	bySize, byAge := l.Enforce(100, time.Hour)
+	assert.Equal(t, int64(384), bySize)
+	assert.Equal(t, int64(0), byAge)
+
+	n, segments := l.Backlog()
+	assert.Equal(t, int64(96), n)
+	assert.Equal(t, 2, segments)
+
+	time.Sleep(10 * time.Millisecond)
+	bySize, byAge = l.Enforce(100, time.Millisecond)
+	assert.Equal(t, int64(0), bySize)
+	assert.Equal(t, int64(48), byAge)
+}
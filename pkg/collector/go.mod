@@ -4,6 +4,7 @@ module github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector
 
 require (
 	connectrpc.com/connect v1.16.2
+	github.com/Shopify/sarama v1.32.0
 	github.com/TarsCloud/TarsGo v1.4.5
 	github.com/TencentBlueKing/bkmonitor-datalink/pkg/libgse v1.7.0
 	github.com/TencentBlueKing/bkmonitor-datalink/pkg/operator v1.0.0
@@ -43,6 +44,7 @@ require (
 	go.uber.org/atomic v1.11.0
 	go.uber.org/automaxprocs v1.5.2
 	golang.org/x/exp v0.0.0-20231226003508-02704c960a9b
+	golang.org/x/time v0.3.0
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240227224415-6ceb2ff114de
 	google.golang.org/grpc v1.63.1
 	google.golang.org/protobuf v1.33.0
@@ -55,7 +57,6 @@ require (
 )
 
 require (
-	github.com/Shopify/sarama v1.32.0 // indirect
 	github.com/aead/chacha20 v0.0.0-20180709150244-8b13a72661da // indirect
 	github.com/alecthomas/units v0.0.0-20211218093645-b94a6e3cc137 // indirect
 	github.com/armon/go-metrics v0.3.10 // indirect
@@ -168,7 +169,6 @@ require (
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/term v0.30.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
-	golang.org/x/time v0.3.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20240227224415-6ceb2ff114de // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
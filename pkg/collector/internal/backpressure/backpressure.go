@@ -0,0 +1,123 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package backpressure 汇总下游（exporter/gse 等）的背压信号 供限流等组件自适应调整
+//
+// 每个信号源上报 [0, 1] 区间的压力值 0 表示空闲 1 表示下游已饱和
+// 超过 staleAfter 未更新的信号视为失效 避免组件停止后残留的高压力值持续生效
+package backpressure
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+const staleAfter = 30 * time.Second
+
+var levelGauge = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: define.MonitoringNamespace,
+		Name:      "backpressure_level",
+		Help:      "Backpressure level reported by downstream components",
+	},
+	[]string{"source"},
+)
+
+type signal struct {
+	value   float64
+	updated time.Time
+}
+
+type registry struct {
+	mut     sync.RWMutex
+	signals map[string]signal
+	now     func() time.Time
+}
+
+func newRegistry() *registry {
+	return &registry{
+		signals: make(map[string]signal),
+		now:     time.Now,
+	}
+}
+
+func (r *registry) report(source string, v float64) {
+	v = clamp(v)
+	r.mut.Lock()
+	r.signals[source] = signal{value: v, updated: r.now()}
+	r.mut.Unlock()
+	levelGauge.WithLabelValues(source).Set(v)
+}
+
+func (r *registry) level() float64 {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	now := r.now()
+	var max float64
+	for _, s := range r.signals {
+		if now.Sub(s.updated) > staleAfter {
+			continue
+		}
+		if s.value > max {
+			max = s.value
+		}
+	}
+	return max
+}
+
+func (r *registry) remove(source string) {
+	r.mut.Lock()
+	delete(r.signals, source)
+	r.mut.Unlock()
+	levelGauge.DeleteLabelValues(source)
+}
+
+func clamp(v float64) float64 {
+	if v < 0 || v != v { // NaN 视为无压力
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+var defaultRegistry = newRegistry()
+
+// Report 上报信号源当前压力值 超出 [0, 1] 的取值会被截断
+func Report(source string, v float64) {
+	defaultRegistry.report(source, v)
+}
+
+// Remove 移除信号源 组件停止时调用
+func Remove(source string) {
+	defaultRegistry.remove(source)
+}
+
+// Level 返回所有有效信号源中的最大压力值
+func Level() float64 {
+	return defaultRegistry.level()
+}
+
+// Ratio 将观测值按 [low, high] 线性映射为压力值
+func Ratio(v, low, high float64) float64 {
+	if high <= low {
+		if v >= high {
+			return 1
+		}
+		return 0
+	}
+	return clamp((v - low) / (high - low))
+}
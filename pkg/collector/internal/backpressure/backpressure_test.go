@@ -0,0 +1,53 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package backpressure
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	now := time.Now()
+	r := newRegistry()
+	r.now = func() time.Time { return now }
+
+	assert.Equal(t, float64(0), r.level())
+
+	r.report("queue", 0.3)
+	r.report("gse", 0.6)
+	assert.Equal(t, 0.6, r.level())
+
+	r.report("gse", 2)
+	assert.Equal(t, float64(1), r.level())
+
+	r.report("gse", math.NaN())
+	assert.Equal(t, 0.3, r.level())
+
+	// 过期信号不再生效
+	now = now.Add(staleAfter + time.Second)
+	assert.Equal(t, float64(0), r.level())
+
+	r.report("queue", 0.5)
+	assert.Equal(t, 0.5, r.level())
+	r.remove("queue")
+	assert.Equal(t, float64(0), r.level())
+}
+
+func TestRatio(t *testing.T) {
+	assert.Equal(t, float64(0), Ratio(0.1, 0.2, 1))
+	assert.Equal(t, 0.5, Ratio(0.5, 0, 1))
+	assert.Equal(t, float64(1), Ratio(2, 0.2, 1))
+	assert.Equal(t, float64(1), Ratio(1, 1, 1))
+	assert.Equal(t, float64(0), Ratio(0.5, 1, 1))
+}
@@ -0,0 +1,296 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package debugtap
+
+import (
+	stdjson "encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
+)
+
+var (
+	tapSessions = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "debugtap_sessions",
+			Help:      "Debug tap active sessions",
+		},
+	)
+
+	tapDroppedEventsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "debugtap_dropped_events_total",
+			Help:      "Debug tap dropped events total",
+		},
+	)
+)
+
+const (
+	PhaseBefore   = "before"
+	PhaseAfter    = "after"
+	PhaseDropped  = "dropped"
+	PhaseExported = "exported"
+)
+
+const (
+	// MaxSessions 同时存在的会话上限
+	MaxSessions = 4
+
+	// MaxQps 单会话每秒采样 record 上限
+	MaxQps = 10
+
+	// MaxDataBytes 单个事件数据上限 超出时仅保留数据大小
+	MaxDataBytes = 64 * 1024
+
+	sessionBufferSize = 128
+)
+
+var ErrTooManySessions = errors.New("too many debug tap sessions")
+
+// Filter 会话过滤条件 Token 必须指定
+type Filter struct {
+	Token      string
+	RecordType define.RecordType // 为空表示全部类型
+}
+
+func (f Filter) match(record *define.Record) bool {
+	if f.Token != record.Token.Original {
+		return false
+	}
+	return f.RecordType == "" || f.RecordType == record.RecordType
+}
+
+// Event 代表 record 在 pipeline 中某个阶段的快照
+type Event struct {
+	Time       time.Time          `json:"time"`
+	ID         uint64             `json:"id"` // 同一条 record 在各阶段保持一致
+	Pipeline   string             `json:"pipeline"`
+	Stage      string             `json:"stage,omitempty"`
+	Phase      string             `json:"phase"`
+	RecordType define.RecordType  `json:"record_type"`
+	Token      string             `json:"token"`
+	Reason     string             `json:"reason,omitempty"`
+	Size       int                `json:"size"`
+	Truncated  bool               `json:"truncated,omitempty"`
+	Data       stdjson.RawMessage `json:"data,omitempty"`
+}
+
+type Session struct {
+	filter  Filter
+	limiter flowcontrol.PassiveRateLimiter
+	ch      chan Event
+	dropped atomic.Int64
+}
+
+func (s *Session) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped 返回因缓冲区已满而丢弃的事件数
+func (s *Session) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Session) send(ev Event) {
+	select {
+	case s.ch <- ev:
+	default:
+		s.dropped.Add(1)
+		tapDroppedEventsTotal.Inc()
+	}
+}
+
+// Tap 管理调试会话 无会话时 Start 仅有一次原子读开销
+type Tap struct {
+	mut      sync.RWMutex
+	sessions map[*Session]struct{}
+	active   atomic.Int32
+	seq      atomic.Uint64
+}
+
+func New() *Tap {
+	return &Tap{sessions: make(map[*Session]struct{})}
+}
+
+// Subscribe 创建会话 qps 会被限制在 (0, MaxQps] 范围内
+func (t *Tap) Subscribe(filter Filter, qps int) (*Session, error) {
+	if filter.Token == "" {
+		return nil, errors.New("empty token")
+	}
+	if qps <= 0 || qps > MaxQps {
+		qps = MaxQps
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if len(t.sessions) >= MaxSessions {
+		return nil, ErrTooManySessions
+	}
+
+	s := &Session{
+		filter:  filter,
+		limiter: flowcontrol.NewTokenBucketPassiveRateLimiter(float32(qps), qps),
+		ch:      make(chan Event, sessionBufferSize),
+	}
+	t.sessions[s] = struct{}{}
+	t.active.Store(int32(len(t.sessions)))
+	tapSessions.Set(float64(len(t.sessions)))
+	return s, nil
+}
+
+func (t *Tap) Unsubscribe(s *Session) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if _, ok := t.sessions[s]; !ok {
+		return
+	}
+	delete(t.sessions, s)
+	s.limiter.Stop()
+	t.active.Store(int32(len(t.sessions)))
+	tapSessions.Set(float64(len(t.sessions)))
+}
+
+func (t *Tap) Enabled() bool {
+	return t.active.Load() > 0
+}
+
+// Start 判断 record 是否被采样 未命中任何会话时返回 nil
+// 采样结果在 record 整个处理流程中保持一致
+func (t *Tap) Start(pipeline string, record *define.Record) *Trace {
+	if !t.Enabled() || record == nil {
+		return nil
+	}
+
+	var sessions []*Session
+	t.mut.RLock()
+	for s := range t.sessions {
+		if s.filter.match(record) && s.limiter.TryAccept() {
+			sessions = append(sessions, s)
+		}
+	}
+	t.mut.RUnlock()
+
+	if len(sessions) == 0 {
+		return nil
+	}
+	return &Trace{
+		id:       t.seq.Add(1),
+		pipeline: pipeline,
+		record:   record,
+		sessions: sessions,
+	}
+}
+
+// Trace 跟踪单条 record 的处理过程 nil 值安全
+type Trace struct {
+	id       uint64
+	pipeline string
+	record   *define.Record
+	sessions []*Session
+}
+
+// Before 记录 processor 处理前快照
+func (tr *Trace) Before(stage string) {
+	tr.emit(stage, PhaseBefore, nil)
+}
+
+// After 记录 processor 处理结果 err 不为空时代表 record 在该阶段被丢弃
+func (tr *Trace) After(stage string, err error) {
+	if err != nil {
+		tr.emit(stage, PhaseDropped, err)
+		return
+	}
+	tr.emit(stage, PhaseAfter, nil)
+}
+
+// Exported 记录提交至 exporter 的最终数据
+func (tr *Trace) Exported() {
+	tr.emit("", PhaseExported, nil)
+}
+
+func (tr *Trace) emit(stage, phase string, err error) {
+	if tr == nil {
+		return
+	}
+
+	ev := Event{
+		Time:       time.Now(),
+		ID:         tr.id,
+		Pipeline:   tr.pipeline,
+		Stage:      stage,
+		Phase:      phase,
+		RecordType: tr.record.RecordType,
+		Token:      tr.record.Token.Original,
+	}
+	if err != nil {
+		ev.Reason = err.Error()
+	}
+
+	// 丢弃阶段数据与处理前一致 无需重复编码
+	if phase != PhaseDropped {
+		b, encodeErr := encodeData(tr.record)
+		if encodeErr != nil {
+			ev.Reason = encodeErr.Error()
+		}
+		ev.Size = len(b)
+		if len(b) > MaxDataBytes {
+			ev.Truncated = true
+		} else {
+			ev.Data = b
+		}
+	}
+
+	for _, s := range tr.sessions {
+		s.send(ev)
+	}
+}
+
+func encodeData(record *define.Record) ([]byte, error) {
+	switch data := record.Data.(type) {
+	case ptrace.Traces:
+		return ptrace.NewJSONMarshaler().MarshalTraces(data)
+	case pmetric.Metrics:
+		return pmetric.NewJSONMarshaler().MarshalMetrics(data)
+	case plog.Logs:
+		return plog.NewJSONMarshaler().MarshalLogs(data)
+	case nil:
+		return nil, nil
+	}
+	return json.Marshal(record.Data)
+}
+
+var defaultTap = New()
+
+func Subscribe(filter Filter, qps int) (*Session, error) {
+	return defaultTap.Subscribe(filter, qps)
+}
+
+func Unsubscribe(s *Session) {
+	defaultTap.Unsubscribe(s)
+}
+
+func Start(pipeline string, record *define.Record) *Trace {
+	return defaultTap.Start(pipeline, record)
+}
@@ -0,0 +1,116 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package debugtap
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+)
+
+func TestTapDisabled(t *testing.T) {
+	tap := New()
+	assert.False(t, tap.Enabled())
+
+	tr := tap.Start("traces_pipeline/common", &define.Record{Token: define.Token{Original: "token1"}})
+	assert.Nil(t, tr)
+
+	// nil trace 安全调用
+	tr.Before("sampler/random")
+	tr.After("sampler/random", nil)
+	tr.Exported()
+}
+
+func TestTapSubscribe(t *testing.T) {
+	tap := New()
+	_, err := tap.Subscribe(Filter{}, 1)
+	assert.Error(t, err)
+
+	var sessions []*Session
+	for i := 0; i < MaxSessions; i++ {
+		s, err := tap.Subscribe(Filter{Token: "token1"}, 1)
+		assert.NoError(t, err)
+		sessions = append(sessions, s)
+	}
+	_, err = tap.Subscribe(Filter{Token: "token1"}, 1)
+	assert.Equal(t, ErrTooManySessions, err)
+	assert.True(t, tap.Enabled())
+
+	for _, s := range sessions {
+		tap.Unsubscribe(s)
+	}
+	tap.Unsubscribe(sessions[0])
+	assert.False(t, tap.Enabled())
+}
+
+func TestTapTrace(t *testing.T) {
+	tap := New()
+	s, err := tap.Subscribe(Filter{Token: "token1", RecordType: define.RecordTraces}, 2)
+	assert.NoError(t, err)
+	defer tap.Unsubscribe(s)
+
+	g := generator.NewTracesGenerator(define.TracesOptions{SpanCount: 1})
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{Original: "token1"},
+		Data:       g.Generate(),
+	}
+
+	// 未命中过滤条件
+	assert.Nil(t, tap.Start("p", &define.Record{RecordType: define.RecordTraces, Token: define.Token{Original: "token2"}}))
+	assert.Nil(t, tap.Start("p", &define.Record{RecordType: define.RecordLogs, Token: define.Token{Original: "token1"}}))
+
+	tr := tap.Start("traces_pipeline/common", record)
+	assert.NotNil(t, tr)
+	tr.Before("sampler/random")
+	tr.After("sampler/random", nil)
+	tr.Before("token_checker/fixed")
+	tr.After("token_checker/fixed", errors.New("invalid token"))
+
+	events := make([]Event, 0, 4)
+	for i := 0; i < 4; i++ {
+		events = append(events, <-s.Events())
+	}
+	assert.Equal(t, PhaseBefore, events[0].Phase)
+	assert.Equal(t, "sampler/random", events[0].Stage)
+	assert.NotEmpty(t, events[0].Data)
+	assert.Equal(t, PhaseAfter, events[1].Phase)
+	assert.Equal(t, PhaseDropped, events[3].Phase)
+	assert.Equal(t, "invalid token", events[3].Reason)
+	assert.Empty(t, events[3].Data)
+	for _, ev := range events {
+		assert.Equal(t, tr.id, ev.ID)
+		assert.Equal(t, "traces_pipeline/common", ev.Pipeline)
+	}
+
+	// 超出 qps 限制后不再采样
+	assert.NotNil(t, tap.Start("p", record))
+	assert.Nil(t, tap.Start("p", record))
+}
+
+func TestSessionBufferFull(t *testing.T) {
+	tap := New()
+	s, err := tap.Subscribe(Filter{Token: "token1"}, 1)
+	assert.NoError(t, err)
+	defer tap.Unsubscribe(s)
+
+	tr := tap.Start("p", &define.Record{Token: define.Token{Original: "token1"}, Data: map[string]string{"k": "v"}})
+	for i := 0; i < sessionBufferSize+10; i++ {
+		tr.Exported()
+	}
+	assert.Equal(t, int64(10), s.Dropped())
+
+	ev := <-s.Events()
+	assert.Equal(t, `{"k":"v"}`, string(ev.Data))
+}
@@ -0,0 +1,131 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package kafkaclient 提供 kafka receiver/exporter 共用的连接配置
+package kafkaclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultVersion     = "2.1.0"
+	defaultClientID    = "bk-collector"
+	defaultDialTimeout = 10 * time.Second
+)
+
+// Config kafka 连接配置
+type Config struct {
+	Brokers     []string      `config:"brokers"`
+	Version     string        `config:"version"`
+	ClientID    string        `config:"client_id"`
+	DialTimeout time.Duration `config:"dial_timeout"`
+	SASL        SASLConfig    `config:"sasl"`
+	TLS         TLSConfig     `config:"tls"`
+}
+
+// SASLConfig 目前仅支持 PLAIN 认证
+type SASLConfig struct {
+	Enabled  bool   `config:"enabled"`
+	Username string `config:"username"`
+	Password string `config:"password"`
+}
+
+type TLSConfig struct {
+	Enabled            bool   `config:"enabled"`
+	InsecureSkipVerify bool   `config:"insecure_skip_verify"`
+	CaFile             string `config:"ca_file"`
+	CertFile           string `config:"cert_file"`
+	KeyFile            string `config:"key_file"`
+}
+
+// Normalize 校验配置并填充默认值
+//
+// 不使用 Validate 命名 避免配置反序列化时被自动调用 组件未启用时也会校验失败
+func (c *Config) Normalize() error {
+	if len(c.Brokers) == 0 {
+		return errors.New("empty kafka brokers")
+	}
+	if c.Version == "" {
+		c.Version = defaultVersion
+	}
+	if _, err := sarama.ParseKafkaVersion(c.Version); err != nil {
+		return err
+	}
+	if c.ClientID == "" {
+		c.ClientID = defaultClientID
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = defaultDialTimeout
+	}
+	return nil
+}
+
+// SaramaConfig 生成 sarama 基础配置 生产者/消费者相关参数由调用方补充
+func (c *Config) SaramaConfig() (*sarama.Config, error) {
+	version, err := sarama.ParseKafkaVersion(c.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := sarama.NewConfig()
+	sc.Version = version
+	sc.ClientID = c.ClientID
+	sc.Net.DialTimeout = c.DialTimeout
+
+	if c.SASL.Enabled {
+		sc.Net.SASL.Enable = true
+		sc.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		sc.Net.SASL.User = c.SASL.Username
+		sc.Net.SASL.Password = c.SASL.Password
+	}
+
+	if c.TLS.Enabled {
+		tlsConf, err := loadTLSConfig(c.TLS)
+		if err != nil {
+			return nil, err
+		}
+		sc.Net.TLS.Enable = true
+		sc.Net.TLS.Config = tlsConf
+	}
+	return sc, nil
+}
+
+func loadTLSConfig(conf TLSConfig) (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+
+	if conf.CaFile != "" {
+		b, err := os.ReadFile(conf.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf("failed to parse ca file: %s", conf.CaFile)
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConf, nil
+}
@@ -0,0 +1,56 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafkaclient
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	t.Run("EmptyBrokers", func(t *testing.T) {
+		c := Config{}
+		assert.Error(t, c.Normalize())
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		c := Config{Brokers: []string{"127.0.0.1:9092"}, Version: "x.y"}
+		assert.Error(t, c.Normalize())
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		c := Config{
+			Brokers: []string{"127.0.0.1:9092"},
+			SASL:    SASLConfig{Enabled: true, Username: "user", Password: "pass"},
+		}
+		assert.NoError(t, c.Normalize())
+		assert.Equal(t, defaultVersion, c.Version)
+		assert.Equal(t, defaultClientID, c.ClientID)
+
+		sc, err := c.SaramaConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, sarama.V2_1_0_0, sc.Version)
+		assert.True(t, sc.Net.SASL.Enable)
+		assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypePlaintext), sc.Net.SASL.Mechanism)
+		assert.False(t, sc.Net.TLS.Enable)
+	})
+
+	t.Run("TLSMissingFile", func(t *testing.T) {
+		c := Config{
+			Brokers: []string{"127.0.0.1:9092"},
+			TLS:     TLSConfig{Enabled: true, CaFile: "/not/exist/ca.pem"},
+		}
+		assert.NoError(t, c.Normalize())
+		_, err := c.SaramaConfig()
+		assert.Error(t, err)
+	})
+}
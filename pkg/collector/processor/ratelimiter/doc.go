@@ -18,6 +18,23 @@ processor:
       qps: 5
       burst: 10
 
+  # 自适应限流器
+  # 每个 token 独立令牌桶 下游背压升高时速率在 [min_qps, qps] 区间线性下调
+  # 背压超过 shed_start 后按优先级由低到高依次丢弃 低优先级全部丢弃后才丢弃更高优先级 被拒绝的请求会携带 Retry-After
+  - name: "rate_limiter/adaptive"
+    config:
+      type: adaptive
+      qps: 500
+      burst: 1000
+      min_qps: 50
+      shed_start: 0.5
+      retry_after: 5s
+      priorities:
+        metrics: 3
+        logs: 2
+        profiles: 1
+        traces: 1
+
   # 不做限制
   - name: "rate_limiter/noop"
     config:
@@ -83,6 +83,18 @@ func (p *rateLimiter) Reload(config map[string]any, customized []processor.SubCo
 func (p *rateLimiter) Process(record *define.Record) (*define.Record, error) {
 	token := record.Token.Original
 	rl := p.rateLimiters.GetByToken(token).(throttle.RateLimiter)
+
+	// 自适应限流器按 token 及数据类型判定 并给出重试建议
+	if decider, ok := rl.(throttle.Decider); ok {
+		d := decider.Decide(token, record.RecordType.S())
+		if !d.Accepted {
+			err := errors.Errorf("ratelimiter rejected the request, token [%s] type [%s] current qps allowed: %f", token, record.RecordType, rl.QPS())
+			return nil, define.NewRetryAfterError(err, d.RetryAfter)
+		}
+		return nil, nil
+	}
+
+	// 固定速率的限流器保持原有的错误返回 gRPC 状态码不变
 	if !rl.TryAccept() {
 		return nil, errors.Errorf("ratelimiter rejected the request, token [%s] max qps allowed: %f", token, rl.QPS())
 	}
@@ -106,3 +106,55 @@ processor:
 	_, err := factory.Process(&define.Record{Token: define.Token{Original: "fortest"}})
 	assert.Error(t, err)
 }
+
+// 固定速率的限流器保持原有的错误返回 不携带重试建议
+func TestTokenBucketRejected(t *testing.T) {
+	content := `
+processor:
+  - name: "rate_limiter/token_bucket"
+    config:
+      type: token_bucket
+      qps: 1
+      burst: 1
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	record := &define.Record{Token: define.Token{Original: "fortest"}}
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	_, err = factory.Process(record)
+	assert.Error(t, err)
+	_, ok := define.RetryAfter(err)
+	assert.False(t, ok)
+}
+
+func TestAdaptiveProcess(t *testing.T) {
+	content := `
+processor:
+  - name: "rate_limiter/adaptive"
+    config:
+      type: adaptive
+      qps: 1
+      burst: 1
+      shed_start: 0.5
+      retry_after: 3s
+      priorities:
+        metrics: 2
+        traces: 1
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+	p := factory.(*rateLimiter)
+	assert.Equal(t, throttle.TypeAdaptive, p.rateLimiters.GetGlobal().(throttle.RateLimiter).Type())
+
+	// 不同 token 独立计算配额
+	for _, token := range []string{"token1", "token2"} {
+		_, err := factory.Process(&define.Record{RecordType: define.RecordMetrics, Token: define.Token{Original: token}})
+		assert.NoError(t, err)
+	}
+
+	_, err := factory.Process(&define.Record{RecordType: define.RecordMetrics, Token: define.Token{Original: "token1"}})
+	assert.Error(t, err)
+	_, ok := define.RetryAfter(err)
+	assert.True(t, ok)
+}
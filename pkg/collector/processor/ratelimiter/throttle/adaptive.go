@@ -0,0 +1,242 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package throttle
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/backpressure"
+)
+
+const (
+	defaultShedStart      = 0.5
+	defaultShedRetryAfter = 5 * time.Second
+	defaultIdleTimeout    = 10 * time.Minute
+)
+
+// defaultPriorities 默认优先级 数值越大越晚被丢弃
+var defaultPriorities = map[string]int{
+	"metrics":  3,
+	"logs":     2,
+	"profiles": 1,
+	"traces":   1,
+}
+
+// Decision 限流判定结果 RetryAfter 为建议客户端重试的等待时长
+type Decision struct {
+	Accepted   bool
+	RetryAfter time.Duration
+}
+
+// Decider 支持按 token 及数据类型判定的限流器
+type Decider interface {
+	Decide(token, recordType string) Decision
+}
+
+// bucket 速率可动态调整的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+}
+
+// take 尝试获取令牌 失败时返回下一个令牌就绪所需时长
+func (b *bucket) take(now time.Time, rate float64, burst int) (bool, time.Duration) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	b.rate = rate
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if rate <= 0 {
+		return false, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// adaptiveRateLimiter 自适应限流器
+//
+// 1) 每个 token 独立令牌桶 避免单个应用耗尽全局配额
+// 2) 下游背压升高时 速率在 [min_qps, qps] 区间线性下调
+// 3) 背压超过 shed_start 后 按数据类型优先级由低到高依次按概率丢弃 低优先级全部丢弃后才开始丢弃更高优先级
+type adaptiveRateLimiter struct {
+	mut        sync.Mutex
+	qps        float64
+	minQps     float64
+	burst      int
+	shedStart  float64
+	retryAfter time.Duration
+	thresholds map[string]float64 // 各优先级数据类型开始丢弃的背压阈值
+	shedWidth  float64            // 单个优先级从开始丢弃到全部丢弃的背压区间宽度
+	buckets    map[string]*bucket
+	lastGc     time.Time
+
+	now   func() time.Time
+	level func() float64
+	rand  func() float64
+}
+
+func newAdaptiveRateLimiter(c Config) *adaptiveRateLimiter {
+	qps := float64(c.Qps)
+	minQps := float64(c.MinQps)
+	if minQps <= 0 || minQps > qps {
+		minQps = qps / 10
+	}
+	burst := c.Burst
+	if burst < int(qps) {
+		burst = int(qps) + 1
+	}
+	shedStart := c.ShedStart
+	if shedStart <= 0 || shedStart >= 1 {
+		shedStart = defaultShedStart
+	}
+	retryAfter := c.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = defaultShedRetryAfter
+	}
+	priorities := c.Priorities
+	if len(priorities) == 0 {
+		priorities = defaultPriorities
+	}
+	thresholds, shedWidth := shedThresholds(priorities, shedStart)
+
+	return &adaptiveRateLimiter{
+		qps:        qps,
+		minQps:     minQps,
+		burst:      burst,
+		shedStart:  shedStart,
+		retryAfter: retryAfter,
+		thresholds: thresholds,
+		shedWidth:  shedWidth,
+		buckets:    make(map[string]*bucket),
+		now:        time.Now,
+		level:      backpressure.Level,
+		rand:       rand.Float64,
+	}
+}
+
+// shedThresholds 计算各数据类型开始丢弃的背压阈值及每个等级的区间宽度
+//
+// 优先级按升序排列为 R 个等级 第 r 级在 [start + (1-start)*r/R, start + (1-start)*(r+1)/R] 区间内
+// 丢弃概率由 0 线性升至 1 因此低优先级全部丢弃后更高优先级才开始丢弃
+func shedThresholds(priorities map[string]int, start float64) (map[string]float64, float64) {
+	levels := make([]int, 0, len(priorities))
+	seen := make(map[int]struct{})
+	for _, p := range priorities {
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		levels = append(levels, p)
+	}
+	sort.Ints(levels)
+
+	rank := make(map[int]int, len(levels))
+	for i, p := range levels {
+		rank[p] = i
+	}
+
+	width := (1 - start) / float64(len(levels))
+	thresholds := make(map[string]float64, len(priorities))
+	for rtype, p := range priorities {
+		thresholds[rtype] = start + width*float64(rank[p])
+	}
+	return thresholds, width
+}
+
+func (rl *adaptiveRateLimiter) Type() string {
+	return TypeAdaptive
+}
+
+func (rl *adaptiveRateLimiter) Stop() {}
+
+func (rl *adaptiveRateLimiter) TryAccept() bool {
+	return rl.Decide("", "").Accepted
+}
+
+// QPS 返回当前背压下的实际速率
+func (rl *adaptiveRateLimiter) QPS() float32 {
+	return float32(rl.currentRate(rl.level()))
+}
+
+func (rl *adaptiveRateLimiter) currentRate(level float64) float64 {
+	return rl.qps - level*(rl.qps-rl.minQps)
+}
+
+// threshold 未配置优先级的数据类型不参与按优先级丢弃
+func (rl *adaptiveRateLimiter) threshold(recordType string) (float64, bool) {
+	if t, ok := rl.thresholds[recordType]; ok {
+		return t, true
+	}
+	// 派生类型（如 traces.derived）沿用原始类型优先级
+	if idx := strings.IndexByte(recordType, '.'); idx > 0 {
+		t, ok := rl.thresholds[recordType[:idx]]
+		return t, ok
+	}
+	return 0, false
+}
+
+// shedProbability 当前背压下该数据类型的丢弃概率
+func (rl *adaptiveRateLimiter) shedProbability(recordType string, level float64) float64 {
+	t, ok := rl.threshold(recordType)
+	if !ok || level <= t {
+		return 0
+	}
+	return math.Min(1, (level-t)/rl.shedWidth)
+}
+
+func (rl *adaptiveRateLimiter) Decide(token, recordType string) Decision {
+	level := rl.level()
+	if p := rl.shedProbability(recordType, level); p > 0 && rl.rand() < p {
+		return Decision{RetryAfter: rl.retryAfter}
+	}
+
+	now := rl.now()
+	rl.mut.Lock()
+	defer rl.mut.Unlock()
+
+	rl.gc(now)
+	b, ok := rl.buckets[token]
+	if !ok {
+		b = &bucket{tokens: float64(rl.burst), last: now, rate: rl.qps}
+		rl.buckets[token] = b
+	}
+	accepted, wait := b.take(now, rl.currentRate(level), rl.burst)
+	if accepted {
+		return Decision{Accepted: true}
+	}
+	if wait <= 0 {
+		wait = rl.retryAfter
+	}
+	return Decision{RetryAfter: wait}
+}
+
+// gc 清理长时间未活跃的 token 调用方需持有锁
+func (rl *adaptiveRateLimiter) gc(now time.Time) {
+	if now.Sub(rl.lastGc) < defaultIdleTimeout {
+		return
+	}
+	rl.lastGc = now
+	for token, b := range rl.buckets {
+		if now.Sub(b.last) > defaultIdleTimeout {
+			delete(rl.buckets, token)
+		}
+	}
+}
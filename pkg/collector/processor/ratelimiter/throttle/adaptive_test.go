@@ -0,0 +1,141 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAdaptive(c Config, level float64, r float64) (*adaptiveRateLimiter, *time.Time) {
+	now := time.Now()
+	rl := newAdaptiveRateLimiter(c)
+	rl.now = func() time.Time { return now }
+	rl.level = func() float64 { return level }
+	rl.rand = func() float64 { return r }
+	return rl, &now
+}
+
+func TestAdaptivePerToken(t *testing.T) {
+	rl, _ := newTestAdaptive(Config{Type: TypeAdaptive, Qps: 5, Burst: 10}, 0, 1)
+	assert.Equal(t, TypeAdaptive, rl.Type())
+
+	accepted := 0
+	for i := 0; i < 20; i++ {
+		if rl.Decide("token1", "metrics").Accepted {
+			accepted++
+		}
+	}
+	assert.Equal(t, 10, accepted)
+
+	// token1 配额耗尽不影响 token2
+	d := rl.Decide("token1", "metrics")
+	assert.False(t, d.Accepted)
+	assert.Equal(t, 200*time.Millisecond, d.RetryAfter)
+	assert.True(t, rl.Decide("token2", "metrics").Accepted)
+}
+
+func TestAdaptiveRate(t *testing.T) {
+	rl, now := newTestAdaptive(Config{Type: TypeAdaptive, Qps: 100, MinQps: 10, Burst: 100}, 1, 1)
+	assert.Equal(t, float32(10), rl.QPS())
+
+	for i := 0; i < 100; i++ {
+		rl.Decide("token1", "")
+	}
+	assert.False(t, rl.Decide("token1", "").Accepted)
+
+	// 背压饱和时按 min_qps 补充令牌
+	*now = now.Add(time.Second)
+	accepted := 0
+	for i := 0; i < 100; i++ {
+		if rl.Decide("token1", "").Accepted {
+			accepted++
+		}
+	}
+	assert.Equal(t, 10, accepted)
+
+	rl.level = func() float64 { return 0.5 }
+	assert.Equal(t, float32(55), rl.QPS())
+}
+
+func TestAdaptiveShedThresholds(t *testing.T) {
+	thresholds, width := shedThresholds(defaultPriorities, 0.5)
+	assert.InDelta(t, 0.1667, width, 0.001)
+	assert.Equal(t, 0.5, thresholds["traces"])
+	assert.Equal(t, 0.5, thresholds["profiles"])
+	assert.InDelta(t, 0.6667, thresholds["logs"], 0.001)
+	assert.InDelta(t, 0.8333, thresholds["metrics"], 0.001)
+}
+
+func TestAdaptiveShedOrder(t *testing.T) {
+	rl, _ := newTestAdaptive(Config{Type: TypeAdaptive, Qps: 1000}, 0, 0)
+	order := []string{"traces", "logs", "metrics"}
+
+	for level := 0.0; level <= 1; level += 0.01 {
+		for i := 1; i < len(order); i++ {
+			lower := rl.shedProbability(order[i-1], level)
+			higher := rl.shedProbability(order[i], level)
+			// 低优先级未全部丢弃前 更高优先级不丢弃
+			if lower < 1 {
+				assert.Zero(t, higher, "level %.2f %s", level, order[i])
+			}
+			assert.GreaterOrEqual(t, lower, higher, "level %.2f %s", level, order[i])
+		}
+	}
+
+	assert.Zero(t, rl.shedProbability("traces", 0.5))
+	assert.Equal(t, 1.0, rl.shedProbability("traces", 0.7))
+	assert.Zero(t, rl.shedProbability("metrics", 0.8))
+	assert.Equal(t, 1.0, rl.shedProbability("metrics", 1))
+	assert.Zero(t, rl.shedProbability("pushgateway", 1))
+}
+
+func TestAdaptiveShed(t *testing.T) {
+	c := Config{Type: TypeAdaptive, Qps: 1000, Burst: 1000, RetryAfter: 3 * time.Second}
+
+	t.Run("NoPressure", func(t *testing.T) {
+		rl, _ := newTestAdaptive(c, 0, 0)
+		assert.True(t, rl.Decide("token1", "traces").Accepted)
+	})
+
+	t.Run("TracesFirst", func(t *testing.T) {
+		// 背压 0.6 时 traces 丢弃概率为 0.6 metrics 未达到阈值
+		rl, _ := newTestAdaptive(c, 0.6, 0.1)
+		d := rl.Decide("token1", "traces")
+		assert.False(t, d.Accepted)
+		assert.Equal(t, 3*time.Second, d.RetryAfter)
+		assert.False(t, rl.Decide("token1", "traces.derived").Accepted)
+		assert.True(t, rl.Decide("token1", "metrics").Accepted)
+		assert.True(t, rl.Decide("token1", "pushgateway").Accepted)
+
+		rl.rand = func() float64 { return 0.7 }
+		assert.True(t, rl.Decide("token1", "traces").Accepted)
+	})
+
+	t.Run("Saturated", func(t *testing.T) {
+		rl, _ := newTestAdaptive(c, 1, 0.99)
+		assert.False(t, rl.Decide("token1", "metrics").Accepted)
+		assert.False(t, rl.Decide("token1", "logs").Accepted)
+	})
+}
+
+func TestAdaptiveGc(t *testing.T) {
+	rl, now := newTestAdaptive(Config{Type: TypeAdaptive, Qps: 5}, 0, 1)
+	rl.Decide("token1", "")
+	assert.Len(t, rl.buckets, 1)
+
+	*now = now.Add(defaultIdleTimeout + time.Second)
+	rl.Decide("token2", "")
+	assert.Len(t, rl.buckets, 1)
+	_, ok := rl.buckets["token2"]
+	assert.True(t, ok)
+}
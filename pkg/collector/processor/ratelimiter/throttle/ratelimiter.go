@@ -11,6 +11,7 @@ package throttle
 
 import (
 	"math"
+	"time"
 
 	"k8s.io/client-go/util/flowcontrol"
 )
@@ -18,6 +19,7 @@ import (
 const (
 	TypeTokenBucket = "token_bucket"
 	TypeNoop        = "noop"
+	TypeAdaptive    = "adaptive"
 )
 
 // Config 限流器配置项
@@ -30,6 +32,20 @@ type Config struct {
 
 	// The maximum number of tokens in the bucket is capped at 'burst'
 	Burst int `config:"burst" mapstructure:"burst"`
+
+	// 以下配置仅 adaptive 类型生效
+
+	// MinQps 背压饱和时的最低速率 默认为 qps 的 1/10
+	MinQps float32 `config:"min_qps" mapstructure:"min_qps"`
+
+	// Priorities 数据类型优先级 数值越大越晚被丢弃
+	Priorities map[string]int `config:"priorities" mapstructure:"priorities"`
+
+	// ShedStart 背压超过该值后开始按优先级丢弃数据 取值 (0, 1)
+	ShedStart float64 `config:"shed_start" mapstructure:"shed_start"`
+
+	// RetryAfter 因背压丢弃数据时建议客户端的重试间隔
+	RetryAfter time.Duration `config:"retry_after" mapstructure:"retry_after"`
 }
 
 // RateLimiter 限流器接口定义
@@ -53,6 +69,11 @@ func New(c Config) RateLimiter {
 	switch c.Type {
 	case TypeTokenBucket:
 		return newTokenBucketRateLimiter(c.Qps, c.Burst)
+	case TypeAdaptive:
+		if c.Qps <= 0 {
+			return newTokenBucketRateLimiter(c.Qps, c.Burst)
+		}
+		return newAdaptiveRateLimiter(c)
 	default:
 		return newNoopRateLimiter()
 	}
@@ -29,6 +29,16 @@ func TestNewRateLimiter(t *testing.T) {
 		assert.Equal(t, TypeTokenBucket, rl.Type())
 		assert.True(t, rl.TryAccept())
 	})
+
+	t.Run("Adaptive", func(t *testing.T) {
+		rl := New(Config{Type: TypeAdaptive, Qps: 5})
+		assert.Equal(t, TypeAdaptive, rl.Type())
+		assert.True(t, rl.TryAccept())
+
+		// qps 非正数时退化为令牌桶语义
+		rl = New(Config{Type: TypeAdaptive, Qps: -1})
+		assert.Equal(t, TypeTokenBucket, rl.Type())
+	})
 }
 
 func TestTokenBucketRateLimiter(t *testing.T) {
@@ -0,0 +1,191 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package redactor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/fields"
+)
+
+const (
+	DetectorPhone      = "phone"
+	DetectorIdCard     = "id_card"
+	DetectorEmail      = "email"
+	DetectorUrlToken   = "url_token"
+	DetectorSqlLiteral = "sql_literal"
+	DetectorRegex      = "regex"
+	DetectorKeyword    = "keyword"
+)
+
+const (
+	ActionMask = "mask"
+	ActionHash = "hash"
+	ActionDrop = "drop"
+)
+
+const (
+	keyBody = "body"
+
+	// groupRedact 正则中该命名分组存在时 仅处理分组内容
+	groupRedact = "redact"
+)
+
+// builtinPatterns 内置检测器正则
+var builtinPatterns = map[string]string{
+	DetectorPhone:      `(?:\+86[- ]?|\b86[- ]?|\b)1[3-9]\d{9}\b`,
+	DetectorIdCard:     `\b[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`,
+	DetectorEmail:      `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	DetectorUrlToken:   `(?i)[?&](?:access_token|token|api_key|apikey|secret|password|passwd|sign|signature|auth)=(?P<redact>[^&#\s]+)`,
+	DetectorSqlLiteral: `'(?:[^'\\]|\\.)*'|\b\d+(?:\.\d+)?\b`,
+}
+
+// builtinKeys 内置检测器默认作用字段 未声明时作用于全部字段
+var builtinKeys = map[string][]string{
+	DetectorSqlLiteral: {"attributes.db.statement"},
+}
+
+type Config struct {
+	Rules []Rule `config:"rules" mapstructure:"rules"`
+}
+
+// Clean 编译规则 存在非法规则时返回错误
+func (c *Config) Clean() error {
+	for i := 0; i < len(c.Rules); i++ {
+		if err := c.Rules[i].Clean(); err != nil {
+			return errors.Wrapf(err, "invalid rule[%d] %s", i, c.Rules[i].Name)
+		}
+	}
+	return nil
+}
+
+type Rule struct {
+	Name        string   `config:"name" mapstructure:"name"`
+	Detector    string   `config:"detector" mapstructure:"detector"`       // 检测器类型
+	Pattern     string   `config:"pattern" mapstructure:"pattern"`         // detector 为 regex 时生效
+	Keywords    []string `config:"keywords" mapstructure:"keywords"`       // detector 为 keyword 时生效
+	Keys        []string `config:"keys" mapstructure:"keys"`               // 作用字段 支持 resource.xxx/attributes.xxx/body 为空表示全部字段
+	Action      string   `config:"action" mapstructure:"action"`           // mask/hash/drop 默认为 mask
+	Replacement string   `config:"replacement" mapstructure:"replacement"` // mask 替换内容 为空时按字符数替换为 *
+	Salt        string   `config:"salt" mapstructure:"salt"`               // hash 加盐
+
+	re            *regexp.Regexp
+	group         int
+	keywords      []string
+	all           bool
+	body          bool
+	resourceKeys  map[string]struct{}
+	attributeKeys map[string]struct{}
+}
+
+func (r *Rule) Clean() error {
+	switch r.Action {
+	case "":
+		r.Action = ActionMask
+	case ActionMask, ActionHash, ActionDrop:
+	default:
+		return errors.Errorf("unsupported action %q", r.Action)
+	}
+
+	var pattern string
+	switch r.Detector {
+	case DetectorPhone, DetectorIdCard, DetectorEmail, DetectorUrlToken, DetectorSqlLiteral:
+		pattern = builtinPatterns[r.Detector]
+		if len(r.Keys) == 0 {
+			r.Keys = builtinKeys[r.Detector]
+		}
+		if r.Detector == DetectorSqlLiteral && r.Replacement == "" {
+			r.Replacement = "?"
+		}
+	case DetectorRegex:
+		if r.Pattern == "" {
+			return errors.New("empty pattern")
+		}
+		pattern = r.Pattern
+	case DetectorKeyword:
+		if len(r.Keywords) == 0 {
+			return errors.New("empty keywords")
+		}
+		quoted := make([]string, 0, len(r.Keywords))
+		r.keywords = make([]string, 0, len(r.Keywords))
+		for _, kw := range r.Keywords {
+			quoted = append(quoted, regexp.QuoteMeta(kw))
+			r.keywords = append(r.keywords, strings.ToLower(kw))
+		}
+		// 文本中匹配 `keyword=value` 或 `keyword: value` 形式
+		pattern = `(?i)\b(?:` + strings.Join(quoted, "|") + `)["']?\s*[:=]\s*["']?(?P<redact>[^\s"'&,;]+)`
+	default:
+		return errors.Errorf("unsupported detector %q", r.Detector)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	r.re = re
+	r.group = re.SubexpIndex(groupRedact)
+
+	r.all = len(r.Keys) == 0
+	r.resourceKeys = make(map[string]struct{})
+	r.attributeKeys = make(map[string]struct{})
+	for _, key := range r.Keys {
+		if key == keyBody {
+			r.body = true
+			continue
+		}
+		ff, k := fields.DecodeFieldFrom(key)
+		switch ff {
+		case fields.FieldFromResource:
+			r.resourceKeys[k] = struct{}{}
+		case fields.FieldFromAttributes:
+			r.attributeKeys[k] = struct{}{}
+		default:
+			return errors.Errorf("unsupported key %q", key)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) matchResource(key string) bool {
+	if r.all {
+		return true
+	}
+	_, ok := r.resourceKeys[key]
+	return ok
+}
+
+func (r *Rule) matchAttribute(key string) bool {
+	if r.all {
+		return true
+	}
+	_, ok := r.attributeKeys[key]
+	return ok
+}
+
+func (r *Rule) matchBody() bool {
+	return r.all || r.body
+}
+
+// matchKeyword 判断字段名是否命中关键字 命中时整个字段值需要处理
+func (r *Rule) matchKeyword(key string) bool {
+	if len(r.keywords) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, kw := range r.keywords {
+		if strings.Contains(key, kw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+/*
+# Redactor: 敏感数据脱敏处理器 支持 traces/logs 的 resource/attributes/body 字段
+
+detector:
+- phone: 手机号
+- id_card: 身份证号
+- email: 邮箱
+- url_token: url 中的 token/access_token/api_key 等参数值
+- sql_literal: sql 中的字符串及数字字面量 默认作用于 attributes.db.statement 替换为 ?
+- regex: 自定义正则 存在命名分组 redact 时仅处理分组内容
+- keyword: 字段名包含关键字时处理整个字段值 文本中 `keyword=value` 形式仅处理 value
+
+action:
+- mask: 替换为 replacement 为空时按字符数替换为 *
+- hash: 替换为 sha256(salt + value) 的十六进制字符串
+- drop: 移除整个 attribute 字段 body 中仅移除命中片段
+
+processor:
+  - name: "redactor/common"
+    config:
+      rules:
+        - name: "phone"
+          detector: "phone"
+          action: "mask"
+          # 为空表示作用于全部字段 支持 resource.xxx/attributes.xxx/body
+          keys:
+            - "attributes.user.phone"
+            - "body"
+        - name: "email"
+          detector: "email"
+          action: "hash"
+          salt: "bk"
+        - name: "sql"
+          detector: "sql_literal"
+        - name: "secret"
+          detector: "keyword"
+          keywords: ["password", "secret"]
+          action: "drop"
+*/
+
+package redactor
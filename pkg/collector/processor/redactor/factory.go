@@ -0,0 +1,127 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package redactor
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+func init() {
+	processor.Register(define.ProcessorRedactor, NewFactory)
+}
+
+func NewFactory(conf map[string]any, customized []processor.SubConfigProcessor) (processor.Processor, error) {
+	return newFactory(conf, customized)
+}
+
+func newFactory(conf map[string]any, customized []processor.SubConfigProcessor) (*redactor, error) {
+	configs := confengine.NewTierConfig()
+
+	c := &Config{}
+	if err := mapstructure.Decode(conf, c); err != nil {
+		return nil, err
+	}
+	if err := c.Clean(); err != nil {
+		return nil, err
+	}
+	configs.SetGlobal(*c)
+
+	for _, custom := range customized {
+		cfg := &Config{}
+		if err := mapstructure.Decode(custom.Config.Config, cfg); err != nil {
+			logger.Errorf("failed to decode config: %v", err)
+			continue
+		}
+		if err := cfg.Clean(); err != nil {
+			logger.Errorf("failed to clean config, token=%s: %v", custom.Token, err)
+			continue
+		}
+		configs.Set(custom.Token, custom.Type, custom.ID, *cfg)
+	}
+
+	return &redactor{
+		CommonProcessor: processor.NewCommonProcessor(conf, customized),
+		configs:         configs,
+	}, nil
+}
+
+type redactor struct {
+	processor.CommonProcessor
+	configs *confengine.TierConfig // type: Config
+}
+
+func (p *redactor) Name() string {
+	return define.ProcessorRedactor
+}
+
+func (p *redactor) IsDerived() bool {
+	return false
+}
+
+func (p *redactor) IsPreCheck() bool {
+	return false
+}
+
+func (p *redactor) Reload(config map[string]any, customized []processor.SubConfigProcessor) {
+	f, err := newFactory(config, customized)
+	if err != nil {
+		logger.Errorf("failed to reload processor: %v", err)
+		return
+	}
+
+	p.CommonProcessor = f.CommonProcessor
+	p.configs = f.configs
+}
+
+func (p *redactor) Process(record *define.Record) (*define.Record, error) {
+	config := p.configs.GetByToken(record.Token.Original).(Config)
+	for i := 0; i < len(config.Rules); i++ {
+		rule := &config.Rules[i]
+		switch record.RecordType {
+		case define.RecordTraces:
+			p.processTraces(record.Data.(ptrace.Traces), rule)
+		case define.RecordLogs:
+			p.processLogs(record.Data.(plog.Logs), rule)
+		}
+	}
+	return nil, nil
+}
+
+func (p *redactor) processTraces(traces ptrace.Traces, rule *Rule) {
+	foreach.SpansSliceResource(traces, func(rs pcommon.Resource) {
+		rule.redactMap(rs.Attributes(), rule.matchResource)
+	})
+	foreach.Spans(traces, func(span ptrace.Span) {
+		rule.redactMap(span.Attributes(), rule.matchAttribute)
+		events := span.Events()
+		for i := 0; i < events.Len(); i++ {
+			rule.redactMap(events.At(i).Attributes(), rule.matchAttribute)
+		}
+	})
+}
+
+func (p *redactor) processLogs(logs plog.Logs, rule *Rule) {
+	foreach.LogsSliceResource(logs, func(rs pcommon.Resource) {
+		rule.redactMap(rs.Attributes(), rule.matchResource)
+	})
+	foreach.Logs(logs, func(logRecord plog.LogRecord) {
+		rule.redactMap(logRecord.Attributes(), rule.matchAttribute)
+		rule.redactBody(logRecord)
+	})
+}
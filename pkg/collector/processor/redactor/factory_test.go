@@ -0,0 +1,288 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package redactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+)
+
+func TestFactory(t *testing.T) {
+	content := `
+processor:
+  - name: "redactor/common"
+    config:
+      rules:
+        - name: "phone"
+          detector: "phone"
+`
+	mainConf := processor.MustLoadConfigs(content)[0].Config
+
+	customContent := `
+processor:
+  - name: "redactor/common"
+    config:
+      rules:
+        - name: "sql"
+          detector: "sql_literal"
+`
+	customConf := processor.MustLoadConfigs(customContent)[0].Config
+
+	obj, err := NewFactory(mainConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: customConf,
+			},
+		},
+	})
+	factory := obj.(*redactor)
+	assert.NoError(t, err)
+	assert.Equal(t, mainConf, factory.MainConfig())
+
+	mainConfig := factory.configs.GetGlobal().(Config)
+	assert.Equal(t, ActionMask, mainConfig.Rules[0].Action)
+
+	customConfig := factory.configs.GetByToken("token1").(Config)
+	assert.Equal(t, "?", customConfig.Rules[0].Replacement)
+	assert.Equal(t, []string{"attributes.db.statement"}, customConfig.Rules[0].Keys)
+
+	assert.Equal(t, define.ProcessorRedactor, factory.Name())
+	assert.False(t, factory.IsDerived())
+	assert.False(t, factory.IsPreCheck())
+
+	factory.Reload(mainConf, nil)
+	assert.Equal(t, mainConf, factory.MainConfig())
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []string{
+		`{"rules": [{"detector": "unknown"}]}`,
+		`{"rules": [{"detector": "regex"}]}`,
+		`{"rules": [{"detector": "regex", "pattern": "("}]}`,
+		`{"rules": [{"detector": "keyword"}]}`,
+		`{"rules": [{"detector": "email", "action": "unknown"}]}`,
+		`{"rules": [{"detector": "email", "keys": ["span_name"]}]}`,
+	}
+	for _, tt := range tests {
+		content := `
+processor:
+  - name: "redactor/common"
+    config: ` + tt
+		_, err := NewFactory(processor.MustLoadConfigs(content)[0].Config, nil)
+		assert.Error(t, err, tt)
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		input  string
+		output string
+	}{
+		{
+			name:   "phone",
+			rule:   Rule{Detector: DetectorPhone},
+			input:  "call 13812345678 now",
+			output: "call *********** now",
+		},
+		{
+			name:   "phone with prefix",
+			rule:   Rule{Detector: DetectorPhone, Replacement: "<phone>"},
+			input:  "tel:+86 13812345678",
+			output: "tel:<phone>",
+		},
+		{
+			name:   "phone with compact prefix",
+			rule:   Rule{Detector: DetectorPhone, Replacement: "<phone>"},
+			input:  "tel:+8613812345678 or 8613912345678, not 213812345678",
+			output: "tel:<phone> or <phone>, not 213812345678",
+		},
+		{
+			name:   "id card",
+			rule:   Rule{Detector: DetectorIdCard, Replacement: "<id>"},
+			input:  "id=11010519491231002X",
+			output: "id=<id>",
+		},
+		{
+			name:   "email",
+			rule:   Rule{Detector: DetectorEmail, Replacement: "<email>"},
+			input:  "from alice@example.com and bob@example.org",
+			output: "from <email> and <email>",
+		},
+		{
+			name:   "url token",
+			rule:   Rule{Detector: DetectorUrlToken, Replacement: "xxx"},
+			input:  "/api/v1?id=1&access_token=abc123&page=2",
+			output: "/api/v1?id=1&access_token=xxx&page=2",
+		},
+		{
+			name:   "sql literal",
+			rule:   Rule{Detector: DetectorSqlLiteral},
+			input:  "SELECT * FROM user WHERE name = 'alice' AND age > 18 AND t1.id = 3.5",
+			output: "SELECT * FROM user WHERE name = ? AND age > ? AND t1.id = ?",
+		},
+		{
+			name:   "keyword",
+			rule:   Rule{Detector: DetectorKeyword, Keywords: []string{"password"}, Replacement: "***"},
+			input:  `login user=admin password="p@ss" ok`,
+			output: `login user=admin password="***" ok`,
+		},
+		{
+			name:   "regex drop",
+			rule:   Rule{Detector: DetectorRegex, Pattern: `\s*card=\d+`, Action: ActionDrop},
+			input:  "pay card=6222020200000000 done",
+			output: "pay done",
+		},
+		{
+			name:   "not matched",
+			rule:   Rule{Detector: DetectorEmail},
+			input:  "nothing here",
+			output: "nothing here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, tt.rule.Clean())
+			s, _ := tt.rule.redactString(tt.input)
+			assert.Equal(t, tt.output, s)
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	r1 := Rule{Detector: DetectorRegex, Pattern: `u\d+`, Action: ActionHash, Salt: "salt1"}
+	assert.NoError(t, r1.Clean())
+	r2 := Rule{Detector: DetectorRegex, Pattern: `u\d+`, Action: ActionHash, Salt: "salt2"}
+	assert.NoError(t, r2.Clean())
+
+	s1, ok := r1.redactString("user u1")
+	assert.True(t, ok)
+	assert.Len(t, s1, len("user ")+64)
+
+	s, _ := r1.redactString("user u1")
+	assert.Equal(t, s1, s)
+
+	s2, _ := r2.redactString("user u1")
+	assert.NotEqual(t, s1, s2)
+}
+
+func TestProcessTraces(t *testing.T) {
+	content := `
+processor:
+  - name: "redactor/common"
+    config:
+      rules:
+        - name: "sql"
+          detector: "sql_literal"
+        - name: "email"
+          detector: "email"
+          action: "hash"
+          keys:
+            - "attributes.user.email"
+            - "resource.owner"
+        - name: "secret"
+          detector: "keyword"
+          keywords: ["secret"]
+          action: "drop"
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	opts := define.TracesOptions{SpanCount: 1}
+	opts.Attributes = map[string]string{
+		"db.statement": "SELECT * FROM t WHERE id = 10",
+		"user.email":   "alice@example.com",
+		"app.secret":   "foo",
+		"peer.email":   "bob@example.com",
+	}
+	opts.Resources = map[string]string{
+		"owner": "carol@example.com",
+	}
+	traces := generator.NewTracesGenerator(opts).Generate()
+	event := testkits.FirstSpan(traces).Events().AppendEmpty()
+	event.Attributes().PutString("db.statement", "DELETE FROM t WHERE name = 'bob'")
+
+	record := define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	}
+	testkits.MustProcess(t, factory, record)
+
+	span := testkits.FirstSpan(record.Data.(ptrace.Traces))
+	attrs := span.Attributes()
+	testkits.AssertAttrsStringKeyVal(t, attrs,
+		"db.statement", "SELECT * FROM t WHERE id = ?",
+		"peer.email", "bob@example.com",
+	)
+	testkits.AssertAttrsNotFound(t, attrs, "app.secret")
+
+	v, _ := attrs.Get("user.email")
+	assert.Len(t, v.AsString(), 64)
+	assert.NotEqual(t, "alice@example.com", v.AsString())
+
+	rs := record.Data.(ptrace.Traces).ResourceSpans().At(0).Resource().Attributes()
+	v, _ = rs.Get("owner")
+	assert.Len(t, v.AsString(), 64)
+
+	v, _ = span.Events().At(0).Attributes().Get("db.statement")
+	assert.Equal(t, "DELETE FROM t WHERE name = ?", v.AsString())
+}
+
+func TestProcessLogs(t *testing.T) {
+	content := `
+processor:
+  - name: "redactor/common"
+    config:
+      rules:
+        - name: "phone"
+          detector: "phone"
+          keys:
+            - "body"
+            - "attributes.phone"
+        - name: "token"
+          detector: "url_token"
+          action: "drop"
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	opts := define.LogsOptions{LogCount: 1, LogLength: 10}
+	opts.Attributes = map[string]string{
+		"phone":    "13812345678",
+		"mobile":   "13812345678",
+		"http.url": "/login?token=abc",
+	}
+	logs := generator.NewLogsGenerator(opts).Generate()
+	testkits.FirstLogRecord(logs).Body().SetStr("user 13812345678 login with /login?token=abc")
+
+	record := define.Record{
+		RecordType: define.RecordLogs,
+		Data:       logs,
+	}
+	testkits.MustProcess(t, factory, record)
+
+	logRecord := testkits.FirstLogRecord(record.Data.(plog.Logs))
+	testkits.AssertAttrsStringKeyVal(t, logRecord.Attributes(),
+		"phone", "***********",
+		"mobile", "13812345678",
+	)
+	testkits.AssertAttrsNotFound(t, logRecord.Attributes(), "http.url")
+	assert.Equal(t, "user *********** login with /login?token=", logRecord.Body().AsString())
+}
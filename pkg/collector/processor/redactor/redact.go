@@ -0,0 +1,112 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package redactor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// transform 按 action 对命中内容进行变换 drop 时返回空串
+func (r *Rule) transform(s string) string {
+	switch r.Action {
+	case ActionHash:
+		h := sha256.Sum256([]byte(r.Salt + s))
+		return hex.EncodeToString(h[:])
+	case ActionDrop:
+		return ""
+	}
+	if r.Replacement != "" {
+		return r.Replacement
+	}
+	return strings.Repeat("*", utf8.RuneCountInString(s))
+}
+
+// redactString 处理文本中所有命中片段 返回处理后的文本以及是否有命中
+func (r *Rule) redactString(s string) (string, bool) {
+	matches := r.re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, false
+	}
+
+	var sb strings.Builder
+	var last int
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if r.group > 0 {
+			start, end = m[2*r.group], m[2*r.group+1]
+		}
+		if start < 0 || start < last {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(r.transform(s[start:end]))
+		last = end
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), true
+}
+
+// redactMap 处理 attributes 字段 drop 动作会移除整个字段
+func (r *Rule) redactMap(attrs pcommon.Map, match func(key string) bool) {
+	var drops []string
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		if !match(k) {
+			return true
+		}
+
+		// 字段名命中关键字时 整个字段值均视为敏感内容
+		if r.matchKeyword(k) {
+			if r.Action == ActionDrop {
+				drops = append(drops, k)
+				return true
+			}
+			v.SetStr(r.transform(v.AsString()))
+			return true
+		}
+
+		if v.Type() != pcommon.ValueTypeStr {
+			return true
+		}
+		s, ok := r.redactString(v.Str())
+		if !ok {
+			return true
+		}
+		if r.Action == ActionDrop {
+			drops = append(drops, k)
+			return true
+		}
+		v.SetStr(s)
+		return true
+	})
+
+	for _, k := range drops {
+		attrs.Remove(k)
+	}
+}
+
+// redactBody 处理日志 body drop 动作仅移除命中片段
+func (r *Rule) redactBody(logRecord plog.LogRecord) {
+	if !r.matchBody() {
+		return
+	}
+	body := logRecord.Body()
+	if body.Type() != pcommon.ValueTypeStr {
+		return
+	}
+	if s, ok := r.redactString(body.Str()); ok {
+		body.SetStr(s)
+	}
+}
@@ -38,6 +38,57 @@ processor:
       max_spans: 100 # 每个 traces 最多允许的 spans 数量
       status_code: # ERROR|OK|UNSET
       - "ERROR"
+
+  # 耗时采样 trace 中存在耗时不小于 min_duration 的 span 即采样
+  - name: "sampler/duration"
+    config:
+      type: "duration"
+      storage_policy: "post"
+      max_duration: "1m"
+      min_duration: "1s"
+
+  # 属性采样 所有规则均匹配的 span 所在 trace 会被采样
+  # key 支持 resource.xxx/attributes.xxx/span_name/kind/status.code
+  # op 支持 reg/eq/nq/startswith/nstartswith/endswith/nendswith/contains/ncontains
+  - name: "sampler/attribute"
+    config:
+      type: "attribute"
+      max_duration: "1m"
+      attributes:
+        - key: "attributes.http.url"
+          op: "reg"
+          values: ["^/api/pay/.*"]
+
+  # 按服务限速采样 每个服务每秒最多采样 traces_per_second 条 trace
+  # 服务闲置超过 max_duration (默认 1m, 不足 1s 时使用默认值) 后回收其令牌桶 服务数超过 10000 时新服务共用同一个令牌桶
+  - name: "sampler/service_rate"
+    config:
+      type: "service_rate"
+      max_duration: "1m"
+      service_key: "service.name" # resource 中标识服务的字段
+      traces_per_second: 10
+
+  # 组合采样 operator 支持 and/or
+  # max_traces_per_second 为子策略的每秒预算 不配置表示不限制
+  # and 组合下所有子策略预算均充足时才统一扣减
+  - name: "sampler/composite"
+    config:
+      type: "composite"
+      storage_policy: "full"
+      max_duration: "1m"
+      operator: "or"
+      policies:
+        - type: "status_code"
+          status_code: ["ERROR"]
+        - type: "duration"
+          min_duration: "3s"
+          max_traces_per_second: 50
+        - type: "attribute"
+          max_traces_per_second: 20
+          attributes:
+            - key: "resource.service.name"
+              op: "eq"
+              values: ["payment"]
 */
 
 package sampler
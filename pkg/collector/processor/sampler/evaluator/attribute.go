@@ -0,0 +1,144 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"regexp"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/fields"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/opmatch"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+// AttributeMatch 描述字段匹配规则
+//
+// key 支持 resource.xxx / attributes.xxx 以及 span_name/kind/status.code 等 span 内置字段
+// op 支持 opmatch 中的所有操作符 values 中任意一个匹配即视为命中
+type AttributeMatch struct {
+	Key    string   `config:"key" mapstructure:"key"`
+	Op     string   `config:"op" mapstructure:"op"`
+	Values []string `config:"values" mapstructure:"values"`
+}
+
+type attributeMatcher struct {
+	from    fields.FieldFrom
+	key     string
+	op      string
+	values  []string
+	regexps []*regexp.Regexp
+}
+
+// attributePolicy 命中所有匹配规则的 span
+type attributePolicy struct {
+	fetcher  fields.SpanFieldFetcher
+	matchers []attributeMatcher
+}
+
+func newAttributePolicy(matches []AttributeMatch) attributePolicy {
+	matchers := make([]attributeMatcher, 0, len(matches))
+	for _, m := range matches {
+		from, key := fields.DecodeFieldFrom(m.Key)
+		if from == fields.FieldFromUnknown {
+			continue
+		}
+
+		matcher := attributeMatcher{
+			from:   from,
+			key:    key,
+			op:     m.Op,
+			values: m.Values,
+		}
+		// 正则提前编译 避免每个 span 重复编译
+		if opmatch.Op(m.Op) == opmatch.OpReg {
+			for _, v := range m.Values {
+				re, err := regexp.Compile(v)
+				if err != nil {
+					logger.Warnf("sampler: invalid regexp '%s' of key '%s': %v", v, m.Key, err)
+					continue
+				}
+				matcher.regexps = append(matcher.regexps, re)
+			}
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return attributePolicy{
+		fetcher:  fields.NewSpanFieldFetcher(),
+		matchers: matchers,
+	}
+}
+
+func (p attributePolicy) fetch(rs pcommon.Map, span ptrace.Span, m attributeMatcher) (string, bool) {
+	switch m.from {
+	case fields.FieldFromResource:
+		v, ok := rs.Get(m.key)
+		if !ok {
+			return "", false
+		}
+		return v.AsString(), true
+	case fields.FieldFromAttributes:
+		v, ok := span.Attributes().Get(m.key)
+		if !ok {
+			return "", false
+		}
+		return v.AsString(), true
+	default:
+		s := p.fetcher.FetchMethod(span, m.key)
+		return s, s != ""
+	}
+}
+
+func (p attributePolicy) Match(rs pcommon.Map, span ptrace.Span) bool {
+	if len(p.matchers) == 0 {
+		return false
+	}
+
+	for _, m := range p.matchers {
+		input, ok := p.fetch(rs, span, m)
+		if !ok {
+			return false
+		}
+
+		var matched bool
+		if opmatch.Op(m.op) == opmatch.OpReg {
+			for _, re := range m.regexps {
+				if re.MatchString(input) {
+					matched = true
+					break
+				}
+			}
+		} else {
+			for _, v := range m.values {
+				if opmatch.Match(input, v, m.op) {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// attributeEvaluator 属性采样 保留包含业务关键 span 的 trace
+type attributeEvaluator struct {
+	*tailEvaluator
+}
+
+func newAttributeEvaluator(config Config) *attributeEvaluator {
+	return &attributeEvaluator{
+		tailEvaluator: newTailEvaluator(evaluatorTypeAttribute, config, newAttributePolicy(config.Attributes)),
+	}
+}
@@ -0,0 +1,115 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/random"
+)
+
+func TestAttributePolicy(t *testing.T) {
+	rsAttrs := pcommon.NewMap()
+	rsAttrs.PutString("service.name", "order")
+
+	span := ptrace.NewSpan()
+	span.SetName("POST /api/pay")
+	span.Attributes().PutString("http.method", "POST")
+	span.Attributes().PutInt("http.status_code", 500)
+
+	tests := []struct {
+		name    string
+		matches []AttributeMatch
+		want    bool
+	}{
+		{
+			name:    "resource eq",
+			matches: []AttributeMatch{{Key: "resource.service.name", Op: "eq", Values: []string{"user", "order"}}},
+			want:    true,
+		},
+		{
+			name:    "attributes int value",
+			matches: []AttributeMatch{{Key: "attributes.http.status_code", Op: "startswith", Values: []string{"5"}}},
+			want:    true,
+		},
+		{
+			name:    "method regexp",
+			matches: []AttributeMatch{{Key: "span_name", Op: "reg", Values: []string{"^POST /api/.*"}}},
+			want:    true,
+		},
+		{
+			name: "all matched",
+			matches: []AttributeMatch{
+				{Key: "resource.service.name", Op: "eq", Values: []string{"order"}},
+				{Key: "attributes.http.method", Op: "eq", Values: []string{"GET"}},
+			},
+			want: false,
+		},
+		{
+			name:    "missing key",
+			matches: []AttributeMatch{{Key: "attributes.db.system", Op: "nq", Values: []string{"mysql"}}},
+			want:    false,
+		},
+		{
+			name:    "invalid regexp",
+			matches: []AttributeMatch{{Key: "span_name", Op: "reg", Values: []string{"(POST"}}},
+			want:    false,
+		},
+		{
+			name: "empty",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newAttributePolicy(tt.matches)
+			assert.Equal(t, tt.want, policy.Match(rsAttrs, span))
+		})
+	}
+}
+
+func TestAttributeEvaluator(t *testing.T) {
+	evaluator := newAttributeEvaluator(Config{
+		MaxDuration: time.Second,
+		Attributes: []AttributeMatch{
+			{Key: "attributes.biz.vip", Op: "eq", Values: []string{"true"}},
+		},
+	})
+	defer evaluator.Stop()
+
+	t1 := random.TraceID()
+	t2 := random.TraceID()
+
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	span1 := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span1.SetTraceID(t1)
+	span1.Attributes().PutBool("biz.vip", true) // 采样
+
+	span2 := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span2.SetTraceID(t1) // 采样（同一条 trace）
+
+	span3 := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span3.SetTraceID(t2) // 未采样
+
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 2, traces.SpanCount())
+	assert.Len(t, evaluator.traces, 1)
+}
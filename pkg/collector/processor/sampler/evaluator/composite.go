@@ -0,0 +1,169 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"golang.org/x/time/rate"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	compositeOperatorAnd = "and"
+	compositeOperatorOr  = "or"
+)
+
+// PolicyConfig composite evaluator 子策略配置
+//
+// MaxTracesPerSecond 为该策略每秒最多准入的 trace 数量 <= 0 表示不限制
+type PolicyConfig struct {
+	Type               string           `config:"type" mapstructure:"type"`
+	StatusCode         []string         `config:"status_code" mapstructure:"status_code"`
+	MinDuration        time.Duration    `config:"min_duration" mapstructure:"min_duration"`
+	Attributes         []AttributeMatch `config:"attributes" mapstructure:"attributes"`
+	MaxTracesPerSecond float64          `config:"max_traces_per_second" mapstructure:"max_traces_per_second"`
+}
+
+type subPolicy struct {
+	typ     string
+	policy  spanPolicy
+	limiter *rate.Limiter
+}
+
+func newSubPolicy(c PolicyConfig) (subPolicy, bool) {
+	var policy spanPolicy
+	switch c.Type {
+	case evaluatorTypeStatusCode:
+		policy = newStatusCodePolicy(c.StatusCode)
+	case evaluatorTypeDuration:
+		policy = newDurationPolicy(c.MinDuration)
+	case evaluatorTypeAttribute:
+		policy = newAttributePolicy(c.Attributes)
+	default:
+		logger.Warnf("sampler: unsupported composite policy type '%s'", c.Type)
+		return subPolicy{}, false
+	}
+
+	sp := subPolicy{typ: c.Type, policy: policy}
+	if c.MaxTracesPerSecond > 0 {
+		burst := int(c.MaxTracesPerSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		sp.limiter = rate.NewLimiter(rate.Limit(c.MaxTracesPerSecond), burst)
+	}
+	return sp, true
+}
+
+func (sp subPolicy) tryAccept(now time.Time) bool {
+	if sp.limiter == nil {
+		return true
+	}
+	return sp.limiter.AllowN(now, 1)
+}
+
+// hasToken 仅检查预算 不消耗令牌
+func (sp subPolicy) hasToken(now time.Time) bool {
+	if sp.limiter == nil {
+		return true
+	}
+	return sp.limiter.TokensAt(now) >= 1
+}
+
+// compositePolicy 按 and/or 组合多个子策略
+//
+// or: 任一子策略命中且其预算充足即准入
+// and: 所有子策略均命中且预算充足才准入 先检查全部预算再统一扣减 避免部分扣减导致欠采样
+type compositePolicy struct {
+	mut      sync.Mutex
+	operator string
+	policies []subPolicy
+}
+
+func newCompositePolicy(operator string, configs []PolicyConfig) *compositePolicy {
+	operator = strings.ToLower(operator)
+	if operator != compositeOperatorAnd {
+		operator = compositeOperatorOr
+	}
+
+	policies := make([]subPolicy, 0, len(configs))
+	for _, c := range configs {
+		sp, ok := newSubPolicy(c)
+		if !ok {
+			continue
+		}
+		policies = append(policies, sp)
+	}
+	return &compositePolicy{operator: operator, policies: policies}
+}
+
+func (p *compositePolicy) Match(rs pcommon.Map, span ptrace.Span) bool {
+	if len(p.policies) == 0 {
+		return false
+	}
+
+	for _, sp := range p.policies {
+		matched := sp.policy.Match(rs, span)
+		if p.operator == compositeOperatorOr && matched {
+			return true
+		}
+		if p.operator == compositeOperatorAnd && !matched {
+			return false
+		}
+	}
+	return p.operator == compositeOperatorAnd
+}
+
+func (p *compositePolicy) Admit(rs pcommon.Map, span ptrace.Span) bool {
+	now := time.Now()
+	if p.operator == compositeOperatorAnd {
+		// 检查与扣减须原子执行 否则并发时仍可能出现部分扣减
+		p.mut.Lock()
+		defer p.mut.Unlock()
+
+		for _, sp := range p.policies {
+			if !sp.hasToken(now) {
+				return false
+			}
+		}
+		for _, sp := range p.policies {
+			sp.tryAccept(now)
+		}
+		return true
+	}
+
+	for _, sp := range p.policies {
+		if !sp.policy.Match(rs, span) {
+			continue
+		}
+		if sp.tryAccept(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// compositeEvaluator 组合采样 多个策略按 and/or 组合 并支持为每个策略设置预算
+type compositeEvaluator struct {
+	*tailEvaluator
+}
+
+func newCompositeEvaluator(config Config) *compositeEvaluator {
+	policy := newCompositePolicy(config.Operator, config.Policies)
+	return &compositeEvaluator{
+		tailEvaluator: newTailEvaluator(evaluatorTypeComposite, config, policy),
+	}
+}
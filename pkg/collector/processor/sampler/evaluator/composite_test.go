@@ -0,0 +1,120 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/random"
+)
+
+func makeCompositeSpan(spans ptrace.SpanSlice, traceID pcommon.TraceID, code ptrace.StatusCode, d time.Duration) {
+	now := time.Now()
+	span := spans.AppendEmpty()
+	span.SetTraceID(traceID)
+	span.Status().SetCode(code)
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(now.Add(d)))
+}
+
+func TestCompositeEvaluatorOr(t *testing.T) {
+	evaluator := newCompositeEvaluator(Config{
+		MaxDuration: time.Minute,
+		Operator:    "or",
+		Policies: []PolicyConfig{
+			{Type: "status_code", StatusCode: []string{"ERROR"}},
+			{Type: "duration", MinDuration: time.Second, MaxTracesPerSecond: 1},
+		},
+	})
+	defer evaluator.Stop()
+
+	t1 := random.TraceID()
+	t2 := random.TraceID()
+	t3 := random.TraceID()
+	t4 := random.TraceID()
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	makeCompositeSpan(spans, t1, ptrace.StatusCodeError, time.Millisecond) // 采样（错误 无预算限制）
+	makeCompositeSpan(spans, t2, ptrace.StatusCodeOk, 2*time.Second)       // 采样（慢请求 消耗预算）
+	makeCompositeSpan(spans, t3, ptrace.StatusCodeOk, 2*time.Second)       // 未采样（预算耗尽）
+	makeCompositeSpan(spans, t4, ptrace.StatusCodeOk, time.Millisecond)    // 未采样
+
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 2, traces.SpanCount())
+	assert.Len(t, evaluator.traces, 2)
+	assert.True(t, evaluator.isSampled(t1))
+	assert.True(t, evaluator.isSampled(t2))
+}
+
+func TestCompositeEvaluatorAnd(t *testing.T) {
+	evaluator := newCompositeEvaluator(Config{
+		MaxDuration: time.Minute,
+		Operator:    "AND",
+		Policies: []PolicyConfig{
+			{Type: "status_code", StatusCode: []string{"ERROR"}},
+			{Type: "duration", MinDuration: time.Second},
+			{Type: "unknown"},
+		},
+	})
+	defer evaluator.Stop()
+
+	t1 := random.TraceID()
+	t2 := random.TraceID()
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	makeCompositeSpan(spans, t1, ptrace.StatusCodeError, 2*time.Second)    // 采样
+	makeCompositeSpan(spans, t2, ptrace.StatusCodeError, time.Millisecond) // 未采样
+
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 1, traces.SpanCount())
+	assert.True(t, evaluator.isSampled(t1))
+}
+
+func TestCompositeEvaluatorEmpty(t *testing.T) {
+	evaluator := newCompositeEvaluator(Config{MaxDuration: time.Minute})
+	defer evaluator.Stop()
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	makeCompositeSpan(spans, random.TraceID(), ptrace.StatusCodeError, time.Second)
+
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 0, traces.SpanCount())
+}
+
+func TestCompositePolicyAndBudget(t *testing.T) {
+	policy := newCompositePolicy("and", []PolicyConfig{
+		{Type: "status_code", StatusCode: []string{"ERROR"}, MaxTracesPerSecond: 1},
+		{Type: "duration", MinDuration: time.Second, MaxTracesPerSecond: 1},
+	})
+
+	// 后一个策略预算耗尽时 前一个策略的预算不应被扣减
+	now := time.Now()
+	assert.True(t, policy.policies[1].limiter.AllowN(now, 1))
+	assert.False(t, policy.Admit(pcommon.NewMap(), ptrace.NewSpan()))
+	assert.True(t, policy.policies[0].hasToken(time.Now()))
+}
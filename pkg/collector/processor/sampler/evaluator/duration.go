@@ -0,0 +1,45 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// durationPolicy 命中耗时不小于 minDuration 的 span
+type durationPolicy struct {
+	minDuration time.Duration
+}
+
+func newDurationPolicy(minDuration time.Duration) durationPolicy {
+	return durationPolicy{minDuration: minDuration}
+}
+
+func (p durationPolicy) Match(_ pcommon.Map, span ptrace.Span) bool {
+	start, end := span.StartTimestamp(), span.EndTimestamp()
+	if end < start {
+		return false
+	}
+	return time.Duration(end-start) >= p.minDuration
+}
+
+// durationEvaluator 耗时采样 保留包含慢 span 的 trace
+type durationEvaluator struct {
+	*tailEvaluator
+}
+
+func newDurationEvaluator(config Config) *durationEvaluator {
+	return &durationEvaluator{
+		tailEvaluator: newTailEvaluator(evaluatorTypeDuration, config, newDurationPolicy(config.MinDuration)),
+	}
+}
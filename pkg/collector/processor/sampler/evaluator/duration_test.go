@@ -0,0 +1,69 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/random"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+)
+
+func TestDurationEvaluator(t *testing.T) {
+	evaluator := newDurationEvaluator(Config{
+		MaxDuration: time.Second,
+		MinDuration: time.Second,
+	})
+	defer evaluator.Stop()
+
+	now := time.Now()
+	t1 := random.TraceID()
+	t2 := random.TraceID()
+
+	// round1
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	span1 := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span1.SetTraceID(t1)
+	span1.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	span1.SetEndTimestamp(pcommon.NewTimestampFromTime(now.Add(2 * time.Second))) // 采样
+
+	span2 := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span2.SetTraceID(t2)
+	span2.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	span2.SetEndTimestamp(pcommon.NewTimestampFromTime(now.Add(time.Millisecond))) // 未采样
+
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+
+	assert.Equal(t, 1, traces.SpanCount())
+	assert.Equal(t, t1, testkits.FirstSpan(traces).TraceID())
+
+	// round2
+	traces = ptrace.NewTraces()
+	rs = traces.ResourceSpans().AppendEmpty()
+	span3 := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span3.SetTraceID(t1) // 采样（已经出现过慢 span）
+
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 1, traces.SpanCount())
+	assert.Len(t, evaluator.traces, 1)
+}
@@ -23,20 +23,38 @@ type Config struct {
 	// random evaluator
 	SamplingPercentage float64 `config:"sampling_percentage" mapstructure:"sampling_percentage"`
 
-	// status_code evaluator
+	// status_code/duration/attribute/service_rate/composite evaluator
 	MaxDuration time.Duration `config:"max_duration" mapstructure:"max_duration"`
 	StatusCode  []string      `config:"status_code" mapstructure:"status_code"`
 
+	// duration evaluator
+	MinDuration time.Duration `config:"min_duration" mapstructure:"min_duration"`
+
+	// attribute evaluator
+	Attributes []AttributeMatch `config:"attributes" mapstructure:"attributes"`
+
+	// service_rate evaluator
+	ServiceKey      string  `config:"service_key" mapstructure:"service_key"`
+	TracesPerSecond float64 `config:"traces_per_second" mapstructure:"traces_per_second"`
+
+	// composite evaluator
+	Operator string         `config:"operator" mapstructure:"operator"`
+	Policies []PolicyConfig `config:"policies" mapstructure:"policies"`
+
 	// drop evaluator
 	// 目前 enabled 字段只对 drop evaluator 生效
 	Enabled bool `config:"enabled" mapstructure:"enabled"`
 }
 
 const (
-	evaluatorTypeAlways     = "always"
-	evaluatorTypeDrop       = "drop"
-	evaluatorTypeRandom     = "random"
-	evaluatorTypeStatusCode = "status_code"
+	evaluatorTypeAlways      = "always"
+	evaluatorTypeDrop        = "drop"
+	evaluatorTypeRandom      = "random"
+	evaluatorTypeStatusCode  = "status_code"
+	evaluatorTypeDuration    = "duration"
+	evaluatorTypeAttribute   = "attribute"
+	evaluatorTypeServiceRate = "service_rate"
+	evaluatorTypeComposite   = "composite"
 )
 
 type Evaluator interface {
@@ -51,6 +69,14 @@ func New(c Config) Evaluator {
 		return newRandomEvaluator(c)
 	case evaluatorTypeStatusCode:
 		return newStatusCodeEvaluator(c)
+	case evaluatorTypeDuration:
+		return newDurationEvaluator(c)
+	case evaluatorTypeAttribute:
+		return newAttributeEvaluator(c)
+	case evaluatorTypeServiceRate:
+		return newServiceRateEvaluator(c)
+	case evaluatorTypeComposite:
+		return newCompositeEvaluator(c)
 	case evaluatorTypeDrop:
 		return newDropEvaluator(c)
 	}
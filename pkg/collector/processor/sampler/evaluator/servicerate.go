@@ -0,0 +1,196 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/fasttime"
+)
+
+const (
+	defaultServiceKey = "service.name"
+
+	// maxServiceLimiters 令牌桶数量上限 超出后新服务共用 overflowService 的令牌桶
+	maxServiceLimiters = 10000
+	overflowService    = "__overflow__"
+
+	// defaultServiceRateMaxDuration 未配置 max_duration 时决策及令牌桶的保留时长
+	defaultServiceRateMaxDuration = time.Minute
+)
+
+type traceDecision struct {
+	keep bool
+	ts   int64
+}
+
+type serviceLimiter struct {
+	limiter flowcontrol.RateLimiter
+	ts      int64
+}
+
+// serviceRateEvaluator 按服务限制每秒采样的 trace 数量
+//
+// trace 首次出现时根据其所属服务的令牌桶决定是否采样 决策会被记录
+// maxDuration 时间内该 trace 后续的 span 沿用首次决策 保证 trace 完整性
+// 服务的令牌桶闲置超过 maxDuration 后被回收 数量达到上限时新服务共用同一个令牌桶
+type serviceRateEvaluator struct {
+	mut             sync.Mutex
+	serviceKey      string
+	tracesPerSecond float32
+	limiters        map[string]*serviceLimiter
+	decisions       map[pcommon.TraceID]traceDecision
+	maxDuration     time.Duration
+	stop            chan struct{}
+	gcInterval      time.Duration
+}
+
+func newServiceRateEvaluator(config Config) *serviceRateEvaluator {
+	serviceKey := config.ServiceKey
+	if serviceKey == "" {
+		serviceKey = defaultServiceKey
+	}
+	// 保留时长不足 1s 时每次 gc 都会清空决策及令牌桶 导致限速失效
+	maxDuration := config.MaxDuration
+	if maxDuration < time.Second {
+		maxDuration = defaultServiceRateMaxDuration
+	}
+
+	eval := &serviceRateEvaluator{
+		serviceKey:      serviceKey,
+		tracesPerSecond: float32(config.TracesPerSecond),
+		limiters:        make(map[string]*serviceLimiter),
+		decisions:       make(map[pcommon.TraceID]traceDecision),
+		maxDuration:     maxDuration,
+		stop:            make(chan struct{}),
+	}
+	go eval.gc()
+	return eval
+}
+
+func (e *serviceRateEvaluator) Evaluate(record *define.Record) error {
+	switch record.RecordType {
+	case define.RecordTraces:
+		e.processTraces(record.Data.(ptrace.Traces))
+	}
+	return nil
+}
+
+func (e *serviceRateEvaluator) Type() string {
+	return evaluatorTypeServiceRate
+}
+
+func (e *serviceRateEvaluator) Stop() {
+	close(e.stop)
+
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	for _, sl := range e.limiters {
+		sl.limiter.Stop()
+	}
+}
+
+// decide 调用方需持有锁
+func (e *serviceRateEvaluator) decide(service string, now int64) bool {
+	if e.tracesPerSecond <= 0 {
+		return false
+	}
+
+	sl, ok := e.limiters[service]
+	if !ok && len(e.limiters) >= maxServiceLimiters {
+		service = overflowService
+		sl, ok = e.limiters[service]
+	}
+	if !ok {
+		burst := int(e.tracesPerSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		sl = &serviceLimiter{limiter: flowcontrol.NewTokenBucketRateLimiter(e.tracesPerSecond, burst)}
+		e.limiters[service] = sl
+	}
+	sl.ts = now
+	return sl.limiter.TryAccept()
+}
+
+func (e *serviceRateEvaluator) processTraces(pdTraces ptrace.Traces) {
+	now := fasttime.UnixTimestamp()
+	keep := make(map[pcommon.TraceID]bool)
+
+	e.mut.Lock()
+	foreach.SpansWithResource(pdTraces, func(rs pcommon.Map, span ptrace.Span) {
+		traceID := span.TraceID()
+		if _, ok := keep[traceID]; ok {
+			return
+		}
+
+		d, ok := e.decisions[traceID]
+		if !ok {
+			var service string
+			if v, exist := rs.Get(e.serviceKey); exist {
+				service = v.AsString()
+			}
+			d = traceDecision{keep: e.decide(service, now)}
+		}
+		d.ts = now
+		e.decisions[traceID] = d
+		keep[traceID] = d.keep
+	})
+	e.mut.Unlock()
+
+	foreach.SpansRemoveIf(pdTraces, func(span ptrace.Span) bool {
+		return !keep[span.TraceID()]
+	})
+}
+
+func (e *serviceRateEvaluator) gc() {
+	d := e.gcInterval
+	if d <= 0 {
+		d = time.Minute
+	}
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	maxDuration := int64(e.maxDuration.Seconds())
+	for {
+		select {
+		case <-e.stop:
+			return
+
+		case <-ticker.C:
+			e.evict(time.Now().Unix(), maxDuration)
+		}
+	}
+}
+
+// evict 清理过期的决策以及闲置的令牌桶
+func (e *serviceRateEvaluator) evict(now, maxDuration int64) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	for traceID, decision := range e.decisions {
+		if now-decision.ts > maxDuration {
+			delete(e.decisions, traceID)
+		}
+	}
+	for service, sl := range e.limiters {
+		if now-sl.ts > maxDuration {
+			sl.limiter.Stop()
+			delete(e.limiters, service)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/random"
+)
+
+func makeServiceTraces(service string, traceIDs ...pcommon.TraceID) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutString("service.name", service)
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	for _, traceID := range traceIDs {
+		span := spans.AppendEmpty()
+		span.SetTraceID(traceID)
+	}
+	return traces
+}
+
+func TestServiceRateEvaluator(t *testing.T) {
+	evaluator := newServiceRateEvaluator(Config{
+		MaxDuration:     time.Minute,
+		TracesPerSecond: 1,
+	})
+	defer evaluator.Stop()
+
+	t1 := random.TraceID()
+	t2 := random.TraceID()
+	t3 := random.TraceID()
+
+	// round1: 每个服务每秒仅准入 1 条 trace
+	traces := makeServiceTraces("order", t1, t2, t1)
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 2, traces.SpanCount())
+	assert.Len(t, evaluator.decisions, 2)
+
+	// round2: 不同服务的预算相互独立
+	traces = makeServiceTraces("user", t3)
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 1, traces.SpanCount())
+
+	// round3: 已决策的 trace 沿用首次决策
+	traces = makeServiceTraces("order", t1, t2)
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 1, traces.SpanCount())
+	assert.Len(t, evaluator.decisions, 3)
+}
+
+func TestServiceRateEvaluatorZeroRate(t *testing.T) {
+	evaluator := newServiceRateEvaluator(Config{MaxDuration: time.Minute})
+	defer evaluator.Stop()
+
+	traces := makeServiceTraces("order", random.TraceID())
+	_ = evaluator.Evaluate(&define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+	})
+	assert.Equal(t, 0, traces.SpanCount())
+}
+
+func TestServiceRateEvaluatorEvict(t *testing.T) {
+	evaluator := newServiceRateEvaluator(Config{
+		MaxDuration:     time.Minute,
+		TracesPerSecond: 1,
+	})
+	defer evaluator.Stop()
+
+	for i := 0; i < maxServiceLimiters+10; i++ {
+		evaluator.mut.Lock()
+		evaluator.decide(fmt.Sprintf("service-%d", i), 100)
+		evaluator.mut.Unlock()
+	}
+	// 超出上限的服务共用同一个令牌桶
+	assert.Len(t, evaluator.limiters, maxServiceLimiters+1)
+	assert.Contains(t, evaluator.limiters, overflowService)
+
+	evaluator.evict(100+60, 60)
+	assert.Len(t, evaluator.limiters, maxServiceLimiters+1)
+	evaluator.evict(100+61, 60)
+	assert.Len(t, evaluator.limiters, 0)
+}
+
+func TestServiceRateEvaluatorDefaultMaxDuration(t *testing.T) {
+	evaluator := newServiceRateEvaluator(Config{TracesPerSecond: 1})
+	defer evaluator.Stop()
+	assert.Equal(t, defaultServiceRateMaxDuration, evaluator.maxDuration)
+
+	evaluator.mut.Lock()
+	evaluator.decide("service", 100)
+	evaluator.mut.Unlock()
+
+	// 未配置 max_duration 时 gc 不会清空刚使用过的令牌桶
+	evaluator.evict(101, int64(evaluator.maxDuration.Seconds()))
+	assert.Len(t, evaluator.limiters, 1)
+}
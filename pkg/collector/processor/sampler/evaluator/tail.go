@@ -0,0 +1,204 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/batchspliter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/sampler/queue"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/fasttime"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+// spanPolicy 判断 span 是否命中采样条件 命中后整条 trace 都会被保留
+type spanPolicy interface {
+	Match(rs pcommon.Map, span ptrace.Span) bool
+}
+
+// admitter 为可选接口 trace 首次命中时判断是否准入 用于预算控制
+// 已经被采样的 trace 不会再调用 Admit 避免重复消耗预算
+type admitter interface {
+	Admit(rs pcommon.Map, span ptrace.Span) bool
+}
+
+// tailEvaluator 尾部采样通用实现
+//
+// 命中 policy 的 trace 会被记录 maxDuration 时间内该 trace 的所有 span 都会被保留
+// 未命中的 span 在 full 存储策略下会缓存在 queue 中 待 trace 命中后一并上报
+type tailEvaluator struct {
+	typ         string
+	mut         sync.RWMutex
+	traces      map[pcommon.TraceID]int64
+	policy      spanPolicy
+	storage     queue.Policy
+	q           *queue.Queue
+	maxDuration time.Duration
+	stop        chan struct{}
+	gcInterval  time.Duration
+}
+
+func newTailEvaluator(typ string, config Config, policy spanPolicy) *tailEvaluator {
+	maxSpans := config.MaxSpan
+	if maxSpans <= 0 {
+		maxSpans = 100
+	}
+
+	eval := &tailEvaluator{
+		typ:         typ,
+		storage:     queue.Policy(config.StoragePolicy),
+		traces:      make(map[pcommon.TraceID]int64),
+		q:           queue.New(config.StoragePolicy, maxSpans),
+		policy:      policy,
+		maxDuration: config.MaxDuration,
+		stop:        make(chan struct{}),
+	}
+	go eval.gc()
+	return eval
+}
+
+func (e *tailEvaluator) Evaluate(record *define.Record) error {
+	switch record.RecordType {
+	case define.RecordTraces:
+		e.processTraces(record)
+	}
+	return nil
+}
+
+func (e *tailEvaluator) Type() string {
+	return e.typ
+}
+
+func (e *tailEvaluator) Stop() {
+	close(e.stop)
+	e.q.Clean()
+}
+
+func (e *tailEvaluator) isSampled(traceID pcommon.TraceID) bool {
+	e.mut.RLock()
+	defer e.mut.RUnlock()
+
+	_, ok := e.traces[traceID]
+	return ok
+}
+
+func (e *tailEvaluator) markSampled(traceID pcommon.TraceID) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.traces[traceID] = fasttime.UnixTimestamp()
+}
+
+func (e *tailEvaluator) processTraces(record *define.Record) {
+	dataID := record.Token.TracesDataId
+	pdTraces := record.Data.(ptrace.Traces)
+	adm, hasAdmitter := e.policy.(admitter)
+
+	// 先遍历一遍确定哪些 span 需要采样 并记录 traceID
+	allTraceIDs := make(map[pcommon.TraceID]struct{})
+	foreach.SpansWithResource(pdTraces, func(rs pcommon.Map, span ptrace.Span) {
+		traceID := span.TraceID()
+		allTraceIDs[traceID] = struct{}{}
+		if !e.policy.Match(rs, span) {
+			return
+		}
+
+		if hasAdmitter && !e.isSampled(traceID) && !adm.Admit(rs, span) {
+			return
+		}
+		e.markSampled(traceID)
+	})
+
+	// 如果 Full 的话需要将 traces 按 span 切分
+	var batch []ptrace.Traces
+	if e.storage.IsFull() {
+		batch = batchspliter.SplitEachSpans(pdTraces)
+	}
+
+	// 移除无须采样的 span 并记录需要缓存的 spanID
+	holdSpanIDs := make(map[pcommon.SpanID]struct{})
+	foreach.SpansRemoveIf(pdTraces, func(span ptrace.Span) bool {
+		ok := e.isSampled(span.TraceID())
+		if !ok {
+			holdSpanIDs[span.SpanID()] = struct{}{}
+		}
+		return !ok
+	})
+
+	// batch 为空无需处理
+	if len(batch) == 0 {
+		return
+	}
+
+	for i := 0; i < len(batch); i++ {
+		t := batch[i]
+		_, spanID, ok := queue.IdFromTraces(t)
+		if !ok {
+			continue
+		}
+		_, ok = holdSpanIDs[spanID] // 已采样的不需要缓存在本地
+		if !ok {
+			continue
+		}
+		if err := e.q.Put(dataID, t); err != nil {
+			logger.Warnf("queue failed to put traces, dataID=%v, err: %v", dataID, err)
+		}
+	}
+
+	// 遍历本次上报所有 traces
+	for traceID := range allTraceIDs {
+		if !e.isSampled(traceID) { // 未命中的 traces 跳过
+			continue
+		}
+
+		// pop 如果已经弹出过数据 则后续快速返回
+		// 因为一旦弹出意味着 e.traces 已经被记录
+		popItems := e.q.Pop(dataID, traceID)
+		for i := 0; i < len(popItems); i++ {
+			traces := popItems[i]
+			rs := pdTraces.ResourceSpans().At(0).ScopeSpans().AppendEmpty()
+			traces.ResourceSpans().At(0).ScopeSpans().At(0).CopyTo(rs)
+		}
+	}
+}
+
+func (e *tailEvaluator) gc() {
+	d := e.gcInterval
+	if d <= 0 {
+		d = time.Minute
+	}
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	maxDuration := int64(e.maxDuration.Seconds())
+	for {
+		select {
+		case <-e.stop:
+			return
+
+		case <-ticker.C:
+			now := time.Now().Unix()
+			e.mut.Lock()
+			for traceID, ts := range e.traces {
+				drop := now-ts > maxDuration
+				if drop {
+					delete(e.traces, traceID)
+				}
+			}
+			e.mut.Unlock()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Config struct {
+	Store           StoreConfig   `config:"store" mapstructure:"store"`
+	Dimensions      []string      `config:"dimensions" mapstructure:"dimensions"`
+	Buckets         []float64     `config:"buckets" mapstructure:"buckets"`
+	MaxSeries       int           `config:"max_series" mapstructure:"max_series"`
+	PublishInterval time.Duration `config:"publish_interval" mapstructure:"publish_interval"`
+	GcInterval      time.Duration `config:"gc_interval" mapstructure:"gc_interval"`
+}
+
+// StoreConfig 未配对 span 的窗口存储配置
+type StoreConfig struct {
+	Ttl      time.Duration `config:"ttl" mapstructure:"ttl"`
+	MaxItems int           `config:"max_items" mapstructure:"max_items"`
+}
+
+// Validate 验证配置默认值
+func (c *Config) Validate() {
+	if c.Store.Ttl <= 0 {
+		c.Store.Ttl = time.Second * 10
+	}
+	if c.Store.MaxItems <= 0 {
+		c.Store.MaxItems = 100000 // 100k
+	}
+	if c.MaxSeries <= 0 {
+		c.MaxSeries = 100000 // 100k
+	}
+	if c.PublishInterval <= 0 {
+		c.PublishInterval = time.Minute
+	}
+	if c.GcInterval <= 0 {
+		c.GcInterval = time.Hour
+	}
+	if len(c.Buckets) == 0 {
+		c.Buckets = prometheus.DefBuckets // 使用 prometheus 默认的 bucket
+	}
+	buckets := make([]float64, len(c.Buckets))
+	copy(buckets, c.Buckets)
+	sort.Float64s(buckets)
+	c.Buckets = buckets
+}
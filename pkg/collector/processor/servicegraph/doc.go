@@ -0,0 +1,41 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+/*
+# ServiceGraph: 服务拓扑处理器 配对 client/server 以及 producer/consumer span 生成服务间调用边指标
+
+配对规则:
+- client/producer span 以自身 spanID 为 key 暂存 server/consumer span 以 parentSpanID 为 key 暂存 双方均到达后生成调用边
+- 携带 db.system 的 client span 直接生成数据库虚拟节点边 connection_type=database
+- producer/consumer 之间的边 connection_type=messaging_system
+- 窗口期内对端未上报时 使用 peer.service/messaging.system 补全为虚拟节点
+- 无 parent 的 server span 以 user 作为调用方
+
+派生指标 (累积值 维度: client/server/connection_type/app_name 以及 client_xxx/server_xxx 额外维度):
+- bk_apm_service_graph_request_total
+- bk_apm_service_graph_request_failed_total
+- bk_apm_service_graph_request_duration_seconds_{bucket,sum,count}
+
+processor:
+  - name: "service_graph/common"
+    config:
+      store:
+        # 未配对 span 的等待时间
+        ttl: 10s
+        max_items: 100000
+      # 额外维度 分别从 client/server span 提取
+      dimensions:
+        - "resource.k8s.namespace.name"
+      buckets: [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+      max_series: 100000
+      publish_interval: 1m
+      gc_interval: 1h
+*/
+
+package servicegraph
@@ -0,0 +1,119 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+func init() {
+	processor.Register(define.ProcessorServiceGraph, NewFactory)
+}
+
+func NewFactory(conf map[string]any, customized []processor.SubConfigProcessor) (processor.Processor, error) {
+	return newFactory(conf, customized, processor.PublishNonSchedRecords)
+}
+
+func newFactory(conf map[string]any, customized []processor.SubConfigProcessor, publishFunc func(r *define.Record)) (*serviceGraphProcessor, error) {
+	graphs := confengine.NewTierConfig()
+
+	var c Config
+	if err := mapstructure.Decode(conf, &c); err != nil {
+		return nil, err
+	}
+	graphs.SetGlobal(newServiceGraph(c, publishFunc))
+
+	for _, custom := range customized {
+		var cfg Config
+		if err := mapstructure.Decode(custom.Config.Config, &cfg); err != nil {
+			logger.Errorf("failed to decode config: %v", err)
+			continue
+		}
+		graphs.Set(custom.Token, custom.Type, custom.ID, newServiceGraph(cfg, publishFunc))
+	}
+
+	return &serviceGraphProcessor{
+		CommonProcessor: processor.NewCommonProcessor(conf, customized),
+		graphs:          graphs,
+		publishFunc:     publishFunc,
+	}, nil
+}
+
+type serviceGraphProcessor struct {
+	processor.CommonProcessor
+	graphs      *confengine.TierConfig // type: *serviceGraph
+	publishFunc func(r *define.Record)
+}
+
+func (p *serviceGraphProcessor) Name() string {
+	return define.ProcessorServiceGraph
+}
+
+func (p *serviceGraphProcessor) IsDerived() bool {
+	return false
+}
+
+func (p *serviceGraphProcessor) IsPreCheck() bool {
+	return false
+}
+
+func (p *serviceGraphProcessor) Reload(config map[string]any, customized []processor.SubConfigProcessor) {
+	f, err := newFactory(config, customized, p.publishFunc)
+	if err != nil {
+		logger.Errorf("failed to reload processor: %v", err)
+		return
+	}
+
+	// 配置未变更的实例保留 避免丢失窗口期内未配对的 span 以及累积值
+	equal := processor.DiffMainConfig(p.MainConfig(), config)
+	if equal {
+		f.graphs.GetGlobal().(*serviceGraph).Stop()
+	} else {
+		p.graphs.GetGlobal().(*serviceGraph).Stop()
+		p.graphs.SetGlobal(f.graphs.GetGlobal())
+	}
+
+	diffRet := processor.DiffCustomizedConfig(p.SubConfigs(), customized)
+	for _, obj := range diffRet.Keep {
+		f.graphs.Get(obj.Token, obj.Type, obj.ID).(*serviceGraph).Stop()
+	}
+
+	for _, obj := range diffRet.Updated {
+		p.graphs.Get(obj.Token, obj.Type, obj.ID).(*serviceGraph).Stop()
+		newGraph := f.graphs.Get(obj.Token, obj.Type, obj.ID)
+		p.graphs.Set(obj.Token, obj.Type, obj.ID, newGraph)
+	}
+
+	for _, obj := range diffRet.Deleted {
+		p.graphs.Get(obj.Token, obj.Type, obj.ID).(*serviceGraph).Stop()
+		p.graphs.Del(obj.Token, obj.Type, obj.ID)
+	}
+
+	p.CommonProcessor = f.CommonProcessor
+}
+
+func (p *serviceGraphProcessor) Clean() {
+	for _, obj := range p.graphs.All() {
+		obj.(*serviceGraph).Stop()
+	}
+}
+
+func (p *serviceGraphProcessor) Process(record *define.Record) (*define.Record, error) {
+	switch record.RecordType {
+	case define.RecordTraces:
+		g := p.graphs.GetByToken(record.Token.Original).(*serviceGraph)
+		g.Consume(record)
+	}
+	return nil, nil
+}
@@ -0,0 +1,212 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.8.0"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/fields"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+)
+
+func TestFactory(t *testing.T) {
+	content := `
+processor:
+  - name: "service_graph/common"
+    config:
+      store:
+        ttl: 5s
+      dimensions:
+        - "attributes.http.method"
+`
+	mainConf := processor.MustLoadConfigs(content)[0].Config
+
+	customContent := `
+processor:
+  - name: "service_graph/common"
+    config:
+      max_series: 10
+`
+	customConf := processor.MustLoadConfigs(customContent)[0].Config
+
+	obj, err := NewFactory(mainConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: customConf,
+			},
+		},
+	})
+	factory := obj.(*serviceGraphProcessor)
+	assert.NoError(t, err)
+	assert.Equal(t, mainConf, factory.MainConfig())
+
+	mainGraph := factory.graphs.GetGlobal().(*serviceGraph)
+	assert.Equal(t, 5*time.Second, mainGraph.conf.Store.Ttl)
+	assert.Equal(t, []dimension{{from: fields.FieldFromAttributes, key: "http.method", label: "http.method"}}, mainGraph.dimensions)
+
+	customGraph := factory.graphs.GetByToken("token1").(*serviceGraph)
+	assert.Equal(t, 10, customGraph.conf.MaxSeries)
+	assert.Equal(t, time.Minute, customGraph.conf.PublishInterval)
+
+	assert.Equal(t, define.ProcessorServiceGraph, factory.Name())
+	assert.False(t, factory.IsDerived())
+	assert.False(t, factory.IsPreCheck())
+
+	factory.Reload(mainConf, nil)
+	assert.Equal(t, mainConf, factory.MainConfig())
+	factory.Clean()
+}
+
+type testSpan struct {
+	service  string
+	kind     ptrace.SpanKind
+	spanID   byte
+	parentID byte
+	attrs    map[string]string
+	failed   bool
+}
+
+func makeTraces(spans ...testSpan) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	for _, s := range spans {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutString(semconv.AttributeServiceName, s.service)
+
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(pcommon.TraceID([16]byte{1}))
+		span.SetSpanID(pcommon.SpanID([8]byte{s.spanID}))
+		if s.parentID > 0 {
+			span.SetParentSpanID(pcommon.SpanID([8]byte{s.parentID}))
+		}
+		span.SetKind(s.kind)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Unix(0, 0)))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Unix(0, int64(200*time.Millisecond))))
+		if s.failed {
+			span.Status().SetCode(ptrace.StatusCodeError)
+		}
+		for k, v := range s.attrs {
+			span.Attributes().PutString(k, v)
+		}
+	}
+	return traces
+}
+
+func collectSeries(records []*define.Record, name string) map[string]float64 {
+	ret := make(map[string]float64)
+	for _, r := range records {
+		metrics := r.Data.(pmetric.Metrics)
+		rms := metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			sms := rms.At(i).ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					m := ms.At(k)
+					if m.Name() != name {
+						continue
+					}
+					dps := m.Gauge().DataPoints()
+					for n := 0; n < dps.Len(); n++ {
+						attrs := dps.At(n).Attributes()
+						client, _ := attrs.Get(labelClient)
+						server, _ := attrs.Get(labelServer)
+						ct, _ := attrs.Get(labelConnectionType)
+						ret[client.AsString()+"->"+server.AsString()+"/"+ct.AsString()] = dps.At(n).DoubleVal()
+					}
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func TestProcess(t *testing.T) {
+	content := `
+processor:
+  - name: "service_graph/common"
+    config:
+      store:
+        ttl: 1s
+`
+	var records []*define.Record
+	f, err := newFactory(processor.MustLoadConfigs(content)[0].Config, nil, func(r *define.Record) {
+		records = append(records, r)
+	})
+	assert.NoError(t, err)
+	defer f.Clean()
+
+	traces := makeTraces(
+		// user -> frontend
+		testSpan{service: "frontend", kind: ptrace.SpanKindServer, spanID: 1},
+		// frontend -> backend 其中 server 侧失败
+		testSpan{service: "frontend", kind: ptrace.SpanKindClient, spanID: 2, parentID: 1},
+		testSpan{service: "backend", kind: ptrace.SpanKindServer, spanID: 3, parentID: 2, failed: true},
+		// backend -> mysql
+		testSpan{service: "backend", kind: ptrace.SpanKindClient, spanID: 4, parentID: 3, attrs: map[string]string{
+			semconv.AttributeDBSystem: "mysql",
+		}},
+		// backend -> worker 通过 kafka
+		testSpan{service: "backend", kind: ptrace.SpanKindProducer, spanID: 5, parentID: 3, attrs: map[string]string{
+			semconv.AttributeMessagingSystem: "kafka",
+		}},
+		testSpan{service: "worker", kind: ptrace.SpanKindConsumer, spanID: 6, parentID: 5},
+		// backend -> redis 对端未上报
+		testSpan{service: "backend", kind: ptrace.SpanKindProducer, spanID: 7, parentID: 3, attrs: map[string]string{
+			semconv.AttributeMessagingSystem: "rabbitmq",
+		}},
+		// 无法补全的边
+		testSpan{service: "backend", kind: ptrace.SpanKindClient, spanID: 8, parentID: 3},
+	)
+	_, err = f.Process(&define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{Original: "token1", MetricsDataId: 1001, AppName: "app1"},
+		Data:       traces,
+	})
+	assert.NoError(t, err)
+
+	g := f.graphs.GetGlobal().(*serviceGraph)
+	assert.Equal(t, 2, g.store.Len())
+
+	now := time.Now()
+	g.store.mut.Lock()
+	g.store.now = func() time.Time { return now.Add(time.Second) }
+	g.store.mut.Unlock()
+	g.store.Expire()
+	assert.Equal(t, 0, g.store.Len())
+
+	g.publish()
+	assert.Len(t, records, 1)
+	assert.Equal(t, int32(1001), records[0].Token.MetricsDataId)
+
+	assert.Equal(t, map[string]float64{
+		"user->frontend/virtual_node":        1,
+		"frontend->backend/":                 1,
+		"backend->mysql/database":            1,
+		"backend->worker/messaging_system":   1,
+		"backend->rabbitmq/messaging_system": 1,
+	}, collectSeries(records, metricRequestTotal))
+
+	failed := collectSeries(records, metricRequestFailedTotal)
+	assert.Equal(t, float64(1), failed["frontend->backend/"])
+	assert.Equal(t, float64(0), failed["backend->mysql/database"])
+
+	sum := collectSeries(records, metricRequestDuration+"_sum")
+	assert.InDelta(t, 0.2, sum["frontend->backend/"], 1e-9)
+}
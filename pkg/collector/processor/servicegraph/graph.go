@@ -0,0 +1,299 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.8.0"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/fields"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/sampler/tracestore"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	labelClient         = "client"
+	labelServer         = "server"
+	labelConnectionType = "connection_type"
+	prefixClient        = "client_"
+	prefixServer        = "server_"
+
+	// nodeUser 无上游调用的 server span 以 user 作为调用方
+	nodeUser = "user"
+)
+
+// dimension 额外维度 分别从 client/server 两侧提取
+type dimension struct {
+	from  fields.FieldFrom
+	key   string
+	label string
+}
+
+// serviceGraph 配对 client/server 以及 producer/consumer span 生成服务间调用边指标
+type serviceGraph struct {
+	conf        Config
+	dimensions  []dimension
+	store       *Store
+	series      *seriesAggregator
+	publishFunc func(r *define.Record)
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func newServiceGraph(conf Config, publishFunc func(r *define.Record)) *serviceGraph {
+	conf.Validate()
+	g := &serviceGraph{
+		conf:        conf,
+		series:      newSeriesAggregator(conf.Buckets, conf.MaxSeries),
+		publishFunc: publishFunc,
+		done:        make(chan struct{}),
+	}
+	g.store = NewStore(conf.Store.Ttl, conf.Store.MaxItems, g.onComplete, g.onExpire)
+
+	for _, s := range conf.Dimensions {
+		from, key := fields.DecodeFieldFrom(s)
+		switch from {
+		case fields.FieldFromResource, fields.FieldFromAttributes:
+			g.dimensions = append(g.dimensions, dimension{from: from, key: key, label: fields.TrimPrefix(s)})
+		default:
+			logger.Warnf("servicegraph: unsupported dimension '%s'", s)
+		}
+	}
+
+	if publishFunc != nil {
+		g.wg.Add(1)
+		go g.loop()
+	}
+	return g
+}
+
+func (g *serviceGraph) loop() {
+	defer g.wg.Done()
+
+	expireTicker := time.NewTicker(time.Second)
+	defer expireTicker.Stop()
+
+	publishTicker := time.NewTicker(g.conf.PublishInterval)
+	defer publishTicker.Stop()
+
+	gcTicker := time.NewTicker(g.conf.GcInterval / 2) // 以 0.5*gcInterval 频率进行清理
+	defer gcTicker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+
+		case <-expireTicker.C:
+			g.store.Expire()
+
+		case <-publishTicker.C:
+			g.publish()
+
+		case <-gcTicker.C:
+			g.series.Gc(g.conf.GcInterval)
+		}
+	}
+}
+
+func (g *serviceGraph) publish() {
+	for _, r := range g.series.Build() {
+		g.publishFunc(r)
+	}
+}
+
+func (g *serviceGraph) Stop() {
+	g.once.Do(func() {
+		close(g.done)
+		g.wg.Wait()
+	})
+}
+
+func (g *serviceGraph) onComplete(e *Edge) {
+	DefaultMetricMonitor.IncEdgesCounter(edgeStatusCompleted)
+	g.observe(e)
+}
+
+// onExpire 对端未在窗口期内上报 存在虚拟节点时补全为虚拟节点边
+func (g *serviceGraph) onExpire(e *Edge) {
+	if e.ClientService == "" || e.VirtualNode == "" {
+		DefaultMetricMonitor.IncEdgesCounter(edgeStatusExpired)
+		return
+	}
+
+	e.ServerService = e.VirtualNode
+	if e.ConnectionType == ConnectionTypeDirect {
+		e.ConnectionType = ConnectionTypeVirtual
+	}
+	DefaultMetricMonitor.IncEdgesCounter(edgeStatusVirtual)
+	g.observe(e)
+}
+
+func (g *serviceGraph) observe(e *Edge) {
+	lbs := map[string]string{
+		labelClient:         e.ClientService,
+		labelServer:         e.ServerService,
+		labelConnectionType: e.ConnectionType,
+		define.TokenAppName: e.AppName,
+	}
+	for k, v := range e.ClientDims {
+		lbs[prefixClient+k] = v
+	}
+	for k, v := range e.ServerDims {
+		lbs[prefixServer+k] = v
+	}
+
+	if !g.series.Observe(e.DataID, lbs, e.Failed, e.Latency()) {
+		DefaultMetricMonitor.IncEdgesCounter(edgeStatusExceeded)
+	}
+}
+
+func (g *serviceGraph) extractDims(rs pcommon.Map, span ptrace.Span) map[string]string {
+	if len(g.dimensions) == 0 {
+		return nil
+	}
+
+	dims := make(map[string]string, len(g.dimensions))
+	for _, d := range g.dimensions {
+		attrs := span.Attributes()
+		if d.from == fields.FieldFromResource {
+			attrs = rs
+		}
+		if v, ok := attrs.Get(d.key); ok {
+			dims[d.label] = v.AsString()
+		}
+	}
+	return dims
+}
+
+func getAttr(attrs pcommon.Map, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := attrs.Get(key); ok && v.AsString() != "" {
+			return v.AsString()
+		}
+	}
+	return ""
+}
+
+func spanLatency(span ptrace.Span) float64 {
+	if span.EndTimestamp() < span.StartTimestamp() {
+		return 0
+	}
+	return time.Duration(span.EndTimestamp() - span.StartTimestamp()).Seconds()
+}
+
+// Consume 处理 traces 数据 需要配对的 span 暂存至 store 等待对端
+func (g *serviceGraph) Consume(record *define.Record) {
+	pdTraces := record.Data.(ptrace.Traces)
+	resourceSpansSlice := pdTraces.ResourceSpans()
+	for i := 0; i < resourceSpansSlice.Len(); i++ {
+		resourceSpans := resourceSpansSlice.At(i)
+		rs := resourceSpans.Resource().Attributes()
+		service := getAttr(rs, semconv.AttributeServiceName)
+		if service == "" {
+			continue
+		}
+
+		scopeSpansSlice := resourceSpans.ScopeSpans()
+		for j := 0; j < scopeSpansSlice.Len(); j++ {
+			spans := scopeSpansSlice.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				g.consumeSpan(record.Token, service, rs, spans.At(k))
+			}
+		}
+	}
+}
+
+func (g *serviceGraph) consumeSpan(token define.Token, service string, rs pcommon.Map, span ptrace.Span) {
+	attrs := span.Attributes()
+	failed := span.Status().Code() == ptrace.StatusCodeError
+
+	switch span.Kind() {
+	case ptrace.SpanKindClient, ptrace.SpanKindProducer:
+		// 数据库不会上报 server span 直接生成虚拟节点边
+		if dbSystem := getAttr(attrs, semconv.AttributeDBSystem); dbSystem != "" && span.Kind() == ptrace.SpanKindClient {
+			DefaultMetricMonitor.IncEdgesCounter(edgeStatusVirtual)
+			g.observe(&Edge{
+				DataID:         token.MetricsDataId,
+				AppName:        token.AppName,
+				ClientService:  service,
+				ServerService:  getAttr(attrs, semconv.AttributePeerService, semconv.AttributeDBName, semconv.AttributeDBSystem),
+				ConnectionType: ConnectionTypeDatabase,
+				ClientDims:     g.extractDims(rs, span),
+				ClientLatency:  spanLatency(span),
+				Failed:         failed,
+			})
+			return
+		}
+
+		key := tracestore.TraceKey{TraceID: span.TraceID(), SpanID: span.SpanID()}
+		g.upsert(key, func(e *Edge) {
+			e.DataID = token.MetricsDataId
+			e.AppName = token.AppName
+			e.ClientService = service
+			e.ClientDims = g.extractDims(rs, span)
+			e.ClientLatency = spanLatency(span)
+			e.Failed = e.Failed || failed
+			if span.Kind() == ptrace.SpanKindProducer {
+				e.ConnectionType = ConnectionTypeMessaging
+				e.VirtualNode = getAttr(attrs, semconv.AttributePeerService, semconv.AttributeMessagingSystem)
+			} else {
+				e.VirtualNode = getAttr(attrs, semconv.AttributePeerService)
+			}
+		})
+
+	case ptrace.SpanKindServer, ptrace.SpanKindConsumer:
+		// 根 span 没有上游调用方
+		if span.ParentSpanID().IsEmpty() {
+			if span.Kind() == ptrace.SpanKindConsumer {
+				return
+			}
+			DefaultMetricMonitor.IncEdgesCounter(edgeStatusVirtual)
+			g.observe(&Edge{
+				DataID:         token.MetricsDataId,
+				AppName:        token.AppName,
+				ClientService:  nodeUser,
+				ServerService:  service,
+				ConnectionType: ConnectionTypeVirtual,
+				ServerDims:     g.extractDims(rs, span),
+				ServerLatency:  spanLatency(span),
+				Failed:         failed,
+			})
+			return
+		}
+
+		key := tracestore.TraceKey{TraceID: span.TraceID(), SpanID: span.ParentSpanID()}
+		g.upsert(key, func(e *Edge) {
+			e.DataID = token.MetricsDataId
+			e.AppName = token.AppName
+			e.ServerService = service
+			e.ServerDims = g.extractDims(rs, span)
+			e.ServerLatency = spanLatency(span)
+			e.Failed = e.Failed || failed
+			if span.Kind() == ptrace.SpanKindConsumer {
+				e.ConnectionType = ConnectionTypeMessaging
+			}
+		})
+	}
+}
+
+func (g *serviceGraph) upsert(key tracestore.TraceKey, update Callback) {
+	if err := g.store.UpsertEdge(key, update); err != nil {
+		DefaultMetricMonitor.IncEdgesCounter(edgeStatusDropped)
+		logger.Debugf("servicegraph: failed to upsert edge: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/labels"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/metricsbuilder"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+)
+
+const (
+	metricRequestTotal       = "bk_apm_service_graph_request_total"
+	metricRequestFailedTotal = "bk_apm_service_graph_request_failed_total"
+	metricRequestDuration    = "bk_apm_service_graph_request_duration_seconds"
+)
+
+type seriesKey struct {
+	dataID int32
+	hash   uint64
+}
+
+type seriesStats struct {
+	labels  map[string]string
+	total   float64
+	failed  float64
+	sum     float64
+	buckets []float64
+	updated int64
+}
+
+// seriesAggregator 按边维度累积请求数/错误数/耗时分布 均为累积值
+type seriesAggregator struct {
+	mut       sync.Mutex
+	buckets   []float64
+	maxSeries int
+	series    map[seriesKey]*seriesStats
+}
+
+func newSeriesAggregator(buckets []float64, maxSeries int) *seriesAggregator {
+	bs := make([]float64, 0, len(buckets)+1)
+	bs = append(bs, buckets...)
+	bs = append(bs, math.Inf(1))
+	return &seriesAggregator{
+		buckets:   bs,
+		maxSeries: maxSeries,
+		series:    make(map[seriesKey]*seriesStats),
+	}
+}
+
+func (a *seriesAggregator) Len() int {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	return len(a.series)
+}
+
+// Observe 记录一次请求 超出 series 上限时返回 false
+func (a *seriesAggregator) Observe(dataID int32, lbs map[string]string, failed bool, latency float64) bool {
+	key := seriesKey{dataID: dataID, hash: labels.HashFromMap(lbs)}
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	s, ok := a.series[key]
+	if !ok {
+		if len(a.series) >= a.maxSeries {
+			return false
+		}
+		s = &seriesStats{
+			labels:  lbs,
+			buckets: make([]float64, len(a.buckets)),
+		}
+		a.series[key] = s
+	}
+
+	s.total++
+	if failed {
+		s.failed++
+	}
+	s.sum += latency
+	for i := 0; i < len(a.buckets); i++ {
+		if latency <= a.buckets[i] {
+			s.buckets[i]++
+		}
+	}
+	s.updated = time.Now().Unix()
+	return true
+}
+
+// Gc 清理长时间未更新的 series
+func (a *seriesAggregator) Gc(expired time.Duration) {
+	deadline := time.Now().Add(-expired).Unix()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for k, s := range a.series {
+		if s.updated < deadline {
+			delete(a.series, k)
+		}
+	}
+}
+
+// Build 按 dataid 生成指标记录
+func (a *seriesAggregator) Build() []*define.Record {
+	ts := pcommon.NewTimestampFromTime(time.Now())
+	builders := make(map[int32]*metricsbuilder.Builder)
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for k, s := range a.series {
+		mb, ok := builders[k.dataID]
+		if !ok {
+			mb = metricsbuilder.New()
+			builders[k.dataID] = mb
+		}
+
+		mb.Build(metricRequestTotal, metricsbuilder.Metric{Val: s.total, Ts: ts, Dimensions: utils.CloneMap(s.labels)})
+		mb.Build(metricRequestFailedTotal, metricsbuilder.Metric{Val: s.failed, Ts: ts, Dimensions: utils.CloneMap(s.labels)})
+		mb.Build(metricRequestDuration+"_sum", metricsbuilder.Metric{Val: s.sum, Ts: ts, Dimensions: utils.CloneMap(s.labels)})
+		mb.Build(metricRequestDuration+"_count", metricsbuilder.Metric{Val: s.total, Ts: ts, Dimensions: utils.CloneMap(s.labels)})
+
+		bucketMetrics := make([]metricsbuilder.Metric, 0, len(a.buckets))
+		for i := 0; i < len(a.buckets); i++ {
+			le := strconv.FormatFloat(a.buckets[i], 'f', -1, 64)
+			dims := utils.CloneMap(s.labels)
+			dims["le"] = le
+			bucketMetrics = append(bucketMetrics, metricsbuilder.Metric{Val: s.buckets[i], Ts: ts, Dimensions: dims})
+		}
+		mb.Build(metricRequestDuration+"_bucket", bucketMetrics...)
+	}
+
+	records := make([]*define.Record, 0, len(builders))
+	for dataID, mb := range builders {
+		records = append(records, &define.Record{
+			RecordType:  define.RecordMetrics,
+			RequestType: define.RequestDerived,
+			Token:       define.Token{MetricsDataId: dataID},
+			Data:        mb.Get(),
+		})
+	}
+	return records
+}
@@ -0,0 +1,43 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesAggregator(t *testing.T) {
+	a := newSeriesAggregator([]float64{0.1, 1}, 2)
+
+	lbs := map[string]string{"client": "a", "server": "b"}
+	assert.True(t, a.Observe(1001, lbs, false, 0.05))
+	assert.True(t, a.Observe(1001, lbs, true, 0.5))
+	assert.True(t, a.Observe(1002, lbs, false, 2))
+	assert.False(t, a.Observe(1002, map[string]string{"client": "c"}, false, 2))
+	assert.Equal(t, 2, a.Len())
+
+	records := a.Build()
+	assert.Len(t, records, 2)
+
+	for k, s := range a.series {
+		if k.dataID != 1001 {
+			continue
+		}
+		assert.Equal(t, float64(2), s.total)
+		assert.Equal(t, float64(1), s.failed)
+		assert.Equal(t, []float64{1, 2, 2}, s.buckets)
+	}
+
+	a.Gc(-time.Second)
+	assert.Equal(t, 0, a.Len())
+}
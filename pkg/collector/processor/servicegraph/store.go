@@ -0,0 +1,145 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/sampler/tracestore"
+)
+
+const (
+	ConnectionTypeDirect    = ""
+	ConnectionTypeDatabase  = "database"
+	ConnectionTypeMessaging = "messaging_system"
+	ConnectionTypeVirtual   = "virtual_node"
+)
+
+var ErrTooManyItems = errors.New("too many items")
+
+// Edge 代表调用链中的一条 caller -> callee 边
+// 以 client/producer span 的 spanID 作为 key 与 server/consumer span 的 parentSpanID 配对
+type Edge struct {
+	Key            tracestore.TraceKey
+	DataID         int32
+	AppName        string
+	ClientService  string
+	ServerService  string
+	ConnectionType string
+	ClientDims     map[string]string
+	ServerDims     map[string]string
+	ClientLatency  float64 // seconds
+	ServerLatency  float64 // seconds
+	Failed         bool
+
+	// VirtualNode 对端未上报时用于补全的虚拟节点名称 如 peer.service/messaging.system
+	VirtualNode string
+
+	expiration time.Time
+}
+
+func (e *Edge) isComplete() bool {
+	return e.ClientService != "" && e.ServerService != ""
+}
+
+// Latency 优先使用 client 视角耗时
+func (e *Edge) Latency() float64 {
+	if e.ClientLatency > 0 {
+		return e.ClientLatency
+	}
+	return e.ServerLatency
+}
+
+type Callback func(e *Edge)
+
+// Store 窗口期存储未配对的边 配对完成或者过期时回调
+type Store struct {
+	mut sync.Mutex
+	l   *list.List
+	m   map[tracestore.TraceKey]*list.Element
+
+	ttl        time.Duration
+	maxItems   int
+	onComplete Callback
+	onExpire   Callback
+	now        func() time.Time
+}
+
+func NewStore(ttl time.Duration, maxItems int, onComplete, onExpire Callback) *Store {
+	return &Store{
+		l:          list.New(),
+		m:          make(map[tracestore.TraceKey]*list.Element),
+		ttl:        ttl,
+		maxItems:   maxItems,
+		onComplete: onComplete,
+		onExpire:   onExpire,
+		now:        time.Now,
+	}
+}
+
+func (s *Store) Len() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.l.Len()
+}
+
+// UpsertEdge 更新或者插入边 配对完成时立即回调并移除
+func (s *Store) UpsertEdge(key tracestore.TraceKey, update Callback) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if ele, ok := s.m[key]; ok {
+		e := ele.Value.(*Edge)
+		update(e)
+		if e.isComplete() {
+			s.l.Remove(ele)
+			delete(s.m, key)
+			s.onComplete(e)
+		}
+		return nil
+	}
+
+	e := &Edge{Key: key}
+	update(e)
+	if e.isComplete() {
+		s.onComplete(e)
+		return nil
+	}
+
+	if s.l.Len() >= s.maxItems {
+		return ErrTooManyItems
+	}
+
+	e.expiration = s.now().Add(s.ttl)
+	s.m[key] = s.l.PushBack(e)
+	return nil
+}
+
+// Expire 清理过期的边 元素按插入顺序排列 遇到未过期元素即可停止
+func (s *Store) Expire() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	now := s.now()
+	for ele := s.l.Front(); ele != nil; ele = s.l.Front() {
+		e := ele.Value.(*Edge)
+		if now.Before(e.expiration) {
+			return
+		}
+		s.l.Remove(ele)
+		delete(s.m, e.Key)
+		s.onExpire(e)
+	}
+}
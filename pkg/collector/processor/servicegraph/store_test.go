@@ -0,0 +1,60 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/sampler/tracestore"
+)
+
+func traceKey(traceID, spanID byte) tracestore.TraceKey {
+	return tracestore.TraceKey{
+		TraceID: pcommon.TraceID([16]byte{traceID}),
+		SpanID:  pcommon.SpanID([8]byte{spanID}),
+	}
+}
+
+func TestStore(t *testing.T) {
+	var completed, expired []*Edge
+	s := NewStore(time.Second, 2,
+		func(e *Edge) { completed = append(completed, e) },
+		func(e *Edge) { expired = append(expired, e) },
+	)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	assert.NoError(t, s.UpsertEdge(traceKey(1, 1), func(e *Edge) { e.ClientService = "a" }))
+	assert.NoError(t, s.UpsertEdge(traceKey(1, 2), func(e *Edge) { e.ServerService = "c" }))
+	assert.Equal(t, 2, s.Len())
+
+	// store 已满
+	assert.ErrorIs(t, s.UpsertEdge(traceKey(1, 3), func(e *Edge) { e.ClientService = "d" }), ErrTooManyItems)
+
+	// 配对完成
+	assert.NoError(t, s.UpsertEdge(traceKey(1, 1), func(e *Edge) { e.ServerService = "b" }))
+	assert.Equal(t, 1, s.Len())
+	assert.Len(t, completed, 1)
+	assert.Equal(t, "a", completed[0].ClientService)
+	assert.Equal(t, "b", completed[0].ServerService)
+
+	s.Expire()
+	assert.Len(t, expired, 0)
+
+	now = now.Add(time.Second)
+	s.Expire()
+	assert.Equal(t, 0, s.Len())
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "c", expired[0].ServerService)
+}
@@ -31,6 +31,10 @@ type OperationConfig struct {
 	Buckets             []float64    `config:"buckets" mapstructure:"buckets"`
 	PublishInterval     string       `config:"publish_interval" mapstructure:"publish_interval"`
 	MaxSeriesGrowthRate int          `config:"max_series_growth_rate" mapstructure:"max_series_growth_rate"`
+
+	// span_event 类型配置
+	EventNames      []string           `config:"event_names" mapstructure:"event_names"`
+	EventAttributes []EventMatchConfig `config:"event_attributes" mapstructure:"event_attributes"`
 }
 
 type RuleConfig struct {
@@ -54,6 +58,7 @@ type ConfigHandler struct {
 
 	accumulatorConfig *accumulator.Config
 	extractorConfig   *ExtractorConfig
+	spanEventConfig   *SpanEventConfig
 }
 
 // NewConfigHandler 创建并返回 ConfigHandler 实例 用于管理配置和提取内容
@@ -69,6 +74,7 @@ func NewConfigHandler(config Config) *ConfigHandler {
 	var types []TypeWithName
 	var accumulatorConfig *accumulator.Config
 	var extractorConfig *ExtractorConfig
+	var spanEventConfig *SpanEventConfig
 	for i := 0; i < len(config.Operations); i++ {
 		conf := config.Operations[i]
 		// accumulator 类型单独处理
@@ -102,6 +108,14 @@ func NewConfigHandler(config Config) *ConfigHandler {
 			}
 			extractorConfig.Validate()
 
+		// span_event 类型不产生指标 无需处理 rules
+		case SpanEventType:
+			spanEventConfig = &SpanEventConfig{
+				Names:      conf.EventNames,
+				Attributes: conf.EventAttributes,
+			}
+			continue
+
 		default:
 			logger.Errorf("invalid extractor type: %s", conf.Type)
 			continue
@@ -145,6 +159,7 @@ func NewConfigHandler(config Config) *ConfigHandler {
 		kinds:             kinds,
 		accumulatorConfig: accumulatorConfig,
 		extractorConfig:   extractorConfig,
+		spanEventConfig:   spanEventConfig,
 	}
 }
 
@@ -170,6 +185,10 @@ func (ch *ConfigHandler) GetExtractorConfig() *ExtractorConfig {
 	return ch.extractorConfig
 }
 
+func (ch *ConfigHandler) GetSpanEventConfig() *SpanEventConfig {
+	return ch.spanEventConfig
+}
+
 func (ch *ConfigHandler) GetTypes() []TypeWithName {
 	return ch.types
 }
@@ -59,6 +59,17 @@ processor:
                   - "span_name"
                   - "kind"
                   - "status.code"
+
+    # 将 span event 转换为日志 派生为 logs.derived 数据 不能与指标类 operation 混用
+    - name: "traces_deriver/span_event"
+      config:
+        operations:
+          - type: "span_event"
+            event_names: ["exception"] # 为空时匹配所有 event
+            event_attributes:
+              - key: "exception.type"
+                op: "reg"
+                values: [".+"]
 */
 
 package tracesderiver
@@ -17,6 +17,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/accumulator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
 )
 
 type Operator interface {
@@ -27,6 +28,14 @@ type Operator interface {
 func NewOperator(conf Config) Operator {
 	ch := NewConfigHandler(conf)
 
+	// span_event 派生日志 与派生指标互斥 需单独配置 processor 实例
+	if spanEventConfig := ch.GetSpanEventConfig(); spanEventConfig != nil {
+		if len(ch.GetTypes()) > 0 {
+			logger.Warnf("tracesderiver: span_event operation is exclusive, metric operations ignored")
+		}
+		return newSpanEventOperator(spanEventConfig)
+	}
+
 	to := tracesOperator{
 		dm: NewSpanDimensionMatcher(ch),
 	}
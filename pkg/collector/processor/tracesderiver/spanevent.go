@@ -0,0 +1,215 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package tracesderiver
+
+import (
+	"regexp"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/opmatch"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	SpanEventType = "span_event"
+
+	spanEventException = "exception"
+
+	// 衍生日志补充的 span 字段
+	spanEventKeyName     = "event.name"
+	spanEventKeySpanName = "span_name"
+	spanEventKeySpanKind = "span_kind"
+)
+
+// EventMatchConfig 描述 span event 属性匹配规则 values 中任意一个匹配即视为命中
+type EventMatchConfig struct {
+	Key    string   `config:"key" mapstructure:"key"`
+	Op     string   `config:"op" mapstructure:"op"`
+	Values []string `config:"values" mapstructure:"values"`
+}
+
+type SpanEventConfig struct {
+	Names      []string
+	Attributes []EventMatchConfig
+}
+
+type eventMatcher struct {
+	key     string
+	op      string
+	values  []string
+	regexps []*regexp.Regexp
+}
+
+func (m eventMatcher) match(input string) bool {
+	if opmatch.Op(m.op) == opmatch.OpReg {
+		for _, re := range m.regexps {
+			if re.MatchString(input) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range m.values {
+		if opmatch.Match(input, v, m.op) {
+			return true
+		}
+	}
+	return false
+}
+
+// spanEventOperator 将命中规则的 span event 转换为日志 派生为 RecordLogsDerived
+//
+// 日志携带 trace_id/span_id 以及 span 所属 resource 便于在日志检索中关联调用链
+type spanEventOperator struct {
+	names    map[string]struct{} // 为空时匹配所有 event
+	matchers []eventMatcher
+}
+
+func newSpanEventOperator(conf *SpanEventConfig) *spanEventOperator {
+	names := make(map[string]struct{})
+	for _, name := range conf.Names {
+		names[name] = struct{}{}
+	}
+
+	matchers := make([]eventMatcher, 0, len(conf.Attributes))
+	for _, attr := range conf.Attributes {
+		if attr.Key == "" {
+			continue
+		}
+		matcher := eventMatcher{key: attr.Key, op: attr.Op, values: attr.Values}
+		// 正则提前编译 避免每个 event 重复编译
+		if opmatch.Op(attr.Op) == opmatch.OpReg {
+			for _, v := range attr.Values {
+				re, err := regexp.Compile(v)
+				if err != nil {
+					logger.Warnf("tracesderiver: invalid regexp '%s' of key '%s': %v", v, attr.Key, err)
+					continue
+				}
+				matcher.regexps = append(matcher.regexps, re)
+			}
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return &spanEventOperator{
+		names:    names,
+		matchers: matchers,
+	}
+}
+
+func (so *spanEventOperator) Clean() {}
+
+// Match 所有属性规则均命中才视为匹配
+func (so *spanEventOperator) Match(event ptrace.SpanEvent) bool {
+	if len(so.names) > 0 {
+		if _, ok := so.names[event.Name()]; !ok {
+			return false
+		}
+	}
+
+	attrs := event.Attributes()
+	for _, m := range so.matchers {
+		v, ok := attrs.Get(m.key)
+		if !ok {
+			return false
+		}
+		if !m.match(v.AsString()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (so *spanEventOperator) Operate(record *define.Record) *define.Record {
+	pdTraces := record.Data.(ptrace.Traces)
+	pdLogs := plog.NewLogs()
+	observed := pcommon.NewTimestampFromTime(time.Now())
+
+	resourceSpansSlice := pdTraces.ResourceSpans()
+	for i := 0; i < resourceSpansSlice.Len(); i++ {
+		resourceSpans := resourceSpansSlice.At(i)
+		// resource/scope 延迟创建 避免产生空数据
+		var resourceLogs plog.ResourceLogs
+		var hasResource bool
+
+		scopeSpansSlice := resourceSpans.ScopeSpans()
+		for j := 0; j < scopeSpansSlice.Len(); j++ {
+			scopeSpans := scopeSpansSlice.At(j)
+			var scopeLogs plog.ScopeLogs
+			var hasScope bool
+
+			spans := scopeSpans.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				events := span.Events()
+				for n := 0; n < events.Len(); n++ {
+					event := events.At(n)
+					if !so.Match(event) {
+						continue
+					}
+
+					if !hasResource {
+						resourceLogs = pdLogs.ResourceLogs().AppendEmpty()
+						resourceSpans.Resource().CopyTo(resourceLogs.Resource())
+						hasResource = true
+					}
+					if !hasScope {
+						scopeLogs = resourceLogs.ScopeLogs().AppendEmpty()
+						scopeSpans.Scope().CopyTo(scopeLogs.Scope())
+						hasScope = true
+					}
+					toLogRecord(span, event, observed, scopeLogs.LogRecords().AppendEmpty())
+				}
+			}
+		}
+	}
+
+	if pdLogs.LogRecordCount() == 0 {
+		return nil
+	}
+
+	return &define.Record{
+		RecordType:  define.RecordLogsDerived,
+		RequestType: define.RequestDerived,
+		Token:       record.Token,
+		Data:        pdLogs,
+	}
+}
+
+func toLogRecord(span ptrace.Span, event ptrace.SpanEvent, observed pcommon.Timestamp, logRecord plog.LogRecord) {
+	ts := event.Timestamp()
+	if ts == 0 {
+		ts = span.EndTimestamp()
+	}
+	logRecord.SetTimestamp(ts)
+	logRecord.SetObservedTimestamp(observed)
+	logRecord.SetTraceID(span.TraceID())
+	logRecord.SetSpanID(span.SpanID())
+	logRecord.Body().SetStr(event.Name())
+
+	if event.Name() == spanEventException {
+		logRecord.SetSeverityNumber(plog.SeverityNumberError)
+		logRecord.SetSeverityText("ERROR")
+	} else {
+		logRecord.SetSeverityNumber(plog.SeverityNumberInfo)
+		logRecord.SetSeverityText("INFO")
+	}
+
+	attrs := logRecord.Attributes()
+	event.Attributes().CopyTo(attrs)
+	attrs.PutString(spanEventKeyName, event.Name())
+	attrs.PutString(spanEventKeySpanName, span.Name())
+	attrs.PutString(spanEventKeySpanKind, span.Kind().String())
+}
@@ -0,0 +1,126 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package tracesderiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+)
+
+func makeSpanEventTraces() ptrace.Traces {
+	pdTraces := ptrace.NewTraces()
+	rs := pdTraces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutString("service.name", "echo")
+
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("GET /healthz")
+	span.SetKind(ptrace.SpanKindServer)
+	span.SetTraceID(pcommon.TraceID([16]byte{1, 2, 3, 4}))
+	span.SetSpanID(pcommon.SpanID([8]byte{5, 6, 7, 8}))
+	span.SetEndTimestamp(2000)
+
+	exception := span.Events().AppendEmpty()
+	exception.SetName("exception")
+	exception.SetTimestamp(1000)
+	exception.Attributes().PutString("exception.type", "java.lang.NullPointerException")
+	exception.Attributes().PutString("exception.stacktrace", "at com.example.Main.main(Main.java:10)")
+
+	log := span.Events().AppendEmpty()
+	log.SetName("log")
+	log.Attributes().PutString("message", "hello")
+
+	// 无命中 event 的 resource 不应产生日志
+	empty := pdTraces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	empty.Events().AppendEmpty().SetName("log")
+	return pdTraces
+}
+
+func TestSpanEventOperator(t *testing.T) {
+	c := Config{
+		Operations: []OperationConfig{
+			{
+				Type:       SpanEventType,
+				EventNames: []string{"exception"},
+				EventAttributes: []EventMatchConfig{
+					{Key: "exception.type", Op: "reg", Values: []string{"NullPointer"}},
+				},
+			},
+		},
+	}
+
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{Original: "fortest"},
+		Data:       makeSpanEventTraces(),
+	}
+
+	derived := NewOperator(c).Operate(record)
+	assert.Equal(t, define.RecordLogsDerived, derived.RecordType)
+	assert.Equal(t, define.RequestDerived, derived.RequestType)
+	assert.Equal(t, "fortest", derived.Token.Original)
+
+	pdLogs := derived.Data.(plog.Logs)
+	assert.Equal(t, 1, pdLogs.ResourceLogs().Len())
+	assert.Equal(t, 1, pdLogs.LogRecordCount())
+	testkits.AssertAttrsStringKeyVal(t, pdLogs.ResourceLogs().At(0).Resource().Attributes(), "service.name", "echo")
+
+	foreach.Logs(pdLogs, func(logRecord plog.LogRecord) {
+		assert.Equal(t, "exception", logRecord.Body().AsString())
+		assert.Equal(t, pcommon.Timestamp(1000), logRecord.Timestamp())
+		assert.Equal(t, "01020304000000000000000000000000", logRecord.TraceID().HexString())
+		assert.Equal(t, "0506070800000000", logRecord.SpanID().HexString())
+		assert.Equal(t, plog.SeverityNumberError, logRecord.SeverityNumber())
+		testkits.AssertAttrsStringKeyVal(t, logRecord.Attributes(),
+			"exception.stacktrace", "at com.example.Main.main(Main.java:10)",
+			"event.name", "exception",
+			"span_name", "GET /healthz",
+			"span_kind", "SPAN_KIND_SERVER",
+		)
+	})
+}
+
+func TestSpanEventOperatorAllEvents(t *testing.T) {
+	c := Config{Operations: []OperationConfig{{Type: SpanEventType}}}
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Data:       makeSpanEventTraces(),
+	}
+
+	derived := NewOperator(c).Operate(record)
+	pdLogs := derived.Data.(plog.Logs)
+	assert.Equal(t, 2, pdLogs.ResourceLogs().Len())
+	assert.Equal(t, 3, pdLogs.LogRecordCount())
+}
+
+func TestSpanEventOperatorNoMatch(t *testing.T) {
+	c := Config{
+		Operations: []OperationConfig{
+			{
+				Type: SpanEventType,
+				EventAttributes: []EventMatchConfig{
+					{Key: "exception.type", Op: "eq", Values: []string{"java.io.IOException"}},
+				},
+			},
+		},
+	}
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Data:       makeSpanEventTraces(),
+	}
+	assert.Nil(t, NewOperator(c).Operate(record))
+}
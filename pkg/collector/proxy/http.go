@@ -72,6 +72,9 @@ func (p *Proxy) V2PushRoute(w http.ResponseWriter, req *http.Request) {
 	}
 	code, processorName, err := p.Validate(r)
 	if err != nil {
+		if v, ok := define.RetryAfterSeconds(err); ok {
+			w.Header().Set("Retry-After", v)
+		}
 		writeResponse(w, err.Error(), int(code))
 		logger.Warnf("failed to run pre-check processors, code=%d, dataid=%v, ip=%v, error %s", code, pd.DataId, ip, err)
 		DefaultMetricMonitor.IncPreCheckFailedCounter(processorName, r.Token.Original, pd.DataId, code)
@@ -55,6 +55,7 @@ func init() {
 		}
 		w.WriteHeader(http.StatusServiceUnavailable) // 未加载到平台配置表示服务未就绪
 	})
+	registerAdminHttpGetRoute(adminSource, "/-/tap", debugTapHandler)
 
 	const pprofSource = "pprof"
 	registerAdminHttpGetRoute(pprofSource, "/debug/pprof/snapshot", pprofsnapshot.HandlerFuncFor())
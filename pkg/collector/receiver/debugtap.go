@@ -0,0 +1,120 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package receiver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/debugtap"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	defaultTapDuration = 30 * time.Second
+	maxTapDuration     = 3 * time.Minute
+)
+
+// debugTapHandler 以 NDJSON 流式输出指定 token 的 record 在 pipeline 各处理器前后的快照
+//
+// 参数:
+// - token: 必填
+// - record_type: 可选 为空表示全部类型
+// - qps: 每秒采样 record 数 上限为 debugtap.MaxQps
+// - duration: 会话持续时间 默认 30s 上限 3m (需小于 admin server 写超时)
+func debugTapHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := debugtap.Filter{Token: q.Get("token")}
+	if filter.Token == "" {
+		writeTapError(w, http.StatusBadRequest, "empty token")
+		return
+	}
+
+	if s := q.Get("record_type"); s != "" {
+		rtype, _ := define.IntoRecordType(s)
+		if rtype == define.RecordUndefined {
+			writeTapError(w, http.StatusBadRequest, fmt.Sprintf("unknown record_type '%s'", s))
+			return
+		}
+		filter.RecordType = rtype
+	}
+
+	var qps int
+	if s := q.Get("qps"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			writeTapError(w, http.StatusBadRequest, fmt.Sprintf("invalid qps '%s'", s))
+			return
+		}
+		qps = n
+	}
+
+	duration := defaultTapDuration
+	if s := q.Get("duration"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			writeTapError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration '%s'", s))
+			return
+		}
+		duration = d
+	}
+	if duration > maxTapDuration {
+		duration = maxTapDuration
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeTapError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	session, err := debugtap.Subscribe(filter, qps)
+	if err != nil {
+		writeTapError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	defer debugtap.Unsubscribe(session)
+	logger.Infof("debug tap session started, token=%s, record_type=%s, duration=%v", filter.Token, filter.RecordType, duration)
+
+	w.Header().Set(define.ContentType, "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-timer.C:
+			logger.Infof("debug tap session finished, token=%s, dropped=%d", filter.Token, session.Dropped())
+			return
+
+		case ev := <-session.Events():
+			if err := encoder.Encode(ev); err != nil {
+				logger.Warnf("debug tap failed to write event: %v", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeTapError(w http.ResponseWriter, code int, msg string) {
+	b, _ := json.Marshal(map[string]string{"status": "failed", "message": msg})
+	WriteResponse(w, define.ContentTypeJson, code, b)
+}
@@ -0,0 +1,71 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package receiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/debugtap"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
+)
+
+func TestDebugTapHandlerBadRequest(t *testing.T) {
+	tests := []string{
+		"/-/tap",
+		"/-/tap?token=token1&record_type=unknown",
+		"/-/tap?token=token1&qps=x",
+		"/-/tap?token=token1&duration=-1s",
+	}
+	for _, tt := range tests {
+		rw := httptest.NewRecorder()
+		debugTapHandler(rw, httptest.NewRequest(http.MethodGet, tt, nil))
+		assert.Equal(t, http.StatusBadRequest, rw.Code, tt)
+	}
+}
+
+func TestDebugTapHandler(t *testing.T) {
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/-/tap?token=token1&record_type=traces&duration=500ms", nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		debugTapHandler(rw, req)
+	}()
+
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{Original: "token1"},
+	}
+	var tr *debugtap.Trace
+	for tr == nil {
+		tr = debugtap.Start("traces_pipeline/common", record)
+		time.Sleep(10 * time.Millisecond)
+	}
+	tr.After("sampler/random", define.ErrSkipEmptyRecord)
+	<-done
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	lines := strings.Split(strings.TrimSpace(rw.Body.String()), "\n")
+	assert.Len(t, lines, 1)
+
+	var ev debugtap.Event
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &ev))
+	assert.Equal(t, debugtap.PhaseDropped, ev.Phase)
+	assert.Equal(t, "sampler/random", ev.Stage)
+	assert.Equal(t, define.ErrSkipEmptyRecord.Error(), ev.Reason)
+}
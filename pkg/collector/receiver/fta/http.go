@@ -149,6 +149,7 @@ func (s HttpService) ExportEvent(w http.ResponseWriter, req *http.Request) {
 		err = errors.Wrapf(err, "run pre-check failed, rtype=fta, code=%d, ip=%s", code, ip)
 		logger.WarnRate(time.Minute, r.Token.Original, err)
 		metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, define.RecordFta, processorName, r.Token.Original, code)
+		receiver.SetRetryAfterHeader(w, err)
 		receiver.WriteResponse(w, define.ContentTypeJson, int(code), errResponse(err))
 		return
 	}
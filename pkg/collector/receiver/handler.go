@@ -38,11 +38,19 @@ func WriteResponse(w http.ResponseWriter, contentType string, statusCode int, ms
 }
 
 func WriteErrResponse(w http.ResponseWriter, contentType string, statusCode int, err error) {
+	SetRetryAfterHeader(w, err)
 	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(statusCode)
 	_, _ = w.Write([]byte(err.Error()))
 }
 
+// SetRetryAfterHeader 错误携带重试建议时设置 Retry-After 头 需在 WriteHeader 之前调用
+func SetRetryAfterHeader(w http.ResponseWriter, err error) {
+	if v, ok := define.RetryAfterSeconds(err); ok {
+		w.Header().Set("Retry-After", v)
+	}
+}
+
 func RecordHandleMetrics(mm *metricMonitor, token define.Token, protocol define.RequestType, rtype define.RecordType, bs int, t time.Time) {
 	mm.AddReceivedBytesCounter(float64(bs), protocol, rtype, token.Original)
 	mm.ObserveBytesDistribution(float64(bs), protocol, rtype, token.Original)
@@ -0,0 +1,138 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/kafkaclient"
+)
+
+var configFieldKafka = fmt.Sprintf("%s.kafka", define.ConfigFieldReceiver)
+
+const (
+	EncodingOtlpProto = "otlp_proto"
+	EncodingOtlpJson  = "otlp_json"
+
+	OffsetOldest = "oldest"
+	OffsetNewest = "newest"
+)
+
+const (
+	defaultGroupID            = "bk-collector"
+	defaultCommitInterval     = time.Second
+	defaultSessionTimeout     = 10 * time.Second
+	defaultHeartbeatInterval  = 3 * time.Second
+	defaultRebalanceRetryWait = 5 * time.Second
+	defaultThrottleRetryWait  = time.Second
+	maxThrottleRetryWait      = 30 * time.Second
+)
+
+type Config struct {
+	Enabled        bool               `config:"enabled"`
+	Client         kafkaclient.Config `config:"client"`
+	GroupID        string             `config:"group_id"`
+	InitialOffset  string             `config:"initial_offset"`  // 无已提交位点时的消费起点 oldest/newest
+	CommitInterval time.Duration      `config:"commit_interval"` // 位点自动提交周期
+	SessionTimeout time.Duration      `config:"session_timeout"`
+	Topics         []TopicConfig      `config:"topics"`
+}
+
+// TopicConfig 描述 topic 的数据类型、编码以及所属 token
+//
+// token 为空时从消息头 X-BK-TOKEN 中提取
+type TopicConfig struct {
+	Name       string `config:"name"`
+	Token      string `config:"token"`
+	RecordType string `config:"record_type"` // traces/metrics/logs
+	Encoding   string `config:"encoding"`    // otlp_proto/otlp_json
+}
+
+func (c *Config) validate() error {
+	if err := c.Client.Normalize(); err != nil {
+		return err
+	}
+	if c.GroupID == "" {
+		c.GroupID = defaultGroupID
+	}
+	if c.InitialOffset == "" {
+		c.InitialOffset = OffsetNewest
+	}
+	if c.InitialOffset != OffsetOldest && c.InitialOffset != OffsetNewest {
+		return errors.Errorf("unsupported initial_offset '%s'", c.InitialOffset)
+	}
+	if c.CommitInterval <= 0 {
+		c.CommitInterval = defaultCommitInterval
+	}
+	if c.SessionTimeout <= 0 {
+		c.SessionTimeout = defaultSessionTimeout
+	}
+
+	if len(c.Topics) == 0 {
+		return errors.New("empty kafka topics")
+	}
+	names := make(map[string]struct{})
+	for i := 0; i < len(c.Topics); i++ {
+		topic := &c.Topics[i]
+		if topic.Name == "" {
+			return errors.Errorf("topics[%d]: empty topic name", i)
+		}
+		if _, ok := names[topic.Name]; ok {
+			return errors.Errorf("duplicated topic '%s'", topic.Name)
+		}
+		names[topic.Name] = struct{}{}
+
+		switch define.RecordType(topic.RecordType) {
+		case define.RecordTraces, define.RecordMetrics, define.RecordLogs:
+		default:
+			return errors.Errorf("topic '%s': unsupported record_type '%s'", topic.Name, topic.RecordType)
+		}
+
+		if topic.Encoding == "" {
+			topic.Encoding = EncodingOtlpProto
+		}
+		if topic.Encoding != EncodingOtlpProto && topic.Encoding != EncodingOtlpJson {
+			return errors.Errorf("topic '%s': unsupported encoding '%s'", topic.Name, topic.Encoding)
+		}
+	}
+	return nil
+}
+
+func (c *Config) topicNames() []string {
+	names := make([]string, 0, len(c.Topics))
+	for _, topic := range c.Topics {
+		names = append(names, topic.Name)
+	}
+	return names
+}
+
+// LoadConfig 加载 kafka 配置 未配置或未启用时返回 nil
+func LoadConfig(conf *confengine.Config) (*Config, error) {
+	if !conf.Has(configFieldKafka) {
+		return nil, nil
+	}
+
+	c := &Config{}
+	if err := conf.UnpackChild(configFieldKafka, c); err != nil {
+		return nil, err
+	}
+	if !c.Enabled {
+		return nil, nil
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,94 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/kafkaclient"
+)
+
+func TestLoadConfig(t *testing.T) {
+	content := `
+receiver:
+  kafka:
+    enabled: true
+    client:
+      brokers: ["127.0.0.1:9092"]
+    group_id: "test-group"
+    initial_offset: oldest
+    topics:
+      - name: "otlp_traces"
+        token: "token1"
+        record_type: traces
+      - name: "otlp_logs"
+        record_type: logs
+        encoding: otlp_json
+`
+	conf := confengine.MustLoadConfigContent(content)
+	c, err := LoadConfig(conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-group", c.GroupID)
+	assert.Equal(t, OffsetOldest, c.InitialOffset)
+	assert.Equal(t, defaultCommitInterval, c.CommitInterval)
+	assert.Equal(t, []string{"otlp_traces", "otlp_logs"}, c.topicNames())
+	assert.Equal(t, EncodingOtlpProto, c.Topics[0].Encoding)
+	assert.Equal(t, EncodingOtlpJson, c.Topics[1].Encoding)
+}
+
+func TestLoadConfigDisabled(t *testing.T) {
+	c, err := LoadConfig(confengine.MustLoadConfigContent(`
+receiver:
+  kafka:
+    enabled: false
+`))
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = LoadConfig(confengine.MustLoadConfigContent(`
+receiver:
+  disabled: false
+`))
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestConfigValidate(t *testing.T) {
+	client := kafkaclient.Config{Brokers: []string{"127.0.0.1:9092"}}
+	tests := []struct {
+		name string
+		c    Config
+	}{
+		{name: "EmptyBrokers", c: Config{Topics: []TopicConfig{{Name: "t", RecordType: "traces"}}}},
+		{name: "EmptyTopics", c: Config{Client: client}},
+		{name: "EmptyTopicName", c: Config{Client: client, Topics: []TopicConfig{{RecordType: "traces"}}}},
+		{name: "DuplicatedTopic", c: Config{Client: client, Topics: []TopicConfig{{Name: "t", RecordType: "traces"}, {Name: "t", RecordType: "logs"}}}},
+		{name: "InvalidRecordType", c: Config{Client: client, Topics: []TopicConfig{{Name: "t", RecordType: "profiles"}}}},
+		{name: "InvalidEncoding", c: Config{Client: client, Topics: []TopicConfig{{Name: "t", RecordType: "traces", Encoding: "avro"}}}},
+		{name: "InvalidOffset", c: Config{Client: client, InitialOffset: "latest", Topics: []TopicConfig{{Name: "t", RecordType: "traces"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.c.validate())
+		})
+	}
+
+	c := Config{Client: client, Topics: []TopicConfig{{Name: "t", RecordType: string(define.RecordMetrics)}}}
+	assert.NoError(t, c.validate())
+	assert.Equal(t, defaultGroupID, c.GroupID)
+	assert.Equal(t, OffsetNewest, c.InitialOffset)
+	assert.Equal(t, defaultSessionTimeout, c.SessionTimeout)
+	assert.Equal(t, time.Second, c.CommitInterval)
+}
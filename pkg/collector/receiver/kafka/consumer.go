@@ -0,0 +1,273 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/prettyprint"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/otlp"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+func init() {
+	receiver.RegisterPullerFactory(define.SourceKafka, New)
+}
+
+var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourceKafka)
+
+type topicHandler struct {
+	token   string
+	rtype   define.RecordType
+	encoder otlp.Encoder
+}
+
+// newConsumerGroup 创建消费组 单测中替换为内存实现
+var newConsumerGroup = func(c *Config) (sarama.ConsumerGroup, error) {
+	sc, err := c.Client.SaramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	sc.Consumer.Return.Errors = true
+	sc.Consumer.Offsets.AutoCommit.Enable = true
+	sc.Consumer.Offsets.AutoCommit.Interval = c.CommitInterval
+	sc.Consumer.Group.Session.Timeout = c.SessionTimeout
+	sc.Consumer.Group.Heartbeat.Interval = defaultHeartbeatInterval
+	sc.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if c.InitialOffset == OffsetOldest {
+		sc.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+	return sarama.NewConsumerGroup(c.Client.Brokers, c.GroupID, sc)
+}
+
+// Consumer 以消费组方式订阅 topic 并使用 otlp 编码器解析数据
+//
+// 消息处理完成（包括预检失败被丢弃）后即标记位点 由 sarama 周期性提交
+type Consumer struct {
+	receiver.Publisher
+	pipeline.Validator
+
+	config   *Config
+	handlers map[string]topicHandler
+	group    sarama.ConsumerGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建 Consumer 未启用时返回 nil
+func New(conf *confengine.Config) (receiver.Puller, error) {
+	c, err := LoadConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return newConsumer(c), nil
+}
+
+func newConsumer(c *Config) *Consumer {
+	handlers := make(map[string]topicHandler)
+	for _, topic := range c.Topics {
+		encoder := otlp.PbEncoder()
+		if topic.Encoding == EncodingOtlpJson {
+			encoder = otlp.JsonEncoder()
+		}
+		handlers[topic.Name] = topicHandler{
+			token:   topic.Token,
+			rtype:   define.RecordType(topic.RecordType),
+			encoder: encoder,
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Consumer{
+		config:   c,
+		handlers: handlers,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (c *Consumer) Start() error {
+	group, err := newConsumerGroup(c.config)
+	if err != nil {
+		return errors.Wrap(err, "create kafka consumer group failed")
+	}
+	c.group = group
+	logger.Infof("kafka consumer start working, group=%s, topics=%v", c.config.GroupID, c.config.topicNames())
+
+	c.wg.Add(2)
+	go func() {
+		defer c.wg.Done()
+		c.loopConsume()
+	}()
+	go func() {
+		defer c.wg.Done()
+		c.loopErrors()
+	}()
+	return nil
+}
+
+func (c *Consumer) Stop() {
+	c.cancel()
+	if c.group != nil {
+		if err := c.group.Close(); err != nil {
+			logger.Warnf("failed to close kafka consumer group: %v", err)
+		}
+	}
+	c.wg.Wait()
+}
+
+// loopConsume 每次 rebalance 后 Consume 会返回 需要循环调用
+func (c *Consumer) loopConsume() {
+	topics := c.config.topicNames()
+	for {
+		err := c.group.Consume(c.ctx, topics, c)
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return
+			}
+			logger.Errorf("kafka consumer group consume failed: %v", err)
+			select {
+			case <-time.After(defaultRebalanceRetryWait):
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (c *Consumer) loopErrors() {
+	for {
+		select {
+		case err, ok := <-c.group.Errors():
+			if !ok {
+				return
+			}
+			logger.Warnf("kafka consumer group error: %v", err)
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Consumer) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+func (c *Consumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			// 被限流的消息不推进位点 等待后重试 避免数据丢失
+			for {
+				wait, retry := c.handleMessage(msg)
+				if !retry {
+					break
+				}
+				select {
+				case <-time.After(wait):
+				case <-session.Context().Done():
+					return nil
+				}
+			}
+			session.MarkMessage(msg, "")
+
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+func messageToken(msg *sarama.ConsumerMessage) string {
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == define.KeyToken {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// throttleWait 优先使用限流器给出的重试建议
+func throttleWait(err error) time.Duration {
+	wait, ok := define.RetryAfter(err)
+	if !ok || wait <= 0 {
+		return defaultThrottleRetryWait
+	}
+	if wait > maxThrottleRetryWait {
+		return maxThrottleRetryWait
+	}
+	return wait
+}
+
+// handleMessage 处理单条消息 retry 为 true 表示消息被限流 需等待 wait 后重试
+// 其余情况（接收成功或解析/预检失败主动丢弃）均可推进位点
+func (c *Consumer) handleMessage(msg *sarama.ConsumerMessage) (wait time.Duration, retry bool) {
+	defer utils.HandleCrash()
+
+	h, ok := c.handlers[msg.Topic]
+	if !ok {
+		return 0, false
+	}
+
+	start := time.Now()
+	data, err := otlp.UnmarshalRecordData(h.encoder, h.rtype, msg.Value)
+	if err != nil {
+		metricMonitor.IncDroppedCounter(define.RequestKafka, h.rtype)
+		logger.Warnf("failed to unmarshal kafka message, topic=%s, partition=%d, offset=%d, error: %s", msg.Topic, msg.Partition, msg.Offset, err)
+		return 0, false
+	}
+
+	token := h.token
+	if token == "" {
+		token = messageToken(msg)
+	}
+
+	r := &define.Record{
+		RequestType: define.RequestKafka,
+		RecordType:  h.rtype,
+		Token:       define.Token{Original: token},
+		Data:        data,
+	}
+	prettyprint.Pretty(h.rtype, data)
+
+	code, processorName, err := c.Validate(r)
+	if err != nil {
+		logger.WarnRate(time.Minute, r.Token.Original, errors.Wrapf(err, "run pre-check failed, code=%d, topic=%s", code, msg.Topic))
+		metricMonitor.IncPreCheckFailedCounter(define.RequestKafka, h.rtype, processorName, r.Token.Original, code)
+		if code == define.StatusCodeTooManyRequests {
+			return throttleWait(err), true
+		}
+		return 0, false
+	}
+
+	c.Publish(r)
+	receiver.RecordHandleMetrics(metricMonitor, r.Token, define.RequestKafka, h.rtype, len(msg.Value), start)
+	return 0, false
+}
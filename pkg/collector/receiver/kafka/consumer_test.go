@@ -0,0 +1,264 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/kafkaclient"
+)
+
+// memClaim/memSession/memGroup 内存版消费组 每个 topic 仅有一个分区
+type memClaim struct {
+	topic string
+	ch    chan *sarama.ConsumerMessage
+}
+
+func (c *memClaim) Topic() string                            { return c.topic }
+func (c *memClaim) Partition() int32                         { return 0 }
+func (c *memClaim) InitialOffset() int64                     { return 0 }
+func (c *memClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *memClaim) Messages() <-chan *sarama.ConsumerMessage { return c.ch }
+
+type memSession struct {
+	ctx    context.Context
+	mut    sync.Mutex
+	marked map[string]int64
+}
+
+func (s *memSession) Claims() map[string][]int32 { return nil }
+func (s *memSession) MemberID() string           { return "member" }
+func (s *memSession) GenerationID() int32        { return 1 }
+func (s *memSession) MarkOffset(string, int32, int64, string) {
+}
+func (s *memSession) Commit() {}
+func (s *memSession) ResetOffset(string, int32, int64, string) {
+}
+func (s *memSession) Context() context.Context { return s.ctx }
+
+func (s *memSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.marked[msg.Topic] = msg.Offset + 1
+}
+
+func (s *memSession) offset(topic string) int64 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.marked[topic]
+}
+
+type memGroup struct {
+	claims  map[string]*memClaim
+	session *memSession
+	errs    chan error
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newMemGroup(topics ...string) *memGroup {
+	claims := make(map[string]*memClaim)
+	for _, topic := range topics {
+		claims[topic] = &memClaim{topic: topic, ch: make(chan *sarama.ConsumerMessage, 10)}
+	}
+	return &memGroup{
+		claims:  claims,
+		session: &memSession{marked: make(map[string]int64)},
+		errs:    make(chan error),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (g *memGroup) produce(topic string, offset int64, value []byte, headers ...*sarama.RecordHeader) {
+	g.claims[topic].ch <- &sarama.ConsumerMessage{Topic: topic, Offset: offset, Value: value, Headers: headers}
+}
+
+func (g *memGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	select {
+	case <-g.closed:
+		return sarama.ErrClosedConsumerGroup
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-g.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	g.session.ctx = ctx
+	if err := handler.Setup(g.session); err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, topic := range topics {
+		wg.Add(1)
+		go func(claim *memClaim) {
+			defer wg.Done()
+			_ = handler.ConsumeClaim(g.session, claim)
+		}(g.claims[topic])
+	}
+	wg.Wait()
+	return handler.Cleanup(g.session)
+}
+
+func (g *memGroup) Errors() <-chan error { return g.errs }
+
+func (g *memGroup) Pause(map[string][]int32)  {}
+func (g *memGroup) Resume(map[string][]int32) {}
+func (g *memGroup) PauseAll()                 {}
+func (g *memGroup) ResumeAll()                {}
+
+func (g *memGroup) Close() error {
+	g.once.Do(func() { close(g.closed) })
+	return nil
+}
+
+func marshalTraces(t *testing.T, traces ptrace.Traces) []byte {
+	b, err := ptraceotlp.NewRequestFromTraces(traces).MarshalProto()
+	assert.NoError(t, err)
+	return b
+}
+
+func newTestConsumer(t *testing.T, group *memGroup) (*Consumer, chan *define.Record) {
+	c := &Config{
+		Client: kafkaclient.Config{Brokers: []string{"127.0.0.1:9092"}},
+		Topics: []TopicConfig{
+			{Name: "otlp_traces", Token: "token1", RecordType: "traces"},
+			{Name: "otlp_logs", RecordType: "logs", Encoding: EncodingOtlpJson},
+		},
+	}
+	assert.NoError(t, c.validate())
+
+	newConsumerGroup = func(*Config) (sarama.ConsumerGroup, error) { return group, nil }
+	consumer := newConsumer(c)
+
+	ch := make(chan *define.Record, 10)
+	consumer.Publisher.Func = func(r *define.Record) { ch <- r }
+	consumer.Validator.Func = func(r *define.Record) (define.StatusCode, string, error) {
+		if r.Token.Original == "" {
+			return define.StatusCodeUnauthorized, define.ProcessorTokenChecker, errors.New("empty token")
+		}
+		return define.StatusCodeOK, "", nil
+	}
+	return consumer, ch
+}
+
+func TestConsumer(t *testing.T) {
+	group := newMemGroup("otlp_traces", "otlp_logs")
+	consumer, ch := newTestConsumer(t, group)
+	assert.NoError(t, consumer.Start())
+	defer consumer.Stop()
+
+	traces := generator.NewTracesGenerator(define.TracesOptions{SpanCount: 2}).Generate()
+	group.produce("otlp_traces", 0, marshalTraces(t, traces))
+
+	r := <-ch
+	assert.Equal(t, define.RecordTraces, r.RecordType)
+	assert.Equal(t, define.RequestKafka, r.RequestType)
+	assert.Equal(t, "token1", r.Token.Original)
+	assert.Equal(t, 2, r.Data.(ptrace.Traces).SpanCount())
+
+	// topic 未配置 token 时从消息头提取
+	logs := generator.NewLogsGenerator(define.LogsOptions{LogCount: 3, LogLength: 8}).Generate()
+	b, err := plog.NewJSONMarshaler().MarshalLogs(logs)
+	assert.NoError(t, err)
+	group.produce("otlp_logs", 5, b, &sarama.RecordHeader{Key: []byte(define.KeyToken), Value: []byte("token2")})
+
+	r = <-ch
+	assert.Equal(t, define.RecordLogs, r.RecordType)
+	assert.Equal(t, "token2", r.Token.Original)
+	assert.Equal(t, 3, r.Data.(plog.Logs).LogRecordCount())
+
+	// 解析失败以及预检失败的消息被丢弃 但位点照常推进
+	group.produce("otlp_traces", 1, []byte("invalid"))
+	group.produce("otlp_logs", 6, b)
+	assert.Eventually(t, func() bool {
+		return group.session.offset("otlp_traces") == 2 && group.session.offset("otlp_logs") == 7
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, ch, 0)
+}
+
+func TestConsumerThrottle(t *testing.T) {
+	group := newMemGroup("otlp_traces", "otlp_logs")
+	consumer, ch := newTestConsumer(t, group)
+
+	var mut sync.Mutex
+	throttled := true
+	consumer.Validator.Func = func(r *define.Record) (define.StatusCode, string, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		if throttled {
+			err := define.NewRetryAfterError(errors.New("rate limited"), 10*time.Millisecond)
+			return define.StatusCodeTooManyRequests, define.ProcessorRateLimiter, err
+		}
+		return define.StatusCodeOK, "", nil
+	}
+	assert.NoError(t, consumer.Start())
+	defer consumer.Stop()
+
+	traces := generator.NewTracesGenerator(define.TracesOptions{SpanCount: 1}).Generate()
+	group.produce("otlp_traces", 0, marshalTraces(t, traces))
+
+	// 限流期间不推进位点
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(0), group.session.offset("otlp_traces"))
+	assert.Len(t, ch, 0)
+
+	mut.Lock()
+	throttled = false
+	mut.Unlock()
+
+	r := <-ch
+	assert.Equal(t, 1, r.Data.(ptrace.Traces).SpanCount())
+	assert.Eventually(t, func() bool {
+		return group.session.offset("otlp_traces") == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestThrottleWait(t *testing.T) {
+	assert.Equal(t, defaultThrottleRetryWait, throttleWait(errors.New("rate limited")))
+	assert.Equal(t, 2*time.Second, throttleWait(define.NewRetryAfterError(errors.New("rate limited"), 2*time.Second)))
+	assert.Equal(t, maxThrottleRetryWait, throttleWait(define.NewRetryAfterError(errors.New("rate limited"), time.Hour)))
+}
+
+func TestConsumerStop(t *testing.T) {
+	group := newMemGroup("otlp_traces", "otlp_logs")
+	consumer, _ := newTestConsumer(t, group)
+	assert.NoError(t, consumer.Start())
+
+	done := make(chan struct{})
+	go func() {
+		consumer.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("consumer stop timeout")
+	}
+}
@@ -28,7 +28,8 @@ type Encoder interface {
 	UnmarshalLogs(b []byte) (plog.Logs, error)
 }
 
-func unmarshalRecordData(encoder Encoder, rtype define.RecordType, b []byte) (any, error) {
+// UnmarshalRecordData 按 RecordType 使用 encoder 解析数据
+func UnmarshalRecordData(encoder Encoder, rtype define.RecordType, b []byte) (any, error) {
 	switch rtype {
 	case define.RecordTraces:
 		return encoder.UnmarshalTraces(b)
@@ -99,6 +99,7 @@ func writeError(w http.ResponseWriter, rh receiver.ResponseHandler, err error, s
 		}
 	}
 
+	receiver.SetRetryAfterHeader(w, err)
 	msg, err := rh.ErrorStatus(s.Proto())
 	if err != nil {
 		receiver.WriteResponse(w, rh.ContentType(), http.StatusInternalServerError, fallbackMsg)
@@ -218,7 +219,7 @@ func (h httpPbResponseHandler) Response(rtype define.RecordType) ([]byte, error)
 }
 
 func (h httpPbResponseHandler) Unmarshal(rtype define.RecordType, b []byte) (any, error) {
-	return unmarshalRecordData(h.encoder, rtype, b)
+	return UnmarshalRecordData(h.encoder, rtype, b)
 }
 
 func (h httpPbResponseHandler) ErrorStatus(status any) ([]byte, error) {
@@ -261,7 +262,7 @@ func (h httpJsonResponseHandler) Response(rtype define.RecordType) ([]byte, erro
 }
 
 func (h httpJsonResponseHandler) Unmarshal(rtype define.RecordType, b []byte) (any, error) {
-	return unmarshalRecordData(h.encoder, rtype, b)
+	return UnmarshalRecordData(h.encoder, rtype, b)
 }
 
 func (h httpJsonResponseHandler) ErrorStatus(status any) ([]byte, error) {
@@ -0,0 +1,152 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+var configFieldScraper = fmt.Sprintf("%s.scraper", define.ConfigFieldReceiver)
+
+const (
+	defaultInterval        = time.Minute
+	defaultTimeout         = 10 * time.Second
+	defaultRefreshInterval = time.Minute
+	defaultMaxBodyBytes    = 32 * 1024 * 1024
+	defaultScheme          = "http"
+	defaultPath            = "/metrics"
+)
+
+type Config struct {
+	Enabled         bool          `config:"enabled"`
+	Interval        time.Duration `config:"interval"`         // 默认采集周期
+	Timeout         time.Duration `config:"timeout"`          // 默认采集超时
+	RefreshInterval time.Duration `config:"refresh_interval"` // 采集目标刷新周期
+	MaxBodyBytes    int64         `config:"max_body_bytes"`   // 单次采集响应体上限
+	Jobs            []JobConfig   `config:"jobs"`
+}
+
+// JobConfig 描述一组采集目标 目标来源于静态配置或 k8scache 中的 pod 信息
+type JobConfig struct {
+	Name           string               `config:"name"`
+	Token          string               `config:"token"`
+	Scheme         string               `config:"scheme"`
+	Path           string               `config:"path"`
+	Params         map[string]string    `config:"params"`
+	Interval       time.Duration        `config:"interval"`
+	Timeout        time.Duration        `config:"timeout"`
+	Labels         map[string]string    `config:"labels"`
+	StaticTargets  []string             `config:"static_targets"` // host:port
+	KubernetesPods KubernetesPodsConfig `config:"kubernetes_pods"`
+}
+
+// KubernetesPodsConfig 从 k8scache 中发现 pod 作为采集目标
+type KubernetesPodsConfig struct {
+	Enabled    bool     `config:"enabled"`
+	Port       int      `config:"port"`
+	Namespaces []string `config:"namespaces"` // 为空表示不过滤
+}
+
+func (c *Config) Validate() error {
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = defaultRefreshInterval
+	}
+	if c.MaxBodyBytes <= 0 {
+		c.MaxBodyBytes = defaultMaxBodyBytes
+	}
+
+	names := make(map[string]struct{})
+	for i := 0; i < len(c.Jobs); i++ {
+		job := &c.Jobs[i]
+		if job.Name == "" {
+			return errors.Errorf("jobs[%d]: empty job name", i)
+		}
+		if _, ok := names[job.Name]; ok {
+			return errors.Errorf("duplicated job name '%s'", job.Name)
+		}
+		names[job.Name] = struct{}{}
+
+		if job.Token == "" {
+			return errors.Errorf("job '%s': empty token", job.Name)
+		}
+		if job.KubernetesPods.Enabled && job.KubernetesPods.Port <= 0 {
+			return errors.Errorf("job '%s': invalid kubernetes_pods port %d", job.Name, job.KubernetesPods.Port)
+		}
+
+		if job.Scheme == "" {
+			job.Scheme = defaultScheme
+		}
+		if job.Scheme != "http" && job.Scheme != "https" {
+			return errors.Errorf("job '%s': unsupported scheme '%s'", job.Name, job.Scheme)
+		}
+		if job.Path == "" {
+			job.Path = defaultPath
+		}
+		if job.Interval <= 0 {
+			job.Interval = c.Interval
+		}
+		if job.Timeout <= 0 {
+			job.Timeout = c.Timeout
+		}
+		// 超时不能超过采集周期 否则会出现同一目标并发采集
+		if job.Timeout > job.Interval {
+			job.Timeout = job.Interval
+		}
+	}
+	return nil
+}
+
+func (job *JobConfig) targetURL(address string) string {
+	u := url.URL{
+		Scheme: job.Scheme,
+		Host:   address,
+		Path:   job.Path,
+	}
+	if len(job.Params) > 0 {
+		q := url.Values{}
+		for k, v := range job.Params {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// LoadConfig 加载 scraper 配置 未配置或未启用时返回 nil
+func LoadConfig(conf *confengine.Config) (*Config, error) {
+	if !conf.Has(configFieldScraper) {
+		return nil, nil
+	}
+
+	c := &Config{}
+	if err := conf.UnpackChild(configFieldScraper, c); err != nil {
+		return nil, err
+	}
+	if !c.Enabled {
+		return nil, nil
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
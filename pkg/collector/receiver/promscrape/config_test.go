@@ -0,0 +1,118 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+)
+
+func TestLoadConfig(t *testing.T) {
+	content := `
+receiver:
+  scraper:
+    enabled: true
+    interval: 30s
+    jobs:
+      - name: "node"
+        token: "token1"
+        timeout: 1m
+        static_targets: ["127.0.0.1:9100"]
+        params:
+          module: "http_2xx"
+        labels:
+          env: "prod"
+      - name: "pods"
+        token: "token2"
+        scheme: "https"
+        path: "/custom/metrics"
+        kubernetes_pods:
+          enabled: true
+          port: 8080
+          namespaces: ["default"]
+`
+	conf := confengine.MustLoadConfigContent(content)
+	c, err := LoadConfig(conf)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	assert.Equal(t, defaultTimeout, c.Timeout)
+	assert.Equal(t, defaultRefreshInterval, c.RefreshInterval)
+	assert.Len(t, c.Jobs, 2)
+
+	node := c.Jobs[0]
+	assert.Equal(t, 30*time.Second, node.Interval)
+	assert.Equal(t, 30*time.Second, node.Timeout) // 不超过采集周期
+	assert.Equal(t, "http://127.0.0.1:9100/metrics?module=http_2xx", node.targetURL("127.0.0.1:9100"))
+
+	pods := c.Jobs[1]
+	assert.Equal(t, defaultTimeout, pods.Timeout)
+	assert.Equal(t, "https://127.0.0.2:8080/custom/metrics", pods.targetURL("127.0.0.2:8080"))
+	assert.Equal(t, []string{"default"}, pods.KubernetesPods.Namespaces)
+}
+
+func TestLoadConfigDisabled(t *testing.T) {
+	conf := confengine.MustLoadConfigContent(`
+receiver:
+  scraper:
+    enabled: false
+`)
+	c, err := LoadConfig(conf)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	conf = confengine.MustLoadConfigContent(`
+receiver:
+  http_server:
+    enabled: true
+`)
+	c, err = LoadConfig(conf)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs []JobConfig
+	}{
+		{
+			name: "empty name",
+			jobs: []JobConfig{{Token: "token1"}},
+		},
+		{
+			name: "empty token",
+			jobs: []JobConfig{{Name: "job1"}},
+		},
+		{
+			name: "duplicated name",
+			jobs: []JobConfig{{Name: "job1", Token: "token1"}, {Name: "job1", Token: "token1"}},
+		},
+		{
+			name: "invalid port",
+			jobs: []JobConfig{{Name: "job1", Token: "token1", KubernetesPods: KubernetesPodsConfig{Enabled: true}}},
+		},
+		{
+			name: "invalid scheme",
+			jobs: []JobConfig{{Name: "job1", Token: "token1", Scheme: "ftp"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{Jobs: tt.jobs}
+			assert.Error(t, c.Validate())
+		})
+	}
+}
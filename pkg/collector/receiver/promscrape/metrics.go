@@ -0,0 +1,95 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+var (
+	scraperTargets = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "receiver_scraper_targets",
+			Help:      "Receiver scraper targets count",
+		},
+		[]string{"job"},
+	)
+
+	scraperScrapedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "receiver_scraper_scraped_total",
+			Help:      "Receiver scraper scraped total",
+		},
+		[]string{"job"},
+	)
+
+	scraperFailedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "receiver_scraper_failed_total",
+			Help:      "Receiver scraper failed total",
+		},
+		[]string{"job"},
+	)
+
+	scraperSamplesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "receiver_scraper_samples_total",
+			Help:      "Receiver scraper samples total",
+		},
+		[]string{"job"},
+	)
+
+	scraperDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "receiver_scraper_duration_seconds",
+			Help:      "Receiver scraper duration seconds",
+			Buckets:   define.DefObserveDuration,
+		},
+		[]string{"job"},
+	)
+)
+
+var DefaultMetricMonitor = &scraperMonitor{}
+
+type scraperMonitor struct{}
+
+func (m *scraperMonitor) SetTargetsCount(job string, n int) {
+	scraperTargets.WithLabelValues(job).Set(float64(n))
+}
+
+func (m *scraperMonitor) DeleteTargetsCount(job string) {
+	scraperTargets.DeleteLabelValues(job)
+}
+
+func (m *scraperMonitor) IncScrapedCounter(job string) {
+	scraperScrapedTotal.WithLabelValues(job).Inc()
+}
+
+func (m *scraperMonitor) IncFailedCounter(job string) {
+	scraperFailedTotal.WithLabelValues(job).Inc()
+}
+
+func (m *scraperMonitor) AddSamplesCounter(job string, n int) {
+	scraperSamplesTotal.WithLabelValues(job).Add(float64(n))
+}
+
+func (m *scraperMonitor) ObserveDuration(t time.Time, job string) {
+	scraperDuration.WithLabelValues(job).Observe(time.Since(t).Seconds())
+}
@@ -0,0 +1,94 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"io"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/metricsbuilder"
+)
+
+// samples 按指标名聚合的样本 names 保留指标首次出现的顺序
+type samples struct {
+	names   []string
+	metrics map[string][]metricsbuilder.Metric
+	count   int
+}
+
+func (s *samples) add(name string, m metricsbuilder.Metric) {
+	if _, ok := s.metrics[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.metrics[name] = append(s.metrics[name], m)
+	s.count++
+}
+
+// parseSamples 解析 Prometheus 文本格式或 OpenMetrics 格式数据
+//
+// 样本未携带时间戳时使用 defaultTs 非法数值（NaN/Inf）会被忽略
+func parseSamples(b []byte, contentType string, defaultTs time.Time) (*samples, error) {
+	p, err := textparse.New(b, contentType)
+	if err != nil {
+		// 无法识别的 Content-Type 会回退到 Prometheus 文本格式解析
+		p = textparse.NewPromParser(b)
+	}
+
+	ret := &samples{metrics: make(map[string][]metricsbuilder.Metric)}
+	ts := pcommon.NewTimestampFromTime(defaultTs)
+
+	var lbs labels.Labels
+	for {
+		entry, err := p.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrap(err, "parse samples failed")
+		}
+		if entry != textparse.EntrySeries {
+			continue
+		}
+
+		_, sampleTs, val := p.Series()
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			continue
+		}
+
+		lbs = lbs[:0]
+		p.Metric(&lbs)
+
+		var name string
+		dims := make(map[string]string, len(lbs))
+		for _, lb := range lbs {
+			if lb.Name == labels.MetricName {
+				name = lb.Value
+				continue
+			}
+			dims[lb.Name] = lb.Value
+		}
+		if name == "" {
+			continue
+		}
+
+		m := metricsbuilder.Metric{Val: val, Ts: ts, Dimensions: dims}
+		if sampleTs != nil {
+			m.Ts = pcommon.NewTimestampFromTime(time.UnixMilli(*sampleTs))
+		}
+		ret.add(name, m)
+	}
+	return ret, nil
+}
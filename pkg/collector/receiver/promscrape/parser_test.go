@@ -0,0 +1,71 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestParseSamples(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Prometheus", func(t *testing.T) {
+		content := `
+# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"} 3 1395066363000
+# TYPE go_goroutines gauge
+go_goroutines 12
+nan_value NaN
+`
+		ss, err := parseSamples([]byte(content), "text/plain; version=0.0.4", now)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, ss.count)
+		assert.Equal(t, []string{"http_requests_total", "go_goroutines"}, ss.names)
+
+		requests := ss.metrics["http_requests_total"]
+		assert.Len(t, requests, 2)
+		assert.Equal(t, float64(1027), requests[0].Val)
+		assert.Equal(t, map[string]string{"method": "post", "code": "200"}, requests[0].Dimensions)
+		assert.Equal(t, pcommon.NewTimestampFromTime(time.UnixMilli(1395066363000)), requests[0].Ts)
+
+		goroutines := ss.metrics["go_goroutines"]
+		assert.Len(t, goroutines, 1)
+		assert.Equal(t, pcommon.NewTimestampFromTime(now), goroutines[0].Ts)
+		assert.Empty(t, goroutines[0].Dimensions)
+	})
+
+	t.Run("OpenMetrics", func(t *testing.T) {
+		content := `# TYPE rpc_calls counter
+rpc_calls_total{service="a"} 10
+# TYPE rpc_latency histogram
+rpc_latency_bucket{le="0.1"} 1
+rpc_latency_bucket{le="+Inf"} 2
+rpc_latency_sum 0.3
+rpc_latency_count 2
+# EOF
+`
+		ss, err := parseSamples([]byte(content), "application/openmetrics-text; version=1.0.0; charset=utf-8", now)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, ss.count)
+		assert.Equal(t, []string{"rpc_calls_total", "rpc_latency_bucket", "rpc_latency_sum", "rpc_latency_count"}, ss.names)
+		assert.Equal(t, "+Inf", ss.metrics["rpc_latency_bucket"][1].Dimensions["le"])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := parseSamples([]byte("metric{a=} 1\n"), "", now)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,321 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/metricsbuilder"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	acceptHeader = `application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1`
+
+	metricUp             = "up"
+	metricScrapeDuration = "scrape_duration_seconds"
+	metricScrapeSamples  = "scrape_samples_scraped"
+)
+
+func init() {
+	receiver.RegisterPullerFactory(define.SourcePromScrape, New)
+}
+
+var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourcePromScrape)
+
+// Scraper 按周期主动拉取 Prometheus/OpenMetrics 端点数据 并转换为 RecordMetrics 进入处理链路
+type Scraper struct {
+	receiver.Publisher
+	pipeline.Validator
+
+	config    *Config
+	client    *http.Client
+	podsRange podsRanger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mut   sync.Mutex
+	loops map[string]context.CancelFunc
+	jobs  map[string]int
+}
+
+// New 创建 Scraper 未启用时返回 nil
+func New(conf *confengine.Config) (receiver.Puller, error) {
+	c, err := LoadConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return newScraper(c, k8sPodsRanger), nil
+}
+
+func newScraper(c *Config, podsRange podsRanger) *Scraper {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scraper{
+		config:    c,
+		podsRange: podsRange,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     time.Minute * 5,
+			},
+		},
+		ctx:    ctx,
+		cancel: cancel,
+		loops:  make(map[string]context.CancelFunc),
+		jobs:   make(map[string]int),
+	}
+}
+
+func (s *Scraper) Start() error {
+	logger.Infof("scraper start working, jobs count=%d", len(s.config.Jobs))
+	s.syncTargets()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loopRefresh()
+	}()
+	return nil
+}
+
+func (s *Scraper) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	s.client.CloseIdleConnections()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for job := range s.jobs {
+		DefaultMetricMonitor.DeleteTargetsCount(job)
+	}
+}
+
+func (s *Scraper) loopRefresh() {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+
+		case <-ticker.C:
+			s.syncTargets()
+		}
+	}
+}
+
+// syncTargets 对比当前采集目标 启动新增目标并停止已移除目标
+func (s *Scraper) syncTargets() {
+	targets := discoverTargets(s.config.Jobs, s.podsRange)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	jobs := make(map[string]int)
+	active := make(map[string]struct{}, len(targets))
+	for _, t := range targets {
+		key := t.key()
+		active[key] = struct{}{}
+		jobs[t.job.Name]++
+		if _, ok := s.loops[key]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(s.ctx)
+		s.loops[key] = cancel
+		s.wg.Add(1)
+		go func(t target) {
+			defer s.wg.Done()
+			s.loopScrape(ctx, t)
+		}(t)
+		logger.Debugf("scraper add target, job=%s, url=%s", t.job.Name, t.url)
+	}
+
+	for key, cancel := range s.loops {
+		if _, ok := active[key]; !ok {
+			cancel()
+			delete(s.loops, key)
+			logger.Debugf("scraper remove target, key=%s", key)
+		}
+	}
+
+	for job := range s.jobs {
+		if _, ok := jobs[job]; !ok {
+			DefaultMetricMonitor.DeleteTargetsCount(job)
+		}
+	}
+	for job, n := range jobs {
+		DefaultMetricMonitor.SetTargetsCount(job, n)
+	}
+	s.jobs = jobs
+}
+
+// scrapeOffset 将各目标的采集时间打散在周期内 避免同一时刻集中请求
+func scrapeOffset(key string, interval time.Duration) time.Duration {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+func (s *Scraper) loopScrape(ctx context.Context, t target) {
+	timer := time.NewTimer(scrapeOffset(t.key(), t.job.Interval))
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return
+	case <-timer.C:
+	}
+
+	ticker := time.NewTicker(t.job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.scrapeAndPublish(ctx, t)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scraper) scrape(ctx context.Context, t target) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.job.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(t.job.Timeout.Seconds(), 'f', -1, 64))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, "", errors.Errorf("server returned HTTP status %s", resp.Status)
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := io.Copy(buf, io.LimitReader(resp.Body, s.config.MaxBodyBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if n > s.config.MaxBodyBytes {
+		return nil, "", errors.Errorf("body size exceeded limit %d bytes", s.config.MaxBodyBytes)
+	}
+	return buf.Bytes(), resp.Header.Get(define.ContentType), nil
+}
+
+func (s *Scraper) scrapeAndPublish(ctx context.Context, t target) {
+	defer utils.HandleCrash()
+
+	job := t.job.Name
+	start := time.Now()
+	r := &define.Record{
+		RecordType:    define.RecordMetrics,
+		RequestType:   define.RequestHttp,
+		RequestClient: define.RequestClient{IP: t.instance()},
+		Token:         define.Token{Original: t.job.Token},
+	}
+
+	code, processorName, err := s.Validate(r)
+	if err != nil {
+		err = errors.Wrapf(err, "run pre-check failed, code=%d, job=%s", code, job)
+		logger.WarnRate(time.Minute, r.Token.Original, err)
+		metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, define.RecordMetrics, processorName, r.Token.Original, code)
+		return
+	}
+
+	DefaultMetricMonitor.IncScrapedCounter(job)
+	b, contentType, err := s.scrape(ctx, t)
+	if ctx.Err() != nil {
+		return // 目标已移除或组件已停止
+	}
+
+	var ss *samples
+	if err == nil {
+		ss, err = parseSamples(b, contentType, start)
+	}
+	if err != nil {
+		logger.Warnf("scraper failed to scrape target, job=%s, url=%s, err: %v", job, t.url, err)
+		DefaultMetricMonitor.IncFailedCounter(job)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordMetrics)
+		ss = &samples{metrics: map[string][]metricsbuilder.Metric{}}
+	}
+	DefaultMetricMonitor.AddSamplesCounter(job, ss.count)
+	DefaultMetricMonitor.ObserveDuration(start, job)
+
+	r.Data = buildMetrics(t, ss, err == nil, start).Get()
+	s.Publish(r)
+	receiver.RecordHandleMetrics(metricMonitor, r.Token, define.RequestHttp, define.RecordMetrics, len(b), start)
+}
+
+// buildMetrics 将样本转换为 pmetric 并追加 up 等采集状态指标
+func buildMetrics(t target, ss *samples, up bool, start time.Time) *metricsbuilder.Builder {
+	keys := make([]string, 0, len(t.labels))
+	for k := range t.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]metricsbuilder.ResourceKv, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, metricsbuilder.ResourceKv{Key: k, Value: t.labels[k]})
+	}
+
+	builder := metricsbuilder.New(kvs...)
+	for _, name := range ss.names {
+		builder.Build(name, ss.metrics[name]...)
+	}
+
+	var upVal float64
+	if up {
+		upVal = 1
+	}
+	ts := pcommon.NewTimestampFromTime(start)
+	builder.Build(metricUp, metricsbuilder.Metric{Val: upVal, Ts: ts})
+	builder.Build(metricScrapeDuration, metricsbuilder.Metric{Val: time.Since(start).Seconds(), Ts: ts})
+	builder.Build(metricScrapeSamples, metricsbuilder.Metric{Val: float64(ss.count), Ts: ts})
+	return builder
+}
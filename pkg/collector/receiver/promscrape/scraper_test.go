@@ -0,0 +1,146 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+)
+
+func newTestScraper(t *testing.T, targets ...string) (*Scraper, chan *define.Record) {
+	c := &Config{
+		Interval:        100 * time.Millisecond,
+		RefreshInterval: time.Minute,
+		Jobs: []JobConfig{
+			{
+				Name:          "test",
+				Token:         "token1",
+				StaticTargets: targets,
+			},
+		},
+	}
+	assert.NoError(t, c.Validate())
+
+	ch := make(chan *define.Record, 16)
+	s := newScraper(c, nil)
+	s.Publisher.Func = func(r *define.Record) { ch <- r }
+	s.Validator.Func = func(r *define.Record) (define.StatusCode, string, error) {
+		return define.StatusCodeOK, "", nil
+	}
+	return s, ch
+}
+
+func metricValues(metrics pmetric.Metrics) map[string]float64 {
+	values := make(map[string]float64)
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		m := ms.At(i)
+		values[m.Name()] = m.Gauge().DataPoints().At(0).DoubleValue()
+	}
+	return values
+}
+
+func TestScraperScrape(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text"))
+		w.Header().Set(define.ContentType, "text/plain; version=0.0.4")
+		w.Write([]byte("go_goroutines{pid=\"1\"} 12\n"))
+	}))
+	defer svr.Close()
+
+	address := strings.TrimPrefix(svr.URL, "http://")
+	s, ch := newTestScraper(t, address)
+	assert.NoError(t, s.Start())
+	defer s.Stop()
+
+	select {
+	case r := <-ch:
+		assert.Equal(t, define.RecordMetrics, r.RecordType)
+		assert.Equal(t, "token1", r.Token.Original)
+
+		metrics := r.Data.(pmetric.Metrics)
+		rs := metrics.ResourceMetrics().At(0).Resource().Attributes()
+		testkits.AssertAttrsStringKeyVal(t, rs, "job", "test", "instance", address)
+
+		values := metricValues(metrics)
+		assert.Equal(t, float64(12), values["go_goroutines"])
+		assert.Equal(t, float64(1), values[metricUp])
+		assert.Equal(t, float64(1), values[metricScrapeSamples])
+
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for scraped record")
+	}
+}
+
+func TestScraperTargetDown(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer svr.Close()
+
+	s, ch := newTestScraper(t, strings.TrimPrefix(svr.URL, "http://"))
+	assert.NoError(t, s.Start())
+	defer s.Stop()
+
+	select {
+	case r := <-ch:
+		values := metricValues(r.Data.(pmetric.Metrics))
+		assert.Equal(t, float64(0), values[metricUp])
+		assert.Equal(t, float64(0), values[metricScrapeSamples])
+
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for scraped record")
+	}
+}
+
+func TestScraperPreCheckFailed(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("target should not be scraped")
+	}))
+	defer svr.Close()
+
+	s, ch := newTestScraper(t, strings.TrimPrefix(svr.URL, "http://"))
+	s.Validator.Func = func(r *define.Record) (define.StatusCode, string, error) {
+		return define.StatusCodeUnauthorized, define.ProcessorTokenChecker, errors.New("invalid token")
+	}
+	assert.NoError(t, s.Start())
+
+	time.Sleep(300 * time.Millisecond)
+	s.Stop()
+	assert.Len(t, ch, 0)
+}
+
+func TestScraperSyncTargets(t *testing.T) {
+	s, _ := newTestScraper(t, "127.0.0.1:1", "127.0.0.1:2")
+	s.syncTargets()
+	assert.Len(t, s.loops, 2)
+
+	s.config.Jobs[0].StaticTargets = []string{"127.0.0.1:2", "127.0.0.1:3"}
+	s.syncTargets()
+	assert.Len(t, s.loops, 2)
+	_, ok := s.loops["test/http://127.0.0.1:1/metrics"]
+	assert.False(t, ok)
+	_, ok = s.loops["test/http://127.0.0.1:3/metrics"]
+	assert.True(t, ok)
+
+	s.Stop()
+	s.syncTargets() // 停止后不再启动新目标
+	assert.Len(t, s.loops, 2)
+}
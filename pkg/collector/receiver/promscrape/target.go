@@ -0,0 +1,102 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/cache/k8scache"
+)
+
+const (
+	labelJob      = "job"
+	labelInstance = "instance"
+)
+
+// podsRanger 遍历 pod 元数据 默认实现为 k8scache
+type podsRanger func(f func(ip string, meta map[string]string) bool)
+
+func k8sPodsRanger(f func(ip string, meta map[string]string) bool) {
+	c := k8scache.Default()
+	if c == nil {
+		return
+	}
+	c.Range(f)
+}
+
+type target struct {
+	job    *JobConfig
+	url    string
+	labels map[string]string // 作为 resource 维度写入
+}
+
+func (t target) key() string {
+	return t.job.Name + "/" + t.url
+}
+
+func (t target) instance() string {
+	return t.labels[labelInstance]
+}
+
+func newTarget(job *JobConfig, address string, meta map[string]string) target {
+	labels := make(map[string]string, len(job.Labels)+len(meta)+2)
+	for k, v := range meta {
+		labels[k] = v
+	}
+	for k, v := range job.Labels {
+		labels[k] = v
+	}
+	labels[labelJob] = job.Name
+	labels[labelInstance] = address
+
+	return target{
+		job:    job,
+		url:    job.targetURL(address),
+		labels: labels,
+	}
+}
+
+// discoverTargets 根据 job 配置生成采集目标 结果按 key 排序
+func discoverTargets(jobs []JobConfig, podsRange podsRanger) []target {
+	var targets []target
+	for i := 0; i < len(jobs); i++ {
+		job := &jobs[i]
+		for _, address := range job.StaticTargets {
+			targets = append(targets, newTarget(job, address, nil))
+		}
+
+		pods := job.KubernetesPods
+		if !pods.Enabled || podsRange == nil {
+			continue
+		}
+
+		namespaces := make(map[string]struct{}, len(pods.Namespaces))
+		for _, ns := range pods.Namespaces {
+			namespaces[ns] = struct{}{}
+		}
+		port := strconv.Itoa(pods.Port)
+		podsRange(func(ip string, meta map[string]string) bool {
+			if len(namespaces) > 0 {
+				if _, ok := namespaces[meta["k8s.namespace.name"]]; !ok {
+					return true
+				}
+			}
+			targets = append(targets, newTarget(job, net.JoinHostPort(ip, port), meta))
+			return true
+		})
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].key() < targets[j].key()
+	})
+	return targets
+}
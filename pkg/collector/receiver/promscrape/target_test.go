@@ -0,0 +1,74 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promscrape
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverTargets(t *testing.T) {
+	c := Config{
+		Jobs: []JobConfig{
+			{
+				Name:          "static",
+				Token:         "token1",
+				StaticTargets: []string{"127.0.0.1:9100", "127.0.0.2:9100"},
+				Labels:        map[string]string{"env": "prod", "job": "overwritten"},
+			},
+			{
+				Name:  "pods",
+				Token: "token2",
+				KubernetesPods: KubernetesPodsConfig{
+					Enabled:    true,
+					Port:       8080,
+					Namespaces: []string{"default"},
+				},
+			},
+		},
+	}
+	assert.NoError(t, c.Validate())
+
+	pods := map[string]map[string]string{
+		"10.0.0.1": {"k8s.namespace.name": "default", "k8s.pod.name": "pod-1"},
+		"10.0.0.2": {"k8s.namespace.name": "kube-system", "k8s.pod.name": "pod-2"},
+	}
+	podsRange := func(f func(ip string, meta map[string]string) bool) {
+		for ip, meta := range pods {
+			if !f(ip, meta) {
+				return
+			}
+		}
+	}
+
+	targets := discoverTargets(c.Jobs, podsRange)
+	assert.Len(t, targets, 3)
+
+	assert.Equal(t, "http://10.0.0.1:8080/metrics", targets[0].url)
+	assert.Equal(t, map[string]string{
+		"job":                "pods",
+		"instance":           "10.0.0.1:8080",
+		"k8s.namespace.name": "default",
+		"k8s.pod.name":       "pod-1",
+	}, targets[0].labels)
+
+	assert.Equal(t, "http://127.0.0.1:9100/metrics", targets[1].url)
+	assert.Equal(t, map[string]string{
+		"job":      "static",
+		"instance": "127.0.0.1:9100",
+		"env":      "prod",
+	}, targets[1].labels)
+	assert.Equal(t, "127.0.0.2:9100", targets[2].instance())
+
+	// k8scache 未安装时仅返回静态目标
+	targets = discoverTargets(c.Jobs, k8sPodsRanger)
+	assert.Len(t, targets, 2)
+}
@@ -185,6 +185,7 @@ func (s HttpService) exportMetrics(w http.ResponseWriter, req *http.Request, job
 	if err != nil {
 		err = errors.Wrapf(err, "run pre-check failed, code=%d, ip=%s", code, ip)
 		logger.WarnRate(time.Minute, r.Token.Original, err)
+		receiver.SetRetryAfterHeader(w, err)
 		receiver.WriteResponse(w, define.ContentTypeJson, int(code), errResponse(err))
 		metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, define.RecordPushGateway, processorName, r.Token.Original, code)
 		return
@@ -43,6 +43,9 @@ type Receiver struct {
 	recvTls     *transport.TLSConfig
 	grpcServer  *grpc.Server
 	tarsServer  *tarstransport.TarsServer
+
+	pullerMut sync.Mutex
+	pullers   map[string]Puller // 主动拉取类组件
 }
 
 var (
@@ -111,7 +114,13 @@ func New(conf *confengine.Config) (*Receiver, error) {
 		throttle.Stop()
 	}
 
+	pullers, err := newPullers(conf)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Receiver{
+		pullers: pullers,
 		config:  c,
 		recvTls: tlsConfig,
 		recvServer: &http.Server{
@@ -127,6 +136,24 @@ func New(conf *confengine.Config) (*Receiver, error) {
 	}, nil
 }
 
+func newPullers(conf *confengine.Config) (map[string]Puller, error) {
+	pullers := make(map[string]Puller)
+	for name, f := range componentsPuller {
+		p, err := f(conf)
+		if err != nil {
+			for _, created := range pullers {
+				created.Stop()
+			}
+			return nil, errors.Wrapf(err, "create '%s' puller failed", name)
+		}
+		if p == nil {
+			continue
+		}
+		pullers[name] = p
+	}
+	return pullers, nil
+}
+
 func (r *Receiver) ready() {
 	for name, f := range componentsReady {
 		f()
@@ -136,6 +163,32 @@ func (r *Receiver) ready() {
 
 func (r *Receiver) Reload(conf *confengine.Config) {
 	globalSkywalkingConfig = LoadConfigFrom(conf)
+
+	pullers, err := newPullers(conf)
+	if err != nil {
+		logger.Errorf("failed to reload pullers: %v", err)
+		return
+	}
+
+	r.pullerMut.Lock()
+	defer r.pullerMut.Unlock()
+
+	r.stopPullers()
+	r.pullers = pullers
+	for name, p := range r.pullers {
+		if err := p.Start(); err != nil {
+			logger.Errorf("failed to start '%s' puller: %v", name, err)
+		}
+	}
+}
+
+// stopPullers 调用方需持有 pullerMut
+func (r *Receiver) stopPullers() {
+	for name, p := range r.pullers {
+		t0 := time.Now()
+		p.Stop()
+		logger.Infof("shutdown '%s' puller, take: %s", name, time.Since(t0))
+	}
 }
 
 func (r *Receiver) startRecvHttpServer() error {
@@ -298,6 +351,16 @@ func (r *Receiver) Start() error {
 		}
 	}()
 
+	// 启动主动拉取类组件
+	r.pullerMut.Lock()
+	for name, p := range r.pullers {
+		logger.Infof("start '%s' puller", name)
+		if err := p.Start(); err != nil {
+			errs <- errors.Wrapf(err, "start '%s' puller failed", name)
+		}
+	}
+	r.pullerMut.Unlock()
+
 	timer := time.NewTimer(time.Second)
 	defer timer.Stop()
 	select {
@@ -394,6 +457,10 @@ func (r *Receiver) Stop() error {
 
 	r.shutdownGrpcServer()
 
+	r.pullerMut.Lock()
+	r.stopPullers()
+	r.pullerMut.Unlock()
+
 	r.wg.Wait()
 	return nil
 }
@@ -10,6 +10,7 @@
 package receiver
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -145,3 +146,13 @@ func TestWriteResponse(t *testing.T) {
 	WriteResponse(r, "application/json", 200, nil)
 	assert.Equal(t, 200, r.Result().StatusCode)
 }
+
+func TestSetRetryAfterHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetRetryAfterHeader(w, define.NewRetryAfterError(errors.New("rejected"), 1500*time.Millisecond))
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	SetRetryAfterHeader(w, errors.New("rejected"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 )
 
@@ -28,6 +29,21 @@ func RegisterReadyFunc(source string, f Ready) {
 	componentsReady[source] = f
 }
 
+// Puller 主动拉取数据的组件 生命周期由 Receiver 统一管理
+type Puller interface {
+	Start() error
+	Stop()
+}
+
+// PullerFactory 根据配置创建 Puller 组件未启用时返回 nil
+type PullerFactory func(conf *confengine.Config) (Puller, error)
+
+var componentsPuller = map[string]PullerFactory{}
+
+func RegisterPullerFactory(source string, f PullerFactory) {
+	componentsPuller[source] = f
+}
+
 type serviceManager struct {
 	httpRoutes   map[string]define.RouteInfo
 	httpRouter   *mux.Router
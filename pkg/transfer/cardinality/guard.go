@@ -0,0 +1,420 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cardinality
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+// 超限处理方式
+const (
+	// ActionDrop : 丢弃超限的指标
+	ActionDrop = "drop"
+	// ActionAggregate : 去掉高基数的维度后再检查 仍超限时丢弃
+	ActionAggregate = "aggregate"
+)
+
+// OtherMetric : 超出指标统计上限后的指标归入该分组
+const OtherMetric = "__other__"
+
+// Config : 基数限制配置
+type Config struct {
+	// MaxSeries : 单个结果表在一个窗口内允许的序列数
+	MaxSeries int `mapstructure:"max_series" json:"max_series"`
+	// MaxMetricSeries : 单个指标在一个窗口内允许的序列数 0 为不限制
+	MaxMetricSeries int `mapstructure:"max_metric_series" json:"max_metric_series"`
+	// MaxLabelValues : 维度取值数超过该值时视为高基数维度 仅对 aggregate 生效
+	MaxLabelValues int `mapstructure:"max_label_values" json:"max_label_values"`
+	// Action : 超限处理方式
+	Action string `mapstructure:"action" json:"action"`
+	// Window : 统计窗口 到期后清空全部统计
+	Window time.Duration `mapstructure:"window" json:"window"`
+	// Precision : 基数估算精度
+	Precision uint8 `mapstructure:"precision" json:"precision"`
+	// MaxTrackedMetrics : 单独统计的指标数上限
+	MaxTrackedMetrics int `mapstructure:"max_tracked_metrics" json:"max_tracked_metrics"`
+	// MaxTrackedLabels : 单独统计的维度数上限
+	MaxTrackedLabels int `mapstructure:"max_tracked_labels" json:"max_tracked_labels"`
+}
+
+// Validate :
+func (c *Config) Validate() error {
+	switch c.Action {
+	case ActionDrop, ActionAggregate:
+	default:
+		return errors.Wrapf(define.ErrValue, "unknown action %s", c.Action)
+	}
+	if c.MaxSeries <= 0 {
+		return errors.Wrapf(define.ErrValue, "max_series must be positive")
+	}
+	if c.MaxMetricSeries < 0 || c.MaxLabelValues < 0 {
+		return errors.Wrapf(define.ErrValue, "limit must not be negative")
+	}
+	if c.Window <= 0 {
+		return errors.Wrapf(define.ErrValue, "window must be positive")
+	}
+	if c.MaxTrackedMetrics <= 0 || c.MaxTrackedLabels <= 0 {
+		return errors.Wrapf(define.ErrValue, "tracked limit must be positive")
+	}
+	return nil
+}
+
+// Result : 单条记录的检查结果
+type Result struct {
+	// Dropped : 需要丢弃的指标
+	Dropped []string
+	// Aggregated : 需要去掉的维度
+	Aggregated []string
+}
+
+// MetricStats :
+type MetricStats struct {
+	Name            string `json:"name"`
+	TrackedSeries   int    `json:"tracked_series"`
+	EstimatedSeries uint64 `json:"estimated_series"`
+	Dropped         uint64 `json:"dropped"`
+}
+
+// LabelStats :
+type LabelStats struct {
+	Key             string `json:"key"`
+	EstimatedValues uint64 `json:"estimated_values"`
+	Aggregated      uint64 `json:"aggregated"`
+}
+
+// Stats : 基数统计快照
+type Stats struct {
+	DataID          int           `json:"data_id"`
+	Table           string        `json:"table"`
+	Action          string        `json:"action"`
+	MaxSeries       int           `json:"max_series"`
+	MaxMetricSeries int           `json:"max_metric_series"`
+	TrackedSeries   int           `json:"tracked_series"`
+	EstimatedSeries uint64        `json:"estimated_series"`
+	Dropped         uint64        `json:"dropped"`
+	Aggregated      uint64        `json:"aggregated"`
+	WindowStart     time.Time     `json:"window_start"`
+	TopMetrics      []MetricStats `json:"top_metrics"`
+	TopLabels       []LabelStats  `json:"top_labels"`
+}
+
+type metricState struct {
+	tracked int
+	dropped uint64
+	sketch  *HyperLogLog
+}
+
+type labelState struct {
+	aggregated uint64
+	sketch     *HyperLogLog
+}
+
+// Guard : 统计单个结果表的序列基数并执行限制
+// 窗口内已放行的序列精确记录 总量不超过 MaxSeries 基数估算同时包含被拒绝的序列
+type Guard struct {
+	lock        sync.Mutex
+	dataID      int
+	table       string
+	conf        Config
+	now         func() time.Time
+	windowStart time.Time
+	series      map[uint64]struct{}
+	sketch      *HyperLogLog
+	metrics     map[string]*metricState
+	other       *metricState
+	labels      map[string]*labelState
+	dropped     uint64
+	aggregated  uint64
+}
+
+// NewGuard :
+func NewGuard(dataID int, table string, conf Config) (*Guard, error) {
+	err := conf.Validate()
+	if err != nil {
+		return nil, err
+	}
+	g := &Guard{
+		dataID: dataID,
+		table:  table,
+		conf:   conf,
+		now:    time.Now,
+	}
+	g.reset(g.now())
+	return g, nil
+}
+
+// DataID :
+func (g *Guard) DataID() int {
+	return g.dataID
+}
+
+// Table :
+func (g *Guard) Table() string {
+	return g.table
+}
+
+// TrackedSeries : 当前窗口内放行的序列数
+func (g *Guard) TrackedSeries() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return len(g.series)
+}
+
+func (g *Guard) reset(now time.Time) {
+	g.windowStart = now
+	g.series = make(map[uint64]struct{})
+	g.sketch = NewHyperLogLog(g.conf.Precision)
+	g.metrics = make(map[string]*metricState)
+	g.other = &metricState{sketch: NewHyperLogLog(g.conf.Precision)}
+	g.labels = make(map[string]*labelState)
+	g.dropped = 0
+	g.aggregated = 0
+}
+
+// observed : 返回指标的统计分组 超出上限的指标归入 other 分组
+func (g *Guard) observed(name string) *metricState {
+	state, ok := g.metrics[name]
+	if !ok && len(g.metrics) < g.conf.MaxTrackedMetrics {
+		state = &metricState{sketch: NewHyperLogLog(g.conf.Precision)}
+		g.metrics[name] = state
+	}
+	if state == nil || state.sketch == nil {
+		return g.other
+	}
+	return state
+}
+
+func (g *Guard) observeLabels(dimensions map[string]string) {
+	for key, value := range dimensions {
+		state, ok := g.labels[key]
+		if !ok {
+			if len(g.labels) >= g.conf.MaxTrackedLabels {
+				continue
+			}
+			state = &labelState{sketch: NewHyperLogLog(g.conf.Precision)}
+			g.labels[key] = state
+		}
+		state.sketch.Add(xxhash.Sum64String(value))
+	}
+}
+
+func (g *Guard) admissible(name string, hash uint64, pending int) bool {
+	if _, ok := g.series[hash]; ok {
+		return true
+	}
+	if len(g.series)+pending >= g.conf.MaxSeries {
+		return false
+	}
+	state, ok := g.metrics[name]
+	if ok && g.conf.MaxMetricSeries > 0 && state.tracked >= g.conf.MaxMetricSeries {
+		return false
+	}
+	return true
+}
+
+func (g *Guard) admit(name string, hash uint64) bool {
+	if !g.admissible(name, hash, 0) {
+		return false
+	}
+	if _, ok := g.series[hash]; ok {
+		return true
+	}
+	// 已放行序列的指标总是精确计数 数量受 MaxSeries 限制
+	state, ok := g.metrics[name]
+	if !ok {
+		state = &metricState{}
+		g.metrics[name] = state
+	}
+	g.series[hash] = struct{}{}
+	state.tracked++
+	return true
+}
+
+// offendingLabels : 取值数超限的维度 未超限但序列数超限时取本条记录中取值数最多的维度
+func (g *Guard) offendingLabels(dimensions map[string]string, overflow bool) []string {
+	var (
+		keys    []string
+		top     string
+		topSize uint64
+	)
+	for key := range dimensions {
+		state, ok := g.labels[key]
+		if !ok {
+			continue
+		}
+		size := state.sketch.Count()
+		if g.conf.MaxLabelValues > 0 && size > uint64(g.conf.MaxLabelValues) {
+			keys = append(keys, key)
+		}
+		if size > topSize || (size == topSize && key < top) {
+			top, topSize = key, size
+		}
+	}
+	if len(keys) == 0 && overflow && top != "" {
+		keys = append(keys, top)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Check : 检查一条记录中的各个指标 返回需要丢弃的指标和需要去掉的维度
+func (g *Guard) Check(metrics []string, dimensions map[string]string) Result {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := g.now()
+	if now.Sub(g.windowStart) >= g.conf.Window {
+		g.reset(now)
+	}
+
+	g.observeLabels(dimensions)
+	hashes := make([]uint64, len(metrics))
+	pending := 0
+	overflow := false
+	for i, name := range metrics {
+		hashes[i] = SeriesHash(name, dimensions)
+		g.sketch.Add(hashes[i])
+		g.observed(name).sketch.Add(hashes[i])
+		if !g.admissible(name, hashes[i], pending) {
+			overflow = true
+		} else if _, ok := g.series[hashes[i]]; !ok {
+			pending++
+		}
+	}
+
+	var result Result
+	if g.conf.Action == ActionAggregate {
+		result.Aggregated = g.offendingLabels(dimensions, overflow)
+		if len(result.Aggregated) > 0 {
+			reduced := make(map[string]string, len(dimensions))
+			for key, value := range dimensions {
+				reduced[key] = value
+			}
+			for _, key := range result.Aggregated {
+				delete(reduced, key)
+				g.labels[key].aggregated++
+			}
+			for i, name := range metrics {
+				hashes[i] = SeriesHash(name, reduced)
+			}
+			g.aggregated++
+		}
+	}
+
+	for i, name := range metrics {
+		if g.admit(name, hashes[i]) {
+			continue
+		}
+		result.Dropped = append(result.Dropped, name)
+		g.observed(name).dropped++
+		g.dropped++
+	}
+	return result
+}
+
+// Stats : 返回当前窗口的统计 top 为指标和维度的返回数量 小于等于 0 时返回全部
+func (g *Guard) Stats(top int) Stats {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	stats := Stats{
+		DataID:          g.dataID,
+		Table:           g.table,
+		Action:          g.conf.Action,
+		MaxSeries:       g.conf.MaxSeries,
+		MaxMetricSeries: g.conf.MaxMetricSeries,
+		TrackedSeries:   len(g.series),
+		EstimatedSeries: g.sketch.Count(),
+		Dropped:         g.dropped,
+		Aggregated:      g.aggregated,
+		WindowStart:     g.windowStart,
+		TopMetrics:      make([]MetricStats, 0, len(g.metrics)),
+		TopLabels:       make([]LabelStats, 0, len(g.labels)),
+	}
+
+	other := MetricStats{
+		Name:            OtherMetric,
+		EstimatedSeries: g.other.sketch.Count(),
+		Dropped:         g.other.dropped,
+	}
+	for name, state := range g.metrics {
+		if state.sketch == nil {
+			other.TrackedSeries += state.tracked
+			continue
+		}
+		stats.TopMetrics = append(stats.TopMetrics, MetricStats{
+			Name:            name,
+			TrackedSeries:   state.tracked,
+			EstimatedSeries: state.sketch.Count(),
+			Dropped:         state.dropped,
+		})
+	}
+	if other.TrackedSeries > 0 || other.EstimatedSeries > 0 {
+		stats.TopMetrics = append(stats.TopMetrics, other)
+	}
+	sort.Slice(stats.TopMetrics, func(i, j int) bool {
+		left, right := stats.TopMetrics[i], stats.TopMetrics[j]
+		if left.EstimatedSeries != right.EstimatedSeries {
+			return left.EstimatedSeries > right.EstimatedSeries
+		}
+		return left.Name < right.Name
+	})
+
+	for key, state := range g.labels {
+		stats.TopLabels = append(stats.TopLabels, LabelStats{
+			Key:             key,
+			EstimatedValues: state.sketch.Count(),
+			Aggregated:      state.aggregated,
+		})
+	}
+	sort.Slice(stats.TopLabels, func(i, j int) bool {
+		left, right := stats.TopLabels[i], stats.TopLabels[j]
+		if left.EstimatedValues != right.EstimatedValues {
+			return left.EstimatedValues > right.EstimatedValues
+		}
+		return left.Key < right.Key
+	})
+
+	if top > 0 {
+		if len(stats.TopMetrics) > top {
+			stats.TopMetrics = stats.TopMetrics[:top]
+		}
+		if len(stats.TopLabels) > top {
+			stats.TopLabels = stats.TopLabels[:top]
+		}
+	}
+	return stats
+}
+
+// SeriesHash : 指标名和按维度名排序后的维度共同确定一条序列
+func SeriesHash(name string, dimensions map[string]string) uint64 {
+	keys := make([]string, 0, len(dimensions))
+	for key := range dimensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	digest := xxhash.New()
+	_, _ = digest.WriteString(name)
+	for _, key := range keys {
+		_, _ = digest.WriteString("\xff")
+		_, _ = digest.WriteString(key)
+		_, _ = digest.WriteString("\xfe")
+		_, _ = digest.WriteString(dimensions[key])
+	}
+	return digest.Sum64()
+}
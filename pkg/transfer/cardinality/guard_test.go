@@ -0,0 +1,179 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cardinality
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// GuardSuite :
+type GuardSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func (s *GuardSuite) newGuard(conf Config) *Guard {
+	if conf.Window == 0 {
+		conf.Window = time.Hour
+	}
+	conf.Precision = 12
+	conf.MaxTrackedMetrics = 2
+	conf.MaxTrackedLabels = 8
+	g, err := NewGuard(1001, "system.cpu", conf)
+	s.NoError(err)
+	g.now = func() time.Time { return s.now }
+	g.reset(s.now)
+	return g
+}
+
+// SetupTest :
+func (s *GuardSuite) SetupTest() {
+	s.now = time.Unix(1600000000, 0)
+}
+
+// TestValidate :
+func (s *GuardSuite) TestValidate() {
+	cases := []Config{
+		{Action: "unknown", MaxSeries: 1, Window: time.Hour, MaxTrackedMetrics: 1, MaxTrackedLabels: 1},
+		{Action: ActionDrop, MaxSeries: 0, Window: time.Hour, MaxTrackedMetrics: 1, MaxTrackedLabels: 1},
+		{Action: ActionDrop, MaxSeries: 1, MaxMetricSeries: -1, Window: time.Hour, MaxTrackedMetrics: 1, MaxTrackedLabels: 1},
+		{Action: ActionDrop, MaxSeries: 1, MaxTrackedMetrics: 1, MaxTrackedLabels: 1},
+		{Action: ActionDrop, MaxSeries: 1, Window: time.Hour},
+	}
+	for i, c := range cases {
+		_, err := NewGuard(1, "t", c)
+		s.Error(err, i)
+	}
+}
+
+// TestDrop :
+func (s *GuardSuite) TestDrop() {
+	g := s.newGuard(Config{Action: ActionDrop, MaxSeries: 3, MaxMetricSeries: 2})
+
+	s.Empty(g.Check([]string{"usage", "idle"}, map[string]string{"ip": "1"}).Dropped)
+	// 已放行的序列不受限制
+	s.Empty(g.Check([]string{"usage", "idle"}, map[string]string{"ip": "1"}).Dropped)
+	// 单个记录中的多个指标共享总量
+	s.Equal([]string{"idle"}, g.Check([]string{"usage", "idle"}, map[string]string{"ip": "2"}).Dropped)
+	// 指标超出自身限制
+	s.Equal([]string{"usage"}, g.Check([]string{"usage"}, map[string]string{"ip": "3"}).Dropped)
+
+	stats := g.Stats(0)
+	s.Equal(3, stats.TrackedSeries)
+	s.Equal(uint64(5), stats.EstimatedSeries)
+	s.Equal(uint64(2), stats.Dropped)
+	s.Len(stats.TopMetrics, 2)
+	s.Equal("usage", stats.TopMetrics[0].Name)
+	s.Equal(2, stats.TopMetrics[0].TrackedSeries)
+	s.Equal(uint64(1), stats.TopMetrics[0].Dropped)
+
+	// 窗口到期后重新统计
+	s.now = s.now.Add(time.Hour)
+	s.Empty(g.Check([]string{"usage"}, map[string]string{"ip": "3"}).Dropped)
+	s.Equal(1, g.TrackedSeries())
+}
+
+// TestAggregate :
+func (s *GuardSuite) TestAggregate() {
+	g := s.newGuard(Config{Action: ActionAggregate, MaxSeries: 7, MaxLabelValues: 5})
+
+	for i := 0; i < 20; i++ {
+		dimensions := map[string]string{
+			"ip":         fmt.Sprintf("%d", i%2),
+			"request_id": fmt.Sprintf("req-%d", i),
+		}
+		result := g.Check([]string{"latency"}, dimensions)
+		s.Empty(result.Dropped, i)
+		if i < 5 {
+			s.Empty(result.Aggregated, i)
+		} else {
+			s.Equal([]string{"request_id"}, result.Aggregated, i)
+		}
+	}
+
+	// 去掉维度后仍超限时丢弃
+	result := g.Check([]string{"latency"}, map[string]string{"ip": "2", "request_id": "req-x"})
+	s.Equal([]string{"request_id"}, result.Aggregated)
+	s.Equal([]string{"latency"}, result.Dropped)
+
+	stats := g.Stats(1)
+	s.Equal(7, stats.TrackedSeries)
+	s.Equal(uint64(16), stats.Aggregated)
+	s.Equal(uint64(1), stats.Dropped)
+	s.Len(stats.TopLabels, 1)
+	s.Equal("request_id", stats.TopLabels[0].Key)
+	s.Equal(uint64(16), stats.TopLabels[0].Aggregated)
+}
+
+// TestAggregateOverflow :
+func (s *GuardSuite) TestAggregateOverflow() {
+	g := s.newGuard(Config{Action: ActionAggregate, MaxSeries: 2})
+
+	s.Equal(Result{}, g.Check([]string{"latency"}, map[string]string{"ip": "1"}))
+	s.Equal(Result{}, g.Check([]string{"latency"}, map[string]string{"ip": "1", "pod": "a"}))
+	// 未配置维度限制时 超限后去掉取值最多的维度
+	s.Equal(Result{Aggregated: []string{"pod"}}, g.Check([]string{"latency"}, map[string]string{"ip": "1", "pod": "b"}))
+	s.Equal(Result{Aggregated: []string{"pod"}, Dropped: []string{"latency"}}, g.Check([]string{"latency"}, map[string]string{"ip": "2", "pod": "c"}))
+}
+
+// TestOtherMetric :
+func (s *GuardSuite) TestOtherMetric() {
+	g := s.newGuard(Config{Action: ActionDrop, MaxSeries: 3})
+	for i := 0; i < 5; i++ {
+		g.Check([]string{fmt.Sprintf("metric_%d", i)}, map[string]string{"ip": "1"})
+	}
+
+	stats := g.Stats(0)
+	s.Len(stats.TopMetrics, 3)
+	s.Equal(OtherMetric, stats.TopMetrics[0].Name)
+	s.Equal(1, stats.TopMetrics[0].TrackedSeries)
+	s.Equal(uint64(3), stats.TopMetrics[0].EstimatedSeries)
+	s.Equal(uint64(2), stats.TopMetrics[0].Dropped)
+	s.Len(g.metrics, 3)
+}
+
+// TestSnapshot :
+func (s *GuardSuite) TestSnapshot() {
+	first := s.newGuard(Config{Action: ActionDrop, MaxSeries: 3})
+	second, err := NewGuard(1000, "system.mem", first.conf)
+	s.NoError(err)
+	Register(first)
+	Register(second)
+	defer Unregister(first)
+	defer Unregister(second)
+
+	stats := Snapshot("", 0)
+	s.Len(stats, 2)
+	s.Equal("system.mem", stats[0].Table)
+	s.Equal("system.cpu", stats[1].Table)
+	s.Len(Snapshot("system.cpu", 0), 1)
+	s.Empty(Snapshot("system.disk", 0))
+}
+
+// TestSeriesHash :
+func (s *GuardSuite) TestSeriesHash() {
+	s.Equal(
+		SeriesHash("usage", map[string]string{"a": "1", "b": "2"}),
+		SeriesHash("usage", map[string]string{"b": "2", "a": "1"}),
+	)
+	s.NotEqual(
+		SeriesHash("usage", map[string]string{"a": "1b"}),
+		SeriesHash("usage", map[string]string{"a": "1", "b": ""}),
+	)
+}
+
+// TestGuardSuite :
+func TestGuardSuite(t *testing.T) {
+	suite.Run(t, new(GuardSuite))
+}
@@ -0,0 +1,88 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cardinality
+
+import (
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog 精度范围 精度为 p 时占用 2^p 字节 标准误差约为 1.04/sqrt(2^p)
+const (
+	MinPrecision = 4
+	MaxPrecision = 16
+)
+
+// HyperLogLog : 基数估算 只接收 64 位哈希值
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog : 精度超出范围时取边界值
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < MinPrecision {
+		precision = MinPrecision
+	} else if precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+	return &HyperLogLog{
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add :
+func (h *HyperLogLog) Add(hash uint64) {
+	index := hash >> (64 - h.precision)
+	// 补一个哨兵位 避免剩余位全为 0 时越界
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1))) + 1
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// Count : 估算值 小基数时使用线性计数修正
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, register := range h.registers {
+		sum += 1 / float64(uint64(1)<<register)
+		if register == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// Reset :
+func (h *HyperLogLog) Reset() {
+	for i := range h.registers {
+		h.registers[i] = 0
+	}
+}
@@ -0,0 +1,53 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cardinality
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+// HyperLogLogSuite :
+type HyperLogLogSuite struct {
+	suite.Suite
+}
+
+// TestCount :
+func (s *HyperLogLogSuite) TestCount() {
+	cases := []int{0, 10, 1000, 100000}
+	for _, size := range cases {
+		h := NewHyperLogLog(12)
+		for i := 0; i < size; i++ {
+			h.Add(xxhash.Sum64String(fmt.Sprintf("series-%d", i)))
+			// 重复值不影响估算
+			h.Add(xxhash.Sum64String(fmt.Sprintf("series-%d", i)))
+		}
+		count := float64(h.Count())
+		s.InDelta(float64(size), count, float64(size)*0.05+1, size)
+	}
+}
+
+// TestReset :
+func (s *HyperLogLogSuite) TestReset() {
+	h := NewHyperLogLog(0)
+	s.Len(h.registers, 1<<MinPrecision)
+	h.Add(xxhash.Sum64String("a"))
+	s.Equal(uint64(1), h.Count())
+	h.Reset()
+	s.Equal(uint64(0), h.Count())
+}
+
+// TestHyperLogLogSuite :
+func TestHyperLogLogSuite(t *testing.T) {
+	suite.Run(t, new(HyperLogLogSuite))
+}
@@ -0,0 +1,59 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cardinality
+
+import (
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/eventbus"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+const (
+	// ConfGuardAction : 默认超限处理方式
+	ConfGuardAction = "cardinality.guard.action"
+	// ConfGuardMaxSeries : 默认单个结果表的序列数上限
+	ConfGuardMaxSeries = "cardinality.guard.max_series"
+	// ConfGuardWindow : 统计窗口
+	ConfGuardWindow = "cardinality.guard.window"
+	// ConfGuardPrecision : 基数估算精度
+	ConfGuardPrecision = "cardinality.guard.precision"
+	// ConfGuardMaxTrackedMetrics : 单独统计的指标数上限
+	ConfGuardMaxTrackedMetrics = "cardinality.guard.max_tracked_metrics"
+	// ConfGuardMaxTrackedLabels : 单独统计的维度数上限
+	ConfGuardMaxTrackedLabels = "cardinality.guard.max_tracked_labels"
+)
+
+// InitConfiguration :
+func InitConfiguration(c define.Configuration) {
+	c.SetDefault(ConfGuardAction, ActionDrop)
+	c.SetDefault(ConfGuardMaxSeries, 100000)
+	c.SetDefault(ConfGuardWindow, time.Hour)
+	c.SetDefault(ConfGuardPrecision, 12)
+	c.SetDefault(ConfGuardMaxTrackedMetrics, 1000)
+	c.SetDefault(ConfGuardMaxTrackedLabels, 256)
+}
+
+// NewConfig : 使用全局配置作为默认值
+func NewConfig(c define.Configuration) *Config {
+	return &Config{
+		Action:            c.GetString(ConfGuardAction),
+		MaxSeries:         c.GetInt(ConfGuardMaxSeries),
+		Window:            c.GetDuration(ConfGuardWindow),
+		Precision:         uint8(c.GetInt(ConfGuardPrecision)),
+		MaxTrackedMetrics: c.GetInt(ConfGuardMaxTrackedMetrics),
+		MaxTrackedLabels:  c.GetInt(ConfGuardMaxTrackedLabels),
+	}
+}
+
+func init() {
+	utils.CheckError(eventbus.Subscribe(eventbus.EvSysConfigPreParse, InitConfiguration))
+}
@@ -0,0 +1,39 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cardinality
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+var (
+	// MonitorRejected 超限处理计数器
+	MonitorRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "cardinality_guard_rejected_total",
+		Help:      "Count of metrics dropped or aggregated by cardinality guard",
+	}, []string{"id", "table", "action"})
+
+	// MonitorSeries 当前窗口内放行的序列数
+	MonitorSeries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: define.AppName,
+		Name:      "cardinality_guard_series",
+		Help:      "Tracked series of cardinality guard in current window",
+	}, []string{"id", "table"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		MonitorRejected,
+		MonitorSeries,
+	)
+}
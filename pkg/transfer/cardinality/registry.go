@@ -0,0 +1,58 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cardinality
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[*Guard]struct{})
+)
+
+// Register : 注册后可以通过 Snapshot 查询统计
+func Register(guard *Guard) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry[guard] = struct{}{}
+}
+
+// Unregister :
+func Unregister(guard *Guard) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	delete(registry, guard)
+}
+
+// Snapshot : 返回已注册的统计 table 不为空时只返回对应结果表
+func Snapshot(table string, top int) []Stats {
+	registryLock.RLock()
+	guards := make([]*Guard, 0, len(registry))
+	for guard := range registry {
+		if table == "" || guard.Table() == table {
+			guards = append(guards, guard)
+		}
+	}
+	registryLock.RUnlock()
+
+	results := make([]Stats, 0, len(guards))
+	for _, guard := range guards {
+		results = append(results, guard.Stats(top))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DataID != results[j].DataID {
+			return results[i].DataID < results[j].DataID
+		}
+		return results[i].Table < results[j].Table
+	})
+	return results
+}
@@ -0,0 +1,263 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
+)
+
+const (
+	// 死信记录中 payload 展示的最大长度
+	deadLetterPayloadWidth = 64
+	// 从 kafka 读取死信时等待单条消息的超时时间
+	deadLetterKafkaTimeout = 10 * time.Second
+)
+
+// scanDeadLetterFile : 逐行读取文件或标准输入中的死信数据
+func scanDeadLetterFile(input string, fn func(data []byte, position string) bool) {
+	var reader io.Reader = os.Stdin
+	if input != "" && input != "-" {
+		file, err := os.Open(input)
+		checkError(err, -2, "open %s failed", input)
+		defer checkFnError(file.Close, -2, "close %s failed", input)
+		reader = file
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if !fn(scanner.Bytes(), fmt.Sprintf("line %d", line)) {
+			return
+		}
+	}
+	checkError(scanner.Err(), -2, "read dead letter failed")
+}
+
+// scanDeadLetterKafka : 从最早的 offset 读取 topic 中的死信数据 读到启动时的最新 offset 为止
+func scanDeadLetterKafka(hosts, topic string, fn func(data []byte, position string) bool) {
+	client, err := sarama.NewClient(strings.Split(hosts, ","), sarama.NewConfig())
+	checkError(err, -2, "connect to kafka %s failed", hosts)
+	defer checkFnError(client.Close, -2, "close kafka client failed")
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	checkError(err, -2, "create kafka consumer failed")
+	defer checkFnError(consumer.Close, -2, "close kafka consumer failed")
+
+	partitions, err := client.Partitions(topic)
+	checkError(err, -2, "get partitions of %s failed", topic)
+
+	for _, partition := range partitions {
+		oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+		checkError(err, -2, "get oldest offset of %s[%d] failed", topic, partition)
+		newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		checkError(err, -2, "get newest offset of %s[%d] failed", topic, partition)
+		if oldest >= newest {
+			continue
+		}
+
+		partitionConsumer, err := consumer.ConsumePartition(topic, partition, oldest)
+		checkError(err, -2, "consume %s[%d] failed", topic, partition)
+
+		goOn, offset := true, oldest
+		for goOn && offset < newest {
+			select {
+			case msg := <-partitionConsumer.Messages():
+				offset = msg.Offset + 1
+				goOn = fn(msg.Value, fmt.Sprintf("partition %d offset %d", partition, msg.Offset))
+			case <-time.After(deadLetterKafkaTimeout):
+				checkError(fmt.Errorf("timeout after %v", deadLetterKafkaTimeout), -2, "read %s[%d] failed", topic, partition)
+			}
+		}
+		checkError(partitionConsumer.Close(), -2, "close %s[%d] consumer failed", topic, partition)
+		if !goOn {
+			return
+		}
+	}
+}
+
+// visitDeadLetter : 读取死信记录 并按照 data_id 及 processor 过滤
+func visitDeadLetter(cmd *cobra.Command, fn func(record *deadletter.Record) bool) {
+	flags := cmd.Flags()
+	input, err := flags.GetString("input")
+	checkError(err, -1, "get input failed")
+	sourceKafka, err := flags.GetString("source-kafka")
+	checkError(err, -1, "get source-kafka failed")
+	sourceTopic, err := flags.GetString("source-topic")
+	checkError(err, -1, "get source-topic failed")
+	dataID, err := flags.GetInt("data-id")
+	checkError(err, -1, "get data-id failed")
+	processor, err := flags.GetString("processor")
+	checkError(err, -1, "get processor failed")
+
+	visit := func(data []byte, position string) bool {
+		if len(data) == 0 {
+			return true
+		}
+		record, err := deadletter.Unmarshal(data)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "skip %s: %v\n", position, err)
+			return true
+		}
+		if dataID != 0 && record.DataID != dataID {
+			return true
+		}
+		if !strings.Contains(record.Processor, processor) {
+			return true
+		}
+		return fn(record)
+	}
+
+	if sourceKafka == "" {
+		scanDeadLetterFile(input, visit)
+		return
+	}
+	if sourceTopic == "" {
+		checkError(fmt.Errorf("source-topic is empty"), -1, "read dead letter from kafka %s failed", sourceKafka)
+	}
+	scanDeadLetterKafka(sourceKafka, sourceTopic, visit)
+}
+
+// deadLetterCmd represents the dead letter command
+var deadLetterCmd = &cobra.Command{
+	Use:   "deadletter",
+	Short: "Inspect and replay dead letter records",
+}
+
+var deadLetterInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Print dead letter records",
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, err := cmd.Flags().GetInt("limit")
+		checkError(err, -1, "get limit failed")
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"time", "data_id", "processor", "stage", "error", "payload"})
+		count := 0
+		visitDeadLetter(cmd, func(record *deadletter.Record) bool {
+			payload := string(record.Payload)
+			if len(payload) > deadLetterPayloadWidth {
+				payload = payload[:deadLetterPayloadWidth] + "..."
+			}
+			table.Append([]string{
+				time.Unix(record.Time, 0).Format(time.RFC3339),
+				strconv.Itoa(record.DataID),
+				record.Processor,
+				string(record.Stage),
+				record.Error,
+				payload,
+			})
+			count++
+			return limit <= 0 || count < limit
+		})
+		table.SetCaption(true, fmt.Sprintf("%d records", count))
+		table.Render()
+	},
+}
+
+var deadLetterReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay raw dead letter records to source kafka topic",
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		dryRun, err := flags.GetBool("dry-run")
+		checkError(err, -1, "get dry-run failed")
+		cluster, err := flags.GetString("kafka")
+		checkError(err, -1, "get kafka failed")
+		topic, err := flags.GetString("topic")
+		checkError(err, -1, "get topic failed")
+
+		producers := make(map[string]sarama.SyncProducer)
+		defer func() {
+			for _, producer := range producers {
+				checkError(producer.Close(), -2, "close producer failed")
+			}
+		}()
+
+		replayed, skipped := 0, 0
+		visitDeadLetter(cmd, func(record *deadletter.Record) bool {
+			// 清洗后的数据已经不是原始格式 回放会导致重复清洗
+			if record.Stage != deadletter.StageRaw {
+				skipped++
+				return true
+			}
+
+			target, host := record.Topic, record.Cluster
+			if topic != "" {
+				target = topic
+			}
+			if cluster != "" {
+				host = cluster
+			}
+			if target == "" || host == "" {
+				skipped++
+				return true
+			}
+
+			if dryRun {
+				fmt.Printf("%s/%s: %s\n", host, target, record.Payload)
+				replayed++
+				return true
+			}
+
+			producer, ok := producers[host]
+			if !ok {
+				conf := sarama.NewConfig()
+				conf.Producer.Return.Successes = true
+				producer, err = sarama.NewSyncProducer(strings.Split(host, ","), conf)
+				checkError(err, -2, "connect to kafka %s failed", host)
+				producers[host] = producer
+			}
+
+			_, _, err := producer.SendMessage(&sarama.ProducerMessage{
+				Topic: target,
+				Value: sarama.ByteEncoder(record.Payload),
+			})
+			checkError(err, -3, "replay record of %d to %s failed", record.DataID, target)
+			replayed++
+			return true
+		})
+
+		fmt.Printf("%d records replayed, %d records skipped\n", replayed, skipped)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deadLetterCmd)
+	deadLetterCmd.AddCommand(deadLetterInspectCmd)
+	deadLetterCmd.AddCommand(deadLetterReplayCmd)
+
+	persistentFlags := deadLetterCmd.PersistentFlags()
+	persistentFlags.StringP("input", "i", "", "dead letter file, default stdin")
+	persistentFlags.String("source-kafka", "", "read dead letter from kafka hosts instead of file, eg: 127.0.0.1:9092")
+	persistentFlags.String("source-topic", "", "dead letter topic, required with --source-kafka")
+	persistentFlags.IntP("data-id", "d", 0, "data id filtering")
+	persistentFlags.StringP("processor", "p", "", "processor filtering")
+
+	deadLetterInspectCmd.Flags().IntP("limit", "n", 0, "max records to print")
+
+	flags := deadLetterReplayCmd.Flags()
+	flags.Bool("dry-run", false, "print records instead of sending")
+	flags.StringP("kafka", "k", "", "override kafka hosts, eg: 127.0.0.1:9092")
+	flags.StringP("topic", "t", "", "override target topic")
+}
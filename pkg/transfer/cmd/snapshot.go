@@ -141,6 +141,7 @@ func (s *snapshotHelper) CaptureService(api *sling.Sling, service *define.Servic
 		{"/debug/vars", "vars.json"},
 		{"/status/process", "process.json"},
 		{"/status/settings", "settings.json"},
+		{"/status/cardinality?top=0", "cardinality.json"},
 	} {
 		name := fmt.Sprintf("%s-%s", service.ID, urlConfig.name)
 		fmt.Printf("\t%s\n", name)
@@ -71,6 +71,9 @@ const (
 
 	PipelineConfigOptKafkaInitialOffset = "kafka_initial_offset"
 
+	// PipelineConfigOptDeadLetterConfig : 死信配置 格式与 shipper 一致 支持 kafka 及 file 类型
+	PipelineConfigOptDeadLetterConfig = "dead_letter_config"
+
 	// 时序类
 	// PipelineConfigOptInjectLocalTime :  增加入库时间指标(bool)
 	PipelineConfigOptInjectLocalTime = "inject_local_time"
@@ -98,7 +101,9 @@ const (
 	PipelineConfigOptLogSeparatedFields = "separator_field_list"
 	// PipelineConfigOptLogSeparatorRegexp : 日志正则提取清洗专用，提取字段
 	PipelineConfigOptLogSeparatorRegexp = "separator_regexp"
-	PipelineConfigOptionIsLogData       = "is_log_data"
+	// PipelineConfigOptLogSeparatorGrok : 日志 grok 提取清洗专用，提取表达式
+	PipelineConfigOptLogSeparatorGrok = "separator_grok"
+	PipelineConfigOptionIsLogData     = "is_log_data"
 	// PipelineConfigOptionRetainExtraJson : JSON清洗时, 未定义字段将会归到ext里
 	PipelineConfigOptionRetainExtraJson = "retain_extra_json"
 	// PipelineConfigOptionRetainContent 数据清洗失败时是否保留原始日志文本
@@ -151,6 +156,8 @@ const (
 	ResultTableOptLogSeparatedFields = "separator_field_list"
 	// ResultTableOptLogSeparatorRegexp : 日志正则提取清洗专用，提取字段
 	ResultTableOptLogSeparatorRegexp = "separator_regexp"
+	// ResultTableOptLogGrokPatterns : 日志 grok 提取清洗专用，自定义模式 {"名称": "表达式"}
+	ResultTableOptLogGrokPatterns = "grok_patterns"
 
 	ResultTableOptLogSeparatorConfigs = "separator_configs"
 
@@ -182,6 +189,12 @@ const (
 
 	// ResultTableOptMustIncludeDimensions 指标中必须拥有指定的所有维度 否则将丢弃
 	ResultTableOptMustIncludeDimensions = "must_include_dimensions"
+
+	// ResultTableOptScriptConfig 清洗后执行的字段转换规则 配置后自动加入 script_processor 节点
+	ResultTableOptScriptConfig = "script_config"
+
+	// ResultTableOptCardinalityGuard 序列基数限制配置 配置后自动加入 cardinality_guard 节点
+	ResultTableOptCardinalityGuard = "cardinality_guard"
 )
 
 // MetaFieldConfig 专用
@@ -258,6 +271,7 @@ func InitLogPipelineOptions(pipe *PipelineConfig) {
 	helper.SetDefault(PipelineConfigOptLogSeparator, " ")
 	helper.SetDefault(PipelineConfigOptLogSeparatedFields, []interface{}(nil))
 	helper.SetDefault(PipelineConfigOptLogSeparatorRegexp, nil)
+	helper.SetDefault(PipelineConfigOptLogSeparatorGrok, nil)
 }
 
 // InitResultTableOptions
@@ -0,0 +1,101 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cstockton/go-conv"
+)
+
+// RemoteWriteMetaClusterInfo :
+type RemoteWriteMetaClusterInfo struct {
+	*SimpleMetaClusterInfo
+}
+
+// GetPath : 写入路径 默认为 /api/v1/write
+func (c *RemoteWriteMetaClusterInfo) GetPath() string {
+	path, _ := c.StorageConfigHelper.GetString("path")
+	if path == "" {
+		return "/api/v1/write"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// SetPath :
+func (c *RemoteWriteMetaClusterInfo) SetPath(value string) {
+	c.StorageConfigHelper.Set("path", value)
+}
+
+// GetURL : 完整写入地址 未配置 url 时由集群地址和 path 拼接
+func (c *RemoteWriteMetaClusterInfo) GetURL() string {
+	url, _ := c.StorageConfigHelper.GetString("url")
+	if url != "" {
+		return url
+	}
+	return c.GetAddress() + c.GetPath()
+}
+
+// SetURL :
+func (c *RemoteWriteMetaClusterInfo) SetURL(value string) {
+	c.StorageConfigHelper.Set("url", value)
+}
+
+// GetHeaders : 附加请求头 如 X-Scope-OrgID 等租户信息
+func (c *RemoteWriteMetaClusterInfo) GetHeaders() map[string]string {
+	headers := make(map[string]string)
+	value, ok := c.StorageConfigHelper.Get("headers")
+	if !ok {
+		return headers
+	}
+	switch items := value.(type) {
+	case map[string]string:
+		for k, v := range items {
+			headers[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range items {
+			headers[k] = conv.String(v)
+		}
+	}
+	return headers
+}
+
+// SetHeaders :
+func (c *RemoteWriteMetaClusterInfo) SetHeaders(value map[string]string) {
+	c.StorageConfigHelper.Set("headers", value)
+}
+
+// GetMetricPrefix : 非单指标单表时作为指标名前缀 一般为 influxdb 中的表名
+func (c *RemoteWriteMetaClusterInfo) GetMetricPrefix() string {
+	prefix, _ := c.StorageConfigHelper.GetString("real_table_name")
+	return prefix
+}
+
+// SetMetricPrefix :
+func (c *RemoteWriteMetaClusterInfo) SetMetricPrefix(value string) {
+	c.StorageConfigHelper.Set("real_table_name", value)
+}
+
+// GetTarget :
+func (c *RemoteWriteMetaClusterInfo) GetTarget() string {
+	return fmt.Sprintf("%s[%s]", c.GetURL(), c.GetMetricPrefix())
+}
+
+// AsRemoteWriteCluster :
+func (c *MetaClusterInfo) AsRemoteWriteCluster() *RemoteWriteMetaClusterInfo {
+	return &RemoteWriteMetaClusterInfo{
+		SimpleMetaClusterInfo: NewSimpleMetaClusterInfo(c),
+	}
+}
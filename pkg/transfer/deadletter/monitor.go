@@ -0,0 +1,39 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package deadletter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+var (
+	// MonitorReported 死信上报计数器
+	MonitorReported = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "dead_letter_reported_total",
+		Help:      "Count of records sent to dead letter sink",
+	}, []string{"id", "processor"})
+
+	// MonitorDropped 死信丢弃计数器
+	MonitorDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "dead_letter_dropped_total",
+		Help:      "Count of records failed to send to dead letter sink",
+	}, []string{"id"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		MonitorReported,
+		MonitorDropped,
+	)
+}
@@ -0,0 +1,75 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package deadletter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+)
+
+// Stage : 被拒绝时数据所处的阶段
+type Stage string
+
+const (
+	// StageRaw : 原始数据 可以直接回放到数据源
+	StageRaw Stage = "raw"
+	// StageETL : 清洗后的数据 仅用于排查 不可回放
+	StageETL Stage = "etl"
+)
+
+// Record : 死信记录
+type Record struct {
+	DataID    int    `json:"data_id"`
+	Processor string `json:"processor"`
+	Stage     Stage  `json:"stage"`
+	Error     string `json:"error"`
+	Payload   []byte `json:"payload"`
+	Cluster   string `json:"cluster,omitempty"`
+	Topic     string `json:"topic,omitempty"`
+	Time      int64  `json:"time"`
+}
+
+// NewRecord :
+func NewRecord(dataID int, stage Stage, processor string, payload define.Payload, err error) *Record {
+	var data []byte
+	if e := payload.To(&data); e != nil {
+		data = []byte(fmt.Sprintf("%+v", payload))
+	} else {
+		// payload 后续仍可能被复用 这里复制一份
+		data = append([]byte(nil), data...)
+	}
+
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+
+	return &Record{
+		DataID:    dataID,
+		Processor: processor,
+		Stage:     stage,
+		Error:     msg,
+		Payload:   data,
+		Time:      time.Now().Unix(),
+	}
+}
+
+// Unmarshal : 解析单行死信记录
+func Unmarshal(data []byte) (*Record, error) {
+	record := new(Record)
+	err := json.Unmarshal(data, record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
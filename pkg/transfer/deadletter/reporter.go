@@ -0,0 +1,220 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// ResultTableName : 死信对应的虚拟结果表 file 类型以此作为文件名
+const ResultTableName = "dead_letter"
+
+// BufferSize : 待写入死信的缓冲大小 缓冲已满时直接丢弃
+var BufferSize = 1024
+
+// item : 待写入死信后端的数据
+type item struct {
+	processor string
+	payload   define.Payload
+}
+
+// Reporter : 将被处理器拒绝的数据写入死信后端 nil 时所有方法均为空操作
+type Reporter struct {
+	lock     sync.RWMutex
+	closed   bool
+	items    chan item
+	done     chan struct{}
+	backend  define.Backend
+	killChan chan error
+	dataID   int
+	cluster  string
+	topic    string
+	dropped  prometheus.Counter
+}
+
+// Report : 上报被拒绝的数据 只写入缓冲 不阻塞调用方的处理器
+func (r *Reporter) Report(stage Stage, processor string, payload define.Payload, err error) {
+	if r == nil {
+		return
+	}
+
+	record := NewRecord(r.dataID, stage, processor, payload, err)
+	if stage == StageRaw {
+		record.Cluster = r.cluster
+		record.Topic = r.topic
+	}
+
+	data, e := json.Marshal(record)
+	if e != nil {
+		logging.Warnf("dead letter %d marshal record from %s failed: %v", r.dataID, processor, e)
+		r.dropped.Inc()
+		return
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.closed {
+		r.dropped.Inc()
+		return
+	}
+
+	select {
+	case r.items <- item{processor: processor, payload: define.NewJSONPayloadFrom(data, payload.SN())}:
+	default:
+		logging.MinuteErrorfSampling(fmt.Sprintf("dead-letter-%d", r.dataID), "dead letter %d buffer is full, dropped record from %s", r.dataID, processor)
+		r.dropped.Inc()
+	}
+}
+
+// push : 死信写入失败不应影响流水线本身
+func (r *Reporter) push(it item) {
+	defer utils.RecoverError(func(e error) {
+		logging.Errorf("dead letter %d push record from %s panic: %v", r.dataID, it.processor, e)
+		r.dropped.Inc()
+	})
+
+	r.backend.Push(it.payload, r.killChan)
+	MonitorReported.WithLabelValues(strconv.Itoa(r.dataID), it.processor).Inc()
+}
+
+// run : 逐条写入死信后端 缓冲关闭后退出
+func (r *Reporter) run() {
+	defer close(r.done)
+	for it := range r.items {
+		r.push(it)
+	}
+}
+
+// Close : 写完缓冲中的数据后关闭死信后端 之后的上报都会被丢弃
+func (r *Reporter) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.items)
+	r.lock.Unlock()
+
+	// 先等待缓冲写完 再关闭后端 后端 Close 会等待其内部协程退出
+	// 此后不会再有错误写入 killChan 才可以安全关闭
+	<-r.done
+	err := r.backend.Close()
+	close(r.killChan)
+	return err
+}
+
+// watch : 死信后端的错误不影响流水线 仅记录日志
+func (r *Reporter) watch() {
+	for err := range r.killChan {
+		logging.Errorf("dead letter %d backend error: %v", r.dataID, err)
+	}
+}
+
+// ParseConfig : 从流水线配置中解析死信后端配置 未配置时返回 nil
+func ParseConfig(pipe *config.PipelineConfig) (*config.MetaClusterInfo, error) {
+	value, ok := utils.NewMapHelper(pipe.Option).Get(config.PipelineConfigOptDeadLetterConfig)
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	cluster := config.NewMetaClusterInfo()
+	err := mapstructure.Decode(value, cluster)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "decode %s", config.PipelineConfigOptDeadLetterConfig)
+	}
+	if cluster.ClusterType == "" {
+		return nil, errors.Wrapf(define.ErrValue, "%s cluster_type is empty", config.PipelineConfigOptDeadLetterConfig)
+	}
+	return cluster, cluster.Clean()
+}
+
+// NewReporter : 按照流水线配置创建死信上报 未配置死信时返回 nil
+func NewReporter(ctx context.Context) (reporter *Reporter, err error) {
+	pipe := config.PipelineConfigFromContext(ctx)
+	if pipe == nil {
+		return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is empty")
+	}
+
+	cluster, err := ParseConfig(pipe)
+	if err != nil || cluster == nil {
+		return nil, err
+	}
+
+	// 部分后端构造失败时直接 panic
+	defer utils.RecoverError(func(e error) {
+		reporter, err = nil, e
+	})
+
+	// 死信不需要经过流水线上的过滤逻辑 使用不带 option 的流水线配置
+	deadLetterPipe := *pipe
+	deadLetterPipe.Option = map[string]interface{}{}
+
+	// 死信后端的生命周期由 Reporter 管理 不随流水线 context 一起取消
+	backendCtx := context.WithoutCancel(ctx)
+	backendCtx = config.PipelineConfigIntoContext(backendCtx, &deadLetterPipe)
+	backendCtx = config.ShipperConfigIntoContext(backendCtx, cluster)
+	backendCtx = config.ResultTableConfigIntoContext(backendCtx, &config.MetaResultTableConfig{
+		ResultTable: ResultTableName,
+		ShipperList: []*config.MetaClusterInfo{cluster},
+	})
+
+	backend, err := define.NewBackend(backendCtx, cluster.ClusterType)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "create dead letter backend %s", cluster.ClusterType)
+	}
+
+	reporter = &Reporter{
+		items:    make(chan item, BufferSize),
+		done:     make(chan struct{}),
+		backend:  backend,
+		killChan: make(chan error),
+		dataID:   pipe.DataID,
+		dropped:  MonitorDropped.WithLabelValues(strconv.Itoa(pipe.DataID)),
+	}
+	if pipe.MQConfig != nil && pipe.MQConfig.ClusterType == "kafka" {
+		mq := pipe.MQConfig.AsKafkaCluster()
+		reporter.cluster = fmt.Sprintf("%s:%d", mq.GetDomain(), mq.GetPort())
+		reporter.topic = mq.GetTopic()
+	}
+	go reporter.watch()
+	go reporter.run()
+
+	logging.Infof("dead letter %d reports to %s", pipe.DataID, cluster.ClusterType)
+	return reporter, nil
+}
+
+// IntoContext :
+func IntoContext(ctx context.Context, reporter *Reporter) context.Context {
+	return context.WithValue(ctx, define.ContextDeadLetterKey, reporter)
+}
+
+// FromContext : 未配置死信时返回 nil
+func FromContext(ctx context.Context) *Reporter {
+	reporter, _ := ctx.Value(define.ContextDeadLetterKey).(*Reporter)
+	return reporter
+}
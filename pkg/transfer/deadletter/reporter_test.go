@@ -0,0 +1,178 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package deadletter_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
+)
+
+// ReporterSuite :
+type ReporterSuite struct {
+	testsuite.ETLSuite
+	lock   sync.Mutex
+	pushed []*deadletter.Record
+	block  chan struct{}
+}
+
+// SetupTest :
+func (s *ReporterSuite) SetupTest() {
+	s.ETLSuite.SetupTest()
+	s.pushed = nil
+	s.block = nil
+	s.PipelineConfig.Option[config.PipelineConfigOptDeadLetterConfig] = map[string]interface{}{
+		"cluster_type": "file",
+	}
+
+	backend := testsuite.NewMockBackend(s.Ctrl)
+	backend.EXPECT().Close().Return(nil).AnyTimes()
+	backend.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(payload define.Payload, killCh chan<- error) {
+		if s.block != nil {
+			<-s.block
+		}
+		var data []byte
+		s.NoError(payload.To(&data))
+		record, err := deadletter.Unmarshal(data)
+		s.NoError(err)
+		s.lock.Lock()
+		s.pushed = append(s.pushed, record)
+		s.lock.Unlock()
+		killCh <- fmt.Errorf("push error")
+	}).AnyTimes()
+	s.Stubs.Stub(&define.NewBackend, func(ctx context.Context, name string) (define.Backend, error) {
+		s.Equal("file", name)
+		s.Equal(deadletter.ResultTableName, config.ResultTableConfigFromContext(ctx).ResultTable)
+		s.Empty(config.PipelineConfigFromContext(ctx).Option)
+		return backend, nil
+	})
+}
+
+// TestParseConfig :
+func (s *ReporterSuite) TestParseConfig() {
+	cluster, err := deadletter.ParseConfig(s.PipelineConfig)
+	s.NoError(err)
+	s.Equal("file", cluster.ClusterType)
+	s.NotNil(cluster.StorageConfig)
+
+	pipe := config.NewPipelineConfig()
+	cluster, err = deadletter.ParseConfig(pipe)
+	s.NoError(err)
+	s.Nil(cluster)
+
+	pipe.Option = map[string]interface{}{
+		config.PipelineConfigOptDeadLetterConfig: map[string]interface{}{},
+	}
+	_, err = deadletter.ParseConfig(pipe)
+	s.Error(err)
+}
+
+// TestReport :
+func (s *ReporterSuite) TestReport() {
+	reporter, err := deadletter.NewReporter(s.CTX)
+	s.NoError(err)
+	s.NotNil(reporter)
+
+	ctx := deadletter.IntoContext(s.CTX, reporter)
+	s.Equal(reporter, deadletter.FromContext(ctx))
+
+	payload := define.NewJSONPayloadFrom([]byte(`{"x":1}`), 0)
+	reporter.Report(deadletter.StageRaw, "test", payload, fmt.Errorf("bad payload"))
+	// Close 会等待缓冲中的数据写完
+	s.NoError(reporter.Close())
+	s.Len(s.pushed, 1)
+	record := s.pushed[0]
+	s.Equal(s.PipelineConfig.DataID, record.DataID)
+	s.Equal("test", record.Processor)
+	s.Equal(deadletter.StageRaw, record.Stage)
+	s.Equal("bad payload", record.Error)
+	s.Equal(`{"x":1}`, string(record.Payload))
+
+	reporter.Report(deadletter.StageETL, "test", payload, fmt.Errorf("bad payload"))
+	s.Len(s.pushed, 1)
+	s.NoError(reporter.Close())
+}
+
+// TestConcurrentClose : 关闭时等待进行中的上报 不会向已关闭的 killChan 写入
+func (s *ReporterSuite) TestConcurrentClose() {
+	reporter, err := deadletter.NewReporter(s.CTX)
+	s.NoError(err)
+
+	var wg sync.WaitGroup
+	payload := define.NewJSONPayloadFrom([]byte(`{"x":1}`), 0)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				reporter.Report(deadletter.StageETL, "test", payload, fmt.Errorf("bad payload"))
+			}
+		}()
+	}
+	s.NoError(reporter.Close())
+	wg.Wait()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.LessOrEqual(len(s.pushed), 1000)
+}
+
+// TestBufferFull : 后端阻塞时 上报不阻塞 缓冲已满的数据直接丢弃并计数
+func (s *ReporterSuite) TestBufferFull() {
+	s.Stubs.Stub(&deadletter.BufferSize, 1)
+	s.block = make(chan struct{})
+	dropped := deadletter.MonitorDropped.WithLabelValues(strconv.Itoa(s.PipelineConfig.DataID))
+	before := testutil.ToFloat64(dropped)
+
+	reporter, err := deadletter.NewReporter(s.CTX)
+	s.NoError(err)
+
+	payload := define.NewJSONPayloadFrom([]byte(`{"x":1}`), 0)
+	for i := 0; i < 5; i++ {
+		reporter.Report(deadletter.StageETL, "test", payload, fmt.Errorf("bad payload"))
+	}
+	// 最多一条正在写入 一条在缓冲中
+	s.GreaterOrEqual(testutil.ToFloat64(dropped)-before, 3.0)
+
+	close(s.block)
+	s.NoError(reporter.Close())
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Equal(5.0, float64(len(s.pushed))+testutil.ToFloat64(dropped)-before)
+}
+
+// TestNilReporter :
+func (s *ReporterSuite) TestNilReporter() {
+	delete(s.PipelineConfig.Option, config.PipelineConfigOptDeadLetterConfig)
+	reporter, err := deadletter.NewReporter(s.CTX)
+	s.NoError(err)
+	s.Nil(reporter)
+	s.Nil(deadletter.FromContext(s.CTX))
+
+	reporter.Report(deadletter.StageRaw, "test", define.NewJSONPayloadFrom([]byte(`{}`), 0), nil)
+	s.NoError(reporter.Close())
+	s.Empty(s.pushed)
+}
+
+// TestReporterSuite :
+func TestReporterSuite(t *testing.T) {
+	suite.Run(t, new(ReporterSuite))
+}
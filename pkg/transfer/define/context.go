@@ -23,6 +23,7 @@ const (
 	ContextETLPluginKey
 	ContextStartCacheKey
 	ContextRuntimeKey
+	ContextDeadLetterKey
 )
 
 //go:generate stringer -type=ContextKey -trimprefix Context
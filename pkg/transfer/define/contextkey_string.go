@@ -25,11 +25,14 @@ func _() {
 	_ = x[ContextStoreKey-5]
 	_ = x[ContextSchedulerKey-6]
 	_ = x[ContextETLPluginKey-7]
+	_ = x[ContextStartCacheKey-8]
+	_ = x[ContextRuntimeKey-9]
+	_ = x[ContextDeadLetterKey-10]
 }
 
-const _ContextKey_name = "ConfigKeyResultTableKeyShipperKeyPipelineKeyMQConfigKeyStoreKeySchedulerKeyETLPluginKey"
+const _ContextKey_name = "ConfigKeyResultTableKeyShipperKeyPipelineKeyMQConfigKeyStoreKeySchedulerKeyETLPluginKeyStartCacheKeyRuntimeKeyDeadLetterKey"
 
-var _ContextKey_index = [...]uint8{0, 9, 23, 33, 44, 55, 63, 75, 87}
+var _ContextKey_index = [...]uint8{0, 9, 23, 33, 44, 55, 63, 75, 87, 100, 110, 123}
 
 func (i ContextKey) String() string {
 	if i < 0 || i >= ContextKey(len(_ContextKey_index)-1) {
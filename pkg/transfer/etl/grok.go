@@ -0,0 +1,241 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+// grok 模式最大嵌套层数 用于检测循环引用
+const grokMaxDepth = 64
+
+// grokReference : %{SYNTAX}, %{SYNTAX:SEMANTIC}, %{SYNTAX:SEMANTIC:TYPE}
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::(int|float|string))?\}`)
+
+// GrokPatterns : 内置 grok 模式库 与 logstash 保持一致 其中依赖环视的模式为兼容 RE2 做了改写
+var GrokPatterns = map[string]string{
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"EMAILLOCALPART":    `[a-zA-Z0-9!#$%&'*+/=?^_{|}~-]+(?:\.[a-zA-Z0-9!#$%&'*+/=?^_{|}~-]+)*`,
+	"EMAILADDRESS":      `%{EMAILLOCALPART}@%{HOSTNAME}`,
+	"INT":               `[+-]?[0-9]+`,
+	"BASE10NUM":         `[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)`,
+	"NUMBER":            `%{BASE10NUM}`,
+	"BASE16NUM":         `[+-]?(?:0x)?[0-9A-Fa-f]+`,
+	"POSINT":            `\b[1-9][0-9]*\b`,
+	"NONNEGINT":         `\b[0-9]+\b`,
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+	"QS":                `%{QUOTEDSTRING}`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"CISCOMAC":          `(?:[A-Fa-f0-9]{4}\.){2}[A-Fa-f0-9]{4}`,
+	"WINDOWSMAC":        `(?:[A-Fa-f0-9]{2}-){5}[A-Fa-f0-9]{2}`,
+	"COMMONMAC":         `(?:[A-Fa-f0-9]{2}:){5}[A-Fa-f0-9]{2}`,
+	"MAC":               `%{CISCOMAC}|%{WINDOWSMAC}|%{COMMONMAC}`,
+	"IPV4":              `(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])(?:\.(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])){3}`,
+	"IPV6":              `::(?:[Ff]{4}(?::0{1,4})?:)?%{IPV4}|(?:[0-9A-Fa-f]{1,4}:){1,4}:%{IPV4}|(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}|(?:[0-9A-Fa-f]{1,4}:){1,6}:[0-9A-Fa-f]{1,4}|(?:[0-9A-Fa-f]{1,4}:){1,5}(?::[0-9A-Fa-f]{1,4}){1,2}|(?:[0-9A-Fa-f]{1,4}:){1,4}(?::[0-9A-Fa-f]{1,4}){1,3}|(?:[0-9A-Fa-f]{1,4}:){1,3}(?::[0-9A-Fa-f]{1,4}){1,4}|(?:[0-9A-Fa-f]{1,4}:){1,2}(?::[0-9A-Fa-f]{1,4}){1,5}|[0-9A-Fa-f]{1,4}:(?::[0-9A-Fa-f]{1,4}){1,6}|(?:[0-9A-Fa-f]{1,4}:){1,7}:|:(?:(?::[0-9A-Fa-f]{1,4}){1,7}|:)`,
+	"IP":                `%{IPV6}|%{IPV4}`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\b`,
+	"IPORHOST":          `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"UNIXPATH":          `(?:/[\w_%!$@:.,+~-]*)+`,
+	"WINPATH":           `(?:[A-Za-z]+:|\\)(?:\\[^\\?*]*)+`,
+	"PATH":              `%{UNIXPATH}|%{WINPATH}`,
+	"URIPROTO":          `[A-Za-z]+(?:\+[A-Za-z+]+)?`,
+	"URIHOST":           `%{IPORHOST}(?::%{POSINT})?`,
+	"URIPATH":           `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":          `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM":      `%{URIPATH}(?:%{URIPARAM})?`,
+	"URI":               `%{URIPROTO}://(?:%{USER}(?::[^@]*)?@)?(?:%{URIHOST})?(?:%{URIPATHPARAM})?`,
+	"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]une?|[Jj]uly?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+	"MONTHNUM":          `1[0-2]|0?[1-9]`,
+	"MONTHDAY":          `3[01]|[12][0-9]|0?[1-9]`,
+	"DAY":               `\b(?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)\b`,
+	"YEAR":              `[0-9]{2}(?:[0-9]{2})?`,
+	"HOUR":              `2[0-3]|[01]?[0-9]`,
+	"MINUTE":            `[0-5][0-9]`,
+	"SECOND":            `(?:60|[0-5]?[0-9])(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"DATE_US":           `%{MONTHNUM}[/-]%{MONTHDAY}[/-]%{YEAR}`,
+	"DATE_EU":           `%{MONTHDAY}[./-]%{MONTHNUM}[./-]%{YEAR}`,
+	"ISO8601_TIMEZONE":  `Z|[+-]%{HOUR}(?::?%{MINUTE})`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?(?:%{ISO8601_TIMEZONE})?`,
+	"DATE":              `%{DATE_US}|%{DATE_EU}`,
+	"DATESTAMP":         `%{DATE}[- ]%{TIME}`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+	"PROG":              `[\x21-\x5a\x5c\x5e-\x7e]+`,
+	"SYSLOGPROG":        `%{PROG:program}(?:\[%{POSINT:pid}\])?`,
+	"SYSLOGHOST":        `%{IPORHOST}`,
+	"SYSLOGBASE":        `%{SYSLOGTIMESTAMP:timestamp} %{SYSLOGHOST:logsource} %{SYSLOGPROG}:`,
+	"LOGLEVEL":          `[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo(?:rmation)?|INFO(?:RMATION)?|[Ww]arn(?:ing)?|WARN(?:ING)?|[Ee]rr(?:or)?|ERR(?:OR)?|[Cc]rit(?:ical)?|CRIT(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|EMERG(?:ENCY)?|[Ee]merg(?:ency)?`,
+	"HTTPDUSER":         `%{EMAILADDRESS}|%{USER}`,
+	"COMMONAPACHELOG":   `%{IPORHOST:clientip} %{HTTPDUSER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" %{NUMBER:response} (?:%{NUMBER:bytes}|-)`,
+	"COMBINEDAPACHELOG": `%{COMMONAPACHELOG} %{QS:referrer} %{QS:agent}`,
+}
+
+// Grok : 编译后的 grok 表达式
+type Grok struct {
+	regex  *regexp.Regexp
+	fields []string
+	types  map[string]string
+}
+
+// grokCompiler : 展开 grok 模式 记录命名分组与字段的对应关系
+type grokCompiler struct {
+	patterns map[string]string
+	fields   map[string]string
+	types    map[string]string
+}
+
+func (c *grokCompiler) expand(pattern string, depth int) (string, error) {
+	if depth > grokMaxDepth {
+		return "", errors.Wrapf(define.ErrValue, "grok pattern nested too deep: %s", pattern)
+	}
+
+	var err error
+	result := grokReference.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+
+		parts := grokReference.FindStringSubmatch(ref)
+		name, semantic, typ := parts[1], parts[2], parts[3]
+		definition, ok := c.patterns[name]
+		if !ok {
+			definition, ok = GrokPatterns[name]
+		}
+		if !ok {
+			err = errors.Wrapf(define.ErrKey, "grok pattern %s not found", name)
+			return ""
+		}
+
+		expanded, e := c.expand(definition, depth+1)
+		if e != nil {
+			err = e
+			return ""
+		}
+		if semantic == "" {
+			return "(?:" + expanded + ")"
+		}
+
+		// 字段名可能包含 . 等非法分组名字符 这里统一使用内部分组名
+		group := fmt.Sprintf("__grok%d", len(c.fields))
+		c.fields[group] = semantic
+		if typ != "" {
+			c.types[semantic] = typ
+		}
+		return "(?P<" + group + ">" + expanded + ")"
+	})
+	return result, err
+}
+
+// NewGrok : patterns 为自定义模式 同名时优先于内置模式
+func NewGrok(pattern string, patterns map[string]string) (*Grok, error) {
+	compiler := &grokCompiler{
+		patterns: patterns,
+		fields:   make(map[string]string),
+		types:    make(map[string]string),
+	}
+	expanded, err := compiler.expand(pattern, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	regex, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "compile grok pattern %s", pattern)
+	}
+
+	names := regex.SubexpNames()
+	fields := make([]string, len(names))
+	for i, name := range names {
+		if field, ok := compiler.fields[name]; ok {
+			fields[i] = field
+		} else if !strings.HasPrefix(name, "__grok") {
+			// 兼容直接书写的命名分组
+			fields[i] = name
+		}
+	}
+
+	return &Grok{
+		regex:  regex,
+		fields: fields,
+		types:  compiler.types,
+	}, nil
+}
+
+// Fields : 表达式提取的全部字段
+func (g *Grok) Fields() []string {
+	fields := make([]string, 0, len(g.fields))
+	seen := make(map[string]struct{}, len(g.fields))
+	for _, field := range g.fields {
+		if _, ok := seen[field]; ok || field == "" {
+			continue
+		}
+		seen[field] = struct{}{}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func (g *Grok) convert(field, value string) interface{} {
+	var (
+		result interface{}
+		err    error
+	)
+	switch g.types[field] {
+	case "int":
+		result, err = strconv.ParseInt(value, 10, 64)
+	case "float":
+		result, err = strconv.ParseFloat(value, 64)
+	default:
+		return value
+	}
+	if err != nil {
+		return value
+	}
+	return result
+}
+
+// Parse : 提取字段 未匹配时字段均为 nil 并返回 false
+func (g *Grok) Parse(value string) (map[string]interface{}, bool) {
+	results := make(map[string]interface{}, len(g.fields))
+	matched := g.regex.FindStringSubmatchIndex(value)
+	for i, field := range g.fields {
+		if field == "" {
+			continue
+		}
+		if matched == nil || matched[2*i] < 0 {
+			if _, ok := results[field]; !ok {
+				results[field] = nil
+			}
+			continue
+		}
+
+		// 同名字段取第一个匹配到的值
+		if current, ok := results[field]; ok && current != nil {
+			continue
+		}
+		results[field] = g.convert(field, value[matched[2*i]:matched[2*i+1]])
+	}
+	return results, matched != nil
+}
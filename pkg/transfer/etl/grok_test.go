@@ -0,0 +1,88 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/etl"
+)
+
+// GrokSuite :
+type GrokSuite struct {
+	suite.Suite
+}
+
+// TestApacheLog :
+func (s *GrokSuite) TestApacheLog() {
+	grok, err := etl.NewGrok(`%{COMBINEDAPACHELOG}`, nil)
+	s.NoError(err)
+
+	result, ok := grok.Parse(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"`)
+	s.True(ok)
+	s.Equal(map[string]interface{}{
+		"clientip":    "127.0.0.1",
+		"ident":       "-",
+		"auth":        "frank",
+		"timestamp":   "10/Oct/2000:13:55:36 -0700",
+		"verb":        "GET",
+		"request":     "/apache_pb.gif",
+		"httpversion": "1.0",
+		"rawrequest":  nil,
+		"response":    "200",
+		"bytes":       "2326",
+		"referrer":    `"http://www.example.com/start.html"`,
+		"agent":       `"Mozilla/4.08 [en] (Win98; I ;Nav)"`,
+	}, result)
+}
+
+// TestCustomPatterns :
+func (s *GrokSuite) TestCustomPatterns() {
+	grok, err := etl.NewGrok(`%{IP:client.ip} %{CODE:code:int} (?P<rest>.*)`, map[string]string{
+		"CODE": `[0-9]{3}`,
+	})
+	s.NoError(err)
+	s.Equal([]string{"client.ip", "code", "rest"}, grok.Fields())
+
+	result, ok := grok.Parse(`fe80::1 404 not found`)
+	s.True(ok)
+	s.Equal(map[string]interface{}{
+		"client.ip": "fe80::1",
+		"code":      int64(404),
+		"rest":      "not found",
+	}, result)
+
+	result, ok = grok.Parse(`404`)
+	s.False(ok)
+	s.Equal(map[string]interface{}{
+		"client.ip": nil,
+		"code":      nil,
+		"rest":      nil,
+	}, result)
+}
+
+// TestInvalidPatterns :
+func (s *GrokSuite) TestInvalidPatterns() {
+	_, err := etl.NewGrok(`%{NOT_EXISTS}`, nil)
+	s.Error(err)
+
+	_, err = etl.NewGrok(`%{A}`, map[string]string{"A": `%{B}`, "B": `%{A}`})
+	s.Error(err)
+
+	_, err = etl.NewGrok(`%{INT}(`, nil)
+	s.Error(err)
+}
+
+// TestGrokSuite :
+func TestGrokSuite(t *testing.T) {
+	suite.Run(t, new(GrokSuite))
+}
@@ -0,0 +1,79 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+// ParseLogfmt : 解析 key=value 格式的日志 单独出现的 key 视为 true
+// 解析出错时返回已解析的部分
+func ParseLogfmt(line string) (map[string]interface{}, error) {
+	results := make(map[string]interface{})
+	i, length := 0, len(line)
+	for i < length {
+		// 跳过空白
+		for i < length && line[i] <= ' ' {
+			i++
+		}
+		if i >= length {
+			break
+		}
+
+		start := i
+		for i < length && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return results, errors.Wrapf(define.ErrValue, "unexpected %q at %d", line[i], i)
+		}
+		if i >= length || line[i] != '=' {
+			if i < length && line[i] == '"' {
+				return results, errors.Wrapf(define.ErrValue, "unexpected %q at %d", line[i], i)
+			}
+			results[key] = true
+			continue
+		}
+
+		// 跳过 =
+		i++
+		if i < length && line[i] == '"' {
+			end := i + 1
+			for end < length && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= length {
+				return results, errors.Wrapf(define.ErrValue, "unterminated quoted value of %s", key)
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return results, errors.WithMessagef(err, "unquote value of %s", key)
+			}
+			results[key] = value
+			i = end + 1
+			continue
+		}
+
+		start = i
+		for i < length && line[i] > ' ' {
+			i++
+		}
+		results[key] = line[start:i]
+	}
+	return results, nil
+}
@@ -0,0 +1,242 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+// syslog 提取的字段
+const (
+	SyslogFieldPriority       = "priority"
+	SyslogFieldFacility       = "facility"
+	SyslogFieldSeverity       = "severity"
+	SyslogFieldVersion        = "version"
+	SyslogFieldTimestamp      = "timestamp"
+	SyslogFieldHostname       = "hostname"
+	SyslogFieldAppName        = "app_name"
+	SyslogFieldProcID         = "proc_id"
+	SyslogFieldMsgID          = "msg_id"
+	SyslogFieldStructuredData = "structured_data"
+	SyslogFieldMessage        = "message"
+)
+
+// RFC3164 时间戳格式 不带年份
+const syslogRFC3164TimeLayout = time.Stamp
+
+// syslogNilValue : RFC5424 中的空值
+const syslogNilValue = "-"
+
+// syslogScanner :
+type syslogScanner struct {
+	line string
+	pos  int
+}
+
+// token : 读取到下一个空格为止
+func (s *syslogScanner) token() (string, bool) {
+	if s.pos >= len(s.line) {
+		return "", false
+	}
+	end := strings.IndexByte(s.line[s.pos:], ' ')
+	if end < 0 {
+		end = len(s.line) - s.pos
+	}
+	token := s.line[s.pos : s.pos+end]
+	s.pos += end
+	if s.pos < len(s.line) {
+		s.pos++
+	}
+	return token, token != ""
+}
+
+func (s *syslogScanner) rest() string {
+	if s.pos >= len(s.line) {
+		return ""
+	}
+	return s.line[s.pos:]
+}
+
+// ParseSyslog : 解析 RFC3164 及 RFC5424 格式的 syslog 根据 PRI 之后是否为版本号自动识别
+// 解析出错时返回已解析的部分
+func ParseSyslog(line string) (map[string]interface{}, error) {
+	results := make(map[string]interface{})
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "<") {
+		return results, errors.Wrapf(define.ErrValue, "syslog priority not found")
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return results, errors.Wrapf(define.ErrValue, "invalid syslog priority")
+	}
+	priority, err := strconv.Atoi(line[1:end])
+	if err != nil || priority < 0 || priority > 191 {
+		return results, errors.Wrapf(define.ErrValue, "invalid syslog priority %s", line[1:end])
+	}
+	results[SyslogFieldPriority] = priority
+	results[SyslogFieldFacility] = priority / 8
+	results[SyslogFieldSeverity] = priority % 8
+
+	scanner := &syslogScanner{line: line, pos: end + 1}
+	rest := scanner.rest()
+	space := strings.IndexByte(rest, ' ')
+	if space > 0 && space <= 2 {
+		if version, err := strconv.Atoi(rest[:space]); err == nil {
+			results[SyslogFieldVersion] = version
+			return results, parseRFC5424(scanner, results)
+		}
+	}
+	return results, parseRFC3164(scanner, results)
+}
+
+// parseRFC5424 : VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseRFC5424(scanner *syslogScanner, results map[string]interface{}) error {
+	// 跳过版本号
+	scanner.token()
+	for _, field := range []string{
+		SyslogFieldTimestamp, SyslogFieldHostname, SyslogFieldAppName, SyslogFieldProcID, SyslogFieldMsgID,
+	} {
+		token, ok := scanner.token()
+		if !ok {
+			return errors.Wrapf(define.ErrValue, "syslog %s not found", field)
+		}
+		if token != syslogNilValue {
+			results[field] = token
+		}
+	}
+
+	data, err := parseStructuredData(scanner)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		results[SyslogFieldStructuredData] = data
+	}
+
+	// 去掉 UTF-8 BOM
+	results[SyslogFieldMessage] = strings.TrimPrefix(scanner.rest(), "\xEF\xBB\xBF")
+	return nil
+}
+
+// parseStructuredData : [id key="value" ...][id ...] 或 -
+func parseStructuredData(scanner *syslogScanner) (map[string]interface{}, error) {
+	line := scanner.line
+	if strings.HasPrefix(scanner.rest(), syslogNilValue) {
+		scanner.token()
+		return nil, nil
+	}
+
+	data := make(map[string]interface{})
+	i := scanner.pos
+	for i < len(line) && line[i] == '[' {
+		i++
+		start := i
+		for i < len(line) && line[i] != ' ' && line[i] != ']' {
+			i++
+		}
+		id := line[start:i]
+		params := make(map[string]interface{})
+		for i < len(line) && line[i] == ' ' {
+			i++
+			start = i
+			for i < len(line) && line[i] != '=' {
+				i++
+			}
+			if i+1 >= len(line) || line[i+1] != '"' {
+				return data, errors.Wrapf(define.ErrValue, "invalid syslog structured data %s", id)
+			}
+			name := line[start:i]
+			i += 2
+
+			var value strings.Builder
+			for i < len(line) && line[i] != '"' {
+				// 仅 " \ ] 需要转义
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte(`"\]`, line[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(line[i])
+				i++
+			}
+			if i >= len(line) {
+				return data, errors.Wrapf(define.ErrValue, "unterminated syslog structured data %s", id)
+			}
+			params[name] = value.String()
+			i++
+		}
+		if i >= len(line) || line[i] != ']' {
+			return data, errors.Wrapf(define.ErrValue, "unterminated syslog structured data %s", id)
+		}
+		i++
+		data[id] = params
+	}
+	if i == scanner.pos {
+		return nil, errors.Wrapf(define.ErrValue, "syslog structured data not found")
+	}
+
+	scanner.pos = i
+	if scanner.pos < len(line) && line[scanner.pos] == ' ' {
+		scanner.pos++
+	}
+	return data, nil
+}
+
+// parseRFC3164 : TIMESTAMP HOSTNAME TAG[PID]: MSG 时间戳兼容 RFC3339 格式
+func parseRFC3164(scanner *syslogScanner, results map[string]interface{}) error {
+	rest := scanner.rest()
+	layoutLength := len(syslogRFC3164TimeLayout)
+	if len(rest) >= layoutLength {
+		if _, err := time.Parse(syslogRFC3164TimeLayout, rest[:layoutLength]); err == nil {
+			results[SyslogFieldTimestamp] = rest[:layoutLength]
+			scanner.pos += layoutLength
+			if scanner.pos < len(scanner.line) && scanner.line[scanner.pos] == ' ' {
+				scanner.pos++
+			}
+		}
+	}
+	if _, ok := results[SyslogFieldTimestamp]; !ok {
+		token, _ := scanner.token()
+		if _, err := time.Parse(time.RFC3339, token); err != nil {
+			return errors.Wrapf(define.ErrValue, "invalid syslog timestamp %s", token)
+		}
+		results[SyslogFieldTimestamp] = token
+	}
+
+	hostname, ok := scanner.token()
+	if !ok {
+		return errors.Wrapf(define.ErrValue, "syslog hostname not found")
+	}
+	results[SyslogFieldHostname] = hostname
+
+	// TAG 以 [ 或 : 结尾 否则视为没有 TAG
+	rest = scanner.rest()
+	end := strings.IndexAny(rest, "[: ")
+	if end > 0 && rest[end] != ' ' {
+		results[SyslogFieldAppName] = rest[:end]
+		rest = rest[end:]
+		if strings.HasPrefix(rest, "[") {
+			pidEnd := strings.IndexByte(rest, ']')
+			if pidEnd < 0 {
+				return errors.Wrapf(define.ErrValue, "unterminated syslog pid")
+			}
+			results[SyslogFieldProcID] = rest[1:pidEnd]
+			rest = rest[pidEnd+1:]
+		}
+		rest = strings.TrimPrefix(rest, ":")
+		rest = strings.TrimPrefix(rest, " ")
+	}
+	results[SyslogFieldMessage] = rest
+	return nil
+}
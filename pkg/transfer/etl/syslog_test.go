@@ -0,0 +1,98 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/etl"
+)
+
+// SyslogSuite :
+type SyslogSuite struct {
+	suite.Suite
+}
+
+// TestRFC3164 :
+func (s *SyslogSuite) TestRFC3164() {
+	cases := []struct {
+		line   string
+		result map[string]interface{}
+	}{
+		{
+			`<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed`,
+			map[string]interface{}{
+				"priority": 34, "facility": 4, "severity": 2,
+				"timestamp": "Oct 11 22:14:15", "hostname": "mymachine",
+				"app_name": "su", "proc_id": "123", "message": "'su root' failed",
+			},
+		},
+		{
+			`<13>Feb  5 17:32:18 10.0.0.99 Use the BFG!`,
+			map[string]interface{}{
+				"priority": 13, "facility": 1, "severity": 5,
+				"timestamp": "Feb  5 17:32:18", "hostname": "10.0.0.99",
+				"message": "Use the BFG!",
+			},
+		},
+		{
+			`<30>2003-10-11T22:14:15Z host app: hello`,
+			map[string]interface{}{
+				"priority": 30, "facility": 3, "severity": 6,
+				"timestamp": "2003-10-11T22:14:15Z", "hostname": "host",
+				"app_name": "app", "message": "hello",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		result, err := etl.ParseSyslog(c.line)
+		s.NoError(err, c.line)
+		s.Equal(c.result, result, c.line)
+	}
+}
+
+// TestRFC5424 :
+func (s *SyslogSuite) TestRFC5424() {
+	result, err := etl.ParseSyslog(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Appli\]cation"][x a="b"] An application event`)
+	s.NoError(err)
+	s.Equal(map[string]interface{}{
+		"priority": 165, "facility": 20, "severity": 5, "version": 1,
+		"timestamp": "2003-10-11T22:14:15.003Z", "hostname": "mymachine.example.com",
+		"app_name": "evntslog", "msg_id": "ID47", "message": "An application event",
+		"structured_data": map[string]interface{}{
+			"exampleSDID@32473": map[string]interface{}{"iut": "3", "eventSource": "Appli]cation"},
+			"x":                 map[string]interface{}{"a": "b"},
+		},
+	}, result)
+
+	result, err = etl.ParseSyslog(`<165>1 2003-10-11T22:14:15.003Z host app 12 - -`)
+	s.NoError(err)
+	s.Equal("", result["message"])
+	s.NotContains(result, "structured_data")
+
+	_, err = etl.ParseSyslog(`<165>1 2003-10-11T22:14:15.003Z host app 12 - [x a="b`)
+	s.Error(err)
+}
+
+// TestInvalid :
+func (s *SyslogSuite) TestInvalid() {
+	for _, line := range []string{``, `hello`, `<999>Oct 11 22:14:15 host x`, `<34>yesterday host x`} {
+		_, err := etl.ParseSyslog(line)
+		s.Error(err, line)
+	}
+}
+
+// TestSyslogSuite :
+func TestSyslogSuite(t *testing.T) {
+	suite.Run(t, new(SyslogSuite))
+}
@@ -122,6 +122,49 @@ func TransformMapByRegexp(pattern string) TransformFn {
 	}
 }
 
+// TransformMapByGrok : patterns 为自定义模式
+func TransformMapByGrok(pattern string, patterns map[string]string) TransformFn {
+	grok, err := NewGrok(pattern, patterns)
+	if err != nil {
+		return TransformErrorForever(err)
+	}
+
+	return func(from interface{}) (to interface{}, err error) {
+		value, err := conv.DefaultConv.String(from)
+		if err != nil {
+			return nil, err
+		}
+
+		results, matched := grok.Parse(value)
+		results[config.LogCleanFailedFlag] = !matched
+		return results, nil
+	}
+}
+
+// TransformMapByLogfmt :
+func TransformMapByLogfmt(from interface{}) (to interface{}, err error) {
+	value, err := conv.DefaultConv.String(from)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ParseLogfmt(value)
+	results[config.LogCleanFailedFlag] = err != nil
+	return results, nil
+}
+
+// TransformMapBySyslog :
+func TransformMapBySyslog(from interface{}) (to interface{}, err error) {
+	value, err := conv.DefaultConv.String(from)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ParseSyslog(value)
+	results[config.LogCleanFailedFlag] = err != nil
+	return results, nil
+}
+
 func TransformMapByJsonWithRetainExtraJSON(table *config.MetaResultTableConfig) TransformFn {
 	options := utils.NewMapHelper(table.Option)
 	retainExtraJSON, _ := options.GetBool(config.PipelineConfigOptionRetainExtraJson)
@@ -264,6 +264,65 @@ func (s *TransformBySeparatorSuite) TestUsage() {
 			},
 			true,
 		},
+		{
+			`127.0.0.1 E42 1.5`,
+			etl.TransformMapByGrok(`%{IP:ip} %{CODE:code} %{NUMBER:cost:float}`, map[string]string{"CODE": `E[0-9]+`}),
+			map[string]interface{}{
+				"ip":                      "127.0.0.1",
+				"code":                    "E42",
+				"cost":                    1.5,
+				config.LogCleanFailedFlag: false,
+			},
+			false,
+		},
+		{
+			`-`,
+			etl.TransformMapByGrok(`%{IP:ip} %{INT:code:int}`, nil),
+			map[string]interface{}{
+				"ip":                      nil,
+				"code":                    nil,
+				config.LogCleanFailedFlag: true,
+			},
+			false,
+		},
+		{
+			`level=info msg="a b" debug`,
+			etl.TransformMapByLogfmt,
+			map[string]interface{}{
+				"level":                   "info",
+				"msg":                     "a b",
+				"debug":                   true,
+				config.LogCleanFailedFlag: false,
+			},
+			false,
+		},
+		{
+			`level=info msg="a b`,
+			etl.TransformMapByLogfmt,
+			map[string]interface{}{
+				"level":                   "info",
+				config.LogCleanFailedFlag: true,
+			},
+			false,
+		},
+		{
+			`<13>1 2003-10-11T22:14:15.003Z host app 12 - [id a="1"] hello`,
+			etl.TransformMapBySyslog,
+			map[string]interface{}{
+				"priority":                13,
+				"facility":                1,
+				"severity":                5,
+				"version":                 1,
+				"timestamp":               "2003-10-11T22:14:15.003Z",
+				"hostname":                "host",
+				"app_name":                "app",
+				"proc_id":                 "12",
+				"structured_data":         map[string]interface{}{"id": map[string]interface{}{"a": "1"}},
+				"message":                 "hello",
+				config.LogCleanFailedFlag: false,
+			},
+			false,
+		},
 	}
 
 	for i, c := range cases {
@@ -3,6 +3,7 @@ module github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer
 go 1.23.0
 
 require (
+	github.com/Knetic/govaluate v3.0.0+incompatible
 	github.com/MauriceGit/skiplist v0.0.0-20181208093031-38aa714e3f14
 	github.com/Shopify/sarama v1.27.0
 	github.com/alicebob/miniredis/v2 v2.14.1
@@ -20,6 +21,7 @@ require (
 	github.com/go-redis/redis v6.15.1+incompatible
 	github.com/go-redis/redis/v8 v8.8.3
 	github.com/golang/mock v1.5.0
+	github.com/golang/snappy v0.0.4
 	github.com/google/go-cmp v0.6.0
 	github.com/hashicorp/consul/api v1.11.0
 	github.com/hashicorp/go-rootcerts v1.0.2
@@ -47,6 +49,7 @@ require (
 	golang.org/x/sync v0.12.0
 	golang.org/x/text v0.23.0
 	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba
+	google.golang.org/protobuf v1.33.0
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
 	gopkg.in/yaml.v2 v2.4.0
 	gopkg.in/yaml.v3 v3.0.1
@@ -68,7 +71,6 @@ require (
 	github.com/fatih/color v1.13.0 // indirect
 	github.com/frankban/quicktest v1.11.0 // indirect
 	github.com/fsnotify/fsnotify v1.5.1 // indirect
-	github.com/golang/snappy v0.0.4 // indirect
 	github.com/google/go-querystring v1.0.0 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.1 // indirect
 	github.com/hashicorp/go-hclog v0.14.1 // indirect
@@ -111,7 +113,6 @@ require (
 	golang.org/x/arch v0.0.0-20210923205945-b76863e36670 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	google.golang.org/protobuf v1.33.0 // indirect
 	gopkg.in/jcmturner/aescts.v1 v1.0.1 // indirect
 	gopkg.in/jcmturner/dnsutils.v1 v1.0.1 // indirect
 	gopkg.in/jcmturner/gokrb5.v7 v7.5.0 // indirect
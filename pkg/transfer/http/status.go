@@ -12,7 +12,9 @@ package http
 import (
 	"net/http"
 	"os"
+	"strconv"
 
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/cardinality"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 )
@@ -38,7 +40,23 @@ func SettingsView(writer http.ResponseWriter, request *http.Request) {
 	WriteJSONResponse(http.StatusOK, writer, config.Configuration.AllSettings())
 }
 
+// CardinalityView return cardinality guard stats, filter by table and limit top metrics and labels by top
+func CardinalityView(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	top := 10
+	if value := query.Get("top"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(writer, "invalid top: "+value, http.StatusBadRequest)
+			return
+		}
+		top = n
+	}
+	WriteJSONResponse(http.StatusOK, writer, cardinality.Snapshot(query.Get("table"), top))
+}
+
 func init() {
 	http.HandleFunc("/status/process", ProcessView)
 	http.HandleFunc("/status/settings", SettingsView)
+	http.HandleFunc("/status/cardinality", CardinalityView)
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
@@ -162,6 +163,7 @@ type TagCheckProcessor struct {
 	*define.ProcessorMonitor
 	ClusterType string
 	storage     TagStorage
+	deadLetter  *deadletter.Reporter
 }
 
 func makeTagAsKey(record *Record) []string {
@@ -207,6 +209,7 @@ func (p *TagCheckProcessor) Process(payload define.Payload, outputChan chan<- de
 	if err != nil {
 		p.CounterFails.Inc()
 		logging.Warnf("%v error %v dropped payload %+v", p, err, payload)
+		p.deadLetter.Report(deadletter.StageETL, p.String(), payload, err)
 		return
 	}
 
@@ -221,6 +224,7 @@ func (p *TagCheckProcessor) Process(payload define.Payload, outputChan chan<- de
 	if !ok {
 		// TODO send a custom event
 		logging.Warnf("%s has too much series", p)
+		p.deadLetter.Report(deadletter.StageETL, p.String(), payload, errors.Wrapf(define.ErrValue, "too much series"))
 		return
 	}
 
@@ -236,6 +240,7 @@ func NewTagCheckProcessor(ctx context.Context, name string) *TagCheckProcessor {
 		BaseDataProcessor: define.NewBaseDataProcessor(name),
 		ProcessorMonitor:  pipeline.NewDataProcessorMonitor(name, config.PipelineConfigFromContext(ctx)),
 		ClusterType:       clusterType,
+		deadLetter:        deadletter.FromContext(ctx),
 	}
 
 	pipelineConfig := config.PipelineConfigFromContext(ctx)
@@ -36,7 +36,11 @@ func (b *LogConfigBuilder) GetStandardProcessors(etl string, pipe *config.Pipeli
 		processors = append(processors, "encoding")
 	}
 
-	processors = append(processors, etl, "log_format")
+	processors = append(processors, etl)
+	if hasScriptConfig(rt) {
+		processors = append(processors, "script_processor")
+	}
+	processors = append(processors, "log_format")
 
 	return processors
 }
@@ -68,6 +68,25 @@ func (s *LogConfigBuilderSuite) TestGetStandardProcessors() {
 			[]string{},
 			[]string{"encoding"},
 		},
+		{
+			stdPipe, stdTable,
+			[]string{},
+			[]string{"script_processor"},
+		},
+		{
+			stdPipe,
+			config.MetaResultTableConfig{
+				ResultTable: "test",
+				SchemaType:  config.ResultTableSchemaTypeFree,
+				Option: map[string]interface{}{
+					config.ResultTableOptScriptConfig: map[string]interface{}{
+						"rules": []interface{}{map[string]interface{}{"action": "drop", "field": "metrics.x"}},
+					},
+				},
+			},
+			[]string{"script_processor", "log_format"},
+			[]string{},
+		},
 	}
 
 	for i, c := range cases {
@@ -48,6 +48,9 @@ func (b *TSConfigBuilder) GetStandardPrepareProcessors(pipe *config.PipelineConf
 	if rtOption.GetOrDefault(config.ResultTableOptEnableBlackList, false) == true {
 		processors = append(processors, "metrics_reporter")
 	}
+	if hasCardinalityGuard(rt) {
+		processors = append(processors, "cardinality_guard")
+	}
 
 	return processors
 }
@@ -78,6 +81,9 @@ func (b *TSConfigBuilder) GetStandardProcessors(etl string, pipe *config.Pipelin
 	}
 
 	processors = append(processors, etl)
+	if hasScriptConfig(rt) {
+		processors = append(processors, "script_processor")
+	}
 	processors = append(processors, b.GetStandardPrepareProcessors(pipe, rt)...)
 	processors = append(processors, b.GetStandardShipperProcessors(pipe, rt)...)
 
@@ -138,6 +138,19 @@ func (s *TSConfigBuilderSuite) TestGetStandardProcessors() {
 			[]string{},
 			[]string{"encoding"},
 		},
+		{
+			stdPipe,
+			config.MetaResultTableConfig{Option: map[string]interface{}{
+				config.ResultTableOptCardinalityGuard: map[string]interface{}{"max_series": 100},
+			}},
+			[]string{"cardinality_guard"},
+			[]string{},
+		},
+		{
+			stdPipe, stdTable,
+			[]string{},
+			[]string{"cardinality_guard"},
+		},
 	}
 
 	for i, c := range cases {
@@ -156,6 +169,31 @@ func (s *TSConfigBuilderSuite) TestGetStandardProcessors() {
 	}
 }
 
+// TestCardinalityGuardOrder : 序列基数限制需要看到注入后的维度 位于注入节点之后 ts_format 之前
+func (s *TSConfigBuilderSuite) TestCardinalityGuardOrder() {
+	pipe := config.PipelineConfig{Option: map[string]interface{}{}}
+	table := config.MetaResultTableConfig{
+		ResultTable: "test",
+		SchemaType:  config.ResultTableSchemaTypeFree,
+		Option: map[string]interface{}{
+			config.ResultTableOptCardinalityGuard: map[string]interface{}{"max_series": 100},
+		},
+	}
+
+	builder, err := pipeline.NewTSConfigBuilder(s.CTX, "")
+	s.NoError(err)
+	index := make(map[string]int)
+	for i, n := range builder.GetStandardProcessors("", &pipe, &table) {
+		index[n] = i
+	}
+	for _, n := range []string{"cmdb_injector", "group_injector", "cardinality_guard", "ts_format"} {
+		s.Contains(index, n)
+	}
+	s.Less(index["cmdb_injector"], index["cardinality_guard"])
+	s.Less(index["group_injector"], index["cardinality_guard"])
+	s.Less(index["cardinality_guard"], index["ts_format"])
+}
+
 // TestTSConfigBuilderSuite
 func TestTSConfigBuilderSuite(t *testing.T) {
 	suite.Run(t, new(TSConfigBuilderSuite))
@@ -11,6 +11,9 @@ package pipeline
 
 import (
 	"context"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
 )
 
 func sendKillChan(ctx context.Context, killCh chan<- error, err error) bool {
@@ -28,3 +31,21 @@ loop:
 	}
 	return false
 }
+
+// hasScriptConfig : 结果表是否配置了字段转换规则
+func hasScriptConfig(rt *config.MetaResultTableConfig) bool {
+	if rt == nil {
+		return false
+	}
+	value, ok := utils.NewMapHelper(rt.Option).Get(config.ResultTableOptScriptConfig)
+	return ok && value != nil
+}
+
+// hasCardinalityGuard : 结果表是否配置了序列基数限制
+func hasCardinalityGuard(rt *config.MetaResultTableConfig) bool {
+	if rt == nil {
+		return false
+	}
+	value, ok := utils.NewMapHelper(rt.Option).Get(config.ResultTableOptCardinalityGuard)
+	return ok && value != nil
+}
@@ -0,0 +1,474 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/cstockton/go-conv"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/etl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// BackendName :
+const BackendName = "remotewrite"
+
+const (
+	// MetricNameLabel : 指标名 label
+	MetricNameLabel = "__name__"
+
+	userAgent          = "bkmonitor-transfer"
+	remoteWriteVersion = "0.1.0"
+)
+
+// NewHTTPClient :
+var NewHTTPClient = func(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout}
+}
+
+// sanitizeName : 将非法字符替换为下划线 保证符合 prometheus 命名规范
+func sanitizeName(name string, allowColon bool) string {
+	if name == "" {
+		return name
+	}
+
+	var b strings.Builder
+	b.Grow(len(name) + 1)
+	for i, r := range name {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		case r == ':' && allowColon:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// labelValue : 维度值统一转换为字符串
+func labelValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return conv.String(value)
+	}
+}
+
+// isRetryable : 5xx 及 429 可以重试 其余 4xx 重试也无意义
+func isRetryable(code int) bool {
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// statusError :
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("remote write response status %d: %s", e.code, e.body)
+}
+
+// checkpoint : 与 kafka 前端的 offset 提交语义一致 只有向前推进时才提交
+// 仅被远端确认写入的数据才会计入 acked 写入失败的数据不会推进 checkpoint
+// 自上次提交以来存在写入失败时 本次提交返回错误且不推进 使调用方保留 offset
+type checkpoint struct {
+	lock      sync.Mutex
+	acked     int64
+	failed    int64
+	committed int64
+}
+
+func (c *checkpoint) ack(n int) {
+	c.lock.Lock()
+	c.acked += int64(n)
+	c.lock.Unlock()
+}
+
+func (c *checkpoint) fail(n int) {
+	c.lock.Lock()
+	c.failed += int64(n)
+	c.lock.Unlock()
+}
+
+// commit : 返回本次推进的数量 存在写入失败时返回错误
+func (c *checkpoint) commit() (int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.failed > 0 {
+		failed := c.failed
+		c.failed = 0
+		return 0, errors.Wrapf(define.ErrOperationForbidden, "%d results failed to flush since last commit", failed)
+	}
+
+	forward := c.acked - c.committed
+	c.committed = c.acked
+	return forward, nil
+}
+
+// BulkHandler
+type BulkHandler struct {
+	pipeline.BaseBulkHandler
+	cli                *http.Client
+	url                string
+	headers            map[string]string
+	userName           string
+	passWord           string
+	metricPrefix       string
+	isSplitMeasurement bool
+	disabledMetrics    map[string]struct{}
+	disabledDimensions map[string]struct{}
+	maxRetries         int
+	minBackoff         time.Duration
+	maxBackoff         time.Duration
+	checkpoint         checkpoint
+	retriedCounter     *prometheus.CounterVec
+	seriesCounter      prometheus.Counter
+}
+
+// metricName : 单指标单表时直接使用指标名 否则以表名作为前缀
+func (b *BulkHandler) metricName(name string) string {
+	if b.isSplitMeasurement || b.metricPrefix == "" {
+		return sanitizeName(name, true)
+	}
+	return sanitizeName(b.metricPrefix+"_"+name, true)
+}
+
+// labels : 维度转换为 label 非法字符替换后同名的维度只保留一个 空名及保留名直接丢弃
+// 冲突时优先保留原名即合法的维度 否则保留原名字典序最小的 保证结果稳定
+func (b *BulkHandler) labels(dimensions map[string]interface{}) []Label {
+	keys := make(map[string]string, len(dimensions))
+	values := make(map[string]string, len(dimensions))
+	for key, value := range dimensions {
+		if _, ok := b.disabledDimensions[key]; ok {
+			continue
+		}
+		// 空值与不存在该 label 等价
+		v := labelValue(value)
+		if v == "" {
+			continue
+		}
+		name := sanitizeName(key, false)
+		if name == "" || name == MetricNameLabel {
+			logging.Debugf("%v skip dimension %q for invalid label name", b, key)
+			continue
+		}
+		if exists, ok := keys[name]; ok {
+			if exists == name || (key != name && exists < key) {
+				logging.Debugf("%v skip dimension %q for label %s conflicts with %q", b, key, name, exists)
+				continue
+			}
+			logging.Debugf("%v skip dimension %q for label %s conflicts with %q", b, exists, name, key)
+		}
+		keys[name] = key
+		values[name] = v
+	}
+
+	labels := make([]Label, 0, len(values)+1)
+	for name, v := range values {
+		labels = append(labels, Label{Name: name, Value: v})
+	}
+	return labels
+}
+
+// Handle : 将 ETLRecord 转换为时间序列 每个指标一条
+func (b *BulkHandler) Handle(ctx context.Context, payload define.Payload, killChan chan<- error) (result interface{}, at time.Time, ok bool) {
+	var record define.ETLRecord
+	err := payload.To(&record)
+	if err != nil {
+		logging.Warnf("%v error %v dropped payload %+v", b, err, payload)
+		return nil, time.Time{}, false
+	}
+	if record.Time == nil {
+		logging.Warnf("%v dropped payload %+v for time is empty", b, payload)
+		return nil, time.Time{}, false
+	}
+	ts := utils.ParseTimeStamp(*record.Time)
+
+	labels := b.labels(record.Dimensions)
+
+	series := make([]TimeSeries, 0, len(record.Metrics))
+	for key, value := range record.Metrics {
+		if _, ok := b.disabledMetrics[key]; ok {
+			continue
+		}
+		v, err := etl.TransformNilFloat64(value)
+		if err != nil || v == nil {
+			logging.Debugf("%v skip metric %s value %v: %v", b, key, value, err)
+			continue
+		}
+
+		item := TimeSeries{
+			Labels:  make([]Label, 0, len(labels)+1),
+			Samples: []Sample{{Value: v.(float64), Timestamp: ts.UnixNano() / int64(time.Millisecond)}},
+		}
+		item.Labels = append(item.Labels, Label{Name: MetricNameLabel, Value: b.metricName(key)})
+		item.Labels = append(item.Labels, labels...)
+		item.SortLabels()
+		series = append(series, item)
+	}
+
+	if len(series) == 0 {
+		logging.Warnf("%v dropped payload %+v for metric is empty", b, payload)
+		return nil, time.Time{}, false
+	}
+
+	return series, ts, true
+}
+
+// send : 发送单个请求 返回的 statusError 用于判断是否需要重试
+func (b *BulkHandler) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+	for k, v := range b.headers {
+		req.Header.Set(k, v)
+	}
+	if b.userName != "" || b.passWord != "" {
+		req.SetBasicAuth(b.userName, b.passWord)
+	}
+
+	resp, err := b.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &statusError{code: resp.StatusCode, body: string(msg)}
+}
+
+// Flush : 合并为一个 WriteRequest 发送 失败时指数退避重试
+func (b *BulkHandler) Flush(ctx context.Context, results []interface{}) (int, error) {
+	count := len(results)
+
+	req := WriteRequest{Timeseries: make([]TimeSeries, 0, count)}
+	for _, value := range results {
+		req.Timeseries = append(req.Timeseries, value.([]TimeSeries)...)
+	}
+	body := snappy.Encode(nil, req.Marshal())
+
+	bf := backoff.NewExponentialBackOff()
+	bf.InitialInterval = b.minBackoff
+	bf.MaxInterval = b.maxBackoff
+	bf.MaxElapsedTime = 0
+
+	var attempts int
+	err := backoff.RetryNotify(func() error {
+		attempts++
+		err := b.send(ctx, body)
+		if e, ok := err.(*statusError); ok && !isRetryable(e.code) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithContext(backoff.WithMaxRetries(bf, uint64(b.maxRetries)), ctx), func(err error, next time.Duration) {
+		code := "unknown"
+		if e, ok := err.(*statusError); ok {
+			code = strconv.Itoa(e.code)
+		}
+		b.retriedCounter.WithLabelValues(code).Inc()
+		logging.Warnf("%v retry to push %d series after %v because of error %v", b, len(req.Timeseries), next, err)
+	})
+
+	// 写入失败时不推进 checkpoint 由 BulkBackendAdapter 记录失败数
+	if err != nil {
+		b.checkpoint.fail(count)
+		return 0, errors.WithMessagef(err, "%v push %d series after %d attempts", b, len(req.Timeseries), attempts)
+	}
+
+	logging.Debugf("%v pushed %d series", b, len(req.Timeseries))
+	b.seriesCounter.Add(float64(len(req.Timeseries)))
+	b.checkpoint.ack(count)
+	return count, nil
+}
+
+// Close :
+func (b *BulkHandler) Close() error {
+	b.cli.CloseIdleConnections()
+	return nil
+}
+
+// isDisabledField : 复用 influxdb 的字段禁用配置 两者写入的都是时序存储
+func isDisabledField(field *config.MetaFieldConfig) bool {
+	value, ok := utils.NewMapHelper(field.Option).GetBool(config.MetaFieldOptInfluxDisabled)
+	return ok && value
+}
+
+func fieldNameSet(fields []*config.MetaFieldConfig) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, field := range fields {
+		set[field.Name()] = struct{}{}
+	}
+	return set
+}
+
+// NewBulkHandler
+func NewBulkHandler(ctx context.Context, rt *config.MetaResultTableConfig, shipper *config.MetaClusterInfo) (*BulkHandler, error) {
+	conf := config.FromContext(ctx)
+	cluster := shipper.AsRemoteWriteCluster()
+
+	auth := config.NewAuthInfo(shipper)
+	userName, err := auth.GetUserName()
+	if err != nil {
+		logging.Debugf("%v may not establish connection %v: username", cluster.GetURL(), define.ErrGetAuth)
+	}
+	passWord, err := auth.GetPassword()
+	if err != nil {
+		logging.Debugf("%v may not establish connection %v: password", cluster.GetURL(), define.ErrGetAuth)
+	}
+
+	var disabledMetrics, disabledDimensions []*config.MetaFieldConfig
+	err = rt.VisitFieldByTag(func(field *config.MetaFieldConfig) error {
+		if isDisabledField(field) {
+			disabledMetrics = append(disabledMetrics, field)
+		}
+		return nil
+	}, func(field *config.MetaFieldConfig) error {
+		if isDisabledField(field) {
+			disabledDimensions = append(disabledDimensions, field)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	options := utils.NewMapHelper(rt.Option)
+	isSplitMeasurement, _ := options.GetBool(config.ResultTableOptIsSplitMeasurement)
+
+	dataID := "0"
+	if pipe := config.PipelineConfigFromContext(ctx); pipe != nil {
+		dataID = strconv.Itoa(pipe.DataID)
+	}
+
+	logging.Infof("remote write %s connect to %s", rt.ResultTable, cluster.GetURL())
+	return &BulkHandler{
+		cli:                NewHTTPClient(conf.GetDuration(ConfRemoteWriteTimeout)),
+		url:                cluster.GetURL(),
+		headers:            cluster.GetHeaders(),
+		userName:           userName,
+		passWord:           passWord,
+		metricPrefix:       cluster.GetMetricPrefix(),
+		isSplitMeasurement: isSplitMeasurement,
+		disabledMetrics:    fieldNameSet(disabledMetrics),
+		disabledDimensions: fieldNameSet(disabledDimensions),
+		maxRetries:         conf.GetInt(ConfRemoteWriteMaxRetries),
+		minBackoff:         conf.GetDuration(ConfRemoteWriteMinBackoff),
+		maxBackoff:         conf.GetDuration(ConfRemoteWriteMaxBackoff),
+		retriedCounter:     MonitorBackendRetried.MustCurryWith(prometheus.Labels{"id": dataID}),
+		seriesCounter:      MonitorBackendSeries.With(prometheus.Labels{"id": dataID}),
+	}, nil
+}
+
+// Backend :
+type Backend struct {
+	*pipeline.BulkBackendAdapter
+	handler          *BulkHandler
+	committedCounter prometheus.Counter
+}
+
+// Commit : 推进已确认写入的 checkpoint 存在写入失败时返回错误
+func (b *Backend) Commit() error {
+	forward, err := b.handler.checkpoint.commit()
+	if err != nil {
+		logging.Warnf("%v hold back checkpoint: %v", b, err)
+		return err
+	}
+	if forward > 0 {
+		b.committedCounter.Inc()
+		logging.Debugf("%v committed %d results", b, forward)
+	}
+	return nil
+}
+
+// NewBackend :
+func NewBackend(ctx context.Context, name string, maxQps int) (*Backend, error) {
+	handler, err := NewBulkHandler(
+		ctx,
+		config.ResultTableConfigFromContext(ctx),
+		config.ShipperConfigFromContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backend{
+		BulkBackendAdapter: pipeline.NewBulkBackendDefaultAdapter(ctx, name, handler, maxQps),
+		handler:            handler,
+		committedCounter: MonitorBackendCommitted.With(prometheus.Labels{
+			"id": strconv.Itoa(config.PipelineConfigFromContext(ctx).DataID),
+		}),
+	}, nil
+}
+
+func init() {
+	define.RegisterBackend(BackendName, func(ctx context.Context, name string) (define.Backend, error) {
+		if config.FromContext(ctx) == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "config is empty")
+		}
+		if config.ShipperConfigFromContext(ctx) == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "shipper config is empty")
+		}
+		pipeConfig := config.PipelineConfigFromContext(ctx)
+		if pipeConfig == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is empty")
+		}
+		if config.ResultTableConfigFromContext(ctx) == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "resultTable config is empty")
+		}
+
+		options := utils.NewMapHelper(pipeConfig.Option)
+		maxQps, _ := options.GetInt(config.PipelineConfigOptMaxQps)
+		return NewBackend(ctx, pipeConfig.FormatName(name), maxQps)
+	})
+}
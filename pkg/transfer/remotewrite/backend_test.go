@@ -0,0 +1,162 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/remotewrite"
+	. "github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
+)
+
+// BackendSuite :
+type BackendSuite struct {
+	ETLSuite
+	server   *httptest.Server
+	status   []int
+	requests int32
+	received chan remotewrite.WriteRequest
+}
+
+// SetupTest :
+func (s *BackendSuite) SetupTest() {
+	s.ETLSuite.SetupTest()
+	s.status = nil
+	s.requests = 0
+	s.received = make(chan remotewrite.WriteRequest, 10)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&s.requests, 1))
+		s.Equal("snappy", r.Header.Get("Content-Encoding"))
+		s.Equal("tenant", r.Header.Get("X-Scope-OrgID"))
+
+		if n <= len(s.status) {
+			w.WriteHeader(s.status[n-1])
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		s.NoError(err)
+		data, err := snappy.Decode(nil, body)
+		s.NoError(err)
+
+		var req remotewrite.WriteRequest
+		s.NoError(req.Unmarshal(data))
+		s.received <- req
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	remotewrite.InitConfiguration(s.Config)
+	s.Config.Set(remotewrite.ConfRemoteWriteMinBackoff, time.Millisecond)
+	s.Config.Set(remotewrite.ConfRemoteWriteMaxBackoff, 10*time.Millisecond)
+	s.Config.Set(remotewrite.ConfRemoteWriteMaxRetries, 2)
+
+	config.NewAuthInfo(s.ShipperConfig).SetUserName("")
+	config.NewAuthInfo(s.ShipperConfig).SetPassword("")
+	cluster := s.ShipperConfig.AsRemoteWriteCluster()
+	cluster.SetURL(s.server.URL + "/api/v1/write")
+	cluster.SetHeaders(map[string]string{"X-Scope-OrgID": "tenant"})
+	cluster.SetMetricPrefix("cpu_summary")
+
+	s.Stubs.Stub(&pipeline.BulkDefaultBufferSize, 1)
+	s.Stubs.Stub(&pipeline.BulkDefaultFlushInterval, time.Millisecond)
+}
+
+// TearDownTest :
+func (s *BackendSuite) TearDownTest() {
+	s.server.Close()
+	s.ETLSuite.TearDownTest()
+}
+
+func (s *BackendSuite) push(data string) *remotewrite.Backend {
+	backend, err := remotewrite.NewBackend(s.CTX, "test", 0)
+	s.NoError(err)
+	s.CheckKillChan(s.KillCh)
+	backend.Push(define.NewJSONPayloadFrom([]byte(data), 0), s.KillCh)
+	return backend
+}
+
+// TestPushData : 测试写入的时间序列内容
+func (s *BackendSuite) TestPushData() {
+	backend := s.push(`{"time":1547616480,"dimensions":{"ip":"127.0.0.1","bk_biz_id":2,"empty":""},"metrics":{"usage":1.5,"idle":null}}`)
+
+	req := <-s.received
+	s.Len(req.Timeseries, 1)
+	s.Equal([]remotewrite.Label{
+		{Name: "__name__", Value: "cpu_summary_usage"},
+		{Name: "bk_biz_id", Value: "2"},
+		{Name: "ip", Value: "127.0.0.1"},
+	}, req.Timeseries[0].Labels)
+	s.Equal([]remotewrite.Sample{{Value: 1.5, Timestamp: 1547616480000}}, req.Timeseries[0].Samples)
+
+	s.NoError(backend.Close())
+	s.NoError(backend.Commit())
+}
+
+// TestRetry : 测试 5xx 时退避重试
+func (s *BackendSuite) TestRetry() {
+	s.status = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	backend := s.push(`{"time":1547616480,"dimensions":{"ip":"127.0.0.1"},"metrics":{"usage":1}}`)
+
+	req := <-s.received
+	s.Len(req.Timeseries, 1)
+	s.Equal(int32(3), atomic.LoadInt32(&s.requests))
+
+	s.NoError(backend.Close())
+	s.NoError(backend.Commit())
+}
+
+// TestPermanentError : 测试 4xx 时不做退避重试 返回错误交由 BulkBackendAdapter 重新写入
+// 存在写入失败时 checkpoint 不推进 下一次提交恢复
+func (s *BackendSuite) TestPermanentError() {
+	s.status = []int{http.StatusBadRequest}
+	backend := s.push(`{"time":1547616480,"dimensions":{"ip":"127.0.0.1"},"metrics":{"usage":1}}`)
+
+	req := <-s.received
+	s.Len(req.Timeseries, 1)
+	s.Equal(int32(2), atomic.LoadInt32(&s.requests))
+
+	s.NoError(backend.Close())
+	s.Error(backend.Commit())
+	s.NoError(backend.Commit())
+}
+
+// TestLabelConflict : 测试非法字符替换后同名的维度只保留一个 空名维度直接丢弃
+func (s *BackendSuite) TestLabelConflict() {
+	for i := 0; i < 10; i++ {
+		backend := s.push(`{"time":1547616480,"dimensions":{"a.b":"x","a_b":"y","c-d":"z","c.d":"w","":"v","__name__":"n"},"metrics":{"usage":1}}`)
+
+		req := <-s.received
+		s.Len(req.Timeseries, 1)
+		s.Equal([]remotewrite.Label{
+			{Name: "__name__", Value: "cpu_summary_usage"},
+			{Name: "a_b", Value: "y"},
+			{Name: "c_d", Value: "z"},
+		}, req.Timeseries[0].Labels)
+
+		s.NoError(backend.Close())
+		s.NoError(backend.Commit())
+	}
+}
+
+// TestBackendSuite :
+func TestBackendSuite(t *testing.T) {
+	suite.Run(t, new(BackendSuite))
+}
@@ -0,0 +1,47 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+var (
+	// MonitorBackendRetried remote write 重试计数器
+	MonitorBackendRetried = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "remotewrite_backend_retried_total",
+		Help:      "Count of remote write requests retried",
+	}, []string{"id", "code"})
+
+	// MonitorBackendSeries remote write 写入的时间序列数
+	MonitorBackendSeries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "remotewrite_backend_series_total",
+		Help:      "Count of time series written by remote write backend",
+	}, []string{"id"})
+
+	// MonitorBackendCommitted remote write 提交计数器
+	MonitorBackendCommitted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "remotewrite_backend_commit_total",
+		Help:      "Remote write backend commits count",
+	}, []string{"id"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		MonitorBackendRetried,
+		MonitorBackendSeries,
+		MonitorBackendCommitted,
+	)
+}
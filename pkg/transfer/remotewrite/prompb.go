@@ -0,0 +1,189 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Label : 与 prompb.Label 保持一致
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample : 与 prompb.Sample 保持一致 Timestamp 为毫秒
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries : 与 prompb.TimeSeries 保持一致
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// SortLabels : remote write 协议要求 label 按名称升序排列
+func (ts *TimeSeries) SortLabels() {
+	sort.Slice(ts.Labels, func(i, j int) bool {
+		return ts.Labels[i].Name < ts.Labels[j].Name
+	})
+}
+
+// WriteRequest : 与 prompb.WriteRequest 保持一致 仅包含 timeseries 字段
+// 为了避免引入 prometheus 主仓库依赖 这里直接按 protobuf wire 格式编码
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+// prompb 字段编号
+const (
+	writeRequestTimeseries = 1
+
+	timeSeriesLabels  = 1
+	timeSeriesSamples = 2
+
+	labelFieldName  = 1
+	labelFieldValue = 2
+
+	sampleValue     = 1
+	sampleTimestamp = 2
+)
+
+func appendLabel(b []byte, l Label) []byte {
+	var buf []byte
+	if l.Name != "" {
+		buf = protowire.AppendTag(buf, labelFieldName, protowire.BytesType)
+		buf = protowire.AppendString(buf, l.Name)
+	}
+	if l.Value != "" {
+		buf = protowire.AppendTag(buf, labelFieldValue, protowire.BytesType)
+		buf = protowire.AppendString(buf, l.Value)
+	}
+	b = protowire.AppendTag(b, timeSeriesLabels, protowire.BytesType)
+	return protowire.AppendBytes(b, buf)
+}
+
+func appendSample(b []byte, s Sample) []byte {
+	var buf []byte
+	if bits := math.Float64bits(s.Value); bits != 0 {
+		buf = protowire.AppendTag(buf, sampleValue, protowire.Fixed64Type)
+		buf = protowire.AppendFixed64(buf, bits)
+	}
+	if s.Timestamp != 0 {
+		buf = protowire.AppendTag(buf, sampleTimestamp, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(s.Timestamp))
+	}
+	b = protowire.AppendTag(b, timeSeriesSamples, protowire.BytesType)
+	return protowire.AppendBytes(b, buf)
+}
+
+func appendTimeSeries(b []byte, ts TimeSeries) []byte {
+	var buf []byte
+	for _, l := range ts.Labels {
+		buf = appendLabel(buf, l)
+	}
+	for _, s := range ts.Samples {
+		buf = appendSample(buf, s)
+	}
+	b = protowire.AppendTag(b, writeRequestTimeseries, protowire.BytesType)
+	return protowire.AppendBytes(b, buf)
+}
+
+// Marshal : 编码为 protobuf 二进制
+func (r *WriteRequest) Marshal() []byte {
+	var b []byte
+	for _, ts := range r.Timeseries {
+		b = appendTimeSeries(b, ts)
+	}
+	return b
+}
+
+// Unmarshal : 解码 protobuf 二进制 主要用于测试及排障
+func (r *WriteRequest) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if num != writeRequestTimeseries || typ != protowire.BytesType {
+			return nil
+		}
+		var ts TimeSeries
+		err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+			switch {
+			case num == timeSeriesLabels && typ == protowire.BytesType:
+				var l Label
+				err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+					switch num {
+					case labelFieldName:
+						l.Name = string(v)
+					case labelFieldValue:
+						l.Value = string(v)
+					}
+					return nil
+				})
+				ts.Labels = append(ts.Labels, l)
+				return err
+			case num == timeSeriesSamples && typ == protowire.BytesType:
+				var s Sample
+				err := consumeFields(v, func(num protowire.Number, typ protowire.Type, _ []byte, n uint64) error {
+					switch num {
+					case sampleValue:
+						s.Value = math.Float64frombits(n)
+					case sampleTimestamp:
+						s.Timestamp = int64(n)
+					}
+					return nil
+				})
+				ts.Samples = append(ts.Samples, s)
+				return err
+			}
+			return nil
+		})
+		r.Timeseries = append(r.Timeseries, ts)
+		return err
+	})
+}
+
+// consumeFields : 遍历消息字段 bytes 类型通过 v 返回 数值类型通过 n 返回
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		var (
+			v []byte
+			n uint64
+		)
+		switch typ {
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		if err := fn(num, typ, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
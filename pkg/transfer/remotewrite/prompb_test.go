@@ -0,0 +1,58 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRequestMarshal(t *testing.T) {
+	req := WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels: []Label{
+					{Name: "job", Value: "transfer"},
+					{Name: "__name__", Value: "cpu_summary_usage"},
+				},
+				Samples: []Sample{
+					{Value: 12.5, Timestamp: 1700000000000},
+					{Value: 0, Timestamp: 1700000060000},
+				},
+			},
+			{
+				Labels:  []Label{{Name: "__name__", Value: "up"}},
+				Samples: []Sample{{Value: -1, Timestamp: 1}},
+			},
+		},
+	}
+	req.Timeseries[0].SortLabels()
+	assert.Equal(t, "__name__", req.Timeseries[0].Labels[0].Name)
+
+	var result WriteRequest
+	assert.NoError(t, result.Unmarshal(req.Marshal()))
+	assert.Equal(t, req, result)
+}
+
+func TestSanitizeName(t *testing.T) {
+	cases := map[string]string{
+		"usage":        "usage",
+		"cpu.usage":    "cpu_usage",
+		"1m_load":      "_1m_load",
+		"disk:io-util": "disk:io_util",
+		"bk_target_ip": "bk_target_ip",
+		"中文":           "__",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, sanitizeName(input, true), input)
+	}
+	assert.Equal(t, "disk_io_util", sanitizeName("disk:io-util", false))
+}
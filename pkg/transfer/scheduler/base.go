@@ -16,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
 )
@@ -46,6 +47,8 @@ type PipelineItem struct {
 	Pipeline define.Pipeline
 	Config   *config.PipelineConfig
 	KillChan <-chan error
+	// DeadLetter 未配置死信时为 nil
+	DeadLetter *deadletter.Reporter
 }
 
 // IsAlive :
@@ -83,6 +86,11 @@ func (i *PipelineItem) errorf(message string, v ...interface{}) error {
 
 // Terminate : stop and wait pipeline
 func (i *PipelineItem) Terminate(timeout time.Duration) error {
+	// 流水线退出后才关闭死信 关闭后的上报会被直接丢弃
+	defer func() {
+		logging.WarnIf("close dead letter error", i.DeadLetter.Close())
+	}()
+
 	timeout = timeout / 2
 	err := i.Stop(timeout)
 	if err != nil {
@@ -17,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
@@ -121,13 +122,23 @@ func (p *PipelineManager) activate(ctx context.Context, conf *config.PipelineCon
 	subCtx := config.PipelineConfigIntoContext(ctx, conf)
 	subCtx = config.MQConfigIntoContext(subCtx, conf.MQConfig)
 	pipeCtx, cancel := context.WithCancel(subCtx)
+
+	// 死信配置异常不影响流水线启动
+	reporter, err := deadletter.NewReporter(pipeCtx)
+	if err != nil {
+		logging.Warnf("create dead letter for pipeline %v failed: %v", conf.DataID, err)
+	}
+	pipeCtx = deadletter.IntoContext(pipeCtx, reporter)
+
 	pipeline, err := define.NewPipeline(pipeCtx, conf.ETLConfig)
 	if err != nil {
 		cancel()
+		logging.WarnIf("close dead letter error", reporter.Close())
 		return errors.Wrapf(err, "create pipeline %v failed", conf.DataID)
 	}
 
 	item := NewPipelineItem(subCtx, cancel, conf, pipeline)
+	item.DeadLetter = reporter
 	err = p.UpdateE(func() error {
 		_, ok := p.pipelines.Get(conf.DataID)
 		if ok {
@@ -0,0 +1,182 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+func argsString(name string, args []interface{}, count int) ([]string, error) {
+	if len(args) != count {
+		return nil, errors.Wrapf(define.ErrValue, "%s expects %d arguments but got %d", name, count, len(args))
+	}
+	results := make([]string, count)
+	for i, arg := range args {
+		value, ok := arg.(string)
+		if !ok {
+			return nil, errors.Wrapf(define.ErrType, "%s expects string arguments but got %T", name, arg)
+		}
+		results[i] = value
+	}
+	return results, nil
+}
+
+// clampIndex : 将浮点数下标限制在 [0, max] 内 避免超大值及 NaN 转换为 int 时溢出
+func clampIndex(v float64, max int) int {
+	if !(v > 0) {
+		return 0
+	}
+	if v >= float64(max) {
+		return max
+	}
+	return int(v)
+}
+
+func stringFunction(name string, count int, fn func(args []string) interface{}) govaluate.ExpressionFunction {
+	return func(args ...interface{}) (interface{}, error) {
+		values, err := argsString(name, args, count)
+		if err != nil {
+			return nil, err
+		}
+		return fn(values), nil
+	}
+}
+
+// newFunctions : 表达式可用的函数 只提供无副作用的函数 返回的字符串长度受 maxLength 限制
+func newFunctions(maxLength int) map[string]govaluate.ExpressionFunction {
+	limit := func(name string, value string) (interface{}, error) {
+		if len(value) > maxLength {
+			return nil, errors.Wrapf(define.ErrValue, "%s result length %d exceeds %d", name, len(value), maxLength)
+		}
+		return value, nil
+	}
+
+	return map[string]govaluate.ExpressionFunction{
+		"lower": stringFunction("lower", 1, func(args []string) interface{} {
+			return strings.ToLower(args[0])
+		}),
+		"upper": stringFunction("upper", 1, func(args []string) interface{} {
+			return strings.ToUpper(args[0])
+		}),
+		"trim": stringFunction("trim", 1, func(args []string) interface{} {
+			return strings.TrimSpace(args[0])
+		}),
+		"contains": stringFunction("contains", 2, func(args []string) interface{} {
+			return strings.Contains(args[0], args[1])
+		}),
+		"startswith": stringFunction("startswith", 2, func(args []string) interface{} {
+			return strings.HasPrefix(args[0], args[1])
+		}),
+		"endswith": stringFunction("endswith", 2, func(args []string) interface{} {
+			return strings.HasSuffix(args[0], args[1])
+		}),
+		"replace": func(args ...interface{}) (interface{}, error) {
+			values, err := argsString("replace", args, 3)
+			if err != nil {
+				return nil, err
+			}
+			// 提前计算结果长度 避免生成超长字符串
+			count := strings.Count(values[0], values[1])
+			if values[1] != "" && len(values[0])+count*(len(values[2])-len(values[1])) > maxLength {
+				return nil, errors.Wrapf(define.ErrValue, "replace result length exceeds %d", maxLength)
+			}
+			return limit("replace", strings.ReplaceAll(values[0], values[1], values[2]))
+		},
+		"substr": func(args ...interface{}) (interface{}, error) {
+			if len(args) != 2 && len(args) != 3 {
+				return nil, errors.Wrapf(define.ErrValue, "substr expects 2 or 3 arguments but got %d", len(args))
+			}
+			value, ok := args[0].(string)
+			if !ok {
+				return nil, errors.Wrapf(define.ErrType, "substr expects string but got %T", args[0])
+			}
+			start, ok := args[1].(float64)
+			if !ok {
+				return nil, errors.Wrapf(define.ErrType, "substr expects number but got %T", args[1])
+			}
+			begin := clampIndex(start, len(value))
+			end := len(value)
+			if len(args) == 3 {
+				length, ok := args[2].(float64)
+				if !ok {
+					return nil, errors.Wrapf(define.ErrType, "substr expects number but got %T", args[2])
+				}
+				// 负数长度表示截取到末尾
+				if length >= 0 {
+					end = begin + clampIndex(length, end-begin)
+				}
+			}
+			return value[begin:end], nil
+		},
+		"len": func(args ...interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return nil, errors.Wrapf(define.ErrValue, "len expects 1 argument but got %d", len(args))
+			}
+			switch value := args[0].(type) {
+			case string:
+				return float64(len(value)), nil
+			case []interface{}:
+				return float64(len(value)), nil
+			case map[string]interface{}:
+				return float64(len(value)), nil
+			case nil:
+				return 0.0, nil
+			default:
+				return nil, errors.Wrapf(define.ErrType, "len not supported for %T", value)
+			}
+		},
+		"str": func(args ...interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return nil, errors.Wrapf(define.ErrValue, "str expects 1 argument but got %d", len(args))
+			}
+			switch value := args[0].(type) {
+			case string:
+				return value, nil
+			case float64:
+				return strconv.FormatFloat(value, 'f', -1, 64), nil
+			case nil:
+				return "", nil
+			default:
+				return limit("str", fmt.Sprintf("%v", value))
+			}
+		},
+		"num": func(args ...interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return nil, errors.Wrapf(define.ErrValue, "num expects 1 argument but got %d", len(args))
+			}
+			switch value := args[0].(type) {
+			case float64:
+				return value, nil
+			case bool:
+				if value {
+					return 1.0, nil
+				}
+				return 0.0, nil
+			case string:
+				return strconv.ParseFloat(strings.TrimSpace(value), 64)
+			default:
+				return nil, errors.Wrapf(define.ErrType, "num not supported for %T", value)
+			}
+		},
+		"isnull": func(args ...interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return nil, errors.Wrapf(define.ErrValue, "isnull expects 1 argument but got %d", len(args))
+			}
+			return args[0] == nil, nil
+		},
+	}
+}
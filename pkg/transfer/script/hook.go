@@ -0,0 +1,51 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package script
+
+import (
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/eventbus"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+const (
+	// ConfScriptTimeout : 单条记录执行全部规则的耗时上限
+	ConfScriptTimeout = "script.processor.timeout"
+	// ConfScriptMaxFields : 单条记录字段数上限
+	ConfScriptMaxFields = "script.processor.max_fields"
+	// ConfScriptMaxValueLength : 计算得到的字符串长度上限
+	ConfScriptMaxValueLength = "script.processor.max_value_length"
+	// ConfScriptMaxRecords : 单条记录拆分后的记录数上限
+	ConfScriptMaxRecords = "script.processor.max_records"
+)
+
+// InitConfiguration :
+func InitConfiguration(c define.Configuration) {
+	c.SetDefault(ConfScriptTimeout, 10*time.Millisecond)
+	c.SetDefault(ConfScriptMaxFields, 1024)
+	c.SetDefault(ConfScriptMaxValueLength, 64*1024)
+	c.SetDefault(ConfScriptMaxRecords, 1000)
+}
+
+// NewConfig : 使用全局配置作为默认限制
+func NewConfig(c define.Configuration) *Config {
+	return &Config{
+		Timeout:        c.GetDuration(ConfScriptTimeout).String(),
+		MaxFields:      c.GetInt(ConfScriptMaxFields),
+		MaxValueLength: c.GetInt(ConfScriptMaxValueLength),
+		MaxRecords:     c.GetInt(ConfScriptMaxRecords),
+	}
+}
+
+func init() {
+	utils.CheckError(eventbus.Subscribe(eventbus.EvSysConfigPreParse, InitConfiguration))
+}
@@ -0,0 +1,136 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package script
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+// Path : 字段路径 如 metrics.usage 表示 metrics 下的 usage 字段 time 表示顶层字段
+type Path struct {
+	Root string
+	Key  string
+}
+
+// String :
+func (p Path) String() string {
+	if p.Key == "" {
+		return p.Root
+	}
+	return p.Root + "." + p.Key
+}
+
+// ParsePath : 只按照第一个 . 切分 字段名本身可以包含 .
+func ParsePath(path string) (Path, error) {
+	parts := strings.SplitN(path, ".", 2)
+	if parts[0] == "" {
+		return Path{}, errors.Wrapf(define.ErrValue, "invalid field path %q", path)
+	}
+	if len(parts) == 1 {
+		return Path{Root: parts[0]}, nil
+	}
+	if parts[1] == "" {
+		return Path{}, errors.Wrapf(define.ErrValue, "invalid field path %q", path)
+	}
+	return Path{Root: parts[0], Key: parts[1]}, nil
+}
+
+// Get :
+func (p Path) Get(record map[string]interface{}) (interface{}, bool) {
+	if p.Key == "" {
+		value, ok := record[p.Root]
+		return value, ok
+	}
+	values, ok := record[p.Root].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := values[p.Key]
+	return value, ok
+}
+
+// Set : 上级不存在时自动创建
+func (p Path) Set(record map[string]interface{}, value interface{}) error {
+	if p.Key == "" {
+		record[p.Root] = value
+		return nil
+	}
+	switch values := record[p.Root].(type) {
+	case map[string]interface{}:
+		values[p.Key] = value
+	case nil:
+		record[p.Root] = map[string]interface{}{p.Key: value}
+	default:
+		return errors.Wrapf(define.ErrType, "%s is not an object", p.Root)
+	}
+	return nil
+}
+
+// Delete :
+func (p Path) Delete(record map[string]interface{}) {
+	if p.Key == "" {
+		delete(record, p.Root)
+		return
+	}
+	values, ok := record[p.Root].(map[string]interface{})
+	if ok {
+		delete(values, p.Key)
+	}
+}
+
+// Clone : 深拷贝记录 拆分时各条记录互不影响
+func Clone(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = Clone(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = Clone(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// CountFields : 统计记录中的字段数 嵌套对象按照其子字段计算
+func CountFields(record map[string]interface{}) int {
+	count := 0
+	for _, value := range record {
+		if values, ok := value.(map[string]interface{}); ok {
+			count += len(values)
+		} else {
+			count++
+		}
+	}
+	return count
+}
+
+// recordParameters : 表达式中的变量 使用 [metrics.usage] 的形式引用 字段不存在时为 nil
+type recordParameters map[string]interface{}
+
+// Get :
+func (p recordParameters) Get(name string) (interface{}, error) {
+	path, err := ParsePath(name)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := path.Get(p)
+	return value, nil
+}
@@ -0,0 +1,315 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package script
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+// 规则动作
+const (
+	// ActionSet : 计算表达式并写入字段
+	ActionSet = "set"
+	// ActionRename : 重命名字段
+	ActionRename = "rename"
+	// ActionDrop : 删除字段
+	ActionDrop = "drop"
+	// ActionSplit : 按照数组或分隔符将一条记录拆分为多条
+	ActionSplit = "split"
+	// ActionFilter : 表达式为 false 时丢弃记录
+	ActionFilter = "filter"
+)
+
+// 表达式不支持循环 单条规则的开销由表达式的 token 数及字段值长度决定
+// 执行过程不可中断 超时只在规则之间检查 因此在编译时限制规则数及表达式复杂度
+const (
+	MaxRules            = 64
+	MaxExpressionLength = 1024
+	MaxExpressionTokens = 256
+)
+
+// RuleConfig : 单条规则配置
+type RuleConfig struct {
+	Action    string `mapstructure:"action" json:"action"`
+	Field     string `mapstructure:"field" json:"field"`
+	Target    string `mapstructure:"target" json:"target"`
+	Expr      string `mapstructure:"expr" json:"expr"`
+	When      string `mapstructure:"when" json:"when"`
+	Separator string `mapstructure:"separator" json:"separator"`
+}
+
+// Config : 脚本配置 未设置的限制项使用全局默认值
+type Config struct {
+	Rules          []*RuleConfig `mapstructure:"rules" json:"rules"`
+	Timeout        string        `mapstructure:"timeout" json:"timeout"`
+	MaxFields      int           `mapstructure:"max_fields" json:"max_fields"`
+	MaxValueLength int           `mapstructure:"max_value_length" json:"max_value_length"`
+	MaxRecords     int           `mapstructure:"max_records" json:"max_records"`
+}
+
+// rule : 编译后的规则
+type rule struct {
+	action    string
+	field     Path
+	target    Path
+	expr      *govaluate.EvaluableExpression
+	when      *govaluate.EvaluableExpression
+	separator string
+}
+
+// Script : 按顺序对记录执行规则
+type Script struct {
+	rules          []*rule
+	timeout        time.Duration
+	maxFields      int
+	maxValueLength int
+	maxRecords     int
+}
+
+func compileExpression(expr string, functions map[string]govaluate.ExpressionFunction) (*govaluate.EvaluableExpression, error) {
+	if len(expr) > MaxExpressionLength {
+		return nil, errors.Wrapf(define.ErrValue, "expression length %d exceeds %d", len(expr), MaxExpressionLength)
+	}
+	expression, err := govaluate.NewEvaluableExpressionWithFunctions(expr, functions)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "compile expression %s", expr)
+	}
+	if count := len(expression.Tokens()); count > MaxExpressionTokens {
+		return nil, errors.Wrapf(define.ErrValue, "expression tokens count %d exceeds %d", count, MaxExpressionTokens)
+	}
+	return expression, nil
+}
+
+func compileRule(conf *RuleConfig, functions map[string]govaluate.ExpressionFunction) (*rule, error) {
+	var err error
+	r := &rule{
+		action:    conf.Action,
+		separator: conf.Separator,
+	}
+
+	switch conf.Action {
+	case ActionSet, ActionRename, ActionDrop, ActionSplit:
+		r.field, err = ParsePath(conf.Field)
+		if err != nil {
+			return nil, err
+		}
+	case ActionFilter:
+	default:
+		return nil, errors.Wrapf(define.ErrValue, "unknown action %q", conf.Action)
+	}
+
+	switch conf.Action {
+	case ActionSet, ActionFilter:
+		if conf.Expr == "" {
+			return nil, errors.Wrapf(define.ErrValue, "%s expr is empty", conf.Action)
+		}
+		r.expr, err = compileExpression(conf.Expr, functions)
+		if err != nil {
+			return nil, err
+		}
+	case ActionRename:
+		r.target, err = ParsePath(conf.Target)
+		if err != nil {
+			return nil, err
+		}
+	case ActionSplit:
+		if r.separator == "" {
+			r.separator = ","
+		}
+	}
+
+	if conf.When != "" {
+		r.when, err = compileExpression(conf.When, functions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// New :
+func New(conf *Config) (*Script, error) {
+	if len(conf.Rules) == 0 {
+		return nil, errors.Wrapf(define.ErrValue, "script rules is empty")
+	}
+	if len(conf.Rules) > MaxRules {
+		return nil, errors.Wrapf(define.ErrValue, "script rules count %d exceeds %d", len(conf.Rules), MaxRules)
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "parse script timeout")
+	}
+	if timeout <= 0 || conf.MaxFields <= 0 || conf.MaxValueLength <= 0 || conf.MaxRecords <= 0 {
+		return nil, errors.Wrapf(define.ErrValue, "script limits should be positive")
+	}
+
+	script := &Script{
+		rules:          make([]*rule, 0, len(conf.Rules)),
+		timeout:        timeout,
+		maxFields:      conf.MaxFields,
+		maxValueLength: conf.MaxValueLength,
+		maxRecords:     conf.MaxRecords,
+	}
+	functions := newFunctions(conf.MaxValueLength)
+	for i, ruleConf := range conf.Rules {
+		r, err := compileRule(ruleConf, functions)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "rule %d", i)
+		}
+		script.rules = append(script.rules, r)
+	}
+	return script, nil
+}
+
+func (s *Script) evaluateBool(expr *govaluate.EvaluableExpression, record map[string]interface{}) (bool, error) {
+	value, err := expr.Eval(recordParameters(record))
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, errors.Wrapf(define.ErrType, "expression %s should return bool but got %T", expr, value)
+	}
+	return result, nil
+}
+
+func (s *Script) split(r *rule, record map[string]interface{}) ([]map[string]interface{}, error) {
+	value, ok := r.field.Get(record)
+	if !ok {
+		return []map[string]interface{}{record}, nil
+	}
+
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case string:
+		for _, item := range strings.Split(v, r.separator) {
+			items = append(items, item)
+		}
+	default:
+		return nil, errors.Wrapf(define.ErrType, "split %s not supported for %T", r.field, value)
+	}
+	if len(items) == 0 {
+		return []map[string]interface{}{record}, nil
+	}
+	if len(items) > s.maxRecords {
+		return nil, errors.Wrapf(define.ErrValue, "split %s into %d records exceeds %d", r.field, len(items), s.maxRecords)
+	}
+
+	results := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		result := record
+		// 最后一条直接复用原记录
+		if i < len(items)-1 {
+			result = Clone(record).(map[string]interface{})
+		}
+		err := r.field.Set(result, item)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// apply : 对单条记录执行规则 返回空列表时表示记录被过滤
+func (s *Script) apply(r *rule, record map[string]interface{}) ([]map[string]interface{}, error) {
+	if r.when != nil {
+		matched, err := s.evaluateBool(r.when, record)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return []map[string]interface{}{record}, nil
+		}
+	}
+
+	switch r.action {
+	case ActionSet:
+		value, err := r.expr.Eval(recordParameters(record))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "set %s", r.field)
+		}
+		if str, ok := value.(string); ok && len(str) > s.maxValueLength {
+			return nil, errors.Wrapf(define.ErrValue, "%s value length %d exceeds %d", r.field, len(str), s.maxValueLength)
+		}
+		err = r.field.Set(record, value)
+		if err != nil {
+			return nil, err
+		}
+	case ActionRename:
+		value, ok := r.field.Get(record)
+		if ok {
+			r.field.Delete(record)
+			err := r.target.Set(record, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case ActionDrop:
+		r.field.Delete(record)
+	case ActionSplit:
+		return s.split(r, record)
+	case ActionFilter:
+		matched, err := s.evaluateBool(r.expr, record)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return nil, nil
+		}
+	}
+	return []map[string]interface{}{record}, nil
+}
+
+// Run : 执行全部规则 record 会被修改 返回空列表时表示记录被过滤
+func (s *Script) Run(record map[string]interface{}) ([]map[string]interface{}, error) {
+	deadline := time.Now().Add(s.timeout)
+	records := []map[string]interface{}{record}
+	for i, r := range s.rules {
+		results := make([]map[string]interface{}, 0, len(records))
+		for _, item := range records {
+			outputs, err := s.apply(r, item)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "rule %d", i)
+			}
+			results = append(results, outputs...)
+			if len(results) > s.maxRecords {
+				return nil, errors.Wrapf(define.ErrValue, "rule %d records count exceeds %d", i, s.maxRecords)
+			}
+
+			// 表达式执行过程不可中断 每条记录执行完单个规则后检查截止时间
+			// 单个表达式的耗时由编译时的 token 数限制及字段值长度限制保证
+			if time.Now().After(deadline) {
+				return nil, errors.Wrapf(define.ErrTimeout, "rule %d exceeds %v", i, s.timeout)
+			}
+		}
+		records = results
+		if len(records) == 0 {
+			return records, nil
+		}
+	}
+
+	for _, item := range records {
+		count := CountFields(item)
+		if count > s.maxFields {
+			return nil, errors.Wrapf(define.ErrValue, "fields count %d exceeds %d", count, s.maxFields)
+		}
+	}
+	return records, nil
+}
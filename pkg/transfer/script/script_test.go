@@ -0,0 +1,168 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package script_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/script"
+)
+
+// ScriptSuite :
+type ScriptSuite struct {
+	suite.Suite
+}
+
+func (s *ScriptSuite) newScript(rules ...*script.RuleConfig) (*script.Script, error) {
+	return script.New(&script.Config{
+		Rules:          rules,
+		Timeout:        "1s",
+		MaxFields:      10,
+		MaxValueLength: 16,
+		MaxRecords:     3,
+	})
+}
+
+// TestRules :
+func (s *ScriptSuite) TestRules() {
+	sc, err := s.newScript(
+		&script.RuleConfig{Action: script.ActionSet, Field: "metrics.usage", Expr: "[metrics.used] / [metrics.total] * 100"},
+		&script.RuleConfig{Action: script.ActionSet, Field: "dimensions.env", Expr: "lower([dimensions.env] ?? 'PROD')"},
+		&script.RuleConfig{Action: script.ActionRename, Field: "dimensions.ip", Target: "dimensions.bk_target_ip"},
+		&script.RuleConfig{Action: script.ActionDrop, Field: "metrics.total"},
+		&script.RuleConfig{Action: script.ActionSplit, Field: "dimensions.disk"},
+		&script.RuleConfig{Action: script.ActionFilter, Expr: "[dimensions.disk] != 'tmpfs'"},
+		&script.RuleConfig{Action: script.ActionSet, Field: "dimensions.level", Expr: "'high'", When: "[metrics.usage] > 20"},
+	)
+	s.NoError(err)
+
+	records, err := sc.Run(map[string]interface{}{
+		"time":       1.0,
+		"dimensions": map[string]interface{}{"ip": "127.0.0.1", "disk": "sda,tmpfs,sdb"},
+		"metrics":    map[string]interface{}{"used": 5.0, "total": 20.0},
+	})
+	s.NoError(err)
+	s.Equal([]map[string]interface{}{
+		{
+			"time":       1.0,
+			"dimensions": map[string]interface{}{"bk_target_ip": "127.0.0.1", "env": "prod", "disk": "sda", "level": "high"},
+			"metrics":    map[string]interface{}{"used": 5.0, "usage": 25.0},
+		},
+		{
+			"time":       1.0,
+			"dimensions": map[string]interface{}{"bk_target_ip": "127.0.0.1", "env": "prod", "disk": "sdb", "level": "high"},
+			"metrics":    map[string]interface{}{"used": 5.0, "usage": 25.0},
+		},
+	}, records)
+}
+
+// TestFilter :
+func (s *ScriptSuite) TestFilter() {
+	sc, err := s.newScript(&script.RuleConfig{Action: script.ActionFilter, Expr: "startswith([dimensions.env], 'prod')"})
+	s.NoError(err)
+
+	records, err := sc.Run(map[string]interface{}{"dimensions": map[string]interface{}{"env": "test"}})
+	s.NoError(err)
+	s.Empty(records)
+
+	records, err = sc.Run(map[string]interface{}{"dimensions": map[string]interface{}{"env": "production"}})
+	s.NoError(err)
+	s.Len(records, 1)
+}
+
+// TestLimits :
+func (s *ScriptSuite) TestLimits() {
+	sc, err := s.newScript(&script.RuleConfig{Action: script.ActionSplit, Field: "dimensions.x", Separator: "|"})
+	s.NoError(err)
+	_, err = sc.Run(map[string]interface{}{"dimensions": map[string]interface{}{"x": "1|2|3|4"}})
+	s.Error(err)
+
+	sc, err = s.newScript(&script.RuleConfig{Action: script.ActionSet, Field: "x", Expr: "replace([x], 'a', 'aaaaaaaa')"})
+	s.NoError(err)
+	_, err = sc.Run(map[string]interface{}{"x": "aaaa"})
+	s.Error(err)
+
+	sc, err = s.newScript(&script.RuleConfig{Action: script.ActionSet, Field: "metrics.x", Expr: "1"})
+	s.NoError(err)
+	_, err = sc.Run(map[string]interface{}{"metrics": map[string]interface{}{
+		"a": 1.0, "b": 1.0, "c": 1.0, "d": 1.0, "e": 1.0, "f": 1.0, "g": 1.0, "h": 1.0, "i": 1.0, "j": 1.0,
+	}})
+	s.Error(err)
+}
+
+// TestTimeout : 超时在每条记录执行完单个规则后检查
+func (s *ScriptSuite) TestTimeout() {
+	sc, err := script.New(&script.Config{
+		Rules:          []*script.RuleConfig{{Action: script.ActionSet, Field: "x", Expr: "1"}},
+		Timeout:        "1ns",
+		MaxFields:      10,
+		MaxValueLength: 16,
+		MaxRecords:     3,
+	})
+	s.NoError(err)
+	_, err = sc.Run(map[string]interface{}{})
+	s.True(errors.Is(err, define.ErrTimeout))
+}
+
+// TestSubstr : 下标越界及超大数值时截断到字符串范围内
+func (s *ScriptSuite) TestSubstr() {
+	cases := []struct {
+		expr   string
+		result string
+	}{
+		{"substr([x], 1, 2)", "bc"},
+		{"substr([x], -1, 2)", "ab"},
+		{"substr([x], 2)", "cde"},
+		{"substr([x], 2, -1)", "cde"},
+		{"substr([x], 10, 2)", ""},
+		{"substr([x], 1, 100000000000000000000)", "bcde"},
+		{"substr([x], 100000000000000000000, 100000000000000000000)", ""},
+		{"substr([x], -100000000000000000000, 9223372036854775807)", "abcde"},
+	}
+	for _, c := range cases {
+		sc, err := s.newScript(&script.RuleConfig{Action: script.ActionSet, Field: "y", Expr: c.expr})
+		s.NoError(err, c.expr)
+		records, err := sc.Run(map[string]interface{}{"x": "abcde"})
+		s.NoError(err, c.expr)
+		s.Equal(c.result, records[0]["y"], c.expr)
+	}
+}
+
+// TestInvalid :
+func (s *ScriptSuite) TestInvalid() {
+	for _, rule := range []*script.RuleConfig{
+		{Action: "exec"},
+		{Action: script.ActionSet, Field: "x"},
+		{Action: script.ActionSet, Field: "x", Expr: "1 +"},
+		{Action: script.ActionRename, Field: "x"},
+		{Action: script.ActionDrop, Field: "metrics."},
+		{Action: script.ActionFilter, Expr: "true", When: "unknown(1)"},
+		// 长度未超限但 token 数超限
+		{Action: script.ActionSet, Field: "x", Expr: strings.Repeat("1+", script.MaxExpressionTokens) + "1"},
+	} {
+		_, err := s.newScript(rule)
+		s.Error(err, rule.Action)
+	}
+
+	sc, err := s.newScript(&script.RuleConfig{Action: script.ActionFilter, Expr: "1 + 1"})
+	s.NoError(err)
+	_, err = sc.Run(map[string]interface{}{})
+	s.Error(err)
+}
+
+// TestScriptSuite :
+func TestScriptSuite(t *testing.T) {
+	suite.Run(t, new(ScriptSuite))
+}
@@ -0,0 +1,178 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/cardinality"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/conv"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// CardinalityGuardProcessor : 限制结果表的序列基数 超限的指标按配置丢弃或去掉高基数维度
+type CardinalityGuardProcessor struct {
+	*define.BaseDataProcessor
+	*define.ProcessorMonitor
+	guard      *cardinality.Guard
+	dataID     string
+	deadLetter *deadletter.Reporter
+}
+
+// Process :
+func (p *CardinalityGuardProcessor) Process(d define.Payload, outputChan chan<- define.Payload, killChan chan<- error) {
+	record := make(map[string]interface{})
+	err := d.To(&record)
+	if err != nil {
+		p.CounterFails.Inc()
+		logging.Warnf("%v convert payload %#v error %v", p, d, err)
+		return
+	}
+
+	metrics, _ := record[define.RecordMetricsFieldName].(map[string]interface{})
+	if len(metrics) == 0 {
+		outputChan <- d
+		p.CounterSuccesses.Inc()
+		return
+	}
+	dimensions, _ := record[define.RecordDimensionsFieldName].(map[string]interface{})
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	labels := make(map[string]string, len(dimensions))
+	for key, value := range dimensions {
+		label, err := conv.DefaultConv.String(value)
+		if err != nil {
+			label = fmt.Sprint(value)
+		}
+		labels[key] = label
+	}
+
+	result := p.guard.Check(names, labels)
+	cardinality.MonitorSeries.WithLabelValues(p.dataID, p.guard.Table()).Set(float64(p.guard.TrackedSeries()))
+	if len(result.Dropped) == 0 && len(result.Aggregated) == 0 {
+		outputChan <- d
+		p.CounterSuccesses.Inc()
+		return
+	}
+
+	if len(result.Aggregated) > 0 {
+		cardinality.MonitorRejected.WithLabelValues(p.dataID, p.guard.Table(), cardinality.ActionAggregate).Inc()
+		for _, key := range result.Aggregated {
+			delete(dimensions, key)
+		}
+	}
+	if len(result.Dropped) > 0 {
+		cardinality.MonitorRejected.WithLabelValues(p.dataID, p.guard.Table(), cardinality.ActionDrop).Add(float64(len(result.Dropped)))
+		for _, name := range result.Dropped {
+			delete(metrics, name)
+		}
+	}
+	if len(metrics) == 0 {
+		logging.MinuteErrorfSampling(p.String(), "%v has too much series, dropped %v", p, result.Dropped)
+		p.deadLetter.Report(deadletter.StageETL, p.String(), d, errors.Wrapf(define.ErrValue, "too much series"))
+		return
+	}
+
+	payload, err := define.DerivePayload(d, record)
+	if err != nil {
+		p.CounterFails.Inc()
+		logging.Warnf("%v create payload from %v error: %v", p, d, err)
+		return
+	}
+	outputChan <- payload
+	p.CounterSuccesses.Inc()
+}
+
+// Finish :
+func (p *CardinalityGuardProcessor) Finish(outputChan chan<- define.Payload, killChan chan<- error) {
+	cardinality.Unregister(p.guard)
+	cardinality.MonitorSeries.DeleteLabelValues(p.dataID, p.guard.Table())
+}
+
+// ParseCardinalityGuardConfig : 解析结果表中的基数限制配置 未配置时返回 nil
+func ParseCardinalityGuardConfig(conf define.Configuration, rt *config.MetaResultTableConfig) (*cardinality.Config, error) {
+	value, ok := utils.NewMapHelper(rt.Option).Get(config.ResultTableOptCardinalityGuard)
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	guardConfig := cardinality.NewConfig(conf)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:     guardConfig,
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = decoder.Decode(value)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "decode %s", config.ResultTableOptCardinalityGuard)
+	}
+	return guardConfig, nil
+}
+
+// NewCardinalityGuardProcessor :
+func NewCardinalityGuardProcessor(ctx context.Context, name string) (*CardinalityGuardProcessor, error) {
+	pipe := config.PipelineConfigFromContext(ctx)
+	rt := config.ResultTableConfigFromContext(ctx)
+	guardConfig, err := ParseCardinalityGuardConfig(config.FromContext(ctx), rt)
+	if err != nil {
+		return nil, err
+	}
+	if guardConfig == nil {
+		return nil, errors.Wrapf(define.ErrOperationForbidden, "%s not set", config.ResultTableOptCardinalityGuard)
+	}
+
+	guard, err := cardinality.NewGuard(pipe.DataID, rt.ResultTable, *guardConfig)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "create cardinality guard of %s", rt.ResultTable)
+	}
+	cardinality.Register(guard)
+
+	return &CardinalityGuardProcessor{
+		BaseDataProcessor: define.NewBaseDataProcessor(name),
+		ProcessorMonitor:  pipeline.NewDataProcessorMonitor(name, pipe),
+		guard:             guard,
+		dataID:            strconv.Itoa(pipe.DataID),
+		deadLetter:        deadletter.FromContext(ctx),
+	}, nil
+}
+
+func init() {
+	define.RegisterDataProcessor("cardinality_guard", func(ctx context.Context, name string) (define.DataProcessor, error) {
+		pipe := config.PipelineConfigFromContext(ctx)
+		if pipe == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is empty")
+		}
+		rt := config.ResultTableConfigFromContext(ctx)
+		if rt == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "result table is empty")
+		}
+		if config.FromContext(ctx) == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "config is empty")
+		}
+		return NewCardinalityGuardProcessor(ctx, pipe.FormatName(rt.FormatName(name)))
+	})
+}
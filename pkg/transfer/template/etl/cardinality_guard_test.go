@@ -0,0 +1,111 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/cardinality"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/template/etl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
+)
+
+// CardinalityGuardProcessorSuite
+type CardinalityGuardProcessorSuite struct {
+	testsuite.ETLSuite
+}
+
+// SetupTest
+func (s *CardinalityGuardProcessorSuite) SetupTest() {
+	s.ETLSuite.SetupTest()
+	cardinality.InitConfiguration(s.Config)
+}
+
+func (s *CardinalityGuardProcessorSuite) run(option map[string]interface{}, records ...string) []map[string]interface{} {
+	s.ResultTableConfig.Option = map[string]interface{}{
+		config.ResultTableOptCardinalityGuard: option,
+	}
+	processor, err := etl.NewCardinalityGuardProcessor(s.CTX, "test")
+	s.NoError(err)
+
+	outputChan := make(chan define.Payload, len(records))
+	s.CheckKillChan(s.KillCh)
+	for _, record := range records {
+		processor.Process(s.MakePayload(record), outputChan, s.KillCh)
+	}
+	s.Len(cardinality.Snapshot(s.ResultTableConfig.ResultTable, 0), 1)
+	processor.Finish(outputChan, s.KillCh)
+	s.Empty(cardinality.Snapshot(s.ResultTableConfig.ResultTable, 0))
+	close(outputChan)
+
+	results := make([]map[string]interface{}, 0)
+	for payload := range outputChan {
+		result := make(map[string]interface{})
+		s.NoError(payload.To(&result))
+		results = append(results, result)
+	}
+	return results
+}
+
+// TestDrop
+func (s *CardinalityGuardProcessorSuite) TestDrop() {
+	results := s.run(map[string]interface{}{"max_series": 3, "max_metric_series": 2, "window": "10m"},
+		`{"time":1,"dimensions":{"ip":"1"},"metrics":{"usage":1,"idle":2},"group_info":[{"a":"1"}]}`,
+		`{"time":1,"dimensions":{"ip":2},"metrics":{"usage":1,"idle":2}}`,
+		`{"time":1,"dimensions":{"ip":"3"},"metrics":{"usage":1}}`,
+	)
+	s.Len(results, 2)
+	s.Equal(map[string]interface{}{"usage": 1.0, "idle": 2.0}, results[0]["metrics"])
+	s.NotNil(results[0]["group_info"])
+	s.Equal(map[string]interface{}{"idle": 2.0}, results[1]["metrics"])
+}
+
+// TestAggregate
+func (s *CardinalityGuardProcessorSuite) TestAggregate() {
+	results := s.run(map[string]interface{}{"max_series": 10, "max_label_values": 1, "action": "aggregate"},
+		`{"time":1,"dimensions":{"ip":"1","pod":"a"},"metrics":{"usage":1}}`,
+		`{"time":1,"dimensions":{"ip":"1","pod":"b"},"metrics":{"usage":1}}`,
+	)
+	s.Len(results, 2)
+	s.Equal(map[string]interface{}{"ip": "1", "pod": "a"}, results[0]["dimensions"])
+	s.Equal(map[string]interface{}{"ip": "1"}, results[1]["dimensions"])
+}
+
+// TestInvalidConfig
+func (s *CardinalityGuardProcessorSuite) TestInvalidConfig() {
+	s.ResultTableConfig.Option = map[string]interface{}{
+		config.ResultTableOptCardinalityGuard: map[string]interface{}{"action": "sample"},
+	}
+	_, err := etl.NewCardinalityGuardProcessor(s.CTX, "test")
+	s.Error(err)
+
+	s.ResultTableConfig.Option = map[string]interface{}{}
+	_, err = etl.NewCardinalityGuardProcessor(s.CTX, "test")
+	s.Error(err)
+}
+
+// TestRegistered : 流水线通过名称创建处理器
+func (s *CardinalityGuardProcessorSuite) TestRegistered() {
+	s.ResultTableConfig.Option = map[string]interface{}{
+		config.ResultTableOptCardinalityGuard: map[string]interface{}{"max_series": 100},
+	}
+	processor, err := define.NewDataProcessor(s.CTX, "cardinality_guard")
+	s.NoError(err)
+	s.NotNil(processor)
+}
+
+// TestCardinalityGuardProcessor
+func TestCardinalityGuardProcessor(t *testing.T) {
+	suite.Run(t, new(CardinalityGuardProcessorSuite))
+}
@@ -28,8 +28,8 @@ const (
 	FieldSeparatorValues = "_separator_values_"
 )
 
-// NewLogProcessor
-func NewLogProcessor(ctx context.Context, name string, update func(record *etl.TSSchemaRecord, decoder *etl.PayloadDecoder)) (*template.RecordProcessor, error) {
+// newLogRecord
+func newLogRecord(ctx context.Context, name string, update func(record *etl.TSSchemaRecord, decoder *etl.PayloadDecoder)) (*etl.TSSchemaRecord, *etl.PayloadDecoder, error) {
 	pipe := config.PipelineConfigFromContext(ctx)
 	options := utils.NewMapHelper(pipe.Option)
 	groupInfoName := options.GetOrDefault(config.PipelineConfigOptDimensionGroupAlias, define.RecordGroupFieldName).(string)
@@ -64,6 +64,15 @@ func NewLogProcessor(ctx context.Context, name string, update func(record *etl.T
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return record, decoder, nil
+}
+
+// NewLogProcessor
+func NewLogProcessor(ctx context.Context, name string, update func(record *etl.TSSchemaRecord, decoder *etl.PayloadDecoder)) (*template.RecordProcessor, error) {
+	record, decoder, err := newLogRecord(ctx, name, update)
 	if err != nil {
 		return nil, err
 	}
@@ -72,3 +81,24 @@ func NewLogProcessor(ctx context.Context, name string, update func(record *etl.T
 		name, config.PipelineConfigFromContext(ctx), record, decoder.Decode,
 	), nil
 }
+
+// NewPreparedLogProcessor : 解析结果在提取维度及指标之前合并 解析得到的字段可以同时作为维度及指标使用
+func NewPreparedLogProcessor(ctx context.Context, name string, transform etl.TransformFn) (*template.RecordProcessor, error) {
+	record, decoder, err := newLogRecord(ctx, name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := etl.NewComplexRecord(name, []etl.Record{
+		etl.NewPrepareRecord([]etl.Record{etl.NewSimpleRecord([]etl.Field{
+			// 分割
+			etl.NewPrepareField(FieldSeparatorValues, etl.ExtractByPath(FieldName), transform),
+			// 合并
+			etl.NewMergeField(FieldSeparatorValues),
+		})}),
+		record,
+	})
+	return template.NewRecordProcessorWithDecoderFn(
+		name, config.PipelineConfigFromContext(ctx), schema, decoder.Decode,
+	), nil
+}
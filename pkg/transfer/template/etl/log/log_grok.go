@@ -0,0 +1,96 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package log
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/conv"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/etl"
+	template "github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/template/etl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// getGrokPatterns : 从结果表配置中获取自定义 grok 模式
+func getGrokPatterns(ctx context.Context) (map[string]string, error) {
+	patterns := make(map[string]string)
+	rt := config.ResultTableConfigFromContext(ctx)
+	if rt == nil {
+		return patterns, nil
+	}
+
+	value, ok := utils.NewMapHelper(rt.Option).Get(config.ResultTableOptLogGrokPatterns)
+	if !ok || value == nil {
+		return patterns, nil
+	}
+
+	switch values := value.(type) {
+	case map[string]string:
+		return values, nil
+	case map[string]interface{}:
+		for name, pattern := range values {
+			definition, err := conv.DefaultConv.String(pattern)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "grok pattern %s", name)
+			}
+			patterns[name] = definition
+		}
+		return patterns, nil
+	default:
+		return nil, errors.Wrapf(define.ErrType, "unknown grok patterns type %T", value)
+	}
+}
+
+// NewGrokLogProcessor
+func NewGrokLogProcessor(ctx context.Context, name string) (*template.RecordProcessor, error) {
+	patterns, err := getGrokPatterns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := config.PipelineConfigFromContext(ctx)
+	pattern, ok := utils.NewMapHelper(pipe.Option).GetString(config.PipelineConfigOptLogSeparatorGrok)
+	if !ok {
+		return nil, errors.Wrapf(define.ErrOperationForbidden, "grok not set")
+	}
+
+	return NewPreparedLogProcessor(ctx, name, etl.TransformMapByGrok(pattern, patterns))
+}
+
+func init() {
+	define.RegisterDataProcessor("grok_log", func(ctx context.Context, name string) (define.DataProcessor, error) {
+		pipeConfig := config.PipelineConfigFromContext(ctx)
+		if pipeConfig == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is empty")
+		}
+
+		options := utils.NewMapHelper(pipeConfig.Option)
+		pattern, ok := options.GetString(config.PipelineConfigOptLogSeparatorGrok)
+		if !ok {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "grok not set")
+		}
+
+		patterns, err := getGrokPatterns(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = etl.NewGrok(pattern, patterns)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewGrokLogProcessor(ctx, pipeConfig.FormatName(name))
+	})
+}
@@ -0,0 +1,63 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package log_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/template/etl/log"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
+)
+
+// GrokLogTest
+type GrokLogTest struct {
+	testsuite.ETLSuite
+}
+
+// TestUsage :
+func (s *GrokLogTest) TestUsage() {
+	s.CTX = testsuite.PipelineConfigStringInfoContext(
+		s.CTX, s.PipelineConfig,
+		`{"result_table_list":[{"option":{"grok_patterns":{"ERRCODE":"E[0-9]+"}},"schema_type":"free","result_table":"2_log.grok_log","field_list":[{"field_name":"log","alias_name":"log","tag":"metric","type":"string","is_config_by_user":true},{"field_name":"_path_","alias_name":"path","tag":"dimension","type":"string","is_config_by_user":true},{"field_name":"clientip","alias_name":"","tag":"dimension","type":"string","is_config_by_user":true},{"field_name":"code","alias_name":"","tag":"metric","type":"string","is_config_by_user":true},{"field_name":"cost","alias_name":"","tag":"metric","type":"float","is_config_by_user":true}]}],"source_label":"bk_monitor","type_label":"log","data_id":1200146,"etl_config":"bk_log_grok","option":{"group_info_alias":"_private_","encoding":"UTF-8","separator_grok":"%{IP:clientip} %{ERRCODE:code} %{NUMBER:cost:float}"}}`,
+	)
+
+	processor, err := log.NewGrokLogProcessor(s.CTX, "test")
+	s.NoError(err)
+
+	s.Run(`{"_path_":"/tmp/grok.log","_private_":[{"bk_app_code":"bk_log_search"}],"_value_":["127.0.0.1 E42 1.5"]}`,
+		processor,
+		func(result map[string]interface{}) {
+			ts := result["time"].(float64)
+			s.EqualRecord(result, map[string]interface{}{
+				"dimensions": map[string]interface{}{
+					"path":     "/tmp/grok.log",
+					"clientip": "127.0.0.1",
+				},
+				"metrics": map[string]interface{}{
+					"log":            "127.0.0.1 E42 1.5",
+					"code":           "E42",
+					"cost":           1.5,
+					"_iteration_idx": 0.0,
+				},
+				"time": ts,
+				"group_info": []map[string]string{
+					{"bk_app_code": "bk_log_search"},
+				},
+			})
+		},
+	)
+}
+
+// TestGrokLogTest :
+func TestGrokLogTest(t *testing.T) {
+	suite.Run(t, new(GrokLogTest))
+}
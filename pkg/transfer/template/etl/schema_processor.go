@@ -15,6 +15,7 @@ import (
 	"github.com/cstockton/go-conv"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/etl"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
@@ -28,8 +29,9 @@ type Decoder func(d define.Payload) ([]etl.Container, error)
 type RecordProcessor struct {
 	*define.BaseDataProcessor
 	*define.ProcessorMonitor
-	Decode Decoder
-	schema etl.Transformer
+	Decode     Decoder
+	DeadLetter *deadletter.Reporter
+	schema     etl.Transformer
 }
 
 // Process : process json data
@@ -38,6 +40,7 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 	if err != nil {
 		logging.MinuteErrorfSampling(p.String(), "%v load %#v error %v", p, d, err)
 		p.CounterFails.Inc()
+		p.DeadLetter.Report(deadletter.StageRaw, p.String(), d, err)
 		return
 	}
 	if len(containers) == 0 {
@@ -46,6 +49,7 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 		return
 	}
 
+	var lastErr error
 	handled := 0
 	for _, from := range containers {
 		if bizID, err := from.Get(define.RecordBizID); err == nil {
@@ -58,12 +62,14 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 		err = p.schema.Transform(from, to)
 		if err != nil {
 			logging.MinuteErrorfSampling(p.String(), "%v transform %v error %v", p, d, err)
+			lastErr = err
 			continue
 		}
 
 		output, err := define.DerivePayload(d, &to)
 		if err != nil {
 			logging.Errorf("%v create payload from %v error: %+v", p, d, err)
+			lastErr = err
 			continue
 		}
 
@@ -74,6 +80,7 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 	if handled == 0 {
 		logging.Warnf("%v handle %#v failed", p, d)
 		p.CounterFails.Inc()
+		p.DeadLetter.Report(deadletter.StageRaw, p.String(), d, lastErr)
 	} else {
 		logging.Debugf("%v push %d items from %v", p, handled, d)
 		p.CounterSuccesses.Inc()
@@ -88,12 +95,14 @@ func NewRecordProcessor(name string, pipeConfig *config.PipelineConfig, schema e
 func NewRecordProcessorWithContext(ctx context.Context, name string, pipeConfig *config.PipelineConfig, schema etl.Transformer) *RecordProcessor {
 	recordProcessor := NewRecordProcessorWithDecoderFn(name, pipeConfig, schema, etl.NewPayloadDecoder().Decode)
 	recordProcessor.DisabledBizIDs = config.ResultTableConfigFromContext(ctx).DisabledBizID()
+	recordProcessor.DeadLetter = deadletter.FromContext(ctx)
 	return recordProcessor
 }
 
 func NewRecordProcessorWithDecoderFnWithContext(ctx context.Context, name string, pipeConfig *config.PipelineConfig, schema etl.Transformer, fn Decoder) *RecordProcessor {
 	recordProcessor := NewRecordProcessorWithDecoderFn(name, pipeConfig, schema, fn)
 	recordProcessor.DisabledBizIDs = config.ResultTableConfigFromContext(ctx).DisabledBizID()
+	recordProcessor.DeadLetter = deadletter.FromContext(ctx)
 	return recordProcessor
 }
 
@@ -0,0 +1,125 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl
+
+import (
+	"context"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/script"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// ScriptProcessor : 按照结果表配置的规则转换清洗后的记录
+type ScriptProcessor struct {
+	*define.BaseDataProcessor
+	*define.ProcessorMonitor
+	script     *script.Script
+	deadLetter *deadletter.Reporter
+}
+
+// Process :
+func (p *ScriptProcessor) Process(d define.Payload, outputChan chan<- define.Payload, killChan chan<- error) {
+	record := make(map[string]interface{})
+	err := d.To(&record)
+	if err != nil {
+		p.CounterFails.Inc()
+		logging.Warnf("%v convert payload %#v error %v", p, d, err)
+		return
+	}
+
+	records, err := p.script.Run(record)
+	if err != nil {
+		p.CounterFails.Inc()
+		logging.MinuteErrorfSampling(p.String(), "%v run script on %v error %v", p, d, err)
+		p.deadLetter.Report(deadletter.StageETL, p.String(), d, err)
+		return
+	}
+	if len(records) == 0 {
+		// 过滤是规则的预期行为 不计入失败
+		logging.Debugf("%v filtered payload %v", p, d)
+		p.CounterSuccesses.Inc()
+		return
+	}
+
+	for _, item := range records {
+		payload, err := define.DerivePayload(d, item)
+		if err != nil {
+			p.CounterFails.Inc()
+			logging.Warnf("%v create payload from %v error: %v", p, d, err)
+			continue
+		}
+		outputChan <- payload
+	}
+	p.CounterSuccesses.Inc()
+}
+
+// ParseScriptConfig : 解析结果表中的规则配置 未配置时返回 nil
+func ParseScriptConfig(conf define.Configuration, rt *config.MetaResultTableConfig) (*script.Config, error) {
+	value, ok := utils.NewMapHelper(rt.Option).Get(config.ResultTableOptScriptConfig)
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	scriptConfig := script.NewConfig(conf)
+	err := mapstructure.Decode(value, scriptConfig)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "decode %s", config.ResultTableOptScriptConfig)
+	}
+	return scriptConfig, nil
+}
+
+// NewScriptProcessor :
+func NewScriptProcessor(ctx context.Context, name string) (*ScriptProcessor, error) {
+	rt := config.ResultTableConfigFromContext(ctx)
+	scriptConfig, err := ParseScriptConfig(config.FromContext(ctx), rt)
+	if err != nil {
+		return nil, err
+	}
+	if scriptConfig == nil {
+		return nil, errors.Wrapf(define.ErrOperationForbidden, "%s not set", config.ResultTableOptScriptConfig)
+	}
+
+	s, err := script.New(scriptConfig)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "create script of %s", rt.ResultTable)
+	}
+
+	return &ScriptProcessor{
+		BaseDataProcessor: define.NewBaseDataProcessor(name),
+		ProcessorMonitor:  pipeline.NewDataProcessorMonitor(name, config.PipelineConfigFromContext(ctx)),
+		script:            s,
+		deadLetter:        deadletter.FromContext(ctx),
+	}, nil
+}
+
+func init() {
+	define.RegisterDataProcessor("script_processor", func(ctx context.Context, name string) (define.DataProcessor, error) {
+		pipe := config.PipelineConfigFromContext(ctx)
+		if pipe == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is empty")
+		}
+		rt := config.ResultTableConfigFromContext(ctx)
+		if rt == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "result table is empty")
+		}
+		if config.FromContext(ctx) == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "config is empty")
+		}
+		return NewScriptProcessor(ctx, pipe.FormatName(rt.FormatName(name)))
+	})
+}
@@ -0,0 +1,89 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package etl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/script"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/template/etl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
+)
+
+// ScriptProcessorSuite
+type ScriptProcessorSuite struct {
+	testsuite.ETLSuite
+}
+
+// SetupTest
+func (s *ScriptProcessorSuite) SetupTest() {
+	s.ETLSuite.SetupTest()
+	script.InitConfiguration(s.Config)
+	s.ResultTableConfig.Option = map[string]interface{}{
+		config.ResultTableOptScriptConfig: map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"action": "filter", "expr": "[dimensions.env] != 'test'"},
+				map[string]interface{}{"action": "split", "field": "dimensions.disk"},
+				map[string]interface{}{"action": "set", "field": "metrics.usage", "expr": "[metrics.used] / [metrics.total] * 100"},
+				map[string]interface{}{"action": "drop", "field": "metrics.total"},
+			},
+		},
+	}
+}
+
+// TestUsage
+func (s *ScriptProcessorSuite) TestUsage() {
+	processor, err := etl.NewScriptProcessor(s.CTX, "test")
+	s.NoError(err)
+
+	outputChan := make(chan define.Payload, 10)
+	s.CheckKillChan(s.KillCh)
+	processor.Process(s.MakePayload(`{"time":1,"dimensions":{"env":"prod","disk":"sda,sdb"},"metrics":{"used":5,"total":20},"group_info":[{"a":"1"}]}`), outputChan, s.KillCh)
+	processor.Process(s.MakePayload(`{"time":1,"dimensions":{"env":"test","disk":"sda"},"metrics":{"used":5,"total":20}}`), outputChan, s.KillCh)
+	processor.Process(s.MakePayload(`{"time":1,"dimensions":{"env":"prod","disk":"sda"},"metrics":{"used":"x","total":20}}`), outputChan, s.KillCh)
+	close(outputChan)
+
+	disks := make([]string, 0)
+	for payload := range outputChan {
+		result := make(map[string]interface{})
+		s.NoError(payload.To(&result))
+		dimensions := result["dimensions"].(map[string]interface{})
+		disks = append(disks, dimensions["disk"].(string))
+		s.Equal(map[string]interface{}{"used": 5.0, "usage": 25.0}, result["metrics"])
+		s.NotNil(result["group_info"])
+	}
+	s.Equal([]string{"sda", "sdb"}, disks)
+}
+
+// TestInvalidConfig
+func (s *ScriptProcessorSuite) TestInvalidConfig() {
+	s.ResultTableConfig.Option = map[string]interface{}{
+		config.ResultTableOptScriptConfig: map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"action": "exec", "expr": "1"},
+			},
+		},
+	}
+	_, err := etl.NewScriptProcessor(s.CTX, "test")
+	s.Error(err)
+
+	s.ResultTableConfig.Option = map[string]interface{}{}
+	_, err = etl.NewScriptProcessor(s.CTX, "test")
+	s.Error(err)
+}
+
+// TestScriptProcessor
+func TestScriptProcessor(t *testing.T) {
+	suite.Run(t, new(ScriptProcessorSuite))
+}
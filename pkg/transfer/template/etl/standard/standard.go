@@ -16,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/deadletter"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
@@ -38,6 +39,7 @@ type Record struct {
 type Processor struct {
 	*define.BaseDataProcessor
 	*define.ProcessorMonitor
+	deadLetter *deadletter.Reporter
 }
 
 // FillDimensions :
@@ -79,18 +81,21 @@ func (p *Processor) Process(d define.Payload, outputChan chan<- define.Payload,
 	if err != nil {
 		p.CounterFails.Inc()
 		logging.Warnf("%v convert record error %v: %v", p, err, d)
+		p.deadLetter.Report(deadletter.StageRaw, p.String(), d, err)
 		return
 	}
 
 	if record.Time == nil {
 		p.CounterFails.Inc()
 		logging.Warnf("%v record time is empty: %v", p, d)
+		p.deadLetter.Report(deadletter.StageRaw, p.String(), d, errors.Wrapf(define.ErrValue, "record time is empty"))
 		return
 	}
 
 	if record.Metrics == nil || len(record.Metrics) == 0 {
 		p.CounterFails.Inc()
 		logging.Warnf("%v record metrics is empty: %v", p, d)
+		p.deadLetter.Report(deadletter.StageRaw, p.String(), d, errors.Wrapf(define.ErrValue, "record metrics is empty"))
 		return
 	}
 
@@ -117,6 +122,7 @@ func NewProcessor(ctx context.Context, name string) *Processor {
 	return &Processor{
 		BaseDataProcessor: define.NewBaseDataProcessor(name),
 		ProcessorMonitor:  pipeline.NewDataProcessorMonitor(name, config.PipelineConfigFromContext(ctx)),
+		deadLetter:        deadletter.FromContext(ctx),
 	}
 }
 
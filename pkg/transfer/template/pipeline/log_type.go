@@ -37,6 +37,9 @@ const (
 	TypeLogJson      = "bk_log_json"
 	TypeLogSeparator = "bk_log_separator"
 	TypeLogRegexp    = "bk_log_regexp"
+	TypeLogGrok      = "bk_log_grok"
+	TypeLogLogfmt    = "bk_log_logfmt"
+	TypeLogSyslog    = "bk_log_syslog"
 )
 
 func init() {
@@ -44,4 +47,7 @@ func init() {
 	define.RegisterPipeline(TypeLogJson, StdLogPipelineCreatorByETLName("json_log"))
 	define.RegisterPipeline(TypeLogSeparator, StdLogPipelineCreatorByETLName("separator_log"))
 	define.RegisterPipeline(TypeLogRegexp, StdLogPipelineCreatorByETLName("regexp_log"))
+	define.RegisterPipeline(TypeLogGrok, StdLogPipelineCreatorByETLName("grok_log"))
+	define.RegisterPipeline(TypeLogLogfmt, StdLogPipelineCreatorByETLName("logfmt_log"))
+	define.RegisterPipeline(TypeLogSyslog, StdLogPipelineCreatorByETLName("syslog_log"))
 }
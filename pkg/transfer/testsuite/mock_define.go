@@ -151,6 +151,20 @@ func (mr *MockDataProcessorMockRecorder) Finish(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockDataProcessor)(nil).Finish), arg0, arg1)
 }
 
+// Index mocks base method.
+func (m *MockDataProcessor) Index() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Index")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Index indicates an expected call of Index.
+func (mr *MockDataProcessorMockRecorder) Index() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Index", reflect.TypeOf((*MockDataProcessor)(nil).Index))
+}
+
 // Process mocks base method.
 func (m *MockDataProcessor) Process(arg0 define.Payload, arg1 chan<- define.Payload, arg2 chan<- error) {
 	m.ctrl.T.Helper()
@@ -163,6 +177,18 @@ func (mr *MockDataProcessorMockRecorder) Process(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Process", reflect.TypeOf((*MockDataProcessor)(nil).Process), arg0, arg1, arg2)
 }
 
+// SetIndex mocks base method.
+func (m *MockDataProcessor) SetIndex(arg0 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetIndex", arg0)
+}
+
+// SetIndex indicates an expected call of SetIndex.
+func (mr *MockDataProcessorMockRecorder) SetIndex(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIndex", reflect.TypeOf((*MockDataProcessor)(nil).SetIndex), arg0)
+}
+
 // String mocks base method.
 func (m *MockDataProcessor) String() string {
 	m.ctrl.T.Helper()
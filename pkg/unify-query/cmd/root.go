@@ -27,6 +27,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/http"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/influxdb"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/promql"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/recordrule"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/redis"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/trace"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/tsdb"
@@ -62,6 +63,7 @@ var rootCmd = &cobra.Command{
 			&promql.Service{},
 			&http.Service{},
 			&featureFlag.Service{},
+			&recordrule.Service{},
 		}
 		log.Infof(ctx, "http service started.")
 
@@ -0,0 +1,112 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// thrift compact protocol 类型定义，parquet 元数据只用到其中一部分
+const (
+	compactI32    byte = 5
+	compactI64    byte = 6
+	compactBinary byte = 8
+	compactList   byte = 9
+	compactStruct byte = 12
+)
+
+// compactWriter 手写的 thrift compact protocol 编码器，仅支持 parquet 元数据写入所需的类型
+type compactWriter struct {
+	buf  bytes.Buffer
+	last []int16
+	tmp  [binary.MaxVarintLen64]byte
+}
+
+func newCompactWriter() *compactWriter {
+	return &compactWriter{last: []int16{0}}
+}
+
+func (c *compactWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(c.tmp[:], v)
+	c.buf.Write(c.tmp[:n])
+}
+
+func (c *compactWriter) zigzag(v int64) {
+	c.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (c *compactWriter) fieldHeader(id int16, typ byte) {
+	top := len(c.last) - 1
+	if delta := id - c.last[top]; delta > 0 && delta <= 15 {
+		c.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		c.buf.WriteByte(typ)
+		c.zigzag(int64(id))
+	}
+	c.last[top] = id
+}
+
+func (c *compactWriter) i32(id int16, v int32) {
+	c.fieldHeader(id, compactI32)
+	c.zigzag(int64(v))
+}
+
+func (c *compactWriter) i64(id int16, v int64) {
+	c.fieldHeader(id, compactI64)
+	c.zigzag(v)
+}
+
+func (c *compactWriter) binary(id int16, v string) {
+	c.fieldHeader(id, compactBinary)
+	c.uvarint(uint64(len(v)))
+	c.buf.WriteString(v)
+}
+
+// listBegin 写入 list 字段头，元素需要调用方依次写入
+func (c *compactWriter) listBegin(id int16, elem byte, size int) {
+	c.fieldHeader(id, compactList)
+	if size < 15 {
+		c.buf.WriteByte(byte(size)<<4 | elem)
+	} else {
+		c.buf.WriteByte(0xf0 | elem)
+		c.uvarint(uint64(size))
+	}
+}
+
+func (c *compactWriter) listI32(v int32) {
+	c.zigzag(int64(v))
+}
+
+func (c *compactWriter) listBinary(v string) {
+	c.uvarint(uint64(len(v)))
+	c.buf.WriteString(v)
+}
+
+func (c *compactWriter) structBegin(id int16) {
+	c.fieldHeader(id, compactStruct)
+	c.last = append(c.last, 0)
+}
+
+// listStructBegin list 中的 struct 元素没有字段头
+func (c *compactWriter) listStructBegin() {
+	c.last = append(c.last, 0)
+}
+
+func (c *compactWriter) structEnd() {
+	c.buf.WriteByte(0)
+	c.last = c.last[:len(c.last)-1]
+}
+
+// Bytes 结束顶层 struct 并返回编码结果
+func (c *compactWriter) Bytes() []byte {
+	c.structEnd()
+	return c.buf.Bytes()
+}
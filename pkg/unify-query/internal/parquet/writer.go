@@ -0,0 +1,308 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package parquet 提供一个最小化的 parquet 流式写入实现，用于原始数据导出：
+// 所有列均为可空的 UTF8 字符串，PLAIN 编码、不压缩，每个 row group 每列只有一个 data page，
+// 写入过程只追加不回写，可以直接写到 http chunked 响应里
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	magic     = "PAR1"
+	createdBy = "bkmonitor unify-query"
+
+	// DefaultRowGroupSize 默认每个 row group 的行数
+	DefaultRowGroupSize = 10000
+	// maxRowGroupBytes 单个 row group 缓存的数据上限，超过后提前落盘
+	maxRowGroupBytes = 64 * 1024 * 1024
+)
+
+// parquet.thrift 中用到的枚举值
+const (
+	typeByteArray         int32 = 6
+	repetitionOptional    int32 = 1
+	convertedUTF8         int32 = 0
+	encodingPlain         int32 = 0
+	encodingRLE           int32 = 3
+	codecUncompressed     int32 = 0
+	pageTypeDataPage      int32 = 0
+	fileMetaDataVersion   int32 = 1
+	definitionLevelIsNull byte  = 0
+	definitionLevelValued byte  = 1
+)
+
+var ErrClosed = errors.New("parquet writer closed")
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type rowGroup struct {
+	columns []columnChunk
+	size    int64
+	rows    int64
+}
+
+type columnBuffer struct {
+	levels []byte
+	values bytes.Buffer
+}
+
+// Writer parquet 流式写入器，非并发安全
+type Writer struct {
+	w            io.Writer
+	columns      []string
+	rowGroupSize int
+
+	buffers  []columnBuffer
+	buffered int
+	bytes    int
+
+	offset    int64
+	rowGroups []rowGroup
+	numRows   int64
+
+	started bool
+	closed  bool
+}
+
+// NewWriter 创建 parquet 写入器，rowGroupSize 小于等于 0 时使用 DefaultRowGroupSize
+func NewWriter(w io.Writer, columns []string, rowGroupSize int) *Writer {
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		rowGroupSize: rowGroupSize,
+		buffers:      make([]columnBuffer, len(columns)),
+	}
+}
+
+// Write 写入一行数据，row 与 columns 一一对应，nil 表示空值
+func (w *Writer) Write(row []*string) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet row has %d values, expected %d", len(row), len(w.columns))
+	}
+
+	var lenBuf [4]byte
+	for i, v := range row {
+		buf := &w.buffers[i]
+		if v == nil {
+			buf.levels = append(buf.levels, definitionLevelIsNull)
+			continue
+		}
+		buf.levels = append(buf.levels, definitionLevelValued)
+		binary.LittleEndian.PutUint32(lenBuf[:], uint32(len(*v)))
+		buf.values.Write(lenBuf[:])
+		buf.values.WriteString(*v)
+		w.bytes += len(*v) + len(lenBuf)
+	}
+	w.buffered++
+
+	if w.buffered >= w.rowGroupSize || w.bytes >= maxRowGroupBytes {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush 把已缓存的行写成一个 row group
+func (w *Writer) Flush() error {
+	if w.closed {
+		return ErrClosed
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	if w.buffered == 0 {
+		return nil
+	}
+
+	rg := rowGroup{
+		columns: make([]columnChunk, len(w.columns)),
+		rows:    int64(w.buffered),
+	}
+	for i := range w.buffers {
+		chunk, err := w.writeColumn(&w.buffers[i])
+		if err != nil {
+			return err
+		}
+		rg.columns[i] = chunk
+		rg.size += chunk.size
+	}
+
+	w.rowGroups = append(w.rowGroups, rg)
+	w.numRows += rg.rows
+	w.buffered = 0
+	w.bytes = 0
+	return nil
+}
+
+// Close 写入剩余数据与文件尾，不会关闭底层 io.Writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	footer := w.fileMetaData()
+	var lenBuf [4]byte
+	binary.LittleEndian.PutUint32(lenBuf[:], uint32(len(footer)))
+	for _, b := range [][]byte{footer, lenBuf[:], []byte(magic)} {
+		if err := w.write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.write([]byte(magic))
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+func (w *Writer) writeColumn(buf *columnBuffer) (columnChunk, error) {
+	levels := encodeLevels(buf.levels)
+
+	body := make([]byte, 0, 4+len(levels)+buf.values.Len())
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(levels)))
+	body = append(body, levels...)
+	body = append(body, buf.values.Bytes()...)
+
+	header := pageHeader(len(body), len(buf.levels))
+	chunk := columnChunk{
+		offset: w.offset,
+		size:   int64(len(header) + len(body)),
+		values: int64(len(buf.levels)),
+	}
+
+	if err := w.write(header); err != nil {
+		return chunk, err
+	}
+	if err := w.write(body); err != nil {
+		return chunk, err
+	}
+
+	buf.levels = buf.levels[:0]
+	buf.values.Reset()
+	return chunk, nil
+}
+
+// encodeLevels 使用 RLE/bit-packing hybrid 编码中的 RLE run 编码 definition level，位宽为 1
+func encodeLevels(levels []byte) []byte {
+	var (
+		out []byte
+		tmp [binary.MaxVarintLen64]byte
+	)
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		n := binary.PutUvarint(tmp[:], uint64(j-i)<<1)
+		out = append(out, tmp[:n]...)
+		out = append(out, levels[i])
+		i = j
+	}
+	return out
+}
+
+func pageHeader(size, numValues int) []byte {
+	c := newCompactWriter()
+	c.i32(1, pageTypeDataPage)
+	c.i32(2, int32(size))
+	c.i32(3, int32(size))
+
+	c.structBegin(5)
+	c.i32(1, int32(numValues))
+	c.i32(2, encodingPlain)
+	c.i32(3, encodingRLE)
+	c.i32(4, encodingRLE)
+	c.structEnd()
+
+	return c.Bytes()
+}
+
+func (w *Writer) fileMetaData() []byte {
+	c := newCompactWriter()
+	c.i32(1, fileMetaDataVersion)
+
+	// schema：根节点 + 每列一个叶子节点
+	c.listBegin(2, compactStruct, len(w.columns)+1)
+	c.listStructBegin()
+	c.binary(4, "schema")
+	c.i32(5, int32(len(w.columns)))
+	c.structEnd()
+	for _, name := range w.columns {
+		c.listStructBegin()
+		c.i32(1, typeByteArray)
+		c.i32(3, repetitionOptional)
+		c.binary(4, name)
+		c.i32(6, convertedUTF8)
+		c.structEnd()
+	}
+
+	c.i64(3, w.numRows)
+
+	c.listBegin(4, compactStruct, len(w.rowGroups))
+	for _, rg := range w.rowGroups {
+		c.listStructBegin()
+		c.listBegin(1, compactStruct, len(rg.columns))
+		for i, chunk := range rg.columns {
+			c.listStructBegin()
+			c.i64(2, chunk.offset)
+
+			c.structBegin(3)
+			c.i32(1, typeByteArray)
+			c.listBegin(2, compactI32, 2)
+			c.listI32(encodingPlain)
+			c.listI32(encodingRLE)
+			c.listBegin(3, compactBinary, 1)
+			c.listBinary(w.columns[i])
+			c.i32(4, codecUncompressed)
+			c.i64(5, chunk.values)
+			c.i64(6, chunk.size)
+			c.i64(7, chunk.size)
+			c.i64(9, chunk.offset)
+			c.structEnd()
+
+			c.structEnd()
+		}
+		c.i64(2, rg.size)
+		c.i64(3, rg.rows)
+		c.structEnd()
+	}
+
+	c.binary(6, createdBy)
+	return c.Bytes()
+}
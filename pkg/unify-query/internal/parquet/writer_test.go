@@ -0,0 +1,207 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go/parquet"
+)
+
+func TestCompactWriter(t *testing.T) {
+	c := newCompactWriter()
+	c.i32(1, 1)
+	c.binary(4, "ab")
+	c.structBegin(5)
+	c.i32(1, -1)
+	c.structEnd()
+	c.i64(21, 1)
+
+	assert.Equal(t, []byte{
+		0x15, 0x02,
+		0x38, 0x02, 'a', 'b',
+		0x1c, 0x15, 0x01, 0x00,
+		0x06, 0x2a, 0x02,
+		0x00,
+	}, c.Bytes())
+}
+
+func TestEncodeLevels(t *testing.T) {
+	assert.Equal(t, []byte{0x04, 0x01, 0x02, 0x00, 0x06, 0x01}, encodeLevels([]byte{1, 1, 0, 1, 1, 1}))
+	assert.Empty(t, encodeLevels(nil))
+}
+
+func TestWriter(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		s   = func(v string) *string { return &v }
+	)
+
+	w := NewWriter(&buf, []string{"a", "level"}, 2)
+	require.NoError(t, w.Write([]*string{s("x"), nil}))
+	assert.Zero(t, buf.Len())
+	// 第一个 row group 写满后直接落盘
+	require.NoError(t, w.Write([]*string{s("y"), s("info")}))
+	assert.Greater(t, buf.Len(), len(magic))
+	require.NoError(t, w.Write([]*string{nil, s("warn")}))
+	assert.Error(t, w.Write([]*string{s("z")}))
+	require.NoError(t, w.Close())
+	assert.ErrorIs(t, w.Write([]*string{s("x"), s("y")}), ErrClosed)
+
+	data := buf.Bytes()
+	assert.Equal(t, magic, string(data[:4]))
+	assert.Equal(t, magic, string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	assert.Contains(t, string(footer), "level")
+	assert.Contains(t, string(footer), createdBy)
+
+	assert.Len(t, w.rowGroups, 2)
+	assert.Equal(t, int64(3), w.numRows)
+	assert.Equal(t, int64(4), w.rowGroups[0].columns[0].offset)
+}
+
+func TestWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []string{"a"}, 0)
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	assert.Equal(t, magic, string(data[:4]))
+	assert.Equal(t, magic, string(data[len(data)-4:]))
+}
+
+// readThrift 使用 thrift 官方实现按 compact 协议解析，返回读取的字节数
+func readThrift(t *testing.T, data []byte, v interface {
+	Read(context.Context, thrift.TProtocol) error
+}) int {
+	mem := thrift.NewTMemoryBufferLen(len(data))
+	_, _ = mem.Write(data)
+	require.NoError(t, v.Read(context.Background(), thrift.NewTCompactProtocolConf(mem, nil)))
+	return len(data) - mem.Len()
+}
+
+// decodePage 按 parquet 规范解析 data page v1：4 字节长度前缀的 definition level（RLE/bit-packing hybrid，位宽 1）+ PLAIN 编码的值
+func decodePage(t *testing.T, page []byte, numValues int) []*string {
+	levelsLen := int(binary.LittleEndian.Uint32(page))
+	levels, page := page[4:4+levelsLen], page[4+levelsLen:]
+
+	var defined []bool
+	for len(levels) > 0 {
+		header, n := binary.Uvarint(levels)
+		require.Greater(t, n, 0)
+		levels = levels[n:]
+		if header&1 == 0 {
+			for i := 0; i < int(header>>1); i++ {
+				defined = append(defined, levels[0] == 1)
+			}
+			levels = levels[1:]
+			continue
+		}
+		groups := int(header >> 1)
+		for _, b := range levels[:groups] {
+			for bit := 0; bit < 8; bit++ {
+				defined = append(defined, b&(1<<bit) != 0)
+			}
+		}
+		levels = levels[groups:]
+	}
+	require.GreaterOrEqual(t, len(defined), numValues)
+
+	values := make([]*string, 0, numValues)
+	for _, ok := range defined[:numValues] {
+		if !ok {
+			values = append(values, nil)
+			continue
+		}
+		size := int(binary.LittleEndian.Uint32(page))
+		v := string(page[4 : 4+size])
+		values = append(values, &v)
+		page = page[4+size:]
+	}
+	assert.Empty(t, page)
+	return values
+}
+
+// TestWriterRoundTrip 使用 thrift 官方实现及 parquet.thrift 生成的结构解析写入结果，校验文件格式
+func TestWriterRoundTrip(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		s   = func(v string) *string { return &v }
+	)
+
+	columns := []string{"message", "level"}
+	rows := [][]*string{
+		{s("hello"), s("info")},
+		{s(""), nil},
+		{nil, s("warn")},
+		{s("中文\n换行"), s("error")},
+		{s("last"), nil},
+	}
+
+	w := NewWriter(&buf, columns, 2)
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	meta := parquet.NewFileMetaData()
+	readThrift(t, data[len(data)-8-footerLen:len(data)-8], meta)
+
+	assert.Equal(t, int64(len(rows)), meta.NumRows)
+	assert.Equal(t, createdBy, meta.GetCreatedBy())
+	require.Len(t, meta.Schema, len(columns)+1)
+	assert.Equal(t, int32(len(columns)), meta.Schema[0].GetNumChildren())
+	for i, name := range columns {
+		el := meta.Schema[i+1]
+		assert.Equal(t, name, el.Name)
+		assert.Equal(t, parquet.Type_BYTE_ARRAY, el.GetType())
+		assert.Equal(t, parquet.FieldRepetitionType_OPTIONAL, el.GetRepetitionType())
+		assert.Equal(t, parquet.ConvertedType_UTF8, el.GetConvertedType())
+	}
+
+	got := make([][]*string, len(columns))
+	require.Len(t, meta.RowGroups, 3)
+	for _, rg := range meta.RowGroups {
+		require.Len(t, rg.Columns, len(columns))
+		for i, chunk := range rg.Columns {
+			md := chunk.MetaData
+			assert.Equal(t, []string{columns[i]}, md.PathInSchema)
+			assert.Equal(t, parquet.CompressionCodec_UNCOMPRESSED, md.Codec)
+			assert.Equal(t, rg.NumRows, md.NumValues)
+
+			header := parquet.NewPageHeader()
+			n := readThrift(t, data[md.DataPageOffset:], header)
+			require.Equal(t, parquet.PageType_DATA_PAGE, header.Type)
+			assert.Equal(t, parquet.Encoding_PLAIN, header.DataPageHeader.Encoding)
+			assert.Equal(t, parquet.Encoding_RLE, header.DataPageHeader.DefinitionLevelEncoding)
+			assert.Equal(t, md.TotalCompressedSize, int64(n)+int64(header.CompressedPageSize))
+
+			page := data[md.DataPageOffset+int64(n) : md.DataPageOffset+int64(n)+int64(header.CompressedPageSize)]
+			got[i] = append(got[i], decodePage(t, page, int(header.DataPageHeader.NumValues))...)
+		}
+	}
+
+	for i, name := range columns {
+		require.Len(t, got[i], len(rows), name)
+		for j, row := range rows {
+			assert.Equal(t, row[i], got[i][j], "%s row %d", name, j)
+		}
+	}
+}
@@ -40,12 +40,17 @@ type Ristretto struct {
 }
 
 func NewRistretto() (*Ristretto, error) {
-	c, err := ristretto.NewCache(&ristretto.Config{
+	return NewRistrettoWithConfig(&ristretto.Config{
 		NumCounters:        viper.GetInt64(RistrettoNumCountersPath),
 		MaxCost:            viper.GetInt64(RistrettoMaxCostPath),
 		BufferItems:        viper.GetInt64(RistrettoBufferItemsPath),
 		IgnoreInternalCost: viper.GetBool(RistrettoIgnoreInternalCostPath),
 	})
+}
+
+// NewRistrettoWithConfig 使用独立配置创建缓存，避免与路由缓存共享容量
+func NewRistrettoWithConfig(conf *ristretto.Config) (*Ristretto, error) {
+	c, err := ristretto.NewCache(conf)
 	if err != nil {
 		return nil, fmt.Errorf("new ristretto cache error, %v", err)
 	}
@@ -38,6 +38,9 @@ const (
 
 	QueryRawError = "QUERY_RAW_ERROR"
 
+	// QueryCostDowngrade 查询代价超出配额，已放大 step 后查询
+	QueryCostDowngrade = "QUERY_COST_DOWNGRADE"
+
 	// QueryRawPartial 原始查询多路合并时部分子查询失败、但至少一路成功
 	QueryRawPartial = "QUERY_RAW_PARTIAL"
 	// QueryTsPartial 时序查询多路合并时部分子查询失败、但至少一路成功
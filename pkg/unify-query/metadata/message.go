@@ -41,8 +41,11 @@ const (
 	MsgQueryReference      = "query_reference"
 	MsgQueryRaw            = "query_raw"
 	MsgQueryRawScroll      = "query_raw_scroll"
+	MsgQueryRawExport      = "query_raw_export"
+	MsgQuerySQL            = "query_sql"
 	MsgQueryExemplar       = "query_exemplar"
 	MsgQueryClusterMetrics = "query_cluster_metrics"
+	MsgQueryCost           = "query_cost"
 
 	MsgRedisLock = "redis_lock"
 
@@ -36,8 +36,10 @@ const (
 )
 
 const (
-	ResultHit  = "hit"
-	ResultMiss = "miss"
+	ResultHit        = "hit"
+	ResultMiss       = "miss"
+	ResultPartialHit = "partial_hit"
+	ResultSkip       = "skip"
 )
 
 // RedisRouterLoadResult 为 unify_query_redis_router_load_total 的 result 标签取值（固定 success|failure，基数可控）。
@@ -167,6 +169,56 @@ var (
 		[]string{"storage_id", "version", "commit_id"},
 	)
 
+	// result_cache_request_total：range 查询结果缓存的命中情况，result 为 hit|partial_hit|miss|skip
+	resultCacheRequestTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "unify_query",
+			Name:      "result_cache_request_total",
+			Help:      "unify-query range query result cache lookup result",
+		},
+		[]string{"result"},
+	)
+
+	// query_cost_check_total：查询代价预估后的配额处理结果，action 为 pass|downgrade|reject
+	queryCostCheckTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "unify_query",
+			Name:      "query_cost_check_total",
+			Help:      "unify-query query cost quota check result",
+		},
+		[]string{"space_uid", "action"},
+	)
+
+	// record_rule_eval_total：recording rule 每条规则的计算结果，status 为 success|failed
+	recordRuleEvalTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "unify_query",
+			Name:      "record_rule_eval_total",
+			Help:      "unify-query recording rule evaluation total",
+		},
+		[]string{"space_uid", "group", "status"},
+	)
+
+	// record_rule_eval_lag_seconds：recording rule 分组从计划计算时间到结果写入完成的延迟
+	recordRuleEvalLagSeconds = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "unify_query",
+			Name:      "record_rule_eval_lag_seconds",
+			Help:      "unify-query recording rule group evaluation lag seconds",
+		},
+		[]string{"space_uid", "group"},
+	)
+
+	// record_rule_samples_total：recording rule 写入的样本数
+	recordRuleSamplesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "unify_query",
+			Name:      "record_rule_samples_total",
+			Help:      "unify-query recording rule written samples total",
+		},
+		[]string{"space_uid", "group"},
+	)
+
 	// redis_router_load_total：space_tsdb LoadRouter 每次结束记 1 次，result 为 success 或 failure（route_key 仅 SpaceAllKey）
 	redisRouterLoadTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -232,6 +284,31 @@ func ResultTableInfoSet(ctx context.Context, value float64, rtTableID, rtDataID,
 	gaugeSet(ctx, metric, value)
 }
 
+func ResultCacheRequestInc(ctx context.Context, result string) {
+	metric, _ := resultCacheRequestTotal.GetMetricWithLabelValues(result)
+	counterInc(ctx, metric)
+}
+
+func QueryCostCheckInc(ctx context.Context, spaceUID, action string) {
+	metric, _ := queryCostCheckTotal.GetMetricWithLabelValues(spaceUID, action)
+	counterInc(ctx, metric)
+}
+
+func RecordRuleEvalInc(ctx context.Context, spaceUID, group, status string) {
+	metric, _ := recordRuleEvalTotal.GetMetricWithLabelValues(spaceUID, group, status)
+	counterInc(ctx, metric)
+}
+
+func RecordRuleEvalLagSet(ctx context.Context, lag time.Duration, spaceUID, group string) {
+	metric, _ := recordRuleEvalLagSeconds.GetMetricWithLabelValues(spaceUID, group)
+	gaugeSet(ctx, metric, lag.Seconds())
+}
+
+func RecordRuleSamplesAdd(ctx context.Context, val int, spaceUID, group string) {
+	metric, _ := recordRuleSamplesTotal.GetMetricWithLabelValues(spaceUID, group)
+	counterAdd(ctx, metric, float64(val))
+}
+
 func JWTRequestInc(ctx context.Context, api, jwtAppCode, jwtAppUserName, spaceUID, status string) {
 	metric, _ := jwtRequestTotal.GetMetricWithLabelValues(api, jwtAppCode, jwtAppUserName, spaceUID, status)
 	counterInc(ctx, metric)
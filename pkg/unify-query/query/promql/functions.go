@@ -0,0 +1,362 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promql
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	prom "github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	// SeasonalBaseline 最近 n 个周期同一时刻的均值，n = 区间 / 周期
+	SeasonalBaseline = "seasonal_baseline"
+	// ZScoreOverTime 最新值相对区间内均值的标准分
+	ZScoreOverTime = "zscore_over_time"
+	// MADScoreOverTime 最新值相对区间内中位数的修正标准分（基于中位数绝对偏差）
+	MADScoreOverTime = "mad_score_over_time"
+	// HoltWintersForecast 二次指数平滑后预测 horizon 秒后的值
+	HoltWintersForecast = "holt_winters_forecast"
+	// TimeToThreshold 区间内线性拟合后到达阈值还需要的秒数
+	TimeToThreshold = "time_to_threshold"
+)
+
+// madScale 正态分布下中位数绝对偏差与标准差的换算系数
+const madScale = 0.6745
+
+// meanADScale MAD 为 0 时使用平均绝对偏差，对应的换算系数
+const meanADScale = 1.253314
+
+type function struct {
+	define *parser.Function
+	call   prom.FunctionCall
+}
+
+// functions 扩展的异常检测及预测函数，仅 unify-query 的 promql 引擎支持，VictoriaMetrics 直查无法使用
+var functions = map[string]function{
+	SeasonalBaseline: {
+		define: &parser.Function{
+			Name:       SeasonalBaseline,
+			ArgTypes:   []parser.ValueType{parser.ValueTypeMatrix, parser.ValueTypeScalar},
+			ReturnType: parser.ValueTypeVector,
+		},
+		call: funcSeasonalBaseline,
+	},
+	ZScoreOverTime: {
+		define: &parser.Function{
+			Name:       ZScoreOverTime,
+			ArgTypes:   []parser.ValueType{parser.ValueTypeMatrix},
+			ReturnType: parser.ValueTypeVector,
+		},
+		call: funcZScoreOverTime,
+	},
+	MADScoreOverTime: {
+		define: &parser.Function{
+			Name:       MADScoreOverTime,
+			ArgTypes:   []parser.ValueType{parser.ValueTypeMatrix},
+			ReturnType: parser.ValueTypeVector,
+		},
+		call: funcMADScoreOverTime,
+	},
+	HoltWintersForecast: {
+		define: &parser.Function{
+			Name:       HoltWintersForecast,
+			ArgTypes:   []parser.ValueType{parser.ValueTypeMatrix, parser.ValueTypeScalar, parser.ValueTypeScalar, parser.ValueTypeScalar},
+			ReturnType: parser.ValueTypeVector,
+		},
+		call: funcHoltWintersForecast,
+	},
+	TimeToThreshold: {
+		define: &parser.Function{
+			Name:       TimeToThreshold,
+			ArgTypes:   []parser.ValueType{parser.ValueTypeMatrix, parser.ValueTypeScalar},
+			ReturnType: parser.ValueTypeVector,
+		},
+		call: funcTimeToThreshold,
+	},
+}
+
+// IsExtendFunction 判断是否为扩展函数
+func IsExtendFunction(name string) bool {
+	_, ok := functions[name]
+	return ok
+}
+
+// FindExtendFunction 返回表达式中使用的第一个扩展函数，未使用时返回空
+func FindExtendFunction(expr parser.Expr) string {
+	var name string
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if call, ok := node.(*parser.Call); ok && name == "" && IsExtendFunction(call.Func.Name) {
+			name = call.Func.Name
+		}
+		return nil
+	})
+	return name
+}
+
+// matrixSelectorRange 获取区间向量参数的区间以及偏移，子查询在引擎中已被替换为 MatrixSelector
+func matrixSelectorRange(arg parser.Expr) (time.Duration, time.Duration) {
+	ms, ok := arg.(*parser.MatrixSelector)
+	if !ok {
+		return 0, 0
+	}
+
+	var offset time.Duration
+	if vs, ok := ms.VectorSelector.(*parser.VectorSelector); ok {
+		offset = vs.Offset
+	}
+	return ms.Range, offset
+}
+
+// === seasonal_baseline(v parser.ValueTypeMatrix, season parser.ValueTypeScalar) Vector ===
+func funcSeasonalBaseline(vals []parser.Value, args parser.Expressions, enh *prom.EvalNodeHelper) prom.Vector {
+	points := vals[0].(prom.Matrix)[0].Points
+	season := time.Duration(vals[1].(prom.Vector)[0].V * float64(time.Second))
+	if season <= 0 {
+		panic(fmt.Errorf("invalid season. Expected: season > 0, got: %s", season))
+	}
+
+	rng, offset := matrixSelectorRange(args[0])
+	n := int(rng / season)
+	if n < 1 || len(points) == 0 {
+		return enh.Out
+	}
+
+	// 每个周期只取目标时刻之前 lookback 内最近的一个点，与瞬时查询的取点方式一致
+	tolerance := GetDefaultLookbackDelta()
+	if tolerance > season {
+		tolerance = season
+	}
+
+	base := enh.Ts - offset.Milliseconds()
+	var (
+		sum   float64
+		count int
+	)
+	for k := 1; k <= n; k++ {
+		target := base - int64(k)*season.Milliseconds()
+		// points 按时间升序，找到第一个大于 target 的点，前一个即为目标点
+		idx := sort.Search(len(points), func(i int) bool {
+			return points[i].T > target
+		}) - 1
+		if idx < 0 || points[idx].T <= target-tolerance.Milliseconds() {
+			continue
+		}
+
+		sum += points[idx].V
+		count++
+	}
+
+	if count == 0 {
+		return enh.Out
+	}
+	return append(enh.Out, prom.Sample{
+		Point: prom.Point{V: sum / float64(count)},
+	})
+}
+
+// === zscore_over_time(v parser.ValueTypeMatrix) Vector ===
+func funcZScoreOverTime(vals []parser.Value, args parser.Expressions, enh *prom.EvalNodeHelper) prom.Vector {
+	points := vals[0].(prom.Matrix)[0].Points
+	if len(points) < 2 {
+		return enh.Out
+	}
+
+	var mean, m2 float64
+	for i, p := range points {
+		delta := p.V - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (p.V - mean)
+	}
+	stddev := math.Sqrt(m2 / float64(len(points)))
+
+	var score float64
+	if stddev != 0 {
+		score = (points[len(points)-1].V - mean) / stddev
+	}
+	return append(enh.Out, prom.Sample{
+		Point: prom.Point{V: score},
+	})
+}
+
+// median 计算中位数，会修改传入的切片顺序
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	l := len(values)
+	if l%2 == 1 {
+		return values[l/2]
+	}
+	return (values[l/2-1] + values[l/2]) / 2
+}
+
+// === mad_score_over_time(v parser.ValueTypeMatrix) Vector ===
+func funcMADScoreOverTime(vals []parser.Value, args parser.Expressions, enh *prom.EvalNodeHelper) prom.Vector {
+	points := vals[0].(prom.Matrix)[0].Points
+	if len(points) < 2 {
+		return enh.Out
+	}
+
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.V
+	}
+	m := median(values)
+
+	var meanAD float64
+	for i, v := range values {
+		values[i] = math.Abs(v - m)
+		meanAD += values[i]
+	}
+	meanAD /= float64(len(values))
+	mad := median(values)
+
+	last := points[len(points)-1].V
+	var score float64
+	switch {
+	case mad != 0:
+		score = madScale * (last - m) / mad
+	case meanAD != 0:
+		// 超过一半的点相同时 MAD 为 0，使用平均绝对偏差代替
+		score = (last - m) / (meanADScale * meanAD)
+	}
+	return append(enh.Out, prom.Sample{
+		Point: prom.Point{V: score},
+	})
+}
+
+// calcTrendValue 与 holt_winters 一致的趋势计算
+func calcTrendValue(i int, tf, s0, s1, b float64) float64 {
+	if i == 0 {
+		return b
+	}
+
+	x := tf * (s1 - s0)
+	y := (1 - tf) * b
+	return x + y
+}
+
+// === holt_winters_forecast(v parser.ValueTypeMatrix, sf, tf, horizon parser.ValueTypeScalar) Vector ===
+func funcHoltWintersForecast(vals []parser.Value, args parser.Expressions, enh *prom.EvalNodeHelper) prom.Vector {
+	points := vals[0].(prom.Matrix)[0].Points
+
+	// 平滑系数
+	sf := vals[1].(prom.Vector)[0].V
+	// 趋势系数
+	tf := vals[2].(prom.Vector)[0].V
+	// 预测的秒数
+	horizon := vals[3].(prom.Vector)[0].V
+
+	if sf <= 0 || sf >= 1 {
+		panic(fmt.Errorf("invalid smoothing factor. Expected: 0 < sf < 1, got: %f", sf))
+	}
+	if tf <= 0 || tf >= 1 {
+		panic(fmt.Errorf("invalid trend factor. Expected: 0 < tf < 1, got: %f", tf))
+	}
+	if horizon < 0 {
+		panic(fmt.Errorf("invalid horizon. Expected: horizon >= 0, got: %f", horizon))
+	}
+
+	l := len(points)
+	if l < 2 {
+		return enh.Out
+	}
+
+	var s0, s1, b float64
+	s1 = points[0].V
+	b = points[1].V - points[0].V
+
+	for i := 1; i < l; i++ {
+		x := sf * points[i].V
+		b = calcTrendValue(i-1, tf, s0, s1, b)
+		y := (1 - sf) * (s1 + b)
+
+		s0, s1 = s1, x+y
+	}
+	// 最后一个点的趋势
+	b = calcTrendValue(l-1, tf, s0, s1, b)
+
+	// 趋势按点计算，需要换算为按平均采样间隔的步数
+	interval := float64(points[l-1].T-points[0].T) / float64(l-1) / 1e3
+	if interval <= 0 {
+		return enh.Out
+	}
+
+	return append(enh.Out, prom.Sample{
+		Point: prom.Point{V: s1 + b*horizon/interval},
+	})
+}
+
+// linearRegression 最小二乘拟合，x 为相对 interceptTime 的秒数
+func linearRegression(points []prom.Point, interceptTime int64) (slope, intercept float64) {
+	var n, sumX, sumY, sumXY, sumX2 float64
+	constY := true
+	for i, p := range points {
+		if i > 0 && p.V != points[0].V {
+			constY = false
+		}
+		x := float64(p.T-interceptTime) / 1e3
+		n++
+		sumX += x
+		sumY += p.V
+		sumXY += x * p.V
+		sumX2 += x * x
+	}
+	if constY {
+		return 0, points[0].V
+	}
+
+	covXY := sumXY - sumX*sumY/n
+	varX := sumX2 - sumX*sumX/n
+
+	slope = covXY / varX
+	intercept = sumY/n - slope*sumX/n
+	return slope, intercept
+}
+
+// === time_to_threshold(v parser.ValueTypeMatrix, threshold parser.ValueTypeScalar) Vector ===
+func funcTimeToThreshold(vals []parser.Value, args parser.Expressions, enh *prom.EvalNodeHelper) prom.Vector {
+	points := vals[0].(prom.Matrix)[0].Points
+	threshold := vals[1].(prom.Vector)[0].V
+	if len(points) < 2 {
+		return enh.Out
+	}
+
+	slope, intercept := linearRegression(points, enh.Ts)
+	if math.IsNaN(slope) || math.IsNaN(intercept) {
+		return enh.Out
+	}
+
+	if intercept == threshold {
+		return append(enh.Out, prom.Sample{})
+	}
+	// 趋势不变或者远离阈值时永远无法到达，不返回数据
+	if slope == 0 {
+		return enh.Out
+	}
+	seconds := (threshold - intercept) / slope
+	if seconds < 0 {
+		return enh.Out
+	}
+
+	return append(enh.Out, prom.Sample{
+		Point: prom.Point{V: seconds},
+	})
+}
+
+func init() {
+	for name, f := range functions {
+		parser.Functions[name] = f.define
+		prom.FunctionCalls[name] = f.call
+	}
+}
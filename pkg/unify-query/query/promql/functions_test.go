@@ -0,0 +1,137 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promql
+
+import (
+	"math"
+	"testing"
+
+	prom "github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callFunction 解析表达式后直接调用扩展函数，区间向量参数使用 points，标量参数使用字面量
+func callFunction(t *testing.T, q string, ts int64, points []prom.Point) prom.Vector {
+	expr, err := parser.ParseExpr(q)
+	require.NoError(t, err)
+
+	call, ok := expr.(*parser.Call)
+	require.True(t, ok)
+
+	vals := make([]parser.Value, len(call.Args))
+	for i, arg := range call.Args {
+		switch a := arg.(type) {
+		case *parser.MatrixSelector:
+			vals[i] = prom.Matrix{{Points: points}}
+		case *parser.NumberLiteral:
+			vals[i] = prom.Vector{{Point: prom.Point{V: a.Val}}}
+		}
+	}
+	return functions[call.Func.Name].call(vals, call.Args, &prom.EvalNodeHelper{Ts: ts})
+}
+
+// linePoints 从 start 开始每 step 毫秒一个点，值为 f(i)
+func linePoints(start, step int64, n int, f func(i int) float64) []prom.Point {
+	points := make([]prom.Point, 0, n)
+	for i := 0; i < n; i++ {
+		points = append(points, prom.Point{T: start + int64(i)*step, V: f(i)})
+	}
+	return points
+}
+
+func TestExtendFunctions(t *testing.T) {
+	const (
+		minute = int64(60 * 1000)
+		day    = 24 * 60 * minute
+	)
+	ts := 10 * day
+
+	for name, c := range map[string]struct {
+		q        string
+		points   []prom.Point
+		expected []float64
+	}{
+		"seasonal baseline": {
+			q: `seasonal_baseline(m[2d5m], 86400)`,
+			points: []prom.Point{
+				{T: ts - 2*day - minute, V: 10},
+				{T: ts - day - 10*minute, V: 100},
+				{T: ts - day - minute, V: 30},
+				{T: ts, V: 1000},
+			},
+			expected: []float64{20},
+		},
+		"seasonal baseline without data in lookback": {
+			q: `seasonal_baseline(m[1d5m], 86400)`,
+			points: []prom.Point{
+				{T: ts - day - 10*minute, V: 100},
+				{T: ts, V: 1000},
+			},
+		},
+		"zscore": {
+			q:        `zscore_over_time(m[10m])`,
+			points:   linePoints(ts-4*minute, minute, 5, func(i int) float64 { return []float64{2, 4, 4, 4, 6}[i] }),
+			expected: []float64{(6 - 4) / math.Sqrt(1.6)},
+		},
+		"zscore constant": {
+			q:        `zscore_over_time(m[10m])`,
+			points:   linePoints(ts-4*minute, minute, 5, func(i int) float64 { return 3 }),
+			expected: []float64{0},
+		},
+		"mad score": {
+			q:        `mad_score_over_time(m[10m])`,
+			points:   linePoints(ts-4*minute, minute, 5, func(i int) float64 { return []float64{1, 2, 3, 4, 13}[i] }),
+			expected: []float64{madScale * (13 - 3) / 1},
+		},
+		"mad score with zero mad": {
+			q:        `mad_score_over_time(m[10m])`,
+			points:   linePoints(ts-4*minute, minute, 5, func(i int) float64 { return []float64{5, 5, 5, 5, 10}[i] }),
+			expected: []float64{5 / (meanADScale * 1)},
+		},
+		"holt winters forecast": {
+			q:        `holt_winters_forecast(m[10m], 0.5, 0.5, 300)`,
+			points:   linePoints(ts-9*minute, minute, 10, func(i int) float64 { return float64(i) }),
+			expected: []float64{14},
+		},
+		"time to threshold": {
+			q:        `time_to_threshold(m[10m], 100)`,
+			points:   linePoints(ts-9*minute, minute, 10, func(i int) float64 { return float64(i) * 6 }),
+			expected: []float64{(100 - 54) / 0.1},
+		},
+		"time to threshold moving away": {
+			q:      `time_to_threshold(m[10m], 0)`,
+			points: linePoints(ts-9*minute, minute, 10, func(i int) float64 { return float64(i + 1) }),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out := callFunction(t, c.q, ts, c.points)
+			actual := make([]float64, 0, len(out))
+			for _, s := range out {
+				actual = append(actual, s.V)
+			}
+			require.Len(t, actual, len(c.expected))
+			for i := range c.expected {
+				assert.InDelta(t, c.expected[i], actual[i], 1e-9)
+			}
+		})
+	}
+}
+
+func TestFindExtendFunction(t *testing.T) {
+	expr, err := parser.ParseExpr(`sum(rate(a[1m])) / sum(zscore_over_time(b[1h]))`)
+	require.NoError(t, err)
+	assert.Equal(t, ZScoreOverTime, FindExtendFunction(expr))
+
+	expr, err = parser.ParseExpr(`holt_winters(a[1h], 0.5, 0.5)`)
+	require.NoError(t, err)
+	assert.Equal(t, "", FindExtendFunction(expr))
+}
@@ -0,0 +1,90 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promql
+
+import (
+	"sort"
+	"time"
+
+	prom "github.com/prometheus/prometheus/promql"
+)
+
+// StepRange 按 step 对齐的查询区间（闭区间）
+type StepRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SplitStepRange 按 interval 将 [start, end] 拆分为互不重叠的区间，每个区间的起点都落在 start + k*step 上，
+// 保证拆分后各区间计算出的点与整体查询一致
+func SplitStepRange(start, end time.Time, step, interval time.Duration) []StepRange {
+	if step <= 0 || interval <= 0 || end.Sub(start) <= interval {
+		return []StepRange{{Start: start, End: end}}
+	}
+
+	n := interval / step
+	if n < 1 {
+		n = 1
+	}
+
+	var ranges []StepRange
+	for s := start; !s.After(end); s = s.Add(n * step) {
+		e := s.Add((n - 1) * step)
+		if !e.Before(end) || end.Sub(e) < step {
+			e = end
+		}
+		ranges = append(ranges, StepRange{Start: s, End: e})
+		if e.Equal(end) {
+			break
+		}
+	}
+	return ranges
+}
+
+// MergeMatrix 按 labels 合并多段查询结果，同一时间点以后出现的为准
+func MergeMatrix(ms ...prom.Matrix) prom.Matrix {
+	var (
+		index = make(map[string]int)
+		res   = make(prom.Matrix, 0)
+	)
+	for _, m := range ms {
+		for _, s := range m {
+			key := s.Metric.String()
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(res)
+				res = append(res, prom.Series{
+					Metric: s.Metric,
+					Points: append([]prom.Point{}, s.Points...),
+				})
+				continue
+			}
+			res[i].Points = append(res[i].Points, s.Points...)
+		}
+	}
+
+	for i := range res {
+		points := res[i].Points
+		sort.SliceStable(points, func(a, b int) bool {
+			return points[a].T < points[b].T
+		})
+		dedup := points[:0]
+		for _, p := range points {
+			if n := len(dedup); n > 0 && dedup[n-1].T == p.T {
+				dedup[n-1] = p
+				continue
+			}
+			dedup = append(dedup, p)
+		}
+		res[i].Points = dedup
+	}
+	sort.Sort(res)
+	return res
+}
@@ -0,0 +1,87 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package promql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	prom "github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStepRange(t *testing.T) {
+	start := time.Unix(0, 0)
+	for name, c := range map[string]struct {
+		end      time.Duration
+		step     time.Duration
+		interval time.Duration
+		expected [][2]time.Duration
+	}{
+		"not split": {
+			end: time.Hour, step: time.Minute, interval: 24 * time.Hour,
+			expected: [][2]time.Duration{{0, time.Hour}},
+		},
+		"split by hour": {
+			end: 150 * time.Minute, step: 10 * time.Minute, interval: time.Hour,
+			expected: [][2]time.Duration{
+				{0, 50 * time.Minute},
+				{60 * time.Minute, 110 * time.Minute},
+				{120 * time.Minute, 150 * time.Minute},
+			},
+		},
+		"interval not multiple of step": {
+			end: 100 * time.Minute, step: 30 * time.Minute, interval: 40 * time.Minute,
+			expected: [][2]time.Duration{
+				{0, 0},
+				{30 * time.Minute, 30 * time.Minute},
+				{60 * time.Minute, 60 * time.Minute},
+				{90 * time.Minute, 100 * time.Minute},
+			},
+		},
+		"end not on step": {
+			end: 125 * time.Minute, step: 10 * time.Minute, interval: time.Hour,
+			expected: [][2]time.Duration{
+				{0, 50 * time.Minute},
+				{60 * time.Minute, 110 * time.Minute},
+				{120 * time.Minute, 125 * time.Minute},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var actual [][2]time.Duration
+			for _, r := range SplitStepRange(start, start.Add(c.end), c.step, c.interval) {
+				actual = append(actual, [2]time.Duration{r.Start.Sub(start), r.End.Sub(start)})
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestMergeMatrix(t *testing.T) {
+	a := labels.FromStrings("a", "1")
+	b := labels.FromStrings("a", "2")
+
+	res := MergeMatrix(
+		prom.Matrix{
+			{Metric: b, Points: []prom.Point{{T: 3, V: 3}}},
+			{Metric: a, Points: []prom.Point{{T: 1, V: 1}, {T: 2, V: 2}}},
+		},
+		prom.Matrix{
+			{Metric: a, Points: []prom.Point{{T: 2, V: 20}, {T: 3, V: 3}}},
+		},
+	)
+
+	assert.Equal(t, prom.Matrix{
+		{Metric: a, Points: []prom.Point{{T: 1, V: 1}, {T: 2, V: 20}, {T: 3, V: 3}}},
+		{Metric: b, Points: []prom.Point{{T: 3, V: 3}}},
+	}, res)
+}
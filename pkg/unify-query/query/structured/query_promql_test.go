@@ -78,6 +78,22 @@ func TestQueryPromQLExpr(t *testing.T) {
 			q: `(100 - (sum(rate(a[1m]))/sum(rate(a[1m]))) * 100) OR on() vector(100)`,
 			r: `(100 - (sum(rate(bkmonitor:a[1m])) / sum(rate(bkmonitor:a[1m]))) * 100) or on () vector(100)`,
 		},
+		"seasonal baseline": {
+			q: `sum(usage) by (ip) / sum(seasonal_baseline(usage[7d5m], 604800)) by (ip)`,
+			r: `sum by (ip) (bkmonitor:usage) / sum by (ip) (seasonal_baseline(bkmonitor:usage[7d5m], 604800))`,
+		},
+		"zscore and mad score": {
+			q: `max(zscore_over_time(usage[1h])) by (ip) > 3 or max(mad_score_over_time(usage[1h])) by (ip) > 3.5`,
+			r: `max by (ip) (zscore_over_time(bkmonitor:usage[1h])) > 3 or max by (ip) (mad_score_over_time(bkmonitor:usage[1h])) > 3.5`,
+		},
+		"holt winters forecast": {
+			q: `holt_winters_forecast(usage{ip="127.0.0.1"}[1h], 0.3, 0.1, 3600)`,
+			r: `holt_winters_forecast(bkmonitor:usage{ip="127.0.0.1"}[1h], 0.3, 0.1, 3600)`,
+		},
+		"time to threshold": {
+			q: `min(time_to_threshold(disk_used[6h], 100)) by (mount) < 4 * 3600`,
+			r: `min by (mount) (time_to_threshold(bkmonitor:disk_used[6h], 100)) < 4 * 3600`,
+		},
 		"group": {
 			q: `group(custom:datalabel:container_cpu_load_average_10s)`,
 			r: `group(custom:datalabel:container_cpu_load_average_10s)`,
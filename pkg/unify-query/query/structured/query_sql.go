@@ -0,0 +1,1120 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package structured
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	antlr "github.com/antlr4-go/antlr/v4"
+	"github.com/prometheus/common/model"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/doris_parser/gen"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+)
+
+const (
+	// SQLTimeBucketFunc 时间分桶函数，例如 time_bucket('1m') 或 time_bucket(dtEventTimeStamp, INTERVAL 1 MINUTE)
+	SQLTimeBucketFunc = "time_bucket"
+
+	// SQLMaxConditionGroups where 条件展开为析取范式后允许的最大分组数
+	SQLMaxConditionGroups = 64
+
+	sqlReferenceName = "a"
+	sqlSelectAll     = "*"
+)
+
+// sqlAggregateMap SQL 聚合函数与存储聚合方法的映射
+var sqlAggregateMap = map[string]string{
+	"count":                 "count",
+	"sum":                   "sum",
+	"avg":                   "avg",
+	"min":                   "min",
+	"max":                   "max",
+	"approx_count_distinct": "cardinality",
+	"ndv":                   "cardinality",
+}
+
+// sqlNegateOperator 条件取反，用于 NOT 下推
+var sqlNegateOperator = map[string]string{
+	ConditionEqual:       ConditionNotEqual,
+	ConditionNotEqual:    ConditionEqual,
+	ConditionRegEqual:    ConditionNotRegEqual,
+	ConditionNotRegEqual: ConditionRegEqual,
+	ConditionContains:    ConditionNotContains,
+	ConditionNotContains: ConditionContains,
+	ConditionExisted:     ConditionNotExisted,
+	ConditionNotExisted:  ConditionExisted,
+	ConditionGt:          ConditionLte,
+	ConditionLte:         ConditionGt,
+	ConditionGte:         ConditionLt,
+	ConditionLt:          ConditionGte,
+}
+
+// sqlIntervalUnit INTERVAL 单位与时间周期的映射，月、季度、年长度不固定，不支持
+var sqlIntervalUnit = map[int]string{
+	gen.DorisParserParserSECOND: "s",
+	gen.DorisParserParserMINUTE: "m",
+	gen.DorisParserParserHOUR:   "h",
+	gen.DorisParserParserDAY:    "d",
+	gen.DorisParserParserWEEK:   "w",
+}
+
+// QuerySQL SQL 查询结构体，把 SELECT 子集转换为结构化查询，使 ES 等非 SQL 存储也可以使用 SQL 查询
+type QuerySQL struct {
+	// SQL 查询语句，支持 SELECT / WHERE / GROUP BY / ORDER BY / LIMIT
+	SQL string `json:"sql" example:"SELECT level, COUNT(*) AS cnt FROM result_table.es GROUP BY level ORDER BY cnt DESC LIMIT 10"`
+	// DataSource 数据源，默认为 bklog
+	DataSource string `json:"data_source,omitempty" example:"bklog"`
+	// SpaceUid 空间ID
+	SpaceUid string `json:"space_uid,omitempty"`
+	// Start 开始时间：单位为毫秒的时间戳
+	Start string `json:"start_time,omitempty" example:"1657848000"`
+	// End 结束时间：单位为毫秒的时间戳
+	End string `json:"end_time,omitempty" example:"1657851600"`
+	// Timezone 时区
+	Timezone string `json:"timezone,omitempty" example:"Asia/Shanghai"`
+}
+
+// SQLColumn SQL 输出列
+type SQLColumn struct {
+	// Name 输出列名，优先使用别名
+	Name string `json:"name"`
+	// Field 原始字段名，COUNT(*) 为 *
+	Field string `json:"field,omitempty"`
+	// Method 聚合方法，为空表示维度或原始字段
+	Method string `json:"method,omitempty"`
+	// IsTime 是否为时间分桶列
+	IsTime bool `json:"is_time,omitempty"`
+
+	key string
+}
+
+// SQLStatement SQL 解析结果
+type SQLStatement struct {
+	TableID    TableID
+	Columns    []SQLColumn
+	SelectAll  bool
+	Conditions Conditions
+	Dimensions []string
+	Window     Window
+	// OrderBy 排序列，优先使用输出列名，原始查询允许使用未出现在 select 中的字段
+	OrderBy OrderBy
+	Limit   int
+	From    int
+
+	groupByTime bool
+}
+
+// IsAggregate 是否为聚合查询
+func (s *SQLStatement) IsAggregate() bool {
+	if len(s.Dimensions) > 0 || s.Window != "" {
+		return true
+	}
+	for _, c := range s.Columns {
+		if c.Method != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Aggregations 聚合列
+func (s *SQLStatement) Aggregations() []SQLColumn {
+	cols := make([]SQLColumn, 0, len(s.Columns))
+	for _, c := range s.Columns {
+		if c.Method != "" {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// Column 按输出列名查找
+func (s *SQLStatement) Column(name string) (SQLColumn, bool) {
+	for _, c := range s.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return SQLColumn{}, false
+}
+
+// ColumnNames 输出列名
+func (s *SQLStatement) ColumnNames() []string {
+	names := make([]string, 0, len(s.Columns))
+	for _, c := range s.Columns {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// Statement 解析 SQL
+func (q *QuerySQL) Statement() (*SQLStatement, error) {
+	return ParseSQL(q.SQL)
+}
+
+func (q *QuerySQL) dataSource() string {
+	if q.DataSource == "" {
+		return BkLog
+	}
+	return q.DataSource
+}
+
+// RawQueryTs 非聚合 SQL 转换为原始数据查询
+func (q *QuerySQL) RawQueryTs(stmt *SQLStatement) *QueryTs {
+	query := &Query{
+		DataSource:    q.dataSource(),
+		TableID:       stmt.TableID,
+		ReferenceName: sqlReferenceName,
+		Conditions:    stmt.Conditions,
+	}
+	if !stmt.SelectAll {
+		for _, c := range stmt.Columns {
+			query.KeepColumns = append(query.KeepColumns, c.Field)
+		}
+	}
+
+	orderBy := make(OrderBy, 0, len(stmt.OrderBy))
+	for _, o := range stmt.OrderBy {
+		prefix, name := "", o
+		if strings.HasPrefix(o, "-") {
+			prefix, name = "-", o[1:]
+		}
+		if c, ok := stmt.Column(name); ok {
+			name = c.Field
+		}
+		orderBy = append(orderBy, prefix+name)
+	}
+
+	return &QueryTs{
+		SpaceUid:  q.SpaceUid,
+		QueryList: []*Query{query},
+		OrderBy:   orderBy,
+		Start:     q.Start,
+		End:       q.End,
+		Timezone:  q.Timezone,
+		Limit:     stmt.Limit,
+		From:      stmt.From,
+	}
+}
+
+// AggregateQueryTs 把单个聚合列转换为 reference 查询，多个聚合列分别查询后按维度合并
+func (q *QuerySQL) AggregateQueryTs(stmt *SQLStatement, col SQLColumn) *QueryTs {
+	query := &Query{
+		DataSource:    q.dataSource(),
+		TableID:       stmt.TableID,
+		FieldName:     col.Field,
+		ReferenceName: sqlReferenceName,
+		AggregateMethodList: AggregateMethodList{
+			{
+				Method:     col.Method,
+				Dimensions: append([]string{}, stmt.Dimensions...),
+				Window:     stmt.Window,
+			},
+		},
+		Conditions: stmt.Conditions,
+	}
+
+	queryTs := &QueryTs{
+		SpaceUid:    q.SpaceUid,
+		QueryList:   []*Query{query},
+		MetricMerge: sqlReferenceName,
+		Start:       q.Start,
+		End:         q.End,
+		Timezone:    q.Timezone,
+		Instant:     stmt.Window == "",
+	}
+	if stmt.Window != "" {
+		queryTs.Step = string(stmt.Window)
+	}
+
+	// 只有单个聚合列且没有时间分桶时，才能把排序和条数下推到 terms 聚合，其余场景在合并后统一排序截断
+	if len(stmt.Aggregations()) == 1 && stmt.Window == "" && len(stmt.OrderBy) <= 1 {
+		pushdown := len(stmt.OrderBy) == 0
+		if len(stmt.OrderBy) == 1 {
+			switch stmt.OrderBy[0] {
+			case col.Name:
+				queryTs.OrderBy = OrderBy{promql.ResultColumnValue}
+				pushdown = true
+			case "-" + col.Name:
+				queryTs.OrderBy = OrderBy{"-" + promql.ResultColumnValue}
+				pushdown = true
+			}
+		}
+		if pushdown && stmt.Limit > 0 {
+			query.Limit = stmt.Limit + stmt.From
+		}
+	}
+
+	return queryTs
+}
+
+// ParseSQL 使用 Doris 语法解析 SQL，并转换为与存储无关的查询描述
+func ParseSQL(q string) (stmt *SQLStatement, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("parse sql (%s) error: %v", q, r)
+		}
+	}()
+
+	q = strings.TrimRight(strings.TrimSpace(q), "; \t\n")
+	if q == "" {
+		return nil, fmt.Errorf("sql is empty")
+	}
+
+	errListener := &sqlErrorListener{DefaultErrorListener: antlr.NewDefaultErrorListener()}
+
+	lexer := gen.NewDorisLexer(antlr.NewInputStream(q))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errListener)
+
+	tokens := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	parser := gen.NewDorisParserParser(tokens)
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(errListener)
+
+	query := parser.Query()
+	if errListener.err == nil && parser.GetCurrentToken().GetTokenType() != antlr.TokenEOF {
+		errListener.err = fmt.Errorf("syntax error: unexpected %q", parser.GetCurrentToken().GetText())
+	}
+	if errListener.err != nil {
+		return nil, fmt.Errorf("parse sql (%s) error: %w", q, errListener.err)
+	}
+
+	p := &sqlParser{tokens: tokens, stmt: &SQLStatement{}}
+	if err = p.query(query); err != nil {
+		return nil, fmt.Errorf("parse sql (%s) error: %w", q, err)
+	}
+	return p.stmt, nil
+}
+
+type sqlErrorListener struct {
+	*antlr.DefaultErrorListener
+	err error
+}
+
+func (l *sqlErrorListener) SyntaxError(_ antlr.Recognizer, _ any, line, column int, msg string, _ antlr.RecognitionException) {
+	if l.err == nil {
+		l.err = fmt.Errorf("syntax error at %d:%d: %s", line, column, msg)
+	}
+}
+
+type sqlParser struct {
+	tokens *antlr.CommonTokenStream
+	table  string
+	alias  string
+	stmt   *SQLStatement
+}
+
+func (p *sqlParser) text(ctx antlr.ParserRuleContext) string {
+	return p.tokens.GetTextFromRuleContext(ctx)
+}
+
+// key 表达式归一化，用于 GROUP BY / ORDER BY 与 SELECT 中的表达式匹配
+func (p *sqlParser) key(ctx antlr.ParserRuleContext) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "\t", "", "\n", "", "`", "").Replace(p.text(ctx)))
+}
+
+func (p *sqlParser) unsupported(ctx antlr.ParserRuleContext) error {
+	return fmt.Errorf("unsupported expression: %s", p.text(ctx))
+}
+
+func (p *sqlParser) query(ctx gen.IQueryContext) error {
+	if ctx.Cte() != nil {
+		return p.unsupported(ctx.Cte())
+	}
+	term, ok := ctx.QueryTerm().(*gen.QueryTermDefaultContext)
+	if !ok {
+		return p.unsupported(ctx.QueryTerm())
+	}
+	primary, ok := term.QueryPrimary().(*gen.QueryPrimaryDefaultContext)
+	if !ok {
+		return p.unsupported(term.QueryPrimary())
+	}
+	spec, ok := primary.QuerySpecification().(*gen.RegularQuerySpecificationContext)
+	if !ok {
+		return p.unsupported(primary.QuerySpecification())
+	}
+	if spec.IntoClause() != nil {
+		return p.unsupported(spec.IntoClause())
+	}
+	if spec.HavingClause() != nil {
+		return p.unsupported(spec.HavingClause())
+	}
+	if spec.QualifyClause() != nil {
+		return p.unsupported(spec.QualifyClause())
+	}
+
+	if err := p.from(spec.FromClause()); err != nil {
+		return err
+	}
+	if err := p.selectClause(spec.SelectClause()); err != nil {
+		return err
+	}
+	if spec.WhereClause() != nil {
+		all, err := p.boolean(spec.WhereClause().BooleanExpression(), false)
+		if err != nil {
+			return err
+		}
+		p.stmt.Conditions = sqlConditions(all)
+	}
+	if spec.AggClause() != nil {
+		if err := p.groupBy(spec.AggClause().GroupingElement()); err != nil {
+			return err
+		}
+	}
+	if err := p.check(); err != nil {
+		return err
+	}
+
+	for _, org := range []gen.IQueryOrganizationContext{spec.QueryOrganization(), ctx.QueryOrganization()} {
+		if org == nil {
+			continue
+		}
+		if err := p.organization(org); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *sqlParser) from(ctx gen.IFromClauseContext) error {
+	if ctx == nil {
+		return fmt.Errorf("from clause is required")
+	}
+	relations := ctx.Relations().AllRelation()
+	if len(relations) != 1 || len(relations[0].AllJoinRelation()) > 0 {
+		return fmt.Errorf("only single table is supported: %s", p.text(ctx))
+	}
+	table, ok := relations[0].RelationPrimary().(*gen.TableNameContext)
+	if !ok {
+		return p.unsupported(relations[0].RelationPrimary())
+	}
+
+	parts := make([]string, 0)
+	for _, part := range table.MultipartIdentifier().AllErrorCapturingIdentifier() {
+		if _, ok := part.ErrorCapturingIdentifierExtra().(*gen.ErrorIdentContext); ok {
+			return fmt.Errorf("invalid table name %s, please use backquotes", p.text(part))
+		}
+		parts = append(parts, sqlIdentifier(part.Identifier().GetText()))
+	}
+	p.table = strings.Join(parts, ".")
+	p.stmt.TableID = TableID(p.table)
+
+	if alias := table.TableAlias(); alias != nil && alias.StrictIdentifier() != nil {
+		p.alias = sqlIdentifier(alias.StrictIdentifier().GetText())
+	}
+	return nil
+}
+
+func (p *sqlParser) selectClause(ctx gen.ISelectClauseContext) error {
+	if ctx.DISTINCT() != nil {
+		return fmt.Errorf("select distinct is not supported, please use group by")
+	}
+
+	names := make(map[string]struct{})
+	for _, ne := range ctx.SelectColumnClause().NamedExpressionSeq().AllNamedExpression() {
+		pe := p.primary(ne.Expression())
+		if pe == nil {
+			return p.unsupported(ne)
+		}
+
+		var alias string
+		if ne.IdentifierOrText() != nil {
+			alias = sqlIdentifier(ne.IdentifierOrText().GetText())
+		}
+
+		col := SQLColumn{key: p.key(pe)}
+		switch v := pe.(type) {
+		case *gen.StarContext:
+			if v.QualifiedName() != nil || len(v.AllExceptOrReplace()) > 0 || alias != "" {
+				return p.unsupported(v)
+			}
+			p.stmt.SelectAll = true
+			continue
+		case *gen.FunctionCallContext:
+			name, method, field, err := p.function(v.FunctionCallExpression())
+			if err != nil {
+				return err
+			}
+			if name == SQLTimeBucketFunc {
+				window, err := p.timeBucket(v.FunctionCallExpression())
+				if err != nil {
+					return err
+				}
+				if err = p.setWindow(window); err != nil {
+					return err
+				}
+				col.IsTime = true
+			} else {
+				col.Method, col.Field = method, field
+			}
+			col.Name = p.text(pe)
+		default:
+			field, err := p.field(pe)
+			if err != nil {
+				return err
+			}
+			col.Name, col.Field = field, field
+		}
+		if alias != "" {
+			col.Name = alias
+		}
+
+		if _, ok := names[col.Name]; ok {
+			return fmt.Errorf("duplicate column name %s", col.Name)
+		}
+		names[col.Name] = struct{}{}
+		p.stmt.Columns = append(p.stmt.Columns, col)
+	}
+
+	if p.stmt.SelectAll && len(p.stmt.Columns) > 0 {
+		return fmt.Errorf("select * can not be used with other columns")
+	}
+	return nil
+}
+
+// function 解析函数调用，返回函数名以及聚合方法和字段
+func (p *sqlParser) function(ctx gen.IFunctionCallExpressionContext) (name, method, field string, err error) {
+	name = strings.ToLower(sqlIdentifier(ctx.FunctionIdentifier().FunctionNameIdentifier().GetText()))
+	if ctx.OVER() != nil || ctx.ORDER() != nil || ctx.FunctionIdentifier().GetDbName() != nil {
+		return name, "", "", p.unsupported(ctx)
+	}
+	if name == SQLTimeBucketFunc {
+		return name, "", "", nil
+	}
+
+	method, ok := sqlAggregateMap[name]
+	if !ok {
+		return name, "", "", fmt.Errorf("unsupported function %s, only support %s and %s", name, strings.Join(sqlAggregateNames(), ", "), SQLTimeBucketFunc)
+	}
+
+	args := ctx.AllExpression()
+	if len(args) != 1 {
+		return name, "", "", fmt.Errorf("function %s expects 1 argument: %s", name, p.text(ctx))
+	}
+	pe := p.primary(args[0])
+	if pe == nil {
+		return name, "", "", p.unsupported(args[0])
+	}
+
+	if ctx.DISTINCT() != nil {
+		if method != CountAggName {
+			return name, "", "", p.unsupported(ctx)
+		}
+		method = "cardinality"
+	}
+
+	if star, ok := pe.(*gen.StarContext); ok {
+		if method != CountAggName || star.QualifiedName() != nil {
+			return name, "", "", p.unsupported(ctx)
+		}
+		return name, method, sqlSelectAll, nil
+	}
+
+	field, err = p.field(pe)
+	return name, method, field, err
+}
+
+// timeBucket 解析时间分桶函数的周期参数，字段参数可选，实际使用结果表的时间字段
+func (p *sqlParser) timeBucket(ctx gen.IFunctionCallExpressionContext) (Window, error) {
+	args := ctx.AllExpression()
+	if len(args) == 0 || len(args) > 2 || ctx.DISTINCT() != nil {
+		return "", fmt.Errorf("usage: %s([time_field, ]'1m'): %s", SQLTimeBucketFunc, p.text(ctx))
+	}
+
+	pe := p.primary(args[len(args)-1])
+	if pe == nil {
+		return "", p.unsupported(args[len(args)-1])
+	}
+
+	var window string
+	switch v := pe.(type) {
+	case *gen.IntervalLiteralContext:
+		interval := v.Interval()
+		unit, ok := sqlIntervalUnit[interval.UnitIdentifier().GetStart().GetTokenType()]
+		if !ok {
+			return "", fmt.Errorf("unsupported interval unit %s", interval.UnitIdentifier().GetText())
+		}
+		value, err := p.constant(p.primary(interval.Expression()))
+		if err != nil {
+			return "", err
+		}
+		window = value + unit
+	default:
+		value, err := p.constant(pe)
+		if err != nil {
+			return "", err
+		}
+		window = value
+	}
+
+	d, err := model.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return "", fmt.Errorf("invalid time bucket %q", window)
+	}
+	return Window(window), nil
+}
+
+func (p *sqlParser) setWindow(window Window) error {
+	if p.stmt.Window != "" && p.stmt.Window != window {
+		return fmt.Errorf("only one time bucket is supported: %s, %s", p.stmt.Window, window)
+	}
+	p.stmt.Window = window
+	return nil
+}
+
+func (p *sqlParser) groupBy(ctx gen.IGroupingElementContext) error {
+	if ctx.ROLLUP() != nil || ctx.CUBE() != nil || ctx.GROUPING() != nil {
+		return p.unsupported(ctx)
+	}
+
+	for _, expr := range ctx.AllExpression() {
+		pe := p.primary(expr)
+		if pe == nil {
+			return p.unsupported(expr)
+		}
+
+		if fc, ok := pe.(*gen.FunctionCallContext); ok {
+			name, _, _, err := p.function(fc.FunctionCallExpression())
+			if err != nil {
+				return err
+			}
+			if name != SQLTimeBucketFunc {
+				return fmt.Errorf("aggregate function can not be used in group by: %s", p.text(pe))
+			}
+			window, err := p.timeBucket(fc.FunctionCallExpression())
+			if err != nil {
+				return err
+			}
+			if err = p.setWindow(window); err != nil {
+				return err
+			}
+			p.stmt.groupByTime = true
+			continue
+		}
+
+		field, err := p.field(pe)
+		if err != nil {
+			return err
+		}
+		// 引用 select 中的别名
+		if c, ok := p.stmt.Column(field); ok && c.Method == "" {
+			if c.IsTime {
+				p.stmt.groupByTime = true
+				continue
+			}
+			field = c.Field
+		}
+		p.stmt.Dimensions = append(p.stmt.Dimensions, field)
+	}
+	return nil
+}
+
+// check 校验聚合查询的输出列都可以由聚合结果得到
+func (p *sqlParser) check() error {
+	if !p.stmt.IsAggregate() {
+		return nil
+	}
+	if p.stmt.SelectAll {
+		return fmt.Errorf("select * can not be used with aggregation")
+	}
+	if len(p.stmt.Aggregations()) == 0 {
+		return fmt.Errorf("aggregate query requires at least one aggregate function")
+	}
+	if p.stmt.Window != "" && !p.stmt.groupByTime {
+		return fmt.Errorf("%s must be used in group by", SQLTimeBucketFunc)
+	}
+
+	for _, c := range p.stmt.Columns {
+		if c.Method != "" || c.IsTime {
+			continue
+		}
+		found := false
+		for _, d := range p.stmt.Dimensions {
+			if d == c.Field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("column %s must appear in group by or be used in an aggregate function", c.Name)
+		}
+	}
+	return nil
+}
+
+func (p *sqlParser) organization(ctx gen.IQueryOrganizationContext) error {
+	if sc := ctx.SortClause(); sc != nil {
+		for _, item := range sc.AllSortItem() {
+			name, err := p.sortName(item.Expression())
+			if err != nil {
+				return err
+			}
+			if item.DESC() != nil {
+				name = "-" + name
+			}
+			p.stmt.OrderBy = append(p.stmt.OrderBy, name)
+		}
+	}
+
+	if lc := ctx.LimitClause(); lc != nil {
+		if lc.GetFinalOffset() != nil {
+			return p.unsupported(lc)
+		}
+		limit, err := strconv.Atoi(lc.GetLimit().GetText())
+		if err != nil {
+			return err
+		}
+		p.stmt.Limit = limit
+		if lc.GetOffset() != nil {
+			from, err := strconv.Atoi(lc.GetOffset().GetText())
+			if err != nil {
+				return err
+			}
+			p.stmt.From = from
+		}
+	}
+	return nil
+}
+
+// sortName 排序表达式转换为输出列名，原始查询允许使用未出现在 select 中的字段
+func (p *sqlParser) sortName(expr gen.IExpressionContext) (string, error) {
+	pe := p.primary(expr)
+	if pe == nil {
+		return "", p.unsupported(expr)
+	}
+
+	key := p.key(pe)
+	for _, c := range p.stmt.Columns {
+		if c.key == key || c.Name == sqlIdentifier(p.text(pe)) {
+			return c.Name, nil
+		}
+	}
+
+	if _, ok := pe.(*gen.FunctionCallContext); ok {
+		return "", fmt.Errorf("order by expression must appear in select: %s", p.text(pe))
+	}
+	field, err := p.field(pe)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range p.stmt.Columns {
+		if c.Method == "" && !c.IsTime && c.Field == field {
+			return c.Name, nil
+		}
+	}
+	if p.stmt.IsAggregate() {
+		return "", fmt.Errorf("order by column %s must appear in select", field)
+	}
+	return field, nil
+}
+
+// boolean 把布尔表达式转换为析取范式，not 表示外层存在取反
+func (p *sqlParser) boolean(ctx gen.IBooleanExpressionContext, not bool) (AllConditions, error) {
+	var (
+		all AllConditions
+		err error
+	)
+	switch v := ctx.(type) {
+	case *gen.LogicalNotContext:
+		return p.boolean(v.BooleanExpression(), !not)
+	case *gen.LogicalBinaryContext:
+		var isAnd bool
+		switch v.GetOperator().GetTokenType() {
+		case gen.DorisParserParserAND, gen.DorisParserParserLOGICALAND:
+			isAnd = true
+		case gen.DorisParserParserOR:
+		default:
+			return nil, p.unsupported(v)
+		}
+
+		left, err := p.boolean(v.GetLeft(), not)
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.boolean(v.GetRight(), not)
+		if err != nil {
+			return nil, err
+		}
+
+		// 德摩根定律：NOT (a AND b) = NOT a OR NOT b
+		if isAnd != not {
+			all = sqlAnd(left, right)
+		} else {
+			all = append(left, right...)
+		}
+	case *gen.PredicatedContext:
+		all, err = p.predicated(v, not)
+	case *gen.IsnullContext:
+		all, err = p.exists(v.ValueExpression(), !not)
+	case *gen.Is_not_null_predContext:
+		all, err = p.exists(v.ValueExpression(), not)
+	default:
+		return nil, p.unsupported(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(all) > SQLMaxConditionGroups {
+		return nil, fmt.Errorf("where clause is too complex, condition groups %d > %d", len(all), SQLMaxConditionGroups)
+	}
+	return all, nil
+}
+
+func (p *sqlParser) predicated(ctx *gen.PredicatedContext, not bool) (AllConditions, error) {
+	pred := ctx.Predicate()
+	if pred == nil {
+		switch v := ctx.ValueExpression().(type) {
+		case *gen.ComparisonContext:
+			return p.comparison(v, not)
+		case *gen.ValueExpressionDefaultContext:
+			if paren, ok := v.PrimaryExpression().(*gen.ParenthesizedExpressionContext); ok && paren.Expression().BooleanExpression() != nil {
+				return p.boolean(paren.Expression().BooleanExpression(), not)
+			}
+		}
+		return nil, p.unsupported(ctx)
+	}
+
+	field, err := p.valueField(ctx.ValueExpression())
+	if err != nil {
+		return nil, err
+	}
+	if pred.NOT() != nil {
+		not = !not
+	}
+
+	cond := func(op string, values ...string) AllConditions {
+		if not {
+			op = sqlNegateOperator[op]
+		}
+		return AllConditions{{{DimensionName: field, Operator: op, Value: values}}}
+	}
+
+	switch pred.GetKind().GetTokenType() {
+	case gen.DorisParserParserBETWEEN:
+		lower, err := p.valueConstant(pred.GetLower())
+		if err != nil {
+			return nil, err
+		}
+		upper, err := p.valueConstant(pred.GetUpper())
+		if err != nil {
+			return nil, err
+		}
+		gte := ConditionField{DimensionName: field, Operator: ConditionGte, Value: []string{lower}}
+		lte := ConditionField{DimensionName: field, Operator: ConditionLte, Value: []string{upper}}
+		if not {
+			gte.Operator, lte.Operator = sqlNegateOperator[gte.Operator], sqlNegateOperator[lte.Operator]
+			return AllConditions{{gte}, {lte}}, nil
+		}
+		return AllConditions{{gte, lte}}, nil
+	case gen.DorisParserParserREGEXP, gen.DorisParserParserRLIKE:
+		value, err := p.valueConstant(pred.GetPattern())
+		if err != nil {
+			return nil, err
+		}
+		return cond(ConditionRegEqual, value), nil
+	case gen.DorisParserParserLIKE:
+		if pred.GetEscape() != nil {
+			return nil, p.unsupported(pred)
+		}
+		value, err := p.valueConstant(pred.GetPattern())
+		if err != nil {
+			return nil, err
+		}
+		return cond(ConditionRegEqual, sqlLikeToRegexp(value)), nil
+	case gen.DorisParserParserIN:
+		if pred.Query() != nil {
+			return nil, p.unsupported(pred)
+		}
+		values := make([]string, 0, len(pred.AllExpression()))
+		for _, expr := range pred.AllExpression() {
+			value, err := p.constant(p.primary(expr))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return cond(ConditionEqual, values...), nil
+	case gen.DorisParserParserNULL:
+		// IS NOT NULL 的 NOT 已经合并到 not 中
+		return p.exists(ctx.ValueExpression(), !not)
+	case gen.DorisParserParserTRUE:
+		return cond(ConditionEqual, "true"), nil
+	case gen.DorisParserParserFALSE:
+		return cond(ConditionEqual, "false"), nil
+	default:
+		return nil, p.unsupported(pred)
+	}
+}
+
+// exists 字段是否存在，isNull 为 true 表示 IS NULL
+func (p *sqlParser) exists(ctx gen.IValueExpressionContext, isNull bool) (AllConditions, error) {
+	field, err := p.valueField(ctx)
+	if err != nil {
+		return nil, err
+	}
+	op := ConditionExisted
+	if isNull {
+		op = ConditionNotExisted
+	}
+	return AllConditions{{{DimensionName: field, Operator: op}}}, nil
+}
+
+func (p *sqlParser) comparison(ctx *gen.ComparisonContext, not bool) (AllConditions, error) {
+	var op string
+	switch ctx.ComparisonOperator().GetStart().GetTokenType() {
+	case gen.DorisParserParserEQ, gen.DorisParserParserNSEQ:
+		op = ConditionEqual
+	case gen.DorisParserParserNEQ:
+		op = ConditionNotEqual
+	case gen.DorisParserParserLT:
+		op = ConditionLt
+	case gen.DorisParserParserLTE:
+		op = ConditionLte
+	case gen.DorisParserParserGT:
+		op = ConditionGt
+	case gen.DorisParserParserGTE:
+		op = ConditionGte
+	default:
+		return nil, p.unsupported(ctx)
+	}
+
+	left, right := ctx.GetLeft(), ctx.GetRight()
+	field, err := p.valueField(left)
+	if err != nil {
+		// 常量在左侧时交换位置，比较方向随之反转
+		if field, err = p.valueField(right); err != nil {
+			return nil, p.unsupported(ctx)
+		}
+		left, right = right, left
+		switch op {
+		case ConditionLt:
+			op = ConditionGt
+		case ConditionLte:
+			op = ConditionGte
+		case ConditionGt:
+			op = ConditionLt
+		case ConditionGte:
+			op = ConditionLte
+		}
+	}
+
+	value, err := p.valueConstant(right)
+	if err != nil {
+		return nil, err
+	}
+	if not {
+		op = sqlNegateOperator[op]
+	}
+	return AllConditions{{{DimensionName: field, Operator: op, Value: []string{value}}}}, nil
+}
+
+// primary 剥离只有单个子节点的表达式层级
+func (p *sqlParser) primary(ctx gen.IExpressionContext) gen.IPrimaryExpressionContext {
+	if ctx == nil || ctx.BooleanExpression() == nil {
+		return nil
+	}
+	predicated, ok := ctx.BooleanExpression().(*gen.PredicatedContext)
+	if !ok || predicated.Predicate() != nil {
+		return nil
+	}
+	return p.valuePrimary(predicated.ValueExpression())
+}
+
+func (p *sqlParser) valuePrimary(ctx gen.IValueExpressionContext) gen.IPrimaryExpressionContext {
+	v, ok := ctx.(*gen.ValueExpressionDefaultContext)
+	if !ok {
+		return nil
+	}
+	pe := v.PrimaryExpression()
+	if paren, ok := pe.(*gen.ParenthesizedExpressionContext); ok {
+		return p.primary(paren.Expression())
+	}
+	return pe
+}
+
+func (p *sqlParser) valueField(ctx gen.IValueExpressionContext) (string, error) {
+	pe := p.valuePrimary(ctx)
+	if pe == nil {
+		return "", p.unsupported(ctx)
+	}
+	return p.field(pe)
+}
+
+// field 字段引用，支持 a.b.c 形式的嵌套字段，并去掉表名或表别名前缀
+func (p *sqlParser) field(ctx gen.IPrimaryExpressionContext) (string, error) {
+	var path []string
+	for ctx != nil {
+		switch v := ctx.(type) {
+		case *gen.ColumnReferenceContext:
+			path = append([]string{sqlIdentifier(v.Identifier().GetText())}, path...)
+			ctx = nil
+		case *gen.DereferenceContext:
+			path = append([]string{sqlIdentifier(v.GetFieldName().GetText())}, path...)
+			ctx = v.GetBase()
+		default:
+			return "", fmt.Errorf("expect column reference: %s", p.text(ctx))
+		}
+	}
+
+	field := strings.Join(path, ".")
+	for _, prefix := range []string{p.alias, p.table} {
+		if prefix != "" && strings.HasPrefix(field, prefix+".") {
+			return strings.TrimPrefix(field, prefix+"."), nil
+		}
+	}
+	return field, nil
+}
+
+func (p *sqlParser) valueConstant(ctx gen.IValueExpressionContext) (string, error) {
+	if v, ok := ctx.(*gen.ArithmeticUnaryContext); ok && v.SUBTRACT() != nil {
+		value, err := p.valueConstant(v.ValueExpression())
+		if err != nil {
+			return "", err
+		}
+		if _, err = strconv.ParseFloat(value, 64); err != nil {
+			return "", p.unsupported(ctx)
+		}
+		return "-" + value, nil
+	}
+	return p.constant(p.valuePrimary(ctx))
+}
+
+func (p *sqlParser) constant(ctx gen.IPrimaryExpressionContext) (string, error) {
+	cd, ok := ctx.(*gen.ConstantDefaultContext)
+	if !ok {
+		if ctx == nil {
+			return "", fmt.Errorf("expect constant")
+		}
+		return "", fmt.Errorf("expect constant: %s", p.text(ctx))
+	}
+	switch v := cd.Constant().(type) {
+	case *gen.StringLiteralContext:
+		return sqlString(v.STRING_LITERAL().GetText()), nil
+	case *gen.TypeConstructorContext:
+		return sqlString(v.STRING_LITERAL().GetText()), nil
+	case *gen.NumericLiteralContext:
+		return v.GetText(), nil
+	case *gen.BooleanLiteralContext:
+		return strings.ToLower(v.GetText()), nil
+	case *gen.NullLiteralContext:
+		return "", fmt.Errorf("null can not be compared, please use IS NULL / IS NOT NULL")
+	default:
+		return "", p.unsupported(cd)
+	}
+}
+
+// sqlAnd 两个析取范式求与，保持左右条件的先后顺序
+func sqlAnd(left, right AllConditions) AllConditions {
+	all := make(AllConditions, 0, len(left)*len(right))
+	for _, l := range left {
+		for _, r := range right {
+			group := make([]ConditionField, 0, len(l)+len(r))
+			group = append(group, l...)
+			group = append(group, r...)
+			all = append(all, group)
+		}
+	}
+	return all
+}
+
+// sqlConditions 析取范式转换为结构化查询条件，组内为 and，组间为 or
+func sqlConditions(all AllConditions) Conditions {
+	var c Conditions
+	for _, group := range all {
+		for i, f := range group {
+			if len(c.FieldList) > 0 {
+				if i == 0 {
+					c.ConditionList = append(c.ConditionList, ConditionOr)
+				} else {
+					c.ConditionList = append(c.ConditionList, ConditionAnd)
+				}
+			}
+			c.FieldList = append(c.FieldList, f)
+		}
+	}
+	return c
+}
+
+// sqlIdentifier 去掉反引号
+func sqlIdentifier(s string) string {
+	if len(s) >= 2 && s[0] == '`' && s[len(s)-1] == '`' {
+		return strings.ReplaceAll(s[1:len(s)-1], "``", "`")
+	}
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return sqlString(s)
+	}
+	return s
+}
+
+// sqlString 解析字符串常量，支持反斜杠转义和引号重复转义，以及 R'...' 原始字符串
+func sqlString(s string) string {
+	if len(s) >= 3 && (s[0] == 'R' || s[0] == 'r') {
+		return s[2 : len(s)-1]
+	}
+	if len(s) < 2 {
+		return s
+	}
+	quote := s[0]
+	s = s[1 : len(s)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				b.WriteByte(s[i])
+			}
+		case s[i] == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// sqlLikeToRegexp LIKE 通配符转换为正则，% 匹配任意字符串，_ 匹配单个字符，其余字符按字面量转义
+func sqlLikeToRegexp(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteByte('.')
+		case '.', '?', '+', '*', '|', '{', '}', '[', ']', '(', ')', '"', '\\', '#', '@', '&', '<', '>', '~', '^', '$':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func sqlAggregateNames() []string {
+	return []string{"count", "sum", "avg", "min", "max", "approx_count_distinct", "ndv"}
+}
@@ -0,0 +1,114 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package structured
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQL(t *testing.T) {
+	testCases := map[string]struct {
+		sql  string
+		stmt string
+		err  string
+	}{
+		"select all": {
+			sql:  "select * from result_table.es where level = 'error' and (gseIndex > 10 or path like '%/var/log_%') order by dtEventTimeStamp desc limit 20 offset 10;",
+			stmt: `{"TableID":"result_table.es","Columns":null,"SelectAll":true,"Conditions":{"field_list":[{"field_name":"level","value":["error"],"op":"eq"},{"field_name":"gseIndex","value":["10"],"op":"gt"},{"field_name":"level","value":["error"],"op":"eq"},{"field_name":"path","value":[".*/var/log..*"],"op":"req"}],"condition_list":["and","or","and"]},"Dimensions":null,"Window":"","OrderBy":["-dtEventTimeStamp"],"Limit":20,"From":10}`,
+		},
+		"select columns with alias": {
+			sql:  "SELECT `__ext`.container_name AS container, t.level FROM `result_table.es` t WHERE level IN ('error', 'warn') AND NOT (gseIndex BETWEEN 1 AND 5) ORDER BY container",
+			stmt: `{"TableID":"result_table.es","Columns":[{"name":"container","field":"__ext.container_name"},{"name":"level","field":"level"}],"SelectAll":false,"Conditions":{"field_list":[{"field_name":"level","value":["error","warn"],"op":"eq"},{"field_name":"gseIndex","value":["1"],"op":"lt"},{"field_name":"level","value":["error","warn"],"op":"eq"},{"field_name":"gseIndex","value":["5"],"op":"gt"}],"condition_list":["and","or","and"]},"Dimensions":null,"Window":"","OrderBy":["container"],"Limit":0,"From":0}`,
+		},
+		"aggregate": {
+			sql:  "SELECT level, COUNT(*) AS cnt, avg(gseIndex), count(DISTINCT path) FROM result_table.es WHERE path IS NOT NULL AND 100 >= gseIndex AND level != '' GROUP BY level ORDER BY cnt DESC, level LIMIT 5",
+			stmt: `{"TableID":"result_table.es","Columns":[{"name":"level","field":"level"},{"name":"cnt","field":"*","method":"count"},{"name":"avg(gseIndex)","field":"gseIndex","method":"avg"},{"name":"count(DISTINCT path)","field":"path","method":"cardinality"}],"SelectAll":false,"Conditions":{"field_list":[{"field_name":"path","value":null,"op":"existed"},{"field_name":"gseIndex","value":["100"],"op":"lte"},{"field_name":"level","value":[""],"op":"ne"}],"condition_list":["and","and"]},"Dimensions":["level"],"Window":"","OrderBy":["-cnt","level"],"Limit":5,"From":0}`,
+		},
+		"aggregate with time bucket": {
+			sql:  "SELECT time_bucket(INTERVAL 5 MINUTE) AS t, level, max(gseIndex) FROM result_table.es WHERE NOT level REGEXP '^debug' GROUP BY t, level ORDER BY max(gseIndex) DESC",
+			stmt: `{"TableID":"result_table.es","Columns":[{"name":"t","is_time":true},{"name":"level","field":"level"},{"name":"max(gseIndex)","field":"gseIndex","method":"max"}],"SelectAll":false,"Conditions":{"field_list":[{"field_name":"level","value":["^debug"],"op":"nreq"}]},"Dimensions":["level"],"Window":"5m","OrderBy":["-max(gseIndex)"],"Limit":0,"From":0}`,
+		},
+		"aggregate without group by column": {
+			sql: "SELECT level, count(*) FROM result_table.es",
+			err: "column level must appear in group by",
+		},
+		"time bucket without group by": {
+			sql: "SELECT time_bucket('1m'), count(*) FROM result_table.es",
+			err: "time_bucket must be used in group by",
+		},
+		"join": {
+			sql: "SELECT * FROM a JOIN b ON a.id = b.id",
+			err: "only single table is supported",
+		},
+		"unsupported function": {
+			sql: "SELECT upper(level) FROM result_table.es",
+			err: "unsupported function upper",
+		},
+		"compare with null": {
+			sql: "SELECT * FROM result_table.es WHERE level = NULL",
+			err: "please use IS NULL",
+		},
+		"syntax error": {
+			sql: "SELECT * FROM result_table.es WHERE",
+			err: "syntax error",
+		},
+	}
+
+	for name, c := range testCases {
+		t.Run(name, func(t *testing.T) {
+			stmt, err := ParseSQL(c.sql)
+			if c.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			actual, _ := json.Marshal(stmt)
+			assert.JSONEq(t, c.stmt, string(actual))
+		})
+	}
+}
+
+func TestQuerySQLToQueryTs(t *testing.T) {
+	q := &QuerySQL{
+		SQL:   "SELECT level, count(*) AS cnt FROM result_table.es WHERE gseIndex > 1 GROUP BY level ORDER BY cnt DESC LIMIT 3",
+		Start: "1741154079",
+		End:   "1741155879",
+	}
+	stmt, err := q.Statement()
+	require.NoError(t, err)
+	require.True(t, stmt.IsAggregate())
+
+	aggs := stmt.Aggregations()
+	require.Len(t, aggs, 1)
+
+	queryTs := q.AggregateQueryTs(stmt, aggs[0])
+	assert.True(t, queryTs.Instant)
+	assert.Equal(t, OrderBy{"-_value"}, queryTs.OrderBy)
+	assert.Equal(t, BkLog, queryTs.QueryList[0].DataSource)
+	assert.Equal(t, "*", queryTs.QueryList[0].FieldName)
+	assert.Equal(t, 3, queryTs.QueryList[0].Limit)
+	assert.Equal(t, AggregateMethodList{{Method: "count", Dimensions: []string{"level"}}}, queryTs.QueryList[0].AggregateMethodList)
+
+	q.SQL = "SELECT level AS l, log FROM result_table.es ORDER BY l DESC LIMIT 10"
+	stmt, err = q.Statement()
+	require.NoError(t, err)
+	require.False(t, stmt.IsAggregate())
+
+	queryTs = q.RawQueryTs(stmt)
+	assert.Equal(t, OrderBy{"-level"}, queryTs.OrderBy)
+	assert.Equal(t, KeepColumns{"level", "log"}, queryTs.QueryList[0].KeepColumns)
+	assert.Equal(t, 10, queryTs.Limit)
+}
@@ -112,6 +112,9 @@ type QueryTs struct {
 
 	// AddDimensions 额外添加的聚合维度，会与每个 function.dimensions 合并
 	AddDimensions []string `json:"add_dimensions,omitempty"`
+
+	// SkipRollup 是否跳过降精度结果表路由，强制查询原始数据
+	SkipRollup bool `json:"skip_rollup,omitempty"`
 }
 
 // StepParse 解析step
@@ -426,6 +429,8 @@ type Query struct {
 	TimeAggregation TimeAggregation `json:"time_aggregation"`
 	// IsDomSampled 是否命中降采样算法
 	IsDomSampled bool `json:"is_dom_sampled"`
+	// Rollup 命中的降精度结果表精度，为空表示查询原始数据
+	Rollup string `json:"-"`
 	// ReferenceName 别名，用于表达式计算
 	ReferenceName string `json:"reference_name,omitempty" example:"a"`
 	// Dimensions promQL 使用维度
@@ -872,6 +872,123 @@ func TestQueryTs_StructToPromQL_WithTableIDConditions(t *testing.T) {
 	})
 }
 
+// TestQueryTs_StructToPromQL 结构体转换为 PromQL，覆盖扩展的异常检测、预测函数
+func TestQueryTs_StructToPromQL(t *testing.T) {
+	for name, c := range map[string]struct {
+		query  *Query
+		promql string
+	}{
+		"empty conditions": {
+			query: &Query{
+				FieldName:     "usage",
+				ReferenceName: "a",
+				Conditions:    Conditions{},
+			},
+			promql: `bkmonitor:usage`,
+		},
+		"matcher escaping": {
+			query: &Query{
+				FieldName:     "usage",
+				ReferenceName: "a",
+				Conditions: Conditions{
+					FieldList: []ConditionField{
+						{DimensionName: "path", Value: []string{`C:\tmp\"a"`}, Operator: ConditionEqual},
+						{DimensionName: "ip", Value: []string{"127.0.0.1", "127.0.0.2"}, Operator: ConditionContains},
+					},
+					ConditionList: []string{"and"},
+				},
+			},
+			promql: `bkmonitor:usage{ip=~"^(127\\.0\\.0\\.1|127\\.0\\.0\\.2)$",path="C:\\tmp\\\"a\""}`,
+		},
+		"seasonal baseline": {
+			query: &Query{
+				FieldName:     "usage",
+				ReferenceName: "a",
+				TimeAggregation: TimeAggregation{
+					Function:  "seasonal_baseline",
+					Window:    "7d5m",
+					VargsList: []any{604800},
+				},
+				AggregateMethodList: AggregateMethodList{
+					{Method: "sum", Dimensions: []string{"ip"}},
+				},
+			},
+			promql: `sum by (ip) (seasonal_baseline(bkmonitor:usage[7d5m], 604800))`,
+		},
+		"zscore": {
+			query: &Query{
+				FieldName:     "usage",
+				ReferenceName: "a",
+				TimeAggregation: TimeAggregation{
+					Function: "zscore_over_time",
+					Window:   "1h",
+				},
+				AggregateMethodList: AggregateMethodList{
+					{Method: "max", Dimensions: []string{"ip"}},
+				},
+			},
+			promql: `max by (ip) (zscore_over_time(bkmonitor:usage[1h]))`,
+		},
+		"mad score": {
+			query: &Query{
+				FieldName:     "usage",
+				ReferenceName: "a",
+				TimeAggregation: TimeAggregation{
+					Function: "mad_score_over_time",
+					Window:   "1h",
+				},
+			},
+			promql: `mad_score_over_time(bkmonitor:usage[1h])`,
+		},
+		"holt winters forecast": {
+			query: &Query{
+				FieldName:     "usage",
+				ReferenceName: "a",
+				Conditions: Conditions{
+					FieldList: []ConditionField{
+						{DimensionName: "ip", Value: []string{"127.0.0.1"}, Operator: ConditionEqual},
+					},
+				},
+				TimeAggregation: TimeAggregation{
+					Function:  "holt_winters_forecast",
+					Window:    "1h",
+					VargsList: []any{0.3, 0.1, 3600},
+				},
+			},
+			promql: `holt_winters_forecast(bkmonitor:usage{ip="127.0.0.1"}[1h], 0.3, 0.1, 3600)`,
+		},
+		"time to threshold": {
+			query: &Query{
+				FieldName:     "disk_used",
+				ReferenceName: "a",
+				TimeAggregation: TimeAggregation{
+					Function:  "time_to_threshold",
+					Window:    "6h",
+					VargsList: []any{100},
+				},
+				AggregateMethodList: AggregateMethodList{
+					{Method: "min", Dimensions: []string{"mount"}},
+				},
+			},
+			promql: `min by (mount) (time_to_threshold(bkmonitor:disk_used[6h], 100))`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts := &QueryTs{
+				QueryList:   []*Query{c.query},
+				MetricMerge: "a",
+			}
+			result, err := ts.ToPromQL(context.TODO())
+			require.NoError(t, err)
+			assert.Equal(t, c.promql, result)
+
+			// 结果需要是合法的 promql
+			_, err = parser.ParseExpr(result)
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestQueryTs_ToQueryReference(t *testing.T) {
 	mock.Init()
 	ctx := md.InitHashID(context.Background())
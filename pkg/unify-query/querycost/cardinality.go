@@ -0,0 +1,66 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/memcache"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/tsdb/prometheus"
+)
+
+func cardinalityKey(qry *metadata.Query) string {
+	return fmt.Sprintf("query_cost:%s|%s|%s|%s|%s|%s|%v",
+		qry.StorageType, qry.StorageID, qry.TableID, qry.VmRt, qry.Field, qry.VmCondition, qry.AllConditions)
+}
+
+// SeriesCardinality 通过存储的 series 接口获取最近 window 内命中的 series 数量，结果缓存 ttl
+// 超过 limit 时按 limit 计算，避免基数查询本身成为大查询
+func SeriesCardinality(mem memcache.Cache, limit int, window, ttl time.Duration) CardinalityFunc {
+	return func(ctx context.Context, qry *metadata.Query, start, end time.Time) (int64, error) {
+		key := cardinalityKey(qry)
+		if mem != nil {
+			if v, ok := mem.Get(key); ok {
+				if n, ok := v.(int64); ok {
+					return n, nil
+				}
+			}
+		}
+
+		instance := prometheus.GetTsDbInstance(ctx, qry)
+		if instance == nil {
+			return 0, fmt.Errorf("storage instance of %s not found", qry.StorageType)
+		}
+
+		lookupStart := end.Add(-window)
+		if lookupStart.Before(start) {
+			lookupStart = start
+		}
+
+		lookup := *qry
+		lookup.Size = limit
+		series, err := instance.QuerySeries(ctx, &lookup, lookupStart, end)
+		if err != nil {
+			return 0, err
+		}
+
+		n := int64(len(series))
+		if limit > 0 && n > int64(limit) {
+			n = int64(limit)
+		}
+		if mem != nil {
+			mem.SetWithTTL(key, n, 1, ttl)
+		}
+		return n, nil
+	}
+}
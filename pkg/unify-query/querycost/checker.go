@@ -0,0 +1,62 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"context"
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metric"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+// Checker 查询执行前预估代价并按空间、用户配额判断是否放行
+type Checker struct {
+	opt       *Options
+	estimator *Estimator
+}
+
+func NewChecker(opt *Options, estimator *Estimator) *Checker {
+	return &Checker{
+		opt:       opt,
+		estimator: estimator,
+	}
+}
+
+// Check 预估查询代价并返回配额判断结果
+func (c *Checker) Check(ctx context.Context, spaceUID string, queryRef metadata.QueryReference, start, end time.Time, step time.Duration) (d *Decision) {
+	var err error
+	ctx, span := trace.NewSpan(ctx, "query-cost-check")
+	defer span.End(&err)
+
+	user := metadata.GetUser(ctx)
+	quota := c.opt.Quota(spaceUID, user)
+	est := c.estimator.Estimate(ctx, queryRef, start, end, step)
+
+	d = quota.Check(est)
+	metric.QueryCostCheckInc(ctx, spaceUID, d.Action)
+
+	span.Set("quota", quota)
+	span.Set("action", d.Action)
+	span.Set("reason", d.Reason)
+	if d.Action == ActionDowngrade {
+		span.Set("downgrade-step", d.Step.String())
+	}
+
+	if d.Action != ActionPass {
+		metadata.NewMessage(
+			metadata.MsgQueryCost,
+			"space %s query %s by %s, estimated %s",
+			spaceUID, d.Action, d.Reason, est,
+		).Warn(ctx)
+	}
+	return d
+}
@@ -0,0 +1,153 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	ants "github.com/panjf2000/ants/v2"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+// CardinalityFunc 获取单个查询命中的 series 数量
+type CardinalityFunc func(ctx context.Context, qry *metadata.Query, start, end time.Time) (int64, error)
+
+// TableCost 单个路由结果表的预估代价
+type TableCost struct {
+	ReferenceName string `json:"reference_name"`
+	TableID       string `json:"table_id"`
+	Series        int64  `json:"series"`
+	// Fallback 基数获取失败，使用默认值预估
+	Fallback bool `json:"fallback,omitempty"`
+}
+
+// Estimate 查询代价预估结果，Points = Series × 每条 series 的点数
+type Estimate struct {
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Step   time.Duration `json:"step"`
+	Tables int           `json:"tables"`
+	Series int64         `json:"series"`
+	Points int64         `json:"points"`
+
+	Costs []TableCost `json:"costs,omitempty"`
+}
+
+func (e *Estimate) String() string {
+	return fmt.Sprintf("tables: %d, series: %d, points: %d, step: %s", e.Tables, e.Series, e.Points, e.Step)
+}
+
+// PointsWithStep 按指定 step 重新计算点数
+func (e *Estimate) PointsWithStep(step time.Duration) int64 {
+	return e.Series * pointsPerSeries(e.Start, e.End, step)
+}
+
+// pointsPerSeries 单条 series 的点数，instant 查询（step 为 0）为 1
+func pointsPerSeries(start, end time.Time, step time.Duration) int64 {
+	if step <= 0 || !end.After(start) {
+		return 1
+	}
+	return int64(end.Sub(start)/step) + 1
+}
+
+// Estimator 根据路由结果以及基数查询预估查询代价
+type Estimator struct {
+	defaultSeries int64
+	maxRoutines   int
+	cardinality   CardinalityFunc
+}
+
+func NewEstimator(defaultSeries int64, maxRoutines int, cardinality CardinalityFunc) *Estimator {
+	return &Estimator{
+		defaultSeries: defaultSeries,
+		maxRoutines:   max(maxRoutines, 1),
+		cardinality:   cardinality,
+	}
+}
+
+// Estimate 预估 queryRef 在 [start, end] 按 step 查询的代价，基数获取失败时使用默认值，不影响查询
+func (e *Estimator) Estimate(ctx context.Context, queryRef metadata.QueryReference, start, end time.Time, step time.Duration) (est *Estimate) {
+	var err error
+	ctx, span := trace.NewSpan(ctx, "query-cost-estimate")
+	defer span.End(&err)
+
+	est = &Estimate{
+		Start: start,
+		End:   end,
+		Step:  step,
+	}
+
+	var (
+		wg      sync.WaitGroup
+		queries []*metadata.Query
+		refs    []string
+	)
+	for _, ref := range slices.Sorted(maps.Keys(queryRef)) {
+		queryRef.Range(ref, func(qry *metadata.Query) {
+			queries = append(queries, qry)
+			refs = append(refs, ref)
+		})
+	}
+	est.Costs = make([]TableCost, len(queries))
+
+	p, _ := ants.NewPool(e.maxRoutines)
+	defer p.Release()
+
+	for i, qry := range queries {
+		est.Costs[i] = TableCost{
+			ReferenceName: refs[i],
+			TableID:       qry.TableID,
+			Series:        e.defaultSeries,
+			Fallback:      true,
+		}
+		if e.cardinality == nil {
+			continue
+		}
+
+		wg.Add(1)
+		submitErr := p.Submit(func() {
+			defer wg.Done()
+			n, cErr := e.cardinality(ctx, qry, start, end)
+			if cErr != nil {
+				metadata.NewMessage(
+					metadata.MsgQueryCost,
+					"get cardinality of %s error: %s",
+					qry.TableID, cErr,
+				).Warn(ctx)
+				return
+			}
+
+			est.Costs[i].Series = n
+			est.Costs[i].Fallback = false
+		})
+		if submitErr != nil {
+			wg.Done()
+		}
+	}
+	wg.Wait()
+
+	for _, c := range est.Costs {
+		est.Series += c.Series
+	}
+	est.Tables = len(est.Costs)
+	est.Points = est.PointsWithStep(step)
+
+	span.Set("estimate-tables", est.Tables)
+	span.Set("estimate-series", est.Series)
+	span.Set("estimate-points", est.Points)
+	return est
+}
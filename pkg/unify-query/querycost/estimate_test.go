@@ -0,0 +1,58 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+)
+
+func TestEstimatorEstimate(t *testing.T) {
+	ctx := metadata.InitHashID(context.Background())
+	start := time.Unix(0, 0)
+	end := start.Add(10 * time.Minute)
+
+	queryRef := metadata.QueryReference{
+		"a": {
+			{QueryList: metadata.QueryList{{TableID: "system.cpu"}, {TableID: "system.mem"}}},
+		},
+		"b": {
+			{QueryList: metadata.QueryList{{TableID: "system.unknown"}}},
+		},
+	}
+
+	cardinality := func(ctx context.Context, qry *metadata.Query, start, end time.Time) (int64, error) {
+		if qry.TableID == "system.unknown" {
+			return 0, errors.New("not found")
+		}
+		return 20, nil
+	}
+
+	est := NewEstimator(5, 2, cardinality).Estimate(ctx, queryRef, start, end, time.Minute)
+	assert.Equal(t, 3, est.Tables)
+	assert.Equal(t, int64(45), est.Series)
+	assert.Equal(t, int64(45*11), est.Points)
+	assert.Equal(t, []TableCost{
+		{ReferenceName: "a", TableID: "system.cpu", Series: 20},
+		{ReferenceName: "a", TableID: "system.mem", Series: 20},
+		{ReferenceName: "b", TableID: "system.unknown", Series: 5, Fallback: true},
+	}, est.Costs)
+
+	// instant 查询每条 series 只有一个点
+	est = NewEstimator(5, 2, nil).Estimate(ctx, queryRef, start, end, 0)
+	assert.Equal(t, int64(15), est.Series)
+	assert.Equal(t, int64(15), est.Points)
+}
@@ -0,0 +1,97 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/spf13/viper"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/eventbus"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/memcache"
+)
+
+var defaultChecker atomic.Pointer[Checker]
+
+// Default 返回全局查询代价检查，未开启时为 nil
+func Default() *Checker {
+	return defaultChecker.Load()
+}
+
+// SetDefault 替换全局查询代价检查，返回旧的检查
+func SetDefault(c *Checker) *Checker {
+	return defaultChecker.Swap(c)
+}
+
+func setDefaultConfig() {
+	viper.SetDefault(EnabledConfigPath, false)
+	viper.SetDefault(DefaultSeriesConfigPath, 100)
+
+	viper.SetDefault(CardinalityLimitConfigPath, 10000)
+	viper.SetDefault(CardinalityWindowConfigPath, "5m")
+	viper.SetDefault(CardinalityTTLConfigPath, "10m")
+	viper.SetDefault(CardinalityMaxRoutinesConfigPath, 8)
+
+	viper.SetDefault(DefaultQuotaConfigPath+".action", ActionReject)
+}
+
+// LoadConfig 按配置重建全局查询代价检查
+func LoadConfig() {
+	ctx := context.Background()
+
+	if !viper.GetBool(EnabledConfigPath) {
+		SetDefault(nil)
+		return
+	}
+
+	opt := &Options{
+		DefaultSeries: viper.GetInt64(DefaultSeriesConfigPath),
+	}
+	if err := viper.UnmarshalKey(DefaultQuotaConfigPath, &opt.DefaultQuota); err != nil {
+		log.Errorf(ctx, "parse %s error: %s", DefaultQuotaConfigPath, err)
+		SetDefault(nil)
+		return
+	}
+	if err := viper.UnmarshalKey(QuotasConfigPath, &opt.Quotas); err != nil {
+		log.Errorf(ctx, "parse %s error: %s", QuotasConfigPath, err)
+		SetDefault(nil)
+		return
+	}
+
+	mem, err := memcache.NewRistrettoWithConfig(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     1e4,
+		BufferItems: 64,
+	})
+	if err != nil {
+		log.Errorf(ctx, "init query cost cardinality cache error: %s", err)
+		SetDefault(nil)
+		return
+	}
+
+	cardinality := SeriesCardinality(
+		mem,
+		viper.GetInt(CardinalityLimitConfigPath),
+		viper.GetDuration(CardinalityWindowConfigPath),
+		viper.GetDuration(CardinalityTTLConfigPath),
+	)
+	estimator := NewEstimator(opt.DefaultSeries, viper.GetInt(CardinalityMaxRoutinesConfigPath), cardinality)
+
+	SetDefault(NewChecker(opt, estimator))
+	log.Infof(ctx, "query cost check enabled, default quota: %+v, quotas: %d", opt.DefaultQuota, len(opt.Quotas))
+}
+
+func init() {
+	eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPreParse, setDefaultConfig)
+	eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPostParse, LoadConfig)
+}
@@ -0,0 +1,124 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+)
+
+var ErrQuotaExceeded = errors.New("query cost exceeds quota")
+
+// Decision 配额判断结果
+type Decision struct {
+	Action   string
+	Estimate *Estimate
+	// Step 降级后的 step，仅 Action 为 downgrade 时有效
+	Step time.Duration
+	// Reason 超出配额的原因
+	Reason string
+}
+
+// Error 拒绝查询时返回的错误，包含预估代价，便于用户调整查询
+func (d *Decision) Error() error {
+	if d.Action != ActionReject {
+		return nil
+	}
+	return fmt.Errorf("%w: %s, estimated %s, please narrow the query by conditions, time range or a larger step",
+		ErrQuotaExceeded, d.Reason, d.Estimate)
+}
+
+// Match 判断配额是否适用于当前空间以及用户
+func (q *Quota) Match(spaceUID string, user *metadata.User) bool {
+	if q.SpaceUID != "" && q.SpaceUID != spaceUID {
+		return false
+	}
+	if user == nil {
+		return q.Source == "" && q.User == ""
+	}
+	if q.Source != "" && q.Source != user.Source {
+		return false
+	}
+	if q.User != "" && q.User != user.Name {
+		return false
+	}
+	return true
+}
+
+// exceeded 返回超出配额的原因，未超出返回空
+func (q *Quota) exceeded(tables int, series, points int64) string {
+	var reasons []string
+	if q.MaxTables > 0 && tables > q.MaxTables {
+		reasons = append(reasons, fmt.Sprintf("tables %d > %d", tables, q.MaxTables))
+	}
+	if q.MaxSeries > 0 && series > q.MaxSeries {
+		reasons = append(reasons, fmt.Sprintf("series %d > %d", series, q.MaxSeries))
+	}
+	if q.MaxPoints > 0 && points > q.MaxPoints {
+		reasons = append(reasons, fmt.Sprintf("points %d > %d", points, q.MaxPoints))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// downgradeStep 计算满足点数配额的最小 step，保持为原 step 的整数倍以复用 step 对齐
+func (q *Quota) downgradeStep(est *Estimate) (time.Duration, bool) {
+	if est.Step <= 0 || est.Series <= 0 || est.Series > q.MaxPoints {
+		return 0, false
+	}
+
+	factor := (est.Points + q.MaxPoints - 1) / q.MaxPoints
+	for ; ; factor++ {
+		step := est.Step * time.Duration(factor)
+		if q.MaxStep > 0 && step > q.MaxStep {
+			return 0, false
+		}
+		if est.PointsWithStep(step) <= q.MaxPoints {
+			return step, true
+		}
+	}
+}
+
+// Check 按配额判断预估代价，tables 以及 series 超出时无法通过降级解决，直接拒绝
+func (q *Quota) Check(est *Estimate) *Decision {
+	d := &Decision{
+		Action:   ActionPass,
+		Estimate: est,
+	}
+
+	d.Reason = q.exceeded(est.Tables, est.Series, est.Points)
+	if d.Reason == "" {
+		return d
+	}
+
+	d.Action = ActionReject
+	if q.Action != ActionDowngrade || q.exceeded(est.Tables, est.Series, 0) != "" {
+		return d
+	}
+
+	if step, ok := q.downgradeStep(est); ok {
+		d.Action = ActionDowngrade
+		d.Step = step
+	}
+	return d
+}
+
+// Quota 返回第一个匹配的配额，都未命中时返回默认配额
+func (o *Options) Quota(spaceUID string, user *metadata.User) *Quota {
+	for i := range o.Quotas {
+		if o.Quotas[i].Match(spaceUID, user) {
+			return &o.Quotas[i]
+		}
+	}
+	return &o.DefaultQuota
+}
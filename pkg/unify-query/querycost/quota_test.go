@@ -0,0 +1,97 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+)
+
+func TestOptionsQuota(t *testing.T) {
+	opt := &Options{
+		DefaultQuota: Quota{MaxPoints: 1},
+		Quotas: []Quota{
+			{SpaceUID: "bkcc__2", User: "admin", MaxPoints: 2},
+			{SpaceUID: "bkcc__2", MaxPoints: 3},
+			{Source: "grafana", MaxPoints: 4},
+		},
+	}
+
+	assert.Equal(t, int64(2), opt.Quota("bkcc__2", &metadata.User{Source: "web", Name: "admin"}).MaxPoints)
+	assert.Equal(t, int64(3), opt.Quota("bkcc__2", &metadata.User{Source: "web", Name: "other"}).MaxPoints)
+	assert.Equal(t, int64(4), opt.Quota("bkcc__3", &metadata.User{Source: "grafana"}).MaxPoints)
+	assert.Equal(t, int64(1), opt.Quota("bkcc__3", nil).MaxPoints)
+}
+
+func TestQuotaCheck(t *testing.T) {
+	start := time.Unix(0, 0)
+	est := &Estimate{
+		Start:  start,
+		End:    start.Add(time.Hour),
+		Step:   time.Minute,
+		Tables: 3,
+		Series: 100,
+	}
+	est.Points = est.PointsWithStep(est.Step)
+	assert.Equal(t, int64(6100), est.Points)
+
+	testCases := map[string]struct {
+		quota  Quota
+		action string
+		step   time.Duration
+		reason string
+	}{
+		"unlimited": {
+			action: ActionPass,
+		},
+		"tables": {
+			quota:  Quota{MaxTables: 2, Action: ActionDowngrade},
+			action: ActionReject,
+			reason: "tables 3 > 2",
+		},
+		"points reject": {
+			quota:  Quota{MaxPoints: 1000},
+			action: ActionReject,
+			reason: "points 6100 > 1000",
+		},
+		"points downgrade": {
+			quota:  Quota{MaxPoints: 1000, Action: ActionDowngrade},
+			action: ActionDowngrade,
+			step:   7 * time.Minute,
+			reason: "points 6100 > 1000",
+		},
+		"series bigger than points": {
+			quota:  Quota{MaxPoints: 50, Action: ActionDowngrade},
+			action: ActionReject,
+			reason: "points 6100 > 50",
+		},
+	}
+
+	for name, c := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d := c.quota.Check(est)
+			assert.Equal(t, c.action, d.Action)
+			assert.Equal(t, c.step, d.Step)
+			assert.Equal(t, c.reason, d.Reason)
+			if d.Action == ActionDowngrade {
+				assert.LessOrEqual(t, est.PointsWithStep(d.Step), c.quota.MaxPoints)
+			}
+			if d.Action == ActionReject {
+				assert.ErrorIs(t, d.Error(), ErrQuotaExceeded)
+			} else {
+				assert.NoError(t, d.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package querycost
+
+import (
+	"time"
+)
+
+const (
+	EnabledConfigPath = "query_cost.enabled"
+	// DefaultSeriesConfigPath 无法获取基数时，单个结果表预估的 series 数量
+	DefaultSeriesConfigPath = "query_cost.default_series"
+
+	// 基数查询配置，通过 series 接口获取最近一段时间内命中的 series 数量
+	CardinalityLimitConfigPath  = "query_cost.cardinality.limit"
+	CardinalityWindowConfigPath = "query_cost.cardinality.window"
+	CardinalityTTLConfigPath    = "query_cost.cardinality.ttl"
+	// 基数查询并发数
+	CardinalityMaxRoutinesConfigPath = "query_cost.cardinality.max_routines"
+
+	// 配额配置，quotas 按顺序匹配，都未命中时使用 default_quota
+	DefaultQuotaConfigPath = "query_cost.default_quota"
+	QuotasConfigPath       = "query_cost.quotas"
+)
+
+const (
+	ActionPass      = "pass"
+	ActionReject    = "reject"
+	ActionDowngrade = "downgrade"
+)
+
+// Quota 查询配额，限制项为 0 时表示不限制
+type Quota struct {
+	// SpaceUID 匹配的空间，为空则匹配所有空间
+	SpaceUID string `mapstructure:"space_uid" json:"space_uid,omitempty"`
+	// Source 匹配的调用来源，为空则匹配所有来源
+	Source string `mapstructure:"source" json:"source,omitempty"`
+	// User 匹配的用户名，为空则匹配所有用户
+	User string `mapstructure:"user" json:"user,omitempty"`
+
+	MaxTables int   `mapstructure:"max_tables" json:"max_tables,omitempty"`
+	MaxSeries int64 `mapstructure:"max_series" json:"max_series,omitempty"`
+	MaxPoints int64 `mapstructure:"max_points" json:"max_points,omitempty"`
+
+	// Action 超出配额后的处理方式：reject 直接拒绝，downgrade 放大 step 后查询
+	Action string `mapstructure:"action" json:"action,omitempty"`
+	// MaxStep 降级时 step 的上限，降级后仍超出配额则拒绝
+	MaxStep time.Duration `mapstructure:"max_step" json:"max_step,omitempty"`
+}
+
+// Options 查询代价配置
+type Options struct {
+	DefaultSeries int64
+	DefaultQuota  Quota
+	Quotas        []Quota
+}
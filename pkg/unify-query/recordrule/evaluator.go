@@ -0,0 +1,221 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metric"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+// QuerySource recording rule 发起查询时使用的来源，便于在查询侧区分
+const QuerySource = "record_rule"
+
+// QueryFunc 在空间下执行 instant 查询
+type QueryFunc func(ctx context.Context, spaceUID, expr string, ts time.Time) (promql.Vector, error)
+
+// Evaluator 按分组调度计算 recording rule，并将结果写入 sink
+type Evaluator struct {
+	groups      []*Group
+	query       QueryFunc
+	sink        Sink
+	evalTimeout time.Duration
+
+	wg  sync.WaitGroup
+	now func() time.Time
+}
+
+func NewEvaluator(groups []*Group, query QueryFunc, sink Sink, evalTimeout time.Duration) *Evaluator {
+	return &Evaluator{
+		groups:      groups,
+		query:       query,
+		sink:        sink,
+		evalTimeout: evalTimeout,
+		now:         time.Now,
+	}
+}
+
+// Run 每个分组启动一个协程按 interval 调度，ctx 结束后退出
+func (e *Evaluator) Run(ctx context.Context) {
+	for _, g := range e.groups {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.runGroup(ctx, g)
+		}()
+	}
+}
+
+// Wait 等待所有分组退出
+func (e *Evaluator) Wait() {
+	e.wg.Wait()
+}
+
+// groupOffset 按分组计算固定的调度偏移，避免所有分组在同一时刻计算
+func groupOffset(g *Group) time.Duration {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(g.Key()))
+	return time.Duration(h.Sum64() % uint64(g.Interval))
+}
+
+// nextEvalTime 返回 now 之后的下一个计算时间，计算时间按 interval 对齐并加上分组偏移
+func nextEvalTime(now time.Time, interval, offset time.Duration) time.Time {
+	next := now.Truncate(interval).Add(offset)
+	for !next.After(now) {
+		next = next.Add(interval)
+	}
+	return next
+}
+
+func (e *Evaluator) runGroup(ctx context.Context, g *Group) {
+	if g.Interval <= 0 {
+		return
+	}
+
+	offset := groupOffset(g)
+	next := nextEvalTime(e.now(), g.Interval, offset)
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := e.EvalGroup(ctx, g, next); err != nil {
+			log.Warnf(ctx, "record rule group %s eval error: %s", g.Key(), err)
+		}
+
+		// 计算耗时超过 interval 时跳过错过的轮次，保持计算时间对齐
+		now := e.now()
+		if missed := nextEvalTime(now, g.Interval, offset); missed.Sub(next) > g.Interval {
+			log.Warnf(ctx, "record rule group %s missed %d iterations", g.Key(), int64(missed.Sub(next)/g.Interval)-1)
+			next = missed
+		} else {
+			next = next.Add(g.Interval)
+		}
+		timer.Reset(time.Until(next))
+	}
+}
+
+// EvalGroup 在 ts 时刻按顺序计算分组内的规则，单条规则失败不影响其它规则
+// 每条规则的结果在计算下一条规则前写入 sink，后面的规则可以引用前面规则本轮的结果
+func (e *Evaluator) EvalGroup(ctx context.Context, g *Group, ts time.Time) (err error) {
+	ctx, span := trace.NewSpan(ctx, "record-rule-eval-group")
+	defer span.End(&err)
+
+	span.Set("space-uid", g.SpaceUID)
+	span.Set("group", g.Key())
+	span.Set("eval-time", ts.String())
+
+	if e.evalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.evalTimeout)
+		defer cancel()
+	}
+
+	var (
+		seriesNum int
+		errs      []error
+	)
+	for _, r := range g.Rules {
+		series, rErr := e.evalRule(ctx, g, r, ts)
+		if rErr != nil {
+			metric.RecordRuleEvalInc(ctx, g.SpaceUID, g.Name, metric.StatusFailed)
+			errs = append(errs, fmt.Errorf("rule %s: %w", r.Record, rErr))
+			continue
+		}
+		metric.RecordRuleEvalInc(ctx, g.SpaceUID, g.Name, metric.StatusSuccess)
+		if len(series) == 0 {
+			continue
+		}
+
+		if wErr := e.sink.Write(ctx, series); wErr != nil {
+			errs = append(errs, fmt.Errorf("rule %s write %d series: %w", r.Record, len(series), wErr))
+			continue
+		}
+		metric.RecordRuleSamplesAdd(ctx, len(series), g.SpaceUID, g.Name)
+		seriesNum += len(series)
+	}
+	metric.RecordRuleEvalLagSet(ctx, e.now().Sub(ts), g.SpaceUID, g.Name)
+
+	span.Set("series-num", seriesNum)
+	return errors.Join(errs...)
+}
+
+// evalRule 计算单条规则，每条规则使用独立的查询上下文，避免查询参数相互覆盖
+func (e *Evaluator) evalRule(ctx context.Context, g *Group, r *Rule, ts time.Time) (series []prompb.TimeSeries, err error) {
+	ctx = metadata.InitHashID(ctx)
+	metadata.SetUser(ctx, &metadata.User{
+		Key:      fmt.Sprintf("%s:%s", QuerySource, g.Name),
+		SpaceUID: g.SpaceUID,
+	})
+
+	ctx, span := trace.NewSpan(ctx, "record-rule-eval")
+	defer span.End(&err)
+
+	span.Set("record", r.Record)
+	span.Set("expr", r.Expr)
+
+	vector, err := e.query(ctx, g.SpaceUID, r.Expr, ts)
+	if err != nil {
+		return nil, err
+	}
+	if g.Limit > 0 && len(vector) > g.Limit {
+		return nil, fmt.Errorf("exceeded limit of %d with %d series", g.Limit, len(vector))
+	}
+
+	var (
+		seen = make(map[uint64]struct{}, len(vector))
+		lb   = labels.NewBuilder(nil)
+	)
+	series = make([]prompb.TimeSeries, 0, len(vector))
+	for _, s := range vector {
+		lb.Reset(s.Metric)
+		lb.Set(labels.MetricName, r.Record)
+		r.Labels.Range(func(l labels.Label) {
+			lb.Set(l.Name, l.Value)
+		})
+		lbs := lb.Labels(nil)
+
+		h := lbs.Hash()
+		if _, ok := seen[h]; ok {
+			return nil, fmt.Errorf("vector contains metrics with the same labelset after applying rule labels: %s", lbs)
+		}
+		seen[h] = struct{}{}
+
+		pLabels := make([]prompb.Label, 0, len(lbs))
+		lbs.Range(func(l labels.Label) {
+			pLabels = append(pLabels, prompb.Label{Name: l.Name, Value: l.Value})
+		})
+		series = append(series, prompb.TimeSeries{
+			Labels:  pLabels,
+			Samples: []prompb.Sample{{Value: s.V, Timestamp: ts.UnixMilli()}},
+		})
+	}
+
+	span.Set("series-num", len(series))
+	return series, nil
+}
@@ -0,0 +1,187 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+)
+
+func TestEvaluatorEvalGroup(t *testing.T) {
+	metadata.InitMetadata()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	ts := time.UnixMilli(1741056420000)
+	query := func(ctx context.Context, spaceUID, expr string, evalTime time.Time) (promql.Vector, error) {
+		assert.Equal(t, "bkcc__2", metadata.GetUser(ctx).SpaceUID)
+		assert.Equal(t, QuerySource, metadata.GetUser(ctx).Source)
+		assert.Equal(t, ts, evalTime)
+
+		switch expr {
+		case "ok":
+			return promql.Vector{
+				{Metric: labels.FromStrings("__name__", "a", "job", "x", "team", "old"), Point: promql.Point{V: 1}},
+				{Metric: labels.FromStrings("job", "y"), Point: promql.Point{V: 2}},
+			}, nil
+		case "dup":
+			return promql.Vector{
+				{Metric: labels.FromStrings("__name__", "a", "job", "x")},
+				{Metric: labels.FromStrings("__name__", "b", "job", "x")},
+			}, nil
+		default:
+			return nil, errors.New("query error")
+		}
+	}
+
+	g := &Group{
+		SpaceUID: "bkcc__2",
+		Name:     "test",
+		Interval: time.Minute,
+		Rules: []*Rule{
+			{Record: "job:ok", Expr: "ok", Labels: labels.FromStrings("team", "infra")},
+			{Record: "job:dup", Expr: "dup"},
+			{Record: "job:failed", Expr: "failed"},
+		},
+	}
+
+	e := NewEvaluator([]*Group{g}, query, sink, time.Second)
+	err = e.EvalGroup(metadata.InitHashID(ctx), g, ts)
+	assert.ErrorContains(t, err, "rule job:dup: vector contains metrics with the same labelset")
+	assert.ErrorContains(t, err, "rule job:failed: query error")
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var samples []FileSample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s FileSample
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &s))
+		samples = append(samples, s)
+	}
+	assert.Equal(t, []FileSample{
+		{Labels: map[string]string{"__name__": "job:ok", "job": "x", "team": "infra"}, Value: "1", Timestamp: ts.UnixMilli()},
+		{Labels: map[string]string{"__name__": "job:ok", "job": "y", "team": "infra"}, Value: "2", Timestamp: ts.UnixMilli()},
+	}, samples)
+
+	// 超过分组 limit 时该规则失败
+	g.Limit = 1
+	g.Rules = g.Rules[:1]
+	e.sink, err = NewFileSink(filepath.Join(t.TempDir(), "limit.ndjson"))
+	require.NoError(t, err)
+	defer e.sink.Close()
+	err = e.EvalGroup(metadata.InitHashID(ctx), g, ts)
+	assert.ErrorContains(t, err, "exceeded limit of 1 with 2 series")
+}
+
+// memorySink 记录写入的 series，用于模拟查询读取已写入的结果
+type memorySink struct {
+	series []prompb.TimeSeries
+}
+
+func (s *memorySink) Write(_ context.Context, series []prompb.TimeSeries) error {
+	s.series = append(s.series, series...)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func TestEvaluatorEvalGroupChained(t *testing.T) {
+	metadata.InitMetadata()
+	ctx := context.Background()
+	sink := &memorySink{}
+
+	ts := time.UnixMilli(1741056420000)
+	query := func(ctx context.Context, spaceUID, expr string, evalTime time.Time) (promql.Vector, error) {
+		switch expr {
+		case "cpu":
+			return promql.Vector{{Metric: labels.FromStrings("job", "x"), Point: promql.Point{V: 2}}}, nil
+		case "job:cpu * 10":
+			// 只能读取已写入 sink 的 job:cpu 本轮结果
+			var vector promql.Vector
+			for _, s := range sink.series {
+				var (
+					name string
+					lbs  []string
+				)
+				for _, l := range s.Labels {
+					if l.Name == labels.MetricName {
+						name = l.Value
+						continue
+					}
+					lbs = append(lbs, l.Name, l.Value)
+				}
+				if name == "job:cpu" && s.Samples[0].Timestamp == evalTime.UnixMilli() {
+					vector = append(vector, promql.Sample{
+						Metric: labels.FromStrings(lbs...),
+						Point:  promql.Point{V: s.Samples[0].Value * 10},
+					})
+				}
+			}
+			return vector, nil
+		default:
+			return nil, errors.New("query error")
+		}
+	}
+
+	g := &Group{
+		SpaceUID: "bkcc__2",
+		Name:     "chained",
+		Interval: time.Minute,
+		Rules: []*Rule{
+			{Record: "job:cpu", Expr: "cpu"},
+			{Record: "job:cpu:x10", Expr: "job:cpu * 10"},
+		},
+	}
+
+	e := NewEvaluator([]*Group{g}, query, sink, time.Second)
+	require.NoError(t, e.EvalGroup(metadata.InitHashID(ctx), g, ts))
+	assert.Equal(t, []prompb.TimeSeries{
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "job:cpu"}, {Name: "job", Value: "x"}},
+			Samples: []prompb.Sample{{Value: 2, Timestamp: ts.UnixMilli()}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "job:cpu:x10"}, {Name: "job", Value: "x"}},
+			Samples: []prompb.Sample{{Value: 20, Timestamp: ts.UnixMilli()}},
+		},
+	}, sink.series)
+}
+
+func TestNextEvalTime(t *testing.T) {
+	now := time.Unix(1741056425, 0)
+	assert.Equal(t, time.Unix(1741056430, 0), nextEvalTime(now, time.Minute, 10*time.Second))
+	assert.Equal(t, time.Unix(1741056490, 0), nextEvalTime(time.Unix(1741056430, 0), time.Minute, 10*time.Second))
+
+	g := &Group{SpaceUID: "bkcc__2", File: "a.yaml", Name: "cpu", Interval: time.Minute}
+	assert.Equal(t, groupOffset(g), groupOffset(g))
+	assert.Less(t, groupOffset(g), time.Minute)
+}
@@ -0,0 +1,93 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+)
+
+// Rule 单条 recording rule
+type Rule struct {
+	Record string
+	Expr   string
+	Labels labels.Labels
+}
+
+// Group 同一分组内的规则按顺序计算，每条规则的结果写入后再计算下一条，后面的规则可以引用前面规则的结果
+type Group struct {
+	SpaceUID string
+	File     string
+	Name     string
+	Interval time.Duration
+	// Limit 单条规则结果的 series 上限，0 表示不限制
+	Limit int
+	Rules []*Rule
+}
+
+// Key 分组唯一标识
+func (g *Group) Key() string {
+	return fmt.Sprintf("%s/%s/%s", g.SpaceUID, g.File, g.Name)
+}
+
+// LoadGroups 加载空间的 Prometheus 规则文件，patterns 支持通配符，告警规则会被忽略
+func LoadGroups(spaceUID string, defaultInterval time.Duration, patterns ...string) ([]*Group, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("space %s rule file pattern %s error: %w", spaceUID, pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var groups []*Group
+	for _, file := range files {
+		ruleGroups, errs := rulefmt.ParseFile(file)
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("space %s parse rule file %s error: %w", spaceUID, file, errors.Join(errs...))
+		}
+
+		for _, rg := range ruleGroups.Groups {
+			g := &Group{
+				SpaceUID: spaceUID,
+				File:     file,
+				Name:     rg.Name,
+				Interval: time.Duration(rg.Interval),
+				Limit:    rg.Limit,
+			}
+			if g.Interval <= 0 {
+				g.Interval = defaultInterval
+			}
+
+			for _, r := range rg.Rules {
+				if r.Record.Value == "" {
+					continue
+				}
+				g.Rules = append(g.Rules, &Rule{
+					Record: r.Record.Value,
+					Expr:   r.Expr.Value,
+					Labels: labels.FromMap(r.Labels),
+				})
+			}
+			if len(g.Rules) > 0 {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return groups, nil
+}
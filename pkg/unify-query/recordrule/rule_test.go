@@ -0,0 +1,77 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGroups(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(`
+groups:
+  - name: cpu
+    interval: 30s
+    rules:
+      - record: job:cpu_usage:sum
+        expr: sum by (job) (rate(bkmonitor:container_cpu_usage_seconds_total[1m]))
+        labels:
+          team: infra
+      - alert: HighCPU
+        expr: job:cpu_usage:sum > 1
+  - name: alert_only
+    rules:
+      - alert: Down
+        expr: up == 0
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte(`
+groups:
+  - name: mem
+    limit: 10
+    rules:
+      - record: job:mem:sum
+        expr: sum by (job) (bkmonitor:container_memory_rss)
+`), 0o644))
+
+	groups, err := LoadGroups("bkcc__2", time.Minute, filepath.Join(dir, "*.yaml"))
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	assert.Equal(t, "cpu", groups[0].Name)
+	assert.Equal(t, 30*time.Second, groups[0].Interval)
+	assert.Equal(t, []*Rule{{
+		Record: "job:cpu_usage:sum",
+		Expr:   "sum by (job) (rate(bkmonitor:container_cpu_usage_seconds_total[1m]))",
+		Labels: labels.FromStrings("team", "infra"),
+	}}, groups[0].Rules)
+
+	assert.Equal(t, "mem", groups[1].Name)
+	assert.Equal(t, time.Minute, groups[1].Interval)
+	assert.Equal(t, 10, groups[1].Limit)
+	assert.Equal(t, "bkcc__2", groups[1].SpaceUID)
+
+	// 规则文件格式错误
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.yaml"), []byte(`
+groups:
+  - name: bad
+    rules:
+      - record: "bad metric"
+        expr: sum(
+`), 0o644))
+	_, err = LoadGroups("bkcc__2", time.Minute, filepath.Join(dir, "*.yaml"))
+	assert.Error(t, err)
+}
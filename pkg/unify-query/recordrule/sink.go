@@ -0,0 +1,155 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+// Sink recording rule 结果写入
+type Sink interface {
+	Write(ctx context.Context, series []prompb.TimeSeries) error
+	Close() error
+}
+
+// RemoteWriteSink 通过 Prometheus remote-write 协议写入
+type RemoteWriteSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewRemoteWriteSink(url string, headers map[string]string, timeout time.Duration) *RemoteWriteSink {
+	return &RemoteWriteSink{
+		url:     url,
+		headers: headers,
+		client: &http.Client{
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Timeout:   timeout,
+		},
+	}
+}
+
+func (s *RemoteWriteSink) Write(ctx context.Context, series []prompb.TimeSeries) (err error) {
+	if len(series) == 0 {
+		return nil
+	}
+
+	ctx, span := trace.NewSpan(ctx, "record-rule-remote-write")
+	defer span.End(&err)
+
+	data, err := (&prompb.WriteRequest{Timeseries: series}).Marshal()
+	if err != nil {
+		return err
+	}
+	body := snappy.Encode(nil, data)
+
+	span.Set("series-num", len(series))
+	span.Set("body-size", len(body))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write %s error: %s, %s", s.url, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (s *RemoteWriteSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// FileSample 本地文件写入格式，每行一个样本，value 与 Prometheus API 一致使用字符串以兼容 NaN
+type FileSample struct {
+	Labels    map[string]string `json:"labels"`
+	Value     string            `json:"value"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+// FileSink 以 NDJSON 格式追加写入本地文件，用于调试以及测试
+type FileSink struct {
+	lock sync.Mutex
+	f    *os.File
+	w    *bufio.Writer
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{
+		f: f,
+		w: bufio.NewWriter(f),
+	}, nil
+}
+
+func (s *FileSink) Write(_ context.Context, series []prompb.TimeSeries) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	enc := json.NewEncoder(s.w)
+	for _, ts := range series {
+		lbs := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			lbs[l.Name] = l.Value
+		}
+		for _, sample := range ts.Samples {
+			if err := enc.Encode(FileSample{Labels: lbs, Value: strconv.FormatFloat(sample.Value, 'f', -1, 64), Timestamp: sample.Timestamp}); err != nil {
+				return err
+			}
+		}
+	}
+	return s.w.Flush()
+}
+
+func (s *FileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.w.Flush(); err != nil {
+		_ = s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
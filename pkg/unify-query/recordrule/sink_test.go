@@ -0,0 +1,61 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteWriteSink(t *testing.T) {
+	var got prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "token", r.Header.Get("X-Bk-Token"))
+
+		compressed, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		require.NoError(t, got.Unmarshal(data))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	series := []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "job:ok"}, {Name: "job", Value: "x"}},
+		Samples: []prompb.Sample{{Value: 1, Timestamp: 1741056420000}},
+	}}
+
+	sink := NewRemoteWriteSink(server.URL, map[string]string{"X-Bk-Token": "token"}, time.Second)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(context.Background(), series))
+	assert.Equal(t, series, got.Timeseries)
+
+	// 非 2xx 返回错误
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer failed.Close()
+
+	err := NewRemoteWriteSink(failed.URL, nil, time.Second).Write(context.Background(), series)
+	assert.ErrorContains(t, err, "400 Bad Request, out of order sample")
+}
@@ -0,0 +1,248 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package resultcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	promPromql "github.com/prometheus/prometheus/promql"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/kvstore"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/memcache"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metric"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+// FetchFunc 查询 [start, end] 区间的数据，返回结果以及是否为部分数据
+type FetchFunc func(ctx context.Context, start, end time.Time) (promPromql.Matrix, bool, error)
+
+type entry struct {
+	Extents []Extent
+	// ExpiredAt 过期时间，unix 秒，用于持久化数据的过期判断
+	ExpiredAt int64
+}
+
+// Cache range 查询结果缓存
+// 按 查询 + step + 对齐偏移 作为 key 缓存多段对齐的结果区间，查询时只请求缺失的时间段，
+// 最近 UnstableWindow 内的数据可能还在写入，不进行缓存
+type Cache struct {
+	opt *Options
+	mem memcache.Cache
+	kv  kvstore.KVStore
+
+	// lock 及 wg 用于登记进行中的缓存读写，Close 时等待其结束后再关闭存储
+	lock   sync.RWMutex
+	wg     sync.WaitGroup
+	closed bool
+
+	now func() time.Time
+}
+
+// NewCache kv 为空时只使用内存缓存
+func NewCache(opt *Options, mem memcache.Cache, kv kvstore.KVStore) *Cache {
+	return &Cache{
+		opt: opt,
+		mem: mem,
+		kv:  kv,
+		now: time.Now,
+	}
+}
+
+// QueryRange 优先使用缓存数据，仅对缺失的区间调用 fetch，合并后返回
+func (c *Cache) QueryRange(ctx context.Context, key string, start, end time.Time, step time.Duration, fetch FetchFunc) (res promPromql.Matrix, isPartial bool, err error) {
+	stepMs := step.Milliseconds()
+	s, e := start.UnixMilli(), end.UnixMilli()
+	if c == nil || stepMs <= 0 || e < s {
+		return fetch(ctx, start, end)
+	}
+
+	// 可缓存的最大时间点，需要落在 step 网格上
+	stable := c.now().Add(-c.opt.UnstableWindow).UnixMilli()
+	if stable < s {
+		metric.ResultCacheRequestInc(ctx, metric.ResultSkip)
+		return fetch(ctx, start, end)
+	}
+	stable = s + (stable-s)/stepMs*stepMs
+	last := s + (e-s)/stepMs*stepMs
+
+	ctx, span := trace.NewSpan(ctx, "result-cache-query-range")
+	defer span.End(&err)
+
+	// 对齐偏移不同的查询数据点不同，不能复用
+	key = fmt.Sprintf("%s:%d:%d", key, stepMs, s%stepMs)
+	span.Set("cache-key", key)
+
+	// 缓存已关闭（配置重载中）时直接查询
+	if !c.acquire() {
+		metric.ResultCacheRequestInc(ctx, metric.ResultSkip)
+		return fetch(ctx, start, end)
+	}
+	extents := c.load(ctx, key)
+	c.release()
+	missing := missingRanges(extents, s, last, stepMs)
+	span.Set("cache-extents-num", len(extents))
+	span.Set("cache-missing-num", len(missing))
+
+	switch {
+	case len(missing) == 0:
+		metric.ResultCacheRequestInc(ctx, metric.ResultHit)
+	case len(extents) == 0 || (len(missing) == 1 && missing[0] == timeRange{start: s, end: last}):
+		metric.ResultCacheRequestInc(ctx, metric.ResultMiss)
+	default:
+		metric.ResultCacheRequestInc(ctx, metric.ResultPartialHit)
+	}
+
+	parts := make([]promPromql.Matrix, 0, len(extents)+len(missing))
+	for _, ext := range extents {
+		if ext.End < s || ext.Start > last {
+			continue
+		}
+		parts = append(parts, filterMatrix(ext.Matrix, s, last))
+	}
+
+	newExtents := append([]Extent{}, extents...)
+	changed := false
+	for _, r := range missing {
+		rangeStart, rangeEnd := time.UnixMilli(r.start), time.UnixMilli(r.end)
+		if r.start == s {
+			rangeStart = start
+		}
+		if r.end == last {
+			rangeEnd = end
+		}
+
+		m, partial, fetchErr := fetch(ctx, rangeStart, rangeEnd)
+		if fetchErr != nil {
+			err = fetchErr
+			return
+		}
+		parts = append(parts, m)
+
+		// 部分数据不写入缓存，避免缓存不完整的结果
+		if partial {
+			isPartial = true
+			continue
+		}
+		if cacheEnd := min(r.end, stable); r.start <= cacheEnd {
+			newExtents = append(newExtents, Extent{
+				Start:  r.start,
+				End:    cacheEnd,
+				Matrix: filterMatrix(m, r.start, cacheEnd),
+			})
+			changed = true
+		}
+	}
+
+	// fetch 期间不登记，避免慢查询阻塞 Close，写入前缓存已关闭时放弃写入
+	if changed && c.acquire() {
+		newExtents = mergeExtents(newExtents, stepMs)
+		if maxExtents := c.opt.MaxExtents; maxExtents > 0 && len(newExtents) > maxExtents {
+			newExtents = newExtents[len(newExtents)-maxExtents:]
+		}
+		c.store(ctx, key, newExtents)
+		c.release()
+	}
+
+	res = promql.MergeMatrix(parts...)
+	return
+}
+
+// acquire 登记一次缓存读写，缓存已关闭时返回 false
+func (c *Cache) acquire() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.closed {
+		return false
+	}
+	c.wg.Add(1)
+	return true
+}
+
+func (c *Cache) release() {
+	c.wg.Done()
+}
+
+func (c *Cache) load(ctx context.Context, key string) []Extent {
+	if v, ok := c.mem.Get(key); ok {
+		if ent, ok := v.(*entry); ok {
+			return ent.Extents
+		}
+	}
+	if c.kv == nil {
+		return nil
+	}
+
+	b, err := c.kv.Get([]byte(key))
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	ent := &entry{}
+	if err = gob.NewDecoder(bytes.NewReader(b)).Decode(ent); err != nil {
+		log.Warnf(ctx, "result cache decode %s error: %s", key, err)
+		return nil
+	}
+
+	ttl := time.Unix(ent.ExpiredAt, 0).Sub(c.now())
+	if ttl <= 0 {
+		_ = c.kv.Delete([]byte(key))
+		return nil
+	}
+	c.mem.SetWithTTL(key, ent, extentsCost(ent.Extents), ttl)
+	return ent.Extents
+}
+
+func (c *Cache) store(ctx context.Context, key string, extents []Extent) {
+	ent := &entry{
+		Extents:   extents,
+		ExpiredAt: c.now().Add(c.opt.TTL).Unix(),
+	}
+	c.mem.SetWithTTL(key, ent, extentsCost(extents), c.opt.TTL)
+	if c.kv == nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(ent); err != nil {
+		log.Warnf(ctx, "result cache encode %s error: %s", key, err)
+		return
+	}
+	if err := c.kv.Put([]byte(key), buf.Bytes()); err != nil {
+		log.Warnf(ctx, "result cache persist %s error: %s", key, err)
+	}
+}
+
+// Close 等待进行中的缓存读写结束后关闭持久化存储，之后的查询不再使用缓存
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.lock.Unlock()
+
+	c.wg.Wait()
+	c.mem.Clear()
+	if c.kv != nil {
+		return c.kv.Close()
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package resultcache
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	promPromql "github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/kvstore/bbolt"
+)
+
+type mapCache struct {
+	lock sync.Mutex
+	data map[string]any
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{data: make(map[string]any)}
+}
+
+func (m *mapCache) Get(k string) (any, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	v, ok := m.data[k]
+	return v, ok
+}
+
+func (m *mapCache) Set(k string, v any, _ int64) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data[k] = v
+	return true
+}
+
+func (m *mapCache) SetWithTTL(k string, v any, cost int64, _ time.Duration) bool {
+	return m.Set(k, v, cost)
+}
+
+func (m *mapCache) Del(k string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.data, k)
+}
+
+func (m *mapCache) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data = make(map[string]any)
+}
+
+type fetchRecorder struct {
+	step    time.Duration
+	partial bool
+	calls   [][2]int64
+}
+
+// fetch 生成 step 网格上的点，值为秒级时间戳
+func (f *fetchRecorder) fetch(_ context.Context, start, end time.Time) (promPromql.Matrix, bool, error) {
+	f.calls = append(f.calls, [2]int64{start.Unix(), end.Unix()})
+	series := promPromql.Series{Metric: labels.FromStrings("a", "1")}
+	for t := start; !t.After(end); t = t.Add(f.step) {
+		series.Points = append(series.Points, promPromql.Point{T: t.UnixMilli(), V: float64(t.Unix())})
+	}
+	return promPromql.Matrix{series}, f.partial, nil
+}
+
+func points(m promPromql.Matrix) []int64 {
+	var res []int64
+	for _, s := range m {
+		for _, p := range s.Points {
+			res = append(res, p.T/1e3)
+		}
+	}
+	return res
+}
+
+func TestMissingRanges(t *testing.T) {
+	extents := []Extent{
+		{Start: 100, End: 200},
+		{Start: 400, End: 500},
+	}
+	for name, c := range map[string]struct {
+		start, end int64
+		expected   []timeRange
+	}{
+		"fully covered": {start: 100, end: 200},
+		"no overlap":    {start: 600, end: 700, expected: []timeRange{{600, 700}}},
+		"gap and tail": {start: 0, end: 700, expected: []timeRange{
+			{0, 90}, {210, 390}, {510, 700},
+		}},
+		"inside gap": {start: 250, end: 350, expected: []timeRange{{250, 350}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, missingRanges(extents, c.start, c.end, 10))
+		})
+	}
+}
+
+func TestMergeExtents(t *testing.T) {
+	a := Extent{Start: 0, End: 10, Matrix: promPromql.Matrix{{Metric: labels.FromStrings("a", "1"), Points: []promPromql.Point{{T: 0, V: 1}, {T: 10, V: 2}}}}}
+	b := Extent{Start: 20, End: 30, Matrix: promPromql.Matrix{{Metric: labels.FromStrings("a", "1"), Points: []promPromql.Point{{T: 20, V: 3}, {T: 30, V: 4}}}}}
+	c := Extent{Start: 50, End: 60}
+
+	res := mergeExtents([]Extent{c, b, a}, 10)
+	assert.Len(t, res, 2)
+	assert.Equal(t, int64(0), res[0].Start)
+	assert.Equal(t, int64(30), res[0].End)
+	assert.Len(t, res[0].Matrix[0].Points, 4)
+	assert.Equal(t, int64(50), res[1].Start)
+}
+
+func TestCacheQueryRange(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(10000, 0)
+	step := time.Minute
+
+	f := &fetchRecorder{step: step}
+	c := NewCache(&Options{TTL: time.Hour, UnstableWindow: 5 * time.Minute}, newMapCache(), nil)
+	c.now = func() time.Time { return now }
+
+	// 首次查询全部取数，最近 5 分钟不缓存
+	res, _, err := c.QueryRange(ctx, "q", time.Unix(3000, 0), time.Unix(9900, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int64{{3000, 9900}}, f.calls)
+	assert.Len(t, points(res), 116)
+
+	// 相同查询只需要补充不稳定窗口的数据
+	f.calls = nil
+	res, _, err = c.QueryRange(ctx, "q", time.Unix(3000, 0), time.Unix(9900, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int64{{9720, 9900}}, f.calls)
+	assert.Len(t, points(res), 116)
+
+	// 向前扩展时间范围，只请求缺失的区间
+	f.calls = nil
+	res, _, err = c.QueryRange(ctx, "q", time.Unix(1200, 0), time.Unix(6000, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int64{{1200, 2940}}, f.calls)
+	assert.Equal(t, int64(1200), points(res)[0])
+	assert.Equal(t, int64(6000), points(res)[len(points(res))-1])
+	assert.Len(t, points(res), 81)
+
+	// 完全命中
+	f.calls = nil
+	_, _, err = c.QueryRange(ctx, "q", time.Unix(1200, 0), time.Unix(6000, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Empty(t, f.calls)
+
+	// 对齐偏移不同的查询不复用
+	_, _, err = c.QueryRange(ctx, "q", time.Unix(1230, 0), time.Unix(6030, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int64{{1230, 6030}}, f.calls)
+
+	// 查询范围全部位于不稳定窗口内时直接查询
+	f.calls = nil
+	_, _, err = c.QueryRange(ctx, "q", time.Unix(9900, 0), time.Unix(10000, 0), step, f.fetch)
+	assert.NoError(t, err)
+	_, _, err = c.QueryRange(ctx, "q", time.Unix(9900, 0), time.Unix(10000, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Len(t, f.calls, 2)
+}
+
+func TestCacheQueryRangePartial(t *testing.T) {
+	ctx := context.Background()
+	step := time.Minute
+
+	f := &fetchRecorder{step: step, partial: true}
+	c := NewCache(&Options{TTL: time.Hour}, newMapCache(), nil)
+	c.now = func() time.Time { return time.Unix(10000, 0) }
+
+	for i := 0; i < 2; i++ {
+		_, isPartial, err := c.QueryRange(ctx, "q", time.Unix(3000, 0), time.Unix(6000, 0), step, f.fetch)
+		assert.NoError(t, err)
+		assert.True(t, isPartial)
+	}
+	assert.Len(t, f.calls, 2)
+}
+
+func TestCacheQueryRangePersist(t *testing.T) {
+	ctx := context.Background()
+	step := time.Minute
+	path := filepath.Join(t.TempDir(), "result_cache.db")
+
+	newCache := func() *Cache {
+		kv := bbolt.NewClient(path, "resultCacheBucket")
+		assert.NoError(t, kv.Open())
+		c := NewCache(&Options{TTL: time.Hour}, newMapCache(), kv)
+		c.now = func() time.Time { return time.Unix(10000, 0) }
+		return c
+	}
+
+	f := &fetchRecorder{step: step}
+	c := newCache()
+	_, _, err := c.QueryRange(ctx, "q", time.Unix(3000, 0), time.Unix(6000, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+
+	// 重启后从 bbolt 中恢复缓存
+	f.calls = nil
+	c = newCache()
+	defer c.Close()
+	res, _, err := c.QueryRange(ctx, "q", time.Unix(3000, 0), time.Unix(6000, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Empty(t, f.calls)
+	assert.Len(t, points(res), 51)
+}
+
+// 关闭时等待进行中的缓存读写，关闭后的查询直接调用 fetch
+func TestCacheCloseInFlight(t *testing.T) {
+	ctx := context.Background()
+	step := time.Minute
+	path := filepath.Join(t.TempDir(), "result_cache.db")
+
+	kv := bbolt.NewClient(path, "resultCacheBucket")
+	assert.NoError(t, kv.Open())
+	c := NewCache(&Options{TTL: time.Hour}, newMapCache(), kv)
+	c.now = func() time.Time { return time.Unix(10000, 0) }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				f := &fetchRecorder{step: step}
+				res, _, err := c.QueryRange(ctx, "q", time.Unix(3000, 0), time.Unix(6000, 0), step, f.fetch)
+				assert.NoError(t, err)
+				assert.Len(t, points(res), 51)
+			}
+		}()
+	}
+	assert.NoError(t, c.Close())
+	wg.Wait()
+
+	// 关闭后不再读取缓存，且可以重新打开同一个 bbolt 文件
+	f := &fetchRecorder{step: step}
+	_, _, err := c.QueryRange(ctx, "q", time.Unix(3000, 0), time.Unix(6000, 0), step, f.fetch)
+	assert.NoError(t, err)
+	assert.Len(t, f.calls, 1)
+	assert.NoError(t, c.Close())
+
+	kv = bbolt.NewClient(path, "resultCacheBucket")
+	assert.NoError(t, kv.Open())
+	assert.NoError(t, kv.Close())
+}
@@ -0,0 +1,115 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package resultcache
+
+import (
+	"sort"
+
+	promPromql "github.com/prometheus/prometheus/promql"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+)
+
+// Extent 一段按 step 对齐的连续查询结果，Start 和 End 为毫秒时间戳（闭区间）
+type Extent struct {
+	Start  int64
+	End    int64
+	Matrix promPromql.Matrix
+}
+
+type timeRange struct {
+	start int64
+	end   int64
+}
+
+// missingRanges 计算 [start, end] 中未被 extents 覆盖的区间，extents 需按 Start 排序且与 start 处于同一 step 网格
+func missingRanges(extents []Extent, start, end, step int64) []timeRange {
+	var (
+		res    []timeRange
+		cursor = start
+	)
+	for _, e := range extents {
+		if e.End < cursor {
+			continue
+		}
+		if e.Start > end {
+			break
+		}
+		if e.Start > cursor {
+			res = append(res, timeRange{start: cursor, end: e.Start - step})
+		}
+		cursor = e.End + step
+		if cursor > end {
+			return res
+		}
+	}
+	if cursor <= end {
+		res = append(res, timeRange{start: cursor, end: end})
+	}
+	return res
+}
+
+// filterMatrix 截取 [start, end] 内的点，不修改原始数据
+func filterMatrix(m promPromql.Matrix, start, end int64) promPromql.Matrix {
+	res := make(promPromql.Matrix, 0, len(m))
+	for _, s := range m {
+		points := make([]promPromql.Point, 0, len(s.Points))
+		for _, p := range s.Points {
+			if p.T >= start && p.T <= end {
+				points = append(points, p)
+			}
+		}
+		if len(points) > 0 {
+			res = append(res, promPromql.Series{Metric: s.Metric, Points: points})
+		}
+	}
+	return res
+}
+
+// mergeExtents 合并重叠或相邻的区间，返回新的切片
+func mergeExtents(extents []Extent, step int64) []Extent {
+	if len(extents) == 0 {
+		return extents
+	}
+	sorted := append([]Extent{}, extents...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	res := make([]Extent, 0, len(sorted))
+	cur := sorted[0]
+	for _, e := range sorted[1:] {
+		if e.Start <= cur.End+step {
+			cur = Extent{
+				Start:  cur.Start,
+				End:    max(cur.End, e.End),
+				Matrix: promql.MergeMatrix(cur.Matrix, e.Matrix),
+			}
+			continue
+		}
+		res = append(res, cur)
+		cur = e
+	}
+	return append(res, cur)
+}
+
+// extentsCost 估算缓存占用的字节数
+func extentsCost(extents []Extent) int64 {
+	var cost int64
+	for _, e := range extents {
+		for _, s := range e.Matrix {
+			cost += int64(len(s.Points)) * 16
+			for _, l := range s.Metric {
+				cost += int64(len(l.Name) + len(l.Value))
+			}
+		}
+	}
+	return cost
+}
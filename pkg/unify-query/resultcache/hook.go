@@ -0,0 +1,106 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package resultcache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/spf13/viper"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/eventbus"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/kvstore"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/kvstore/bbolt"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/memcache"
+)
+
+var defaultCache atomic.Pointer[Cache]
+
+// Default 返回全局结果缓存，未开启时为 nil
+func Default() *Cache {
+	return defaultCache.Load()
+}
+
+// SetDefault 替换全局结果缓存，返回旧的缓存
+func SetDefault(c *Cache) *Cache {
+	return defaultCache.Swap(c)
+}
+
+func setDefaultConfig() {
+	viper.SetDefault(EnabledConfigPath, false)
+	viper.SetDefault(TTLConfigPath, "24h")
+	viper.SetDefault(UnstableWindowConfigPath, "5m")
+	viper.SetDefault(MaxExtentsConfigPath, 8)
+	viper.SetDefault(MaxCostConfigPath, 1<<29)
+	viper.SetDefault(NumCountersConfigPath, 1e6)
+
+	viper.SetDefault(PersistEnabledConfigPath, false)
+	viper.SetDefault(PersistPathConfigPath, "result_cache.db")
+	viper.SetDefault(PersistBucketConfigPath, "resultCacheBucket")
+}
+
+func newCacheFromConfig() (*Cache, error) {
+	mem, err := memcache.NewRistrettoWithConfig(&ristretto.Config{
+		NumCounters: viper.GetInt64(NumCountersConfigPath),
+		MaxCost:     viper.GetInt64(MaxCostConfigPath),
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var kv kvstore.KVStore
+	if viper.GetBool(PersistEnabledConfigPath) {
+		client := bbolt.NewClient(viper.GetString(PersistPathConfigPath), viper.GetString(PersistBucketConfigPath))
+		if err = client.Open(); err != nil {
+			return nil, fmt.Errorf("open result cache persist store error: %w", err)
+		}
+		kv = client
+	}
+
+	return NewCache(&Options{
+		TTL:            viper.GetDuration(TTLConfigPath),
+		UnstableWindow: viper.GetDuration(UnstableWindowConfigPath),
+		MaxExtents:     viper.GetInt(MaxExtentsConfigPath),
+	}, mem, kv), nil
+}
+
+// LoadConfig 按配置重建全局结果缓存
+func LoadConfig() {
+	ctx := context.Background()
+
+	// 先关闭旧缓存，释放 bbolt 文件锁，Close 会等待进行中的缓存读写结束
+	// 仍持有旧缓存的查询在关闭后直接查询，不再读写缓存
+	if old := SetDefault(nil); old != nil {
+		if err := old.Close(); err != nil {
+			log.Warnf(ctx, "close result cache error: %s", err)
+		}
+	}
+
+	if !viper.GetBool(EnabledConfigPath) {
+		return
+	}
+
+	c, err := newCacheFromConfig()
+	if err != nil {
+		log.Errorf(ctx, "init result cache error: %s", err)
+		return
+	}
+	SetDefault(c)
+	log.Infof(ctx, "result cache enabled, ttl: %s, unstable window: %s", c.opt.TTL, c.opt.UnstableWindow)
+}
+
+func init() {
+	eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPreParse, setDefaultConfig)
+	eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPostParse, LoadConfig)
+}
@@ -0,0 +1,38 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package resultcache
+
+import (
+	"time"
+)
+
+const (
+	EnabledConfigPath        = "result_cache.enabled"
+	TTLConfigPath            = "result_cache.ttl"
+	UnstableWindowConfigPath = "result_cache.unstable_window"
+	MaxExtentsConfigPath     = "result_cache.max_extents"
+	MaxCostConfigPath        = "result_cache.max_cost"
+	NumCountersConfigPath    = "result_cache.num_counters"
+
+	// 持久化配置，开启后缓存写入 bbolt，重启后仍可复用
+	PersistEnabledConfigPath = "result_cache.persist.enabled"
+	PersistPathConfigPath    = "result_cache.persist.path"
+	PersistBucketConfigPath  = "result_cache.persist.bucket"
+)
+
+// Options 结果缓存配置
+type Options struct {
+	// TTL 缓存过期时间
+	TTL time.Duration
+	// UnstableWindow 最近这段时间内的数据可能还未写入完整，不进行缓存
+	UnstableWindow time.Duration
+	// MaxExtents 单个查询最多保留的区间数量，超过后淘汰最早的区间
+	MaxExtents int
+}
@@ -0,0 +1,54 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package rollup
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/eventbus"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+)
+
+var defaultRouter atomic.Pointer[Router]
+
+// Default 返回全局降精度路由，未开启时为 nil
+func Default() *Router {
+	return defaultRouter.Load()
+}
+
+// SetDefault 替换全局降精度路由，返回旧的路由
+func SetDefault(r *Router) *Router {
+	return defaultRouter.Swap(r)
+}
+
+func setDefaultConfig() {
+	viper.SetDefault(EnabledConfigPath, false)
+	viper.SetDefault(LabelConfigPath, DefaultLabel)
+}
+
+// LoadConfig 按配置重建全局降精度路由
+func LoadConfig() {
+	if !viper.GetBool(EnabledConfigPath) {
+		SetDefault(nil)
+		return
+	}
+
+	label := viper.GetString(LabelConfigPath)
+	SetDefault(NewRouter(label, nil))
+	log.Infof(context.Background(), "rollup route enabled, label: %s", label)
+}
+
+func init() {
+	eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPreParse, setDefaultConfig)
+	eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPostParse, LoadConfig)
+}
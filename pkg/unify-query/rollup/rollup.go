@@ -0,0 +1,203 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package rollup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/influxdb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	routerInfluxdb "github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/router/influxdb"
+)
+
+const (
+	rate     = "rate"
+	increase = "increase"
+)
+
+// Metadata 空间及结果表路由信息
+type Metadata interface {
+	GetSpace(ctx context.Context, spaceUID string) routerInfluxdb.Space
+	GetResultTable(ctx context.Context, tableID string, ignoreKeyNotFound bool) *routerInfluxdb.ResultTableDetail
+}
+
+// Table 降精度结果表，每个原始字段按聚合方式预聚合为 {field}_{aggregation} 字段
+type Table struct {
+	TableID  string
+	Interval time.Duration
+}
+
+// rewrite 原始数据上的时间聚合函数在降精度表上的等价计算
+type rewrite struct {
+	// aggregation 使用的预聚合字段
+	aggregation string
+	// function 降精度表上的时间聚合函数
+	function string
+	// minSamples 聚合窗口内至少需要的降精度点数
+	minSamples int
+}
+
+// rewrites 只有可以由预聚合结果二次聚合得到的函数才允许路由，avg 等需要多个字段参与计算的函数不支持
+var rewrites = map[string]rewrite{
+	structured.SumOT:   {aggregation: structured.SUM, function: structured.SumOT, minSamples: 1},
+	structured.CountOT: {aggregation: structured.COUNT, function: structured.SumOT, minSamples: 1},
+	structured.MinOT:   {aggregation: structured.MIN, function: structured.MinOT, minSamples: 1},
+	structured.MaxOT:   {aggregation: structured.MAX, function: structured.MaxOT, minSamples: 1},
+	structured.LastOT:  {aggregation: structured.LAST, function: structured.LastOT, minSamples: 1},
+	// counter 使用每个周期的最后一个值，窗口内至少两个点才能计算增量
+	rate:     {aggregation: structured.LAST, function: rate, minSamples: 2},
+	increase: {aggregation: structured.LAST, function: increase, minSamples: 2},
+}
+
+// FieldName 降精度表中预聚合字段名
+func FieldName(field, aggregation string) string {
+	return fmt.Sprintf("%s_%s", field, aggregation)
+}
+
+// ParseTables 解析原始结果表标签中登记的降精度结果表，按精度从粗到细排序
+func ParseTables(s string) ([]Table, error) {
+	tables := make([]Table, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		interval, tableID, ok := strings.Cut(item, "=")
+		if !ok || tableID == "" {
+			return nil, fmt.Errorf("rollup table format error: %s", item)
+		}
+		d, err := model.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("rollup table interval error: %s, %w", item, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("rollup table interval must be positive: %s", item)
+		}
+
+		tables = append(tables, Table{
+			TableID:  strings.TrimSpace(tableID),
+			Interval: time.Duration(d),
+		})
+	}
+
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].Interval > tables[j].Interval
+	})
+	return tables, nil
+}
+
+// Router 按查询 step 将查询路由到降精度结果表
+type Router struct {
+	label string
+	md    Metadata
+}
+
+// NewRouter md 为空时使用全局空间路由
+func NewRouter(label string, md Metadata) *Router {
+	if label == "" {
+		label = DefaultLabel
+	}
+	return &Router{
+		label: label,
+		md:    md,
+	}
+}
+
+func (r *Router) metadata() Metadata {
+	if r.md != nil {
+		return r.md
+	}
+
+	router, err := influxdb.GetSpaceTsDbRouter()
+	if err != nil {
+		return nil
+	}
+	return router
+}
+
+// Route 判断查询是否可以使用降精度结果表，命中后改写查询的结果表、字段和时间聚合函数，返回命中的结果表
+func (r *Router) Route(ctx context.Context, spaceUID string, step time.Duration, q *structured.Query) (Table, bool) {
+	var table Table
+
+	// 已经改写过的查询不再重复处理
+	if q == nil || q.Rollup != "" {
+		return table, false
+	}
+	if q.DataSource != "" && q.DataSource != structured.BkMonitor {
+		return table, false
+	}
+	if q.TableID == "" || q.FieldName == "" || q.IsRegexp {
+		return table, false
+	}
+	if q.TimeAggregation.IsSubQuery {
+		return table, false
+	}
+
+	rw, ok := rewrites[q.TimeAggregation.Function]
+	if !ok {
+		return table, false
+	}
+	window, err := q.TimeAggregation.Window.Duration()
+	if err != nil || window <= 0 || step <= 0 {
+		return table, false
+	}
+
+	md := r.metadata()
+	if md == nil {
+		return table, false
+	}
+
+	rt := md.GetResultTable(ctx, string(q.TableID), true)
+	if rt == nil || rt.Labels[r.label] == "" {
+		return table, false
+	}
+	tables, err := ParseTables(rt.Labels[r.label])
+	if err != nil {
+		log.Warnf(ctx, "parse rollup tables of %s error: %s", q.TableID, err)
+		return table, false
+	}
+
+	space := md.GetSpace(ctx, spaceUID)
+	field := FieldName(q.FieldName, rw.aggregation)
+	for _, t := range tables {
+		// 精度需要小于 step，同时窗口需要是精度的整数倍，才能保证二次聚合结果与原始数据一致
+		if t.Interval > step || window%t.Interval != 0 || window < t.Interval*time.Duration(rw.minSamples) {
+			continue
+		}
+		// 降精度表需要在当前空间下
+		if _, ok = space[t.TableID]; !ok {
+			continue
+		}
+		detail := md.GetResultTable(ctx, t.TableID, true)
+		if detail == nil {
+			continue
+		}
+		if len(detail.Fields) > 0 && !slices.Contains(detail.Fields, field) {
+			continue
+		}
+
+		q.TableID = structured.TableID(t.TableID)
+		q.FieldName = field
+		q.TimeAggregation.Function = rw.function
+		q.Rollup = model.Duration(t.Interval).String()
+		return t, true
+	}
+
+	return table, false
+}
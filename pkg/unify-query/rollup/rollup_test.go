@@ -0,0 +1,199 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package rollup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	routerInfluxdb "github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/router/influxdb"
+)
+
+type mockMetadata struct {
+	space routerInfluxdb.Space
+	rts   map[string]*routerInfluxdb.ResultTableDetail
+}
+
+func (m *mockMetadata) GetSpace(_ context.Context, _ string) routerInfluxdb.Space {
+	return m.space
+}
+
+func (m *mockMetadata) GetResultTable(_ context.Context, tableID string, _ bool) *routerInfluxdb.ResultTableDetail {
+	return m.rts[tableID]
+}
+
+func TestParseTables(t *testing.T) {
+	tables, err := ParseTables("1m=2_rollup_1m.cpu, 1h=2_rollup_1h.cpu,5m=2_rollup_5m.cpu")
+	require.NoError(t, err)
+	assert.Equal(t, []Table{
+		{TableID: "2_rollup_1h.cpu", Interval: time.Hour},
+		{TableID: "2_rollup_5m.cpu", Interval: 5 * time.Minute},
+		{TableID: "2_rollup_1m.cpu", Interval: time.Minute},
+	}, tables)
+
+	_, err = ParseTables("2_rollup_1m.cpu")
+	assert.Error(t, err)
+
+	_, err = ParseTables("1x=2_rollup_1m.cpu")
+	assert.Error(t, err)
+}
+
+func TestRouterRoute(t *testing.T) {
+	md := &mockMetadata{
+		space: routerInfluxdb.Space{
+			"system.cpu":      {TableId: "system.cpu"},
+			"2_rollup_1m.cpu": {TableId: "2_rollup_1m.cpu"},
+			"2_rollup_1h.cpu": {TableId: "2_rollup_1h.cpu"},
+		},
+		rts: map[string]*routerInfluxdb.ResultTableDetail{
+			"system.cpu": {
+				TableId: "system.cpu",
+				Fields:  []string{"usage", "total"},
+				Labels:  map[string]string{DefaultLabel: "1m=2_rollup_1m.cpu,1h=2_rollup_1h.cpu,5m=2_rollup_5m.cpu"},
+			},
+			"2_rollup_1m.cpu": {
+				TableId: "2_rollup_1m.cpu",
+				Fields:  []string{"usage_sum", "usage_count", "usage_min", "usage_max", "usage_last", "total_last"},
+			},
+			"2_rollup_5m.cpu": {
+				TableId: "2_rollup_5m.cpu",
+				Fields:  []string{"usage_sum", "usage_count"},
+			},
+			"2_rollup_1h.cpu": {
+				TableId: "2_rollup_1h.cpu",
+				Fields:  []string{"usage_sum", "usage_count", "usage_max"},
+			},
+		},
+	}
+	router := NewRouter("", md)
+
+	for name, c := range map[string]struct {
+		query    *structured.Query
+		step     time.Duration
+		tableID  string
+		field    string
+		function string
+		rollup   string
+	}{
+		"sum with 1h step": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "usage",
+				TimeAggregation: structured.TimeAggregation{Function: structured.SumOT, Window: "1h"},
+			},
+			step:     time.Hour,
+			tableID:  "2_rollup_1h.cpu",
+			field:    "usage_sum",
+			function: structured.SumOT,
+			rollup:   "1h",
+		},
+		"count to sum": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "usage",
+				TimeAggregation: structured.TimeAggregation{Function: structured.CountOT, Window: "2h"},
+			},
+			step:     time.Hour,
+			tableID:  "2_rollup_1h.cpu",
+			field:    "usage_count",
+			function: structured.SumOT,
+			rollup:   "1h",
+		},
+		"window not aligned to 1h": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "usage",
+				TimeAggregation: structured.TimeAggregation{Function: structured.MinOT, Window: "90m"},
+			},
+			step:     time.Hour,
+			tableID:  "2_rollup_1m.cpu",
+			field:    "usage_min",
+			function: structured.MinOT,
+			rollup:   "1m",
+		},
+		"5m table not in space": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "usage",
+				TimeAggregation: structured.TimeAggregation{Function: structured.SumOT, Window: "5m"},
+			},
+			step:     5 * time.Minute,
+			tableID:  "2_rollup_1m.cpu",
+			field:    "usage_sum",
+			function: structured.SumOT,
+			rollup:   "1m",
+		},
+		"rate need two samples": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "total",
+				TimeAggregation: structured.TimeAggregation{Function: "rate", Window: "1m"},
+			},
+			step:     time.Hour,
+			tableID:  "system.cpu",
+			field:    "total",
+			function: "rate",
+		},
+		"rate": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "total",
+				TimeAggregation: structured.TimeAggregation{Function: "rate", Window: "5m"},
+			},
+			step:     time.Hour,
+			tableID:  "2_rollup_1m.cpu",
+			field:    "total_last",
+			function: "rate",
+			rollup:   "1m",
+		},
+		"step too small": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "usage",
+				TimeAggregation: structured.TimeAggregation{Function: structured.SumOT, Window: "1m"},
+			},
+			step:     30 * time.Second,
+			tableID:  "system.cpu",
+			field:    "usage",
+			function: structured.SumOT,
+		},
+		"avg not supported": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "usage",
+				TimeAggregation: structured.TimeAggregation{Function: structured.AvgOT, Window: "1h"},
+			},
+			step:     time.Hour,
+			tableID:  "system.cpu",
+			field:    "usage",
+			function: structured.AvgOT,
+		},
+		"field missing in rollup": {
+			query: &structured.Query{
+				TableID: "system.cpu", FieldName: "total",
+				TimeAggregation: structured.TimeAggregation{Function: structured.SumOT, Window: "1h"},
+			},
+			step:     time.Hour,
+			tableID:  "system.cpu",
+			field:    "total",
+			function: structured.SumOT,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, ok := router.Route(context.Background(), "bkcc__2", c.step, c.query)
+			assert.Equal(t, c.rollup != "", ok)
+			assert.Equal(t, structured.TableID(c.tableID), c.query.TableID)
+			assert.Equal(t, c.field, c.query.FieldName)
+			assert.Equal(t, c.function, c.query.TimeAggregation.Function)
+			assert.Equal(t, c.rollup, c.query.Rollup)
+
+			// 重复路由不会再次改写
+			_, ok = router.Route(context.Background(), "bkcc__2", c.step, c.query)
+			assert.False(t, ok)
+		})
+	}
+}
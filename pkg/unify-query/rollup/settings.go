@@ -0,0 +1,24 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package rollup
+
+const (
+	EnabledConfigPath = "query.rollup.enabled"
+	// LabelConfigPath 原始结果表上登记降精度结果表的标签名
+	LabelConfigPath = "query.rollup.label"
+)
+
+const (
+	// DefaultLabel 标签值格式为 {interval}={table_id}，多个用逗号分隔，例如：1m=2_rollup_1m.cpu,1h=2_rollup_1h.cpu
+	DefaultLabel = "bk_rollup_tables"
+
+	// Raw 未命中降精度结果表时的精度
+	Raw = "raw"
+)
@@ -0,0 +1,527 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/parquet"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metric"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	redisUtil "github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/redis"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+const (
+	RawExportFormatNDJSON  = "ndjson"
+	RawExportFormatCSV     = "csv"
+	RawExportFormatParquet = "parquet"
+
+	// 流式导出开始后无法再修改状态码，行数与错误信息通过 http trailer 返回
+	RawExportRowsTrailer  = "X-Bk-Export-Rows"
+	RawExportErrorTrailer = "X-Bk-Export-Error"
+
+	// trailer 错误信息最大长度
+	rawExportErrorMaxLength = 1024
+)
+
+type rawExportOptions struct {
+	format string
+	fields []string
+	limit  int
+}
+
+// parseRawExportOptions 解析导出参数：format 导出格式，fields 导出字段（可重复或逗号分隔），limit 最大导出行数
+func parseRawExportOptions(c *gin.Context) (rawExportOptions, error) {
+	opts := rawExportOptions{
+		format: strings.ToLower(c.DefaultQuery("format", RawExportFormatNDJSON)),
+		limit:  RawExportMaxRows,
+	}
+
+	switch opts.format {
+	case RawExportFormatNDJSON, RawExportFormatCSV, RawExportFormatParquet:
+	default:
+		return opts, fmt.Errorf("unsupported export format: %s", opts.format)
+	}
+
+	for _, v := range c.QueryArray("fields") {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" && !slices.Contains(opts.fields, field) {
+				opts.fields = append(opts.fields, field)
+			}
+		}
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return opts, fmt.Errorf("invalid export limit: %s", v)
+		}
+		// 不允许超过配置的最大导出行数
+		if RawExportMaxRows <= 0 || limit < RawExportMaxRows {
+			opts.limit = limit
+		}
+	}
+
+	return opts, nil
+}
+
+// rawExportWriter 导出格式写入器，Flush 会把已缓存的数据推送给客户端
+type rawExportWriter interface {
+	Write(row map[string]any) error
+	Flush() error
+	Close() error
+}
+
+func newRawExportWriter(w io.Writer, opts rawExportOptions) rawExportWriter {
+	out := &flushWriter{Writer: bufio.NewWriter(w), w: w}
+	switch opts.format {
+	case RawExportFormatCSV:
+		return &csvExportWriter{out: out, csv: csv.NewWriter(out), columns: opts.fields}
+	case RawExportFormatParquet:
+		return &parquetExportWriter{out: out, columns: opts.fields}
+	default:
+		return &ndjsonExportWriter{out: out, fields: opts.fields}
+	}
+}
+
+func rawExportContentType(format string) string {
+	switch format {
+	case RawExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case RawExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+type flushWriter struct {
+	*bufio.Writer
+	w io.Writer
+}
+
+func (f *flushWriter) Flush() error {
+	if err := f.Writer.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// rowColumns 未指定导出字段时，使用首行数据的字段作为表头
+func rowColumns(row map[string]any) []string {
+	columns := make([]string, 0, len(row))
+	for k := range row {
+		columns = append(columns, k)
+	}
+	slices.Sort(columns)
+	return columns
+}
+
+// exportValue 把字段值转换为字符串，嵌套结构使用 json 序列化
+func exportValue(v any) (string, bool) {
+	switch value := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	case map[string]any, []any:
+		b, _ := json.Marshal(value)
+		return string(b), true
+	default:
+		if s, err := cast.ToStringE(value); err == nil {
+			return s, true
+		}
+		b, _ := json.Marshal(value)
+		return string(b), true
+	}
+}
+
+type ndjsonExportWriter struct {
+	out    *flushWriter
+	fields []string
+}
+
+func (n *ndjsonExportWriter) Write(row map[string]any) error {
+	if len(n.fields) > 0 {
+		selected := make(map[string]any, len(n.fields))
+		for _, field := range n.fields {
+			if v, ok := row[field]; ok {
+				selected[field] = v
+			}
+		}
+		row = selected
+	}
+
+	b, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if _, err = n.out.Write(b); err != nil {
+		return err
+	}
+	return n.out.WriteByte('\n')
+}
+
+func (n *ndjsonExportWriter) Flush() error {
+	return n.out.Flush()
+}
+
+func (n *ndjsonExportWriter) Close() error {
+	return n.out.Flush()
+}
+
+type csvExportWriter struct {
+	out     *flushWriter
+	csv     *csv.Writer
+	columns []string
+	header  bool
+	record  []string
+}
+
+func (c *csvExportWriter) writeHeader() error {
+	if c.header || len(c.columns) == 0 {
+		return nil
+	}
+	c.header = true
+	c.record = make([]string, len(c.columns))
+	return c.csv.Write(c.columns)
+}
+
+func (c *csvExportWriter) Write(row map[string]any) error {
+	if len(c.columns) == 0 {
+		c.columns = rowColumns(row)
+	}
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	for i, column := range c.columns {
+		c.record[i], _ = exportValue(row[column])
+	}
+	return c.csv.Write(c.record)
+}
+
+func (c *csvExportWriter) Flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	return c.out.Flush()
+}
+
+func (c *csvExportWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.Flush()
+}
+
+type parquetExportWriter struct {
+	out     *flushWriter
+	columns []string
+	writer  *parquet.Writer
+	record  []*string
+}
+
+func (p *parquetExportWriter) init(row map[string]any) {
+	if p.writer != nil {
+		return
+	}
+	if len(p.columns) == 0 && row != nil {
+		p.columns = rowColumns(row)
+	}
+	p.writer = parquet.NewWriter(p.out, p.columns, RawExportParquetRowGroupSize)
+	p.record = make([]*string, len(p.columns))
+}
+
+func (p *parquetExportWriter) Write(row map[string]any) error {
+	p.init(row)
+	for i, column := range p.columns {
+		p.record[i] = nil
+		if s, ok := exportValue(row[column]); ok {
+			p.record[i] = &s
+		}
+	}
+	return p.writer.Write(p.record)
+}
+
+// Flush parquet 按 row group 落盘，这里只推送已经编码完成的数据
+func (p *parquetExportWriter) Flush() error {
+	return p.out.Flush()
+}
+
+func (p *parquetExportWriter) Close() error {
+	p.init(nil)
+	if err := p.writer.Close(); err != nil {
+		return err
+	}
+	return p.out.Flush()
+}
+
+// trailerValue 将控制字符替换为空格并截断，避免换行等字符导致 trailer 非法被丢弃
+func trailerValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, strings.ToValidUTF8(s, "?"))
+	if len(s) > rawExportErrorMaxLength {
+		s = strings.ToValidUTF8(s[:rawExportErrorMaxLength], "")
+	}
+	return s
+}
+
+// queryRawExport 复用 scroll 的分片查询逻辑逐轮拉取原始数据并写入 w，分片状态只保存在内存中，
+// 达到 limit、数据拉取完毕或 ctx 取消（客户端断开）时结束，返回实际写入的行数
+func queryRawExport(ctx context.Context, queryTs *structured.QueryTs, limit int, w rawExportWriter) (int64, error) {
+	var (
+		rows int64
+		err  error
+	)
+
+	ctx, span := trace.NewSpan(ctx, "query-raw-export")
+	defer span.End(&err)
+
+	if queryTs.Scroll == "" {
+		queryTs.Scroll = ScrollWindowTimeout
+	}
+	if queryTs.Limit == 0 {
+		queryTs.Limit = ScrollSliceLimit
+	}
+	if limit > 0 && limit < queryTs.Limit {
+		queryTs.Limit = limit
+	}
+
+	queryRef, err := queryTs.ToQueryReference(ctx)
+	if err != nil {
+		return rows, metadata.NewMessage(
+			metadata.MsgQueryRawExport,
+			"查询参数配置异常",
+		).Error(ctx, err)
+	}
+	queryRef = excludeElasticsearchIndexPrefixMissingQueries(ctx, queryRef, metadata.MsgQueryRawExport, nil)
+	if queryRef.Count() == 0 {
+		return rows, nil
+	}
+
+	// 导出在单个请求内完成，不需要跨请求保存 scroll 状态，因此不写 redis
+	session := redisUtil.NewScrollSession("", 0, 0, queryTs.SliceMax, redisUtil.DefaultSliceMaxFailedNum, queryTs.Limit)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	span.Set("export-limit", limit)
+	span.Set("export-slice-length", session.SliceLength())
+	span.Set("export-page-size", queryTs.Limit)
+
+	for round := 0; !session.Done(); round++ {
+		if err = ctx.Err(); err != nil {
+			return rows, err
+		}
+
+		var (
+			receiveWg sync.WaitGroup
+			dataCh    = make(chan map[string]any)
+			errCh     = make(chan error)
+
+			queryErr strings.Builder
+			writeErr error
+			stop     bool
+		)
+
+		receiveWg.Add(1)
+		go func() {
+			defer receiveWg.Done()
+			for e := range errCh {
+				queryErr.WriteString(fmt.Sprintf("query error: %s ", e.Error()))
+			}
+		}()
+
+		receiveWg.Add(1)
+		go func() {
+			defer receiveWg.Done()
+			for d := range dataCh {
+				// 停止后仍需要把 channel 消费完，避免阻塞查询协程
+				if stop {
+					continue
+				}
+
+				delete(d, metadata.KeyTableUUID)
+				if writeErr = w.Write(d); writeErr != nil {
+					stop = true
+					cancel()
+					continue
+				}
+
+				rows++
+				if limit > 0 && rows >= int64(limit) {
+					stop = true
+					cancel()
+				}
+			}
+		}()
+
+		queryRawScrollRound(ctx, queryRef, session, dataCh, errCh)
+
+		close(dataCh)
+		close(errCh)
+		receiveWg.Wait()
+
+		span.Set(fmt.Sprintf("export-round-%d-rows", round), rows)
+
+		if writeErr != nil {
+			err = writeErr
+			return rows, err
+		}
+		if stop {
+			return rows, nil
+		}
+		if err = ctx.Err(); err != nil {
+			return rows, err
+		}
+		if queryErr.Len() > 0 {
+			err = metadata.NewMessage(
+				metadata.MsgQueryRawExport,
+				"导出原始数据报错",
+			).Error(ctx, errors.New(strings.TrimSpace(queryErr.String())))
+			return rows, err
+		}
+
+		if err = w.Flush(); err != nil {
+			return rows, err
+		}
+	}
+
+	return rows, nil
+}
+
+// HandlerQueryRawExport
+// @Summary  export raw data with chunked transfer
+// @ID       query_raw_export
+// @Produce  application/x-ndjson,text/csv,application/vnd.apache.parquet
+// @Param    traceparent            header    string                        false  "TraceID" default(00-3967ac0f1648bf0216b27631730d7eb9-8e3c31d5109e78dd-01)
+// @Param    Bk-Query-Source   		header    string                        false  "来源" default(username:goodman)
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param	 X-Bk-Scope-Skip-Space  header	  string						false  "是否跳过空间验证" default()
+// @Param    format                 query     string                        false  "导出格式：ndjson、csv、parquet" default(ndjson)
+// @Param    fields                 query     []string                      false  "导出字段，支持逗号分隔"
+// @Param    limit                  query     int                           false  "最大导出行数"
+// @Param    data                  	body      structured.QueryTs  			true   "json data"
+// @Success  200                   	{string}  string
+// @Failure  400                   	{object}  ErrResponse
+// @Router   /query/ts/raw/export [post]
+func HandlerQueryRawExport(c *gin.Context) {
+	var (
+		ctx  = c.Request.Context()
+		resp = &response{c: c}
+		user = metadata.GetUser(ctx)
+		err  error
+		span *trace.Span
+		rows int64
+	)
+
+	ctx, span = trace.NewSpan(ctx, "handler-query-raw-export")
+	defer func() {
+		span.End(&err)
+	}()
+
+	span.Set("request-url", c.Request.URL.String())
+	span.Set("request-header", c.Request.Header)
+
+	span.Set("query-source", user.Key)
+	span.Set("query-tenant-id", user.TenantID)
+	span.Set("query-space-uid", user.SpaceUID)
+
+	opts, err := parseRawExportOptions(c)
+	if err != nil {
+		resp.failed(ctx, metadata.NewMessage(
+			metadata.MsgQueryRawExport,
+			"导出参数校验异常",
+		).Error(ctx, err))
+		return
+	}
+
+	queryTs := &structured.QueryTs{}
+	err = json.NewDecoder(c.Request.Body).Decode(queryTs)
+	if err != nil {
+		resp.failed(ctx, err)
+		return
+	}
+
+	if user.SpaceUID != "" {
+		queryTs.SpaceUid = user.SpaceUID
+	}
+
+	queryStr, _ := json.Marshal(queryTs)
+	span.Set("query-body", string(queryStr))
+	span.Set("export-format", opts.format)
+	span.Set("export-fields", opts.fields)
+	span.Set("export-limit", opts.limit)
+
+	if err = validateQueryTsDataSource(queryTs); err != nil {
+		resp.failed(ctx, metadata.NewMessage(
+			metadata.MsgQueryRawExport,
+			"查询参数校验异常",
+		).Error(ctx, err))
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", rawExportContentType(opts.format))
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export.%s"`, opts.format))
+	header.Set("Trailer", RawExportRowsTrailer+", "+RawExportErrorTrailer)
+
+	w := newRawExportWriter(c.Writer, opts)
+	rows, err = queryRawExport(ctx, queryTs, opts.limit, w)
+	if err == nil {
+		err = w.Close()
+	}
+	span.Set("export-rows", rows)
+
+	if err != nil {
+		// 还没有数据写出时，仍然按照普通接口返回错误
+		if !c.Writer.Written() {
+			header.Del("Content-Type")
+			header.Del("Content-Disposition")
+			header.Del("Trailer")
+			resp.failed(ctx, err)
+			return
+		}
+		header.Set(RawExportErrorTrailer, trailerValue(err.Error()))
+	}
+	header.Set(RawExportRowsTrailer, strconv.FormatInt(rows, 10))
+
+	status := metric.StatusSuccess
+	if err != nil {
+		status = metric.StatusFailed
+	}
+	metric.APIRequestInc(ctx, c.Request.URL.Path, status, user.SpaceUID, user.Source)
+}
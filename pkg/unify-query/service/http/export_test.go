@@ -0,0 +1,193 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/influxdb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/mock"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+)
+
+func TestParseRawExportOptions(t *testing.T) {
+	defer func(maxRows int) { RawExportMaxRows = maxRows }(RawExportMaxRows)
+	RawExportMaxRows = 100
+
+	for name, c := range map[string]struct {
+		query string
+		opts  rawExportOptions
+		err   bool
+	}{
+		"default": {
+			opts: rawExportOptions{format: RawExportFormatNDJSON, limit: 100},
+		},
+		"csv with fields": {
+			query: "format=CSV&fields=a,b&fields=c&fields=a&limit=10",
+			opts:  rawExportOptions{format: RawExportFormatCSV, fields: []string{"a", "b", "c"}, limit: 10},
+		},
+		"limit over max": {
+			query: "format=parquet&limit=1000",
+			opts:  rawExportOptions{format: RawExportFormatParquet, limit: 100},
+		},
+		"unknown format": {
+			query: "format=xlsx",
+			err:   true,
+		},
+		"invalid limit": {
+			query: "limit=-1",
+			err:   true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/query/ts/raw/export?"+c.query, nil)
+
+			opts, err := parseRawExportOptions(ctx)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.opts, opts)
+		})
+	}
+}
+
+func TestRawExportWriter(t *testing.T) {
+	rows := []map[string]any{
+		{"b": "x", "a": 1.5, "nested": map[string]any{"k": "v"}},
+		{"b": "y,z", "c": "ignored"},
+	}
+
+	for name, c := range map[string]struct {
+		opts     rawExportOptions
+		expected string
+	}{
+		"ndjson": {
+			opts:     rawExportOptions{format: RawExportFormatNDJSON, fields: []string{"a", "b"}},
+			expected: "{\"a\":1.5,\"b\":\"x\"}\n{\"b\":\"y,z\"}\n",
+		},
+		"csv inferred columns": {
+			opts:     rawExportOptions{format: RawExportFormatCSV},
+			expected: "a,b,nested\n1.5,x,\"{\"\"k\"\":\"\"v\"\"}\"\n,\"y,z\",\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newRawExportWriter(&buf, c.opts)
+			for _, row := range rows {
+				require.NoError(t, w.Write(row))
+			}
+			require.NoError(t, w.Close())
+			assert.Equal(t, c.expected, buf.String())
+		})
+	}
+
+	t.Run("parquet", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := newRawExportWriter(&buf, rawExportOptions{format: RawExportFormatParquet})
+		for _, row := range rows {
+			require.NoError(t, w.Write(row))
+		}
+		require.NoError(t, w.Close())
+
+		data := buf.String()
+		assert.Equal(t, "PAR1", data[:4])
+		assert.Equal(t, "PAR1", data[len(data)-4:])
+		assert.Contains(t, data, `{"k":"v"}`)
+		assert.NotContains(t, data, "ignored")
+	})
+}
+
+func TestTrailerValue(t *testing.T) {
+	for name, c := range map[string]struct {
+		input    string
+		expected string
+	}{
+		"plain":         {input: "query failed", expected: "query failed"},
+		"newline":       {input: "line1\r\nline2\tend", expected: "line1  line2 end"},
+		"control":       {input: "a\x00b\x7fc", expected: "a b c"},
+		"invalid utf8":  {input: "a\xffb", expected: "a?b"},
+		"truncate":      {input: strings.Repeat("x", rawExportErrorMaxLength+10), expected: strings.Repeat("x", rawExportErrorMaxLength)},
+		"truncate rune": {input: strings.Repeat("x", rawExportErrorMaxLength-1) + "中", expected: strings.Repeat("x", rawExportErrorMaxLength-1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, trailerValue(c.input))
+		})
+	}
+}
+
+func TestQueryRawExport(t *testing.T) {
+	mock.Init()
+	ctx := metadata.InitHashID(context.Background())
+	influxdb.MockSpaceRouter(ctx)
+
+	mock.Es.Set(map[string]any{
+		`{"_source":{"includes":["level"]},"query":{"bool":{"filter":{"range":{"dtEventTimeStamp":{"format":"epoch_second","from":1757051309,"include_lower":true,"include_upper":true,"to":1757054909}}}}},"size":2,"sort":["_doc"]}`: `{"_scroll_id":"export_1","hits":{"total":{"value":3,"relation":"eq"},"hits":[{"_index":"es_index","_id":"1","_source":{"level":"info"}},{"_index":"es_index","_id":"2","_source":{"level":"warn"}}]}}`,
+		`{"scroll":"1m","scroll_id":"export_1"}`: `{"_scroll_id":"export_2","hits":{"total":{"value":3,"relation":"eq"},"hits":[{"_index":"es_index","_id":"3","_source":{"level":"error"}}]}}`,
+		`{"scroll":"1m","scroll_id":"export_2"}`: `{"_scroll_id":"export_2","hits":{"total":{"value":3,"relation":"eq"},"hits":[]}}`,
+	})
+
+	for name, c := range map[string]struct {
+		limit    int
+		opts     rawExportOptions
+		rows     int64
+		expected string
+	}{
+		"ndjson all pages": {
+			opts:     rawExportOptions{format: RawExportFormatNDJSON, fields: []string{"__doc_id", "level"}},
+			rows:     3,
+			expected: "{\"__doc_id\":\"1\",\"level\":\"info\"}\n{\"__doc_id\":\"2\",\"level\":\"warn\"}\n{\"__doc_id\":\"3\",\"level\":\"error\"}\n",
+		},
+		"csv with limit": {
+			limit:    2,
+			opts:     rawExportOptions{format: RawExportFormatCSV, fields: []string{"level", "__doc_id"}},
+			rows:     2,
+			expected: "level,__doc_id\ninfo,1\nwarn,2\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.InitHashID(ctx)
+			qts := &structured.QueryTs{
+				SpaceUid: influxdb.SpaceUid,
+				QueryList: []*structured.Query{
+					{
+						TableID:     "result_table.es",
+						KeepColumns: []string{"level"},
+					},
+				},
+				Start:  "1757051309",
+				End:    "1757054909",
+				Limit:  2,
+				Scroll: "1m",
+			}
+
+			var buf bytes.Buffer
+			w := newRawExportWriter(&buf, c.opts)
+			rows, err := queryRawExport(ctx, qts, c.limit, w)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			assert.Equal(t, c.rows, rows)
+			assert.Equal(t, c.expected, buf.String())
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/eventbus"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/parquet"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
 )
 
@@ -56,6 +57,8 @@ func setDefaultConfig() {
 	viper.SetDefault(TSQueryReferenceQueryHandlePathConfigPath, "/query/ts/reference")
 	viper.SetDefault(TSQueryRawQueryHandlePathConfigPath, "/query/ts/raw")
 	viper.SetDefault(TSQueryRawQueryWithScrollHandlePathConfigPath, "/query/ts/raw_with_scroll")
+	viper.SetDefault(TSQueryRawExportHandlePathConfigPath, "/query/ts/raw/export")
+	viper.SetDefault(TSQuerySQLHandlePathConfigPath, "/query/ts/sql")
 	viper.SetDefault(TSQueryRawMAXLimitConfigPath, 1e2)
 	viper.SetDefault(QueryRawESBatchMaxMembersConfigPath, DefaultQueryRawESBatchMaxMembers)
 	viper.SetDefault(QueryRawESBatchMaxBodyBytesConfigPath, DefaultQueryRawESBatchMaxBodyBytes)
@@ -69,6 +72,7 @@ func setDefaultConfig() {
 
 	viper.SetDefault(TSQueryLabelValuesPathConfigPath, "/query/ts/label/:label_name/values")
 	viper.SetDefault(TSQueryClusterMetricsPathConfigPath, "/query/ts/cluster_metrics")
+	viper.SetDefault(PromAPIPathConfigPath, "/api/v1")
 
 	viper.SetDefault(PrintHandlePathConfigPath, "/print")
 	viper.SetDefault(FeatureFlagHandlePathConfigPath, "/ff")
@@ -86,8 +90,17 @@ func setDefaultConfig() {
 
 	// 分段查询配置
 	viper.SetDefault(SegmentedEnable, false)
-	viper.SetDefault(SegmentedMaxRoutines, 1)
+	viper.SetDefault(SegmentedMaxRoutines, 4)
 	viper.SetDefault(SegmentedMinInterval, "5m")
+	viper.SetDefault(SegmentedInterval, "24h")
+
+	// remote read 配置，与 prometheus 默认值保持一致
+	viper.SetDefault(RemoteReadSampleLimitConfigPath, 5e7)
+	viper.SetDefault(RemoteReadMaxBytesInFrameConfigPath, 1024*1024)
+
+	// 原始数据导出配置
+	viper.SetDefault(RawExportMaxRowsConfigPath, 1e7)
+	viper.SetDefault(RawExportParquetRowGroupSizeConfigPath, parquet.DefaultRowGroupSize)
 
 	viper.SetDefault(QueryMaxRoutingConfigPath, 4)
 
@@ -189,6 +202,17 @@ func LoadConfig() {
 
 	QueryMaxRouting = viper.GetInt(QueryMaxRoutingConfigPath)
 
+	SegmentedQueryEnable = viper.GetBool(SegmentedEnable)
+	SegmentedQueryMaxRoutines = viper.GetInt(SegmentedMaxRoutines)
+	// 拆分区间不小于 min_interval，避免拆得过碎
+	SegmentedQueryInterval = max(viper.GetDuration(SegmentedInterval), viper.GetDuration(SegmentedMinInterval))
+
+	RemoteReadSampleLimit = viper.GetInt(RemoteReadSampleLimitConfigPath)
+	RemoteReadMaxBytesInFrame = viper.GetInt(RemoteReadMaxBytesInFrameConfigPath)
+
+	RawExportMaxRows = viper.GetInt(RawExportMaxRowsConfigPath)
+	RawExportParquetRowGroupSize = viper.GetInt(RawExportParquetRowGroupSizeConfigPath)
+
 	ClusterMetricQueryPrefix = viper.GetString(ClusterMetricQueryPrefixConfigPath)
 	ClusterMetricQueryTimeout = viper.GetDuration(ClusterMetricQueryTimeoutConfigPath)
 
@@ -0,0 +1,763 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ants "github.com/panjf2000/ants/v2"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	promPromql "github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/set"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metric"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/tsdb/prometheus"
+)
+
+// Prometheus HTTP API 兼容接口，参考 https://prometheus.io/docs/prometheus/latest/querying/api/
+// 空间信息与其他接口一致，由 metadata 中间件从请求头中解析
+
+const (
+	promAPIStatusSuccess = "success"
+	promAPIStatusError   = "error"
+
+	promAPIErrorBadData   = "bad_data"
+	promAPIErrorExecution = "execution"
+
+	promAPIDefaultRange = time.Hour
+	// promAPIDefaultMatch labels / label values 接口未传入 match[] 时使用的选择器
+	promAPIDefaultMatch = `{__name__=~".+"}`
+)
+
+// PromAPIResponse Prometheus 标准响应结构
+type PromAPIResponse struct {
+	Status    string   `json:"status"`
+	Data      any      `json:"data,omitempty"`
+	ErrorType string   `json:"errorType,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// PromAPIQueryData query / query_range 的 data 结构
+type PromAPIQueryData struct {
+	ResultType string `json:"resultType"`
+	Result     any    `json:"result"`
+}
+
+// PromAPISeries matrix 中的单条序列
+type PromAPISeries struct {
+	Metric map[string]string `json:"metric"`
+	Values []PromAPIPoint    `json:"values"`
+}
+
+// PromAPISample vector 中的单个样本
+type PromAPISample struct {
+	Metric map[string]string `json:"metric"`
+	Value  PromAPIPoint      `json:"value"`
+}
+
+// PromAPIPoint 序列化为 [<unix 秒>, "<value>"]
+type PromAPIPoint struct {
+	T int64
+	V float64
+}
+
+func (p PromAPIPoint) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 32)
+	b = append(b, '[')
+	b = strconv.AppendFloat(b, float64(p.T)/1e3, 'f', -1, 64)
+	b = append(b, ',', '"')
+	b = strconv.AppendFloat(b, p.V, 'f', -1, 64)
+	b = append(b, '"', ']')
+	return b, nil
+}
+
+type promAPIError struct {
+	typ string
+	err error
+}
+
+func (e *promAPIError) Error() string {
+	return e.err.Error()
+}
+
+func badData(format string, args ...any) error {
+	return &promAPIError{typ: promAPIErrorBadData, err: fmt.Errorf(format, args...)}
+}
+
+type promAPIResponse struct {
+	c *gin.Context
+}
+
+func (r *promAPIResponse) failed(ctx context.Context, err error) {
+	user := metadata.GetUser(ctx)
+	metric.APIRequestInc(ctx, r.c.Request.URL.Path, metric.StatusFailed, user.SpaceUID, user.Source)
+
+	var (
+		code = http.StatusUnprocessableEntity
+		typ  = promAPIErrorExecution
+	)
+	if e, ok := err.(*promAPIError); ok {
+		code = http.StatusBadRequest
+		typ = e.typ
+	}
+
+	r.c.JSON(code, PromAPIResponse{
+		Status:    promAPIStatusError,
+		ErrorType: typ,
+		Error:     err.Error(),
+	})
+}
+
+func (r *promAPIResponse) success(ctx context.Context, data any, warnings ...string) {
+	user := metadata.GetUser(ctx)
+	metric.APIRequestInc(ctx, r.c.Request.URL.Path, metric.StatusSuccess, user.SpaceUID, user.Source)
+
+	r.c.JSON(http.StatusOK, PromAPIResponse{
+		Status:   promAPIStatusSuccess,
+		Data:     data,
+		Warnings: warnings,
+	})
+}
+
+// parsePromAPITime 解析 prometheus 时间参数，支持 unix 秒（可带小数）和 RFC3339
+func parsePromAPITime(s string, defaultTime time.Time) (time.Time, error) {
+	if s == "" {
+		return defaultTime, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e3))*int64(time.Millisecond)), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, badData("cannot parse %q to a valid timestamp", s)
+}
+
+// parsePromAPIDuration 解析 prometheus 时长参数，支持秒数（可带小数）和 1m / 1h 等格式
+func parsePromAPIDuration(s string) (time.Duration, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		d := time.Duration(f * float64(time.Second))
+		if d <= 0 {
+			return 0, badData("cannot parse %q to a valid duration. It overflows int64 or is not positive", s)
+		}
+		return d, nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, badData("cannot parse %q to a valid duration", s)
+}
+
+// promAPITimeRange 转换为内部统一的时间戳字符串，整秒使用秒级，否则统一使用毫秒级
+func promAPITimeRange(start, end time.Time) (string, string) {
+	if start.Nanosecond() == 0 && end.Nanosecond() == 0 {
+		return strconv.FormatInt(start.Unix(), 10), strconv.FormatInt(end.Unix(), 10)
+	}
+	return strconv.FormatInt(start.UnixMilli(), 10), strconv.FormatInt(end.UnixMilli(), 10)
+}
+
+// promAPIForm 合并 url 参数和 post form 参数
+func promAPIForm(c *gin.Context) (map[string][]string, error) {
+	if err := c.Request.ParseForm(); err != nil {
+		return nil, badData("parse form error: %s", err)
+	}
+	return c.Request.Form, nil
+}
+
+func promAPIFormValue(form map[string][]string, key string) string {
+	if v := form[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// promAPISelectorRange 解析 series / labels / label values 接口的公共参数
+// matchRequired 为 false 时 未传入 match[] 使用 promAPIDefaultMatch 查询空间下全部指标
+func promAPISelectorRange(form map[string][]string, matchRequired bool) (matches []string, start, end string, err error) {
+	endTime, err := parsePromAPITime(promAPIFormValue(form, "end"), time.Now())
+	if err != nil {
+		return
+	}
+	startTime, err := parsePromAPITime(promAPIFormValue(form, "start"), endTime.Add(-promAPIDefaultRange))
+	if err != nil {
+		return
+	}
+	if endTime.Before(startTime) {
+		err = badData("end timestamp must not be before start time")
+		return
+	}
+
+	matches = form["match[]"]
+	if len(matches) == 0 {
+		if matchRequired {
+			err = badData("no match[] parameter provided")
+			return
+		}
+		matches = []string{promAPIDefaultMatch}
+	}
+
+	start, end = promAPITimeRange(startTime, endTime)
+	return
+}
+
+func promAPILabels(lbs labels.Labels, decodeFunc func(string) string) map[string]string {
+	m := make(map[string]string, len(lbs))
+	for _, l := range lbs {
+		name := l.Name
+		if decodeFunc != nil {
+			name = decodeFunc(name)
+		}
+		// es 查询使用了空格作为占位符
+		if l.Value == " " {
+			l.Value = ""
+		}
+		m[name] = l.Value
+	}
+	return m
+}
+
+// promAPIQuery 复用 promql 查询链路，返回 prometheus 格式的查询结果
+func promAPIQuery(ctx context.Context, queryPromQL *structured.QueryPromQL) (data *PromAPIQueryData, warnings []string, err error) {
+	ctx, span := trace.NewSpan(ctx, "prom-api-query")
+	defer span.End(&err)
+
+	query, err := promQLToStruct(ctx, queryPromQL)
+	if err != nil {
+		err = &promAPIError{typ: promAPIErrorBadData, err: err}
+		return
+	}
+
+	instance, stmt, _, err := queryTsToInstanceAndStmt(ctx, query)
+	if err != nil {
+		return
+	}
+
+	qb := metadata.GetQueryParams(ctx)
+	span.Set("storage-type", instance.InstanceType())
+	span.Set("stmt", stmt)
+	span.Set("query-params", qb)
+
+	decodeFunc := metadata.GetFieldFormat(ctx).DecodeFunc()
+
+	if query.Instant {
+		var vector promPromql.Vector
+		vector, err = instance.DirectQuery(ctx, stmt, qb.End)
+		if err != nil {
+			return
+		}
+
+		result := make([]PromAPISample, 0, len(vector))
+		for _, s := range vector {
+			result = append(result, PromAPISample{
+				Metric: promAPILabels(s.Metric, decodeFunc),
+				Value:  PromAPIPoint{T: s.T, V: s.V},
+			})
+		}
+		span.Set("resp-series-num", len(result))
+
+		data = &PromAPIQueryData{
+			ResultType: string(parser.ValueTypeVector),
+			Result:     result,
+		}
+		return
+	}
+
+	matrix, isPartial, err := directQueryRange(ctx, query, instance, stmt)
+	if err != nil {
+		return
+	}
+
+	result := make([]PromAPISeries, 0, len(matrix))
+	for _, s := range matrix {
+		values := make([]PromAPIPoint, 0, len(s.Points))
+		for _, p := range s.Points {
+			values = append(values, PromAPIPoint{T: p.T, V: p.V})
+		}
+		result = append(result, PromAPISeries{
+			Metric: promAPILabels(s.Metric, decodeFunc),
+			Values: values,
+		})
+	}
+	span.Set("resp-series-num", len(result))
+
+	if isPartial {
+		warnings = append(warnings, "query result is partial")
+	}
+
+	data = &PromAPIQueryData{
+		ResultType: string(parser.ValueTypeMatrix),
+		Result:     result,
+	}
+	return
+}
+
+// QueryInstant 在 ctx 的空间下执行 PromQL instant 查询，供 recording rule 等内部模块复用查询链路
+func QueryInstant(ctx context.Context, promQL string, ts time.Time) (vector promPromql.Vector, err error) {
+	ctx, span := trace.NewSpan(ctx, "query-instant")
+	defer span.End(&err)
+
+	span.Set("promql", promQL)
+	span.Set("time", ts.String())
+
+	queryPromQL := &structured.QueryPromQL{
+		PromQL:       promQL,
+		Instant:      true,
+		NotTimeAlign: true,
+	}
+	queryPromQL.Start, queryPromQL.End = promAPITimeRange(ts, ts)
+
+	query, err := promQLToStruct(ctx, queryPromQL)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, stmt, _, err := queryTsToInstanceAndStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	span.Set("stmt", stmt)
+
+	return instance.DirectQuery(ctx, stmt, metadata.GetQueryParams(ctx).End)
+}
+
+// promAPIRangeQueryRef 将 match[] 转换为查询引用，并按路由并发执行 fn，任一路由失败时返回错误
+func promAPIRangeQueryRef(ctx context.Context, match, start, end string, fn func(qry *metadata.Query, start, end time.Time) error) error {
+	query, err := promQLToStruct(ctx, &structured.QueryPromQL{
+		PromQL: match,
+		Start:  start,
+		End:    end,
+	})
+	if err != nil {
+		return &promAPIError{typ: promAPIErrorBadData, err: err}
+	}
+
+	queryRef, _, err := queryTsToReference(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	p, _ := ants.NewPool(QueryMaxRouting)
+	defer p.Release()
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs []error
+	)
+	addErr := func(e error) {
+		lock.Lock()
+		defer lock.Unlock()
+		errs = append(errs, e)
+	}
+
+	qb := metadata.GetQueryParams(ctx)
+	queryRef.Range("", func(qry *metadata.Query) {
+		wg.Add(1)
+		submitErr := p.Submit(func() {
+			defer wg.Done()
+			if qErr := fn(qry, qb.Start, qb.End); qErr != nil {
+				addErr(qErr)
+			}
+		})
+		if submitErr != nil {
+			wg.Done()
+			addErr(submitErr)
+		}
+	})
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func promAPIHandleQuery(c *gin.Context, instant bool) {
+	var (
+		ctx  = c.Request.Context()
+		resp = &promAPIResponse{c: c}
+		user = metadata.GetUser(ctx)
+
+		err error
+	)
+
+	ctx, span := trace.NewSpan(ctx, "handler-prom-api-query")
+	defer func() {
+		span.End(&err)
+		if err != nil {
+			resp.failed(ctx, err)
+		}
+	}()
+
+	span.Set("query-source", user.Key)
+	span.Set("query-space-uid", user.SpaceUID)
+	span.Set("request-url", c.Request.URL.String())
+	span.Set("request-header", c.Request.Header)
+
+	form, err := promAPIForm(c)
+	if err != nil {
+		return
+	}
+
+	queryPromQL := &structured.QueryPromQL{
+		PromQL:        promAPIFormValue(form, "query"),
+		LookBackDelta: promAPIFormValue(form, "lookback_delta"),
+		Instant:       instant,
+		// prometheus 以 start 为起点按 step 计算，不做对齐
+		NotTimeAlign: true,
+	}
+	if queryPromQL.PromQL == "" {
+		err = badData("invalid parameter \"query\": query is empty")
+		return
+	}
+
+	if instant {
+		var t time.Time
+		t, err = parsePromAPITime(promAPIFormValue(form, "time"), time.Now())
+		if err != nil {
+			return
+		}
+		queryPromQL.Start, queryPromQL.End = promAPITimeRange(t, t)
+	} else {
+		var (
+			startTime, endTime time.Time
+			step               time.Duration
+		)
+		startTime, err = parsePromAPITime(promAPIFormValue(form, "start"), time.Time{})
+		if err == nil {
+			endTime, err = parsePromAPITime(promAPIFormValue(form, "end"), time.Time{})
+		}
+		if err != nil {
+			return
+		}
+		if startTime.IsZero() || endTime.IsZero() {
+			err = badData("invalid parameter \"start\" or \"end\": both are required")
+			return
+		}
+		if endTime.Before(startTime) {
+			err = badData("invalid parameter \"end\": end timestamp must not be before start time")
+			return
+		}
+
+		step, err = parsePromAPIDuration(promAPIFormValue(form, "step"))
+		if err != nil {
+			return
+		}
+
+		queryPromQL.Start, queryPromQL.End = promAPITimeRange(startTime, endTime)
+		queryPromQL.Step = model.Duration(step).String()
+	}
+
+	span.Set("query-promql", queryPromQL.PromQL)
+	metadata.NewMessage(
+		metadata.MsgQueryPromQL,
+		"%s, header: %+v, data: %+v",
+		c.Request.URL.String(), c.Request.Header, queryPromQL,
+	).Info(ctx)
+
+	data, warnings, err := promAPIQuery(ctx, queryPromQL)
+	if err != nil {
+		return
+	}
+
+	resp.success(ctx, data, warnings...)
+}
+
+// HandlerPromAPIQuery
+// @Summary  prometheus http api: instant query
+// @ID       prom_api_query
+// @Produce  json
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param    query                  query     string                        true   "PromQL"
+// @Param    time                   query     string                        false  "查询时间，unix 秒或 RFC3339"
+// @Success  200                   	{object}  PromAPIResponse
+// @Failure  400                   	{object}  PromAPIResponse
+// @Router   /api/v1/query [get]
+func HandlerPromAPIQuery(c *gin.Context) {
+	promAPIHandleQuery(c, true)
+}
+
+// HandlerPromAPIQueryRange
+// @Summary  prometheus http api: range query
+// @ID       prom_api_query_range
+// @Produce  json
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param    query                  query     string                        true   "PromQL"
+// @Param    start                  query     string                        true   "开始时间，unix 秒或 RFC3339"
+// @Param    end                    query     string                        true   "结束时间，unix 秒或 RFC3339"
+// @Param    step                   query     string                        true   "步长，秒数或 1m 等格式"
+// @Success  200                   	{object}  PromAPIResponse
+// @Failure  400                   	{object}  PromAPIResponse
+// @Router   /api/v1/query_range [get]
+func HandlerPromAPIQueryRange(c *gin.Context) {
+	promAPIHandleQuery(c, false)
+}
+
+// HandlerPromAPISeries
+// @Summary  prometheus http api: series
+// @ID       prom_api_series
+// @Produce  json
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param    match[]                query     []string                      true   "series selector"
+// @Success  200                   	{object}  PromAPIResponse
+// @Failure  400                   	{object}  PromAPIResponse
+// @Router   /api/v1/series [get]
+func HandlerPromAPISeries(c *gin.Context) {
+	var (
+		ctx  = c.Request.Context()
+		resp = &promAPIResponse{c: c}
+
+		err error
+	)
+
+	ctx, span := trace.NewSpan(ctx, "handler-prom-api-series")
+	defer func() {
+		span.End(&err)
+		if err != nil {
+			resp.failed(ctx, err)
+		}
+	}()
+
+	span.Set("request-url", c.Request.URL.String())
+	span.Set("request-header", c.Request.Header)
+
+	form, err := promAPIForm(c)
+	if err != nil {
+		return
+	}
+	matches, start, end, err := promAPISelectorRange(form, true)
+	if err != nil {
+		return
+	}
+	limit := cast.ToInt(promAPIFormValue(form, "limit"))
+
+	var (
+		lock      sync.Mutex
+		seriesSet = set.New[string]()
+		data      = make([]map[string]string, 0)
+	)
+	for _, match := range matches {
+		err = promAPIRangeQueryRef(ctx, match, start, end, func(qry *metadata.Query, start, end time.Time) error {
+			instance := prometheus.GetTsDbInstance(ctx, qry)
+			if instance == nil {
+				return nil
+			}
+
+			res, qErr := instance.QuerySeries(ctx, qry, start, end)
+			if qErr != nil {
+				return qErr
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			for _, r := range res {
+				key := labels.FromMap(r).String()
+				if seriesSet.Existed(key) {
+					continue
+				}
+				seriesSet.Add(key)
+				data = append(data, r)
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	sort.Slice(data, func(i, j int) bool {
+		return labels.Compare(labels.FromMap(data[i]), labels.FromMap(data[j])) < 0
+	})
+	if limit > 0 && len(data) > limit {
+		data = data[:limit]
+	}
+	span.Set("result-num", len(data))
+
+	resp.success(ctx, data)
+}
+
+// HandlerPromAPILabels
+// @Summary  prometheus http api: label names
+// @ID       prom_api_labels
+// @Produce  json
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param    match[]                query     []string                      false  "series selector，为空时查询空间下全部指标"
+// @Success  200                   	{object}  PromAPIResponse
+// @Failure  400                   	{object}  PromAPIResponse
+// @Router   /api/v1/labels [get]
+func HandlerPromAPILabels(c *gin.Context) {
+	var (
+		ctx  = c.Request.Context()
+		resp = &promAPIResponse{c: c}
+
+		err error
+	)
+
+	ctx, span := trace.NewSpan(ctx, "handler-prom-api-labels")
+	defer func() {
+		span.End(&err)
+		if err != nil {
+			resp.failed(ctx, err)
+		}
+	}()
+
+	span.Set("request-url", c.Request.URL.String())
+	span.Set("request-header", c.Request.Header)
+
+	form, err := promAPIForm(c)
+	if err != nil {
+		return
+	}
+	matches, start, end, err := promAPISelectorRange(form, false)
+	if err != nil {
+		return
+	}
+
+	lbl := set.New[string]()
+	for _, match := range matches {
+		err = promAPIRangeQueryRef(ctx, match, start, end, func(qry *metadata.Query, start, end time.Time) error {
+			instance := prometheus.GetTsDbInstance(ctx, qry)
+			if instance == nil {
+				return nil
+			}
+
+			res, qErr := instance.QueryLabelNames(ctx, qry, start, end)
+			if qErr != nil {
+				return qErr
+			}
+			lbl.Add(res...)
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	data := sortAndLimitStrings(lbl.ToArray(), cast.ToInt(promAPIFormValue(form, "limit")))
+	span.Set("result-num", len(data))
+
+	resp.success(ctx, data)
+}
+
+// HandlerPromAPILabelValues
+// @Summary  prometheus http api: label values
+// @ID       prom_api_label_values
+// @Produce  json
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param    label_name             path      string                        true   "维度名"
+// @Param    match[]                query     []string                      false  "series selector，为空时查询空间下全部指标"
+// @Success  200                   	{object}  PromAPIResponse
+// @Failure  400                   	{object}  PromAPIResponse
+// @Router   /api/v1/label/{label_name}/values [get]
+func HandlerPromAPILabelValues(c *gin.Context) {
+	var (
+		ctx  = c.Request.Context()
+		resp = &promAPIResponse{c: c}
+
+		queryLimit int
+
+		err error
+	)
+
+	ctx, span := trace.NewSpan(ctx, "handler-prom-api-label-values")
+	defer func() {
+		span.End(&err)
+		if err != nil {
+			resp.failed(ctx, err)
+		}
+	}()
+
+	span.Set("request-url", c.Request.URL.String())
+	span.Set("request-header", c.Request.Header)
+
+	labelName := metadata.GetFieldFormat(ctx).DecodeFunc()(c.Param("label_name"))
+	if !model.LabelName(labelName).IsValid() {
+		err = badData("invalid label name: %q", labelName)
+		return
+	}
+	span.Set("request-label-name", labelName)
+
+	form, err := promAPIForm(c)
+	if err != nil {
+		return
+	}
+	matches, start, end, err := promAPISelectorRange(form, false)
+	if err != nil {
+		return
+	}
+
+	limit := promAPIFormValue(form, "limit")
+	if limit == "" {
+		queryLimit = viper.GetInt(LabelValuesDefaultLimitConfigPath)
+	} else if queryLimit, err = cast.ToIntE(limit); err != nil {
+		err = badData("invalid parameter \"limit\": %s", err)
+		return
+	}
+	queryLimit = configuredTagValuesLimit(queryLimit)
+
+	values := set.New[string]()
+	for _, match := range matches {
+		var query *structured.QueryTs
+		query, err = promQLToStruct(ctx, &structured.QueryPromQL{
+			PromQL: match,
+			Start:  start,
+			End:    end,
+		})
+		if err != nil {
+			err = &promAPIError{typ: promAPIErrorBadData, err: err}
+			return
+		}
+
+		instance, stmt, _, qErr := queryTsToInstanceAndStmt(ctx, query)
+		if qErr != nil {
+			err = qErr
+			return
+		}
+
+		var matcher []*labels.Matcher
+		matcher, err = parser.ParseMetricSelector(stmt)
+		if err != nil {
+			return
+		}
+
+		qb := metadata.GetQueryParams(ctx)
+		var res []string
+		res, err = instance.DirectLabelValues(ctx, labelName, qb.Start, qb.End, queryLimit, matcher...)
+		if err != nil {
+			return
+		}
+		values.Add(res...)
+	}
+
+	data := sortAndLimitStrings(values.ToArray(), queryLimit)
+	span.Set("result-num", len(data))
+
+	resp.success(ctx, data)
+}
+
+// promAPIPath 拼接 prometheus api 前缀
+func promAPIPath(prefix string, elem ...string) string {
+	return strings.TrimRight(prefix, "/") + "/" + strings.Join(elem, "/")
+}
@@ -0,0 +1,300 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/influxdb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/mock"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/tsdb/victoriaMetrics"
+)
+
+func TestParsePromAPITime(t *testing.T) {
+	for name, c := range map[string]struct {
+		in       string
+		expected time.Time
+		err      bool
+	}{
+		"unix seconds":       {in: "1741060043", expected: time.Unix(1741060043, 0)},
+		"unix float seconds": {in: "1741060043.5", expected: time.UnixMilli(1741060043500)},
+		"rfc3339":            {in: "2025-03-04T03:47:23Z", expected: time.Unix(1741060043, 0)},
+		"invalid":            {in: "now-1h", err: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := parsePromAPITime(c.in, time.Time{})
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, c.expected.Equal(actual), actual.String())
+		})
+	}
+
+	start, end := promAPITimeRange(time.Unix(1741056443, 0), time.Unix(1741060043, 0))
+	assert.Equal(t, "1741056443", start)
+	assert.Equal(t, "1741060043", end)
+
+	start, end = promAPITimeRange(time.Unix(1741056443, 0), time.UnixMilli(1741060043500))
+	assert.Equal(t, "1741056443000", start)
+	assert.Equal(t, "1741060043500", end)
+
+	d, err := parsePromAPIDuration("15")
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Second, d)
+
+	d, err = parsePromAPIDuration("1m")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, d)
+
+	_, err = parsePromAPIDuration("-1")
+	assert.Error(t, err)
+}
+
+func TestPromAPIHandler(t *testing.T) {
+	mock.Init()
+	ctx := metadata.InitHashID(context.Background())
+	influxdb.MockSpaceRouter(ctx)
+	promql.MockEngine()
+
+	mock.Vm.Set(map[string]any{
+		`query_range:17410564431741060043600count by (bcs_cluster_id) (a)`: victoriaMetrics.Data{
+			ResultType: victoriaMetrics.MatrixType,
+			Result: []victoriaMetrics.Series{
+				{
+					Metric: map[string]string{
+						"bcs_cluster_id": "BCS-K8S-00000",
+					},
+					Values: []victoriaMetrics.Value{
+						{1741056443, "2042"},
+						{1741057043, "2056"},
+					},
+				},
+			},
+		},
+		`query:1741060043sum by (bcs_cluster_id) (a)`: victoriaMetrics.Data{
+			ResultType: victoriaMetrics.VectorType,
+			Result: []victoriaMetrics.Series{
+				{
+					Metric: map[string]string{
+						"bcs_cluster_id": "BCS-K8S-00000",
+					},
+					Value: victoriaMetrics.Value{
+						1741060043, "1172",
+					},
+				},
+			},
+		},
+		`label_values:17410564431741060043bcs_cluster_id{result_table_id="2_bcs_prom_computation_result_table", __name__="kube_pod_info_value"}`: []string{
+			"BCS-K8S-00001",
+			"BCS-K8S-00000",
+		},
+		`label_values:17410564431741060043bcs_cluster_id{result_table_id="2_bcs_prom_computation_result_table", __name__=~".+_value"}`: []string{
+			"BCS-K8S-00000",
+		},
+		`labels:17410564431741060043{result_table_id="2_bcs_prom_computation_result_table", __name__=~".+_value"}`: []string{
+			"bcs_cluster_id",
+			"__name__",
+		},
+		`labels:17410564431741060043{result_table_id="2_bcs_prom_computation_result_table", __name__="container_cpu_usage_seconds_total_value"}`: []string{
+			"namespace",
+			"__name__",
+		},
+		`series:17410564431741060043{result_table_id="2_bcs_prom_computation_result_table", __name__="container_cpu_usage_seconds_total_value"}`: []map[string]string{
+			{
+				"__name__":  "container_cpu_usage_seconds_total_value",
+				"namespace": "default",
+			},
+			{
+				"__name__":  "container_cpu_usage_seconds_total_value",
+				"namespace": "bkbase",
+			},
+			{
+				"__name__":  "container_cpu_usage_seconds_total_value",
+				"namespace": "default",
+			},
+		},
+	})
+
+	testCases := map[string]struct {
+		handler  func(c *gin.Context)
+		method   string
+		values   url.Values
+		params   gin.Params
+		status   int
+		expected string
+	}{
+		"query range": {
+			handler: HandlerPromAPIQueryRange,
+			method:  http.MethodGet,
+			values: url.Values{
+				"query": {`count(container_cpu_usage_seconds_total) by (bcs_cluster_id)`},
+				"start": {"1741056443"},
+				"end":   {"2025-03-04T03:47:23Z"},
+				"step":  {"600"},
+			},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"bcs_cluster_id":"BCS-K8S-00000"},"values":[[1741056443,"2042"],[1741057043,"2056"]]}]}}`,
+		},
+		"query range by post form": {
+			handler: HandlerPromAPIQueryRange,
+			method:  http.MethodPost,
+			values: url.Values{
+				"query": {`count(container_cpu_usage_seconds_total) by (bcs_cluster_id)`},
+				"start": {"1741056443"},
+				"end":   {"1741060043"},
+				"step":  {"10m"},
+			},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"bcs_cluster_id":"BCS-K8S-00000"},"values":[[1741056443,"2042"],[1741057043,"2056"]]}]}}`,
+		},
+		"query range without step": {
+			handler: HandlerPromAPIQueryRange,
+			method:  http.MethodGet,
+			values: url.Values{
+				"query": {`count(container_cpu_usage_seconds_total) by (bcs_cluster_id)`},
+				"start": {"1741056443"},
+				"end":   {"1741060043"},
+			},
+			status:   http.StatusBadRequest,
+			expected: `{"status":"error","errorType":"bad_data","error":"cannot parse \"\" to a valid duration"}`,
+		},
+		"instant query": {
+			handler: HandlerPromAPIQuery,
+			method:  http.MethodGet,
+			values: url.Values{
+				"query": {`sum(kube_pod_info) by (bcs_cluster_id)`},
+				"time":  {"1741060043"},
+			},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"bcs_cluster_id":"BCS-K8S-00000"},"value":[1741060043,"1172"]}]}}`,
+		},
+		"instant query without query": {
+			handler:  HandlerPromAPIQuery,
+			method:   http.MethodGet,
+			values:   url.Values{},
+			status:   http.StatusBadRequest,
+			expected: `{"status":"error","errorType":"bad_data","error":"invalid parameter \"query\": query is empty"}`,
+		},
+		"series": {
+			handler: HandlerPromAPISeries,
+			method:  http.MethodGet,
+			values: url.Values{
+				"match[]": {`container_cpu_usage_seconds_total`},
+				"start":   {"1741056443"},
+				"end":     {"1741060043"},
+			},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":[{"__name__":"container_cpu_usage_seconds_total_value","namespace":"bkbase"},{"__name__":"container_cpu_usage_seconds_total_value","namespace":"default"}]}`,
+		},
+		"labels": {
+			handler: HandlerPromAPILabels,
+			method:  http.MethodGet,
+			values: url.Values{
+				"match[]": {`container_cpu_usage_seconds_total`},
+				"start":   {"1741056443"},
+				"end":     {"1741060043"},
+			},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":["__name__","namespace"]}`,
+		},
+		"series without match": {
+			handler: HandlerPromAPISeries,
+			method:  http.MethodGet,
+			values: url.Values{
+				"start": {"1741056443"},
+				"end":   {"1741060043"},
+			},
+			status:   http.StatusBadRequest,
+			expected: `{"status":"error","errorType":"bad_data","error":"no match[] parameter provided"}`,
+		},
+		"series with query error": {
+			handler: HandlerPromAPISeries,
+			method:  http.MethodGet,
+			values: url.Values{
+				"match[]": {`kube_pod_info`},
+				"start":   {"1741056443"},
+				"end":     {"1741060043"},
+			},
+			status:   http.StatusUnprocessableEntity,
+			expected: `{"status":"error","errorType":"execution","error":"查询异常: 查询 http://127.0.0.1:12001/bk_data/query_sync/ 报错: Post \"http://127.0.0.1:12001/bk_data/query_sync/\": vm mock data is empty in \"series:17410564431741060043{result_table_id=\"2_bcs_prom_computation_result_table\", __name__=\"kube_pod_info_value\"}\""}`,
+		},
+		"labels without match": {
+			handler: HandlerPromAPILabels,
+			method:  http.MethodGet,
+			values: url.Values{
+				"start": {"1741056443"},
+				"end":   {"1741060043"},
+			},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":["__name__","bcs_cluster_id"]}`,
+		},
+		"label values": {
+			handler: HandlerPromAPILabelValues,
+			method:  http.MethodGet,
+			values: url.Values{
+				"match[]": {`kube_pod_info`},
+				"start":   {"1741056443"},
+				"end":     {"1741060043"},
+			},
+			params:   gin.Params{{Key: "label_name", Value: "bcs_cluster_id"}},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":["BCS-K8S-00000","BCS-K8S-00001"]}`,
+		},
+		"label values without match": {
+			handler: HandlerPromAPILabelValues,
+			method:  http.MethodGet,
+			values: url.Values{
+				"start": {"1741056443"},
+				"end":   {"1741060043"},
+			},
+			params:   gin.Params{{Key: "label_name", Value: "bcs_cluster_id"}},
+			status:   http.StatusOK,
+			expected: `{"status":"success","data":["BCS-K8S-00000"]}`,
+		},
+	}
+
+	for name, c := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx = metadata.InitHashID(ctx)
+			metadata.SetUser(ctx, &metadata.User{SpaceUID: influxdb.SpaceUid})
+
+			var req *http.Request
+			if c.method == http.MethodPost {
+				req, _ = http.NewRequestWithContext(ctx, c.method, "http://127.0.0.1/api/v1", strings.NewReader(c.values.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req, _ = http.NewRequestWithContext(ctx, c.method, "http://127.0.0.1/api/v1?"+c.values.Encode(), nil)
+			}
+
+			w := &Writer{}
+			ginC := &gin.Context{
+				Request: req,
+				Writer:  w,
+				Params:  c.params,
+			}
+			c.handler(ginC)
+
+			assert.Equal(t, c.status, w.Status())
+			assert.JSONEq(t, c.expected, w.body())
+		})
+	}
+}
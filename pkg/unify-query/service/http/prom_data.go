@@ -27,6 +27,8 @@ type PromData struct {
 	Status               *metadata.Status `json:"status,omitempty"`
 	TraceID              string           `json:"trace_id,omitempty"`
 	IsPartial            bool             `json:"is_partial"`
+	// Resolution 各查询使用的数据精度，命中降精度结果表时返回，raw 表示原始数据
+	Resolution map[string]string `json:"resolution,omitempty"`
 	// ResultTableID 来自 QueryReference 路由解析结果；查询响应只暴露 RT 列表，不返回完整 RouteInfo。
 	ResultTableID []string `json:"result_table_id,omitempty"`
 }
@@ -65,25 +67,28 @@ func (d *PromData) SetResultTableIDFromRouteInfo(routeInfo []metadata.RouteInfo)
 // MarshalJSON 在未调用 SetResultTableID 时沿用 result_table_id 的 omitempty；调用后即使为空也输出 []。
 func (d *PromData) MarshalJSON() ([]byte, error) {
 	type promData struct {
-		Tables        []*TablesItem    `json:"series"`
-		Status        *metadata.Status `json:"status,omitempty"`
-		TraceID       string           `json:"trace_id,omitempty"`
-		IsPartial     bool             `json:"is_partial"`
-		ResultTableID []string         `json:"result_table_id,omitempty"`
+		Tables        []*TablesItem     `json:"series"`
+		Status        *metadata.Status  `json:"status,omitempty"`
+		TraceID       string            `json:"trace_id,omitempty"`
+		IsPartial     bool              `json:"is_partial"`
+		Resolution    map[string]string `json:"resolution,omitempty"`
+		ResultTableID []string          `json:"result_table_id,omitempty"`
 	}
 	if d.includeResultTableID {
 		type promDataWithResultTableID struct {
-			Tables        []*TablesItem    `json:"series"`
-			Status        *metadata.Status `json:"status,omitempty"`
-			TraceID       string           `json:"trace_id,omitempty"`
-			IsPartial     bool             `json:"is_partial"`
-			ResultTableID []string         `json:"result_table_id"`
+			Tables        []*TablesItem     `json:"series"`
+			Status        *metadata.Status  `json:"status,omitempty"`
+			TraceID       string            `json:"trace_id,omitempty"`
+			IsPartial     bool              `json:"is_partial"`
+			Resolution    map[string]string `json:"resolution,omitempty"`
+			ResultTableID []string          `json:"result_table_id"`
 		}
 		return json.Marshal(promDataWithResultTableID{
 			Tables:        d.Tables,
 			Status:        d.Status,
 			TraceID:       d.TraceID,
 			IsPartial:     d.IsPartial,
+			Resolution:    d.Resolution,
 			ResultTableID: normalizeResultTableID(d.ResultTableID),
 		})
 	}
@@ -92,6 +97,7 @@ func (d *PromData) MarshalJSON() ([]byte, error) {
 		Status:        d.Status,
 		TraceID:       d.TraceID,
 		IsPartial:     d.IsPartial,
+		Resolution:    d.Resolution,
 		ResultTableID: d.ResultTableID,
 	})
 }
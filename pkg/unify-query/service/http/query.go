@@ -505,7 +505,7 @@ func queryRawWithScroll(ctx context.Context, queryTs *structured.QueryTs, sessio
 
 		total              int64
 		err                error
-		resultTableOptions = make(metadata.ResultTableOptions)
+		resultTableOptions metadata.ResultTableOptions
 		routeInfo          []metadata.RouteInfo
 	)
 
@@ -572,6 +572,24 @@ func queryRawWithScroll(ctx context.Context, queryTs *structured.QueryTs, sessio
 		}
 	}()
 
+	total, resultTableOptions = queryRawScrollRound(ctx, queryRef, session, dataCh, errCh)
+
+	close(dataCh)
+	close(errCh)
+
+	receiveWg.Wait()
+
+	return total, list, resultTableOptions, routeInfo, session.Done(), err
+}
+
+// queryRawScrollRound 按 session 中记录的分片状态把 queryRef 中的每个查询推进一轮，数据写入 dataCh，异常写入 errCh，
+// 调用方负责消费并关闭两个 channel
+func queryRawScrollRound(ctx context.Context, queryRef metadata.QueryReference, session *redisUtil.ScrollSession, dataCh chan<- map[string]any, errCh chan<- error) (int64, metadata.ResultTableOptions) {
+	var (
+		total              int64
+		resultTableOptions = make(metadata.ResultTableOptions)
+	)
+
 	// 多协程查询数据
 	var (
 		wg         sync.WaitGroup
@@ -674,12 +692,7 @@ func queryRawWithScroll(ctx context.Context, queryTs *structured.QueryTs, sessio
 
 	wg.Wait()
 
-	close(dataCh)
-	close(errCh)
-
-	receiveWg.Wait()
-
-	return total, list, resultTableOptions, routeInfo, session.Done(), err
+	return total, resultTableOptions
 }
 
 func queryReferenceWithPromEngine(ctx context.Context, queryTs *structured.QueryTs) (*PromData, error) {
@@ -733,6 +746,12 @@ func queryReferenceWithPromEngine(ctx context.Context, queryTs *structured.Query
 	if err != nil {
 		return nil, err
 	}
+	queryRef, err = checkQueryCost(ctx, queryTs, queryRef, func() (metadata.QueryReference, error) {
+		return queryTs.ToQueryReference(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
 	// reference 查询复用内部路由摘要，并在响应阶段投影成 RT 列表。
 	resp.SetResultTableIDFromRouteInfo(queryRef.CollectRouteInfo())
 
@@ -797,6 +816,7 @@ func queryReferenceWithPromEngine(ctx context.Context, queryTs *structured.Query
 	sortTablesByOrderBy(tables, queryTs.OrderBy)
 
 	resp.IsPartial = isPartial
+	resp.Resolution = rollupResolution(queryTs)
 	err = resp.Fill(tables)
 	if err != nil {
 		return nil, err
@@ -878,6 +898,9 @@ func queryTsToReference(ctx context.Context, queryTs *structured.QueryTs) (metad
 		queryTs.Step = promql.GetDefaultStep().String()
 	}
 
+	// step 足够大时使用降精度结果表
+	routeRollup(ctx, queryTs)
+
 	// 转换成 queryRef
 	queryRef, err := queryTs.ToQueryReference(ctx)
 	return queryRef, lookBackDelta, err
@@ -899,6 +922,15 @@ func queryTsToInstanceAndStmt(ctx context.Context, queryTs *structured.QueryTs)
 	if err != nil {
 		return instance, stmt, routeInfo, err
 	}
+	// 查询前预估代价，超出配额时拒绝或降级 step
+	queryRef, err = checkQueryCost(ctx, queryTs, queryRef, func() (metadata.QueryReference, error) {
+		ref, delta, rebuildErr := queryTsToReference(ctx, queryTs)
+		lookBackDelta = delta
+		return ref, rebuildErr
+	})
+	if err != nil {
+		return instance, stmt, routeInfo, err
+	}
 	// 在生成 PromQL 前固定路由摘要，后续存储查询结果不参与推导。
 	routeInfo = queryRef.CollectRouteInfo()
 
@@ -931,6 +963,18 @@ func queryTsToInstanceAndStmt(ctx context.Context, queryTs *structured.QueryTs)
 
 	stmt = expr.String()
 
+	// 扩展的异常检测、预测函数只有 promql 引擎支持，直查时无法下发到存储
+	if metadata.GetQueryParams(ctx).IsDirectQuery() {
+		if name := promql.FindExtendFunction(expr); name != "" {
+			err = metadata.NewMessage(
+				metadata.MsgQueryTs,
+				"函数 %s 不支持 VictoriaMetrics 直查",
+				name,
+			).Error(ctx, nil)
+			return instance, stmt, routeInfo, err
+		}
+	}
+
 	if instance == nil {
 		err = fmt.Errorf("storage get error")
 		return instance, stmt, routeInfo, err
@@ -975,7 +1019,7 @@ func queryTsWithPromEngine(ctx context.Context, query *structured.QueryTs) (any,
 	if query.Instant {
 		res, err = instance.DirectQuery(ctx, stmt, qb.End)
 	} else {
-		res, isPartial, err = instance.DirectQueryRange(ctx, stmt, qb.AlignStart, qb.End, qb.Step)
+		res, isPartial, err = directQueryRange(ctx, query, instance, stmt)
 	}
 	if err != nil {
 		return nil, err
@@ -1022,6 +1066,7 @@ func queryTsWithPromEngine(ctx context.Context, query *structured.QueryTs) (any,
 	sortTablesByOrderBy(tables, query.OrderBy)
 
 	resp.IsPartial = isPartial
+	resp.Resolution = rollupResolution(query)
 	err = resp.Fill(tables)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,59 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/querycost"
+)
+
+// checkQueryCost 查询执行前预估代价，超出配额时拒绝，或放大 step 后通过 rebuild 重新生成 queryRef
+func checkQueryCost(ctx context.Context, queryTs *structured.QueryTs, queryRef metadata.QueryReference, rebuild func() (metadata.QueryReference, error)) (metadata.QueryReference, error) {
+	checker := querycost.Default()
+	if checker == nil {
+		return queryRef, nil
+	}
+
+	qp := metadata.GetQueryParams(ctx)
+	step := qp.Step
+	if queryTs.Instant {
+		step = 0
+	}
+
+	d := checker.Check(ctx, queryTs.SpaceUid, queryRef, qp.Start, qp.End, step)
+	switch d.Action {
+	case querycost.ActionReject:
+		return nil, d.Error()
+	case querycost.ActionDowngrade:
+		oldStep := queryTs.Step
+		newStep := model.Duration(d.Step).String()
+
+		queryTs.Step = newStep
+		for _, ql := range queryTs.QueryList {
+			// 子查询单独指定了 step 的保持不变
+			if ql.Step == "" || ql.Step == oldStep {
+				ql.Step = newStep
+			}
+		}
+
+		metadata.NewMessage(
+			metadata.MsgQueryCost,
+			"查询代价超出配额（%s），step 由 %s 调整为 %s，预估 %s",
+			d.Reason, oldStep, newStep, d.Estimate,
+		).Status(ctx, metadata.QueryCostDowngrade)
+		return rebuild()
+	}
+	return queryRef, nil
+}
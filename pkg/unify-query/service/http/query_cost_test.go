@@ -0,0 +1,96 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/influxdb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/mock"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/querycost"
+)
+
+func TestCheckQueryCost(t *testing.T) {
+	ctx := metadata.InitHashID(context.Background())
+
+	mock.Init()
+	promql.MockEngine()
+	influxdb.MockSpaceRouter(ctx)
+
+	cardinality := func(ctx context.Context, qry *metadata.Query, start, end time.Time) (int64, error) {
+		return 10, nil
+	}
+	defer querycost.SetDefault(nil)
+
+	testCases := map[string]struct {
+		quota querycost.Quota
+		step  string
+		err   string
+	}{
+		"pass": {
+			quota: querycost.Quota{MaxSeries: 100, MaxPoints: 1000},
+			step:  "1m",
+		},
+		"reject by series": {
+			quota: querycost.Quota{MaxSeries: 5, Action: querycost.ActionDowngrade},
+			err:   "query cost exceeds quota: series 10 > 5, estimated tables: 1, series: 10, points: 610, step: 1m0s",
+		},
+		"reject by points": {
+			quota: querycost.Quota{MaxPoints: 200, Action: querycost.ActionReject},
+			err:   "query cost exceeds quota: points 610 > 200, estimated tables: 1, series: 10, points: 610, step: 1m0s",
+		},
+		"downgrade": {
+			quota: querycost.Quota{MaxPoints: 200, Action: querycost.ActionDowngrade},
+			step:  "4m",
+		},
+		"downgrade over max step": {
+			quota: querycost.Quota{MaxPoints: 200, Action: querycost.ActionDowngrade, MaxStep: 2 * time.Minute},
+			err:   "query cost exceeds quota: points 610 > 200",
+		},
+	}
+
+	for name, c := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.InitHashID(ctx)
+			querycost.SetDefault(querycost.NewChecker(
+				&querycost.Options{DefaultQuota: c.quota},
+				querycost.NewEstimator(100, 1, cardinality),
+			))
+
+			query, err := promQLToStruct(ctx, &structured.QueryPromQL{
+				PromQL: `sum(count_over_time(datasource:result_table:influxdb:cpu_summary{}[1m]))`,
+				Start:  "1741056443",
+				End:    "1741060043",
+				Step:   "1m",
+			})
+			assert.NoError(t, err)
+			query.SpaceUid = influxdb.SpaceUid
+
+			_, _, _, err = queryTsToInstanceAndStmt(ctx, query)
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				assert.True(t, errors.Is(err, querycost.ErrQuotaExceeded))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.step, query.Step)
+			assert.Equal(t, structured.StepParse(c.step), metadata.GetQueryParams(ctx).Step)
+		})
+	}
+}
@@ -53,6 +53,14 @@ func registerDefaultHandlers(registerHandler *endpoint.RegisterHandler) {
 	handlerPath = viper.GetString(TSQueryRawQueryWithScrollHandlePathConfigPath)
 	registerHandler.Register(http.MethodPost, handlerPath, HandlerQueryRawWithScroll)
 
+	// query/raw/export
+	handlerPath = viper.GetString(TSQueryRawExportHandlePathConfigPath)
+	registerHandler.Register(http.MethodPost, handlerPath, HandlerQueryRawExport)
+
+	// query/ts/sql
+	handlerPath = viper.GetString(TSQuerySQLHandlePathConfigPath)
+	registerHandler.Register(http.MethodPost, handlerPath, HandlerQuerySQL)
+
 	// query/ts/exemplar
 	handlerPath = viper.GetString(TSQueryExemplarHandlePathConfigPath)
 	registerHandler.Register(http.MethodPost, handlerPath, HandlerQueryExemplar)
@@ -91,6 +99,18 @@ func registerDefaultHandlers(registerHandler *endpoint.RegisterHandler) {
 	// query/ts/cluster_metrics/
 	handlerPath = viper.GetString(TSQueryClusterMetricsPathConfigPath)
 	registerHandler.Register(http.MethodPost, handlerPath, HandlerQueryTsClusterMetrics)
+
+	// api/v1：Prometheus HTTP API 兼容接口，同时支持 GET 和 POST(form)
+	promAPIPrefix := viper.GetString(PromAPIPathConfigPath)
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		registerHandler.Register(method, promAPIPath(promAPIPrefix, "query"), HandlerPromAPIQuery)
+		registerHandler.Register(method, promAPIPath(promAPIPrefix, "query_range"), HandlerPromAPIQueryRange)
+		registerHandler.Register(method, promAPIPath(promAPIPrefix, "series"), HandlerPromAPISeries)
+		registerHandler.Register(method, promAPIPath(promAPIPrefix, "labels"), HandlerPromAPILabels)
+	}
+	registerHandler.Register(http.MethodGet, promAPIPath(promAPIPrefix, "label", ":label_name", "values"), HandlerPromAPILabelValues)
+	// api/v1/read：Prometheus remote read，与其他查询接口一样经过空间鉴权
+	registerHandler.Register(http.MethodPost, promAPIPath(promAPIPrefix, "read"), HandlerRemoteRead)
 }
 
 func registerOtherHandlers(registerHandler *endpoint.RegisterHandler) {
@@ -0,0 +1,281 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ants "github.com/panjf2000/ants/v2"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	promRemote "github.com/prometheus/prometheus/storage/remote"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metric"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/tsdb/prometheus"
+)
+
+// remoteReadError 请求参数异常，返回 400
+type remoteReadError struct {
+	err error
+}
+
+func (e *remoteReadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *remoteReadError) Unwrap() error {
+	return e.err
+}
+
+var remoteReadMarshalPool = &sync.Pool{}
+
+// remoteReadSelector 将 remote read 的 matchers 转换为 PromQL 选择器，必须指定指标名
+func remoteReadSelector(matchers []*prompb.LabelMatcher) (string, error) {
+	ms, err := promRemote.FromLabelMatchers(matchers)
+	if err != nil {
+		return "", err
+	}
+
+	vs := &parser.VectorSelector{}
+	for _, m := range ms {
+		if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+			vs.Name = m.Value
+			continue
+		}
+		vs.LabelMatchers = append(vs.LabelMatchers, m)
+	}
+	if vs.Name == "" {
+		return "", errors.New("remote read query must contain a __name__ equal matcher")
+	}
+	return vs.String(), nil
+}
+
+// remoteReadSeriesSet 将 prompb.Query 转换为路由后的查询，从各存储实例的 QuerySeriesSet 读取原始数据并合并
+func remoteReadSeriesSet(ctx context.Context, query *prompb.Query) (set storage.SeriesSet, err error) {
+	ctx, span := trace.NewSpan(ctx, "remote-read-series-set")
+	defer span.End(&err)
+
+	selector, err := remoteReadSelector(query.Matchers)
+	if err != nil {
+		return nil, &remoteReadError{err: err}
+	}
+
+	start := time.UnixMilli(query.StartTimestampMs)
+	end := time.UnixMilli(query.EndTimestampMs)
+
+	span.Set("selector", selector)
+	span.Set("start", start.String())
+	span.Set("end", end.String())
+
+	queryPromQL := &structured.QueryPromQL{
+		PromQL:       selector,
+		NotTimeAlign: true,
+	}
+	queryPromQL.Start, queryPromQL.End = promAPITimeRange(start, end)
+
+	queryTs, err := promQLToStruct(ctx, queryPromQL)
+	if err != nil {
+		return nil, &remoteReadError{err: err}
+	}
+
+	// 与其他查询接口一致，路由基于请求头中的空间进行鉴权和过滤
+	queryRef, _, err := queryTsToReference(ctx, queryTs)
+	if err != nil {
+		return nil, err
+	}
+
+	var queries []*metadata.Query
+	queryRef.Range("", func(qry *metadata.Query) {
+		queries = append(queries, qry)
+	})
+	span.Set("query-num", len(queries))
+
+	var (
+		wg   sync.WaitGroup
+		sets = make([]storage.SeriesSet, len(queries))
+		errs = make([]error, len(queries))
+	)
+
+	p, _ := ants.NewPool(max(QueryMaxRouting, 1))
+	defer p.Release()
+
+	for i, qry := range queries {
+		wg.Add(1)
+		submitErr := p.Submit(func() {
+			defer wg.Done()
+
+			instance := prometheus.GetTsDbInstance(ctx, qry)
+			if instance == nil {
+				errs[i] = fmt.Errorf("storage instance of %s not found", qry.TableID)
+				return
+			}
+
+			s := instance.QuerySeriesSet(ctx, qry, start, end)
+			if s == nil {
+				return
+			}
+			if s.Err() != nil {
+				errs[i] = s.Err()
+				return
+			}
+			sets[i] = s
+		})
+		if submitErr != nil {
+			wg.Done()
+			errs[i] = submitErr
+		}
+	}
+	wg.Wait()
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	validSets := make([]storage.SeriesSet, 0, len(sets))
+	for _, s := range sets {
+		if s != nil {
+			validSets = append(validSets, s)
+		}
+	}
+	return storage.NewMergeSeriesSet(validSets, storage.ChainedSeriesMerge), nil
+}
+
+func remoteReadSamples(ctx context.Context, c *gin.Context, req *prompb.ReadRequest) error {
+	resp := &prompb.ReadResponse{
+		Results: make([]*prompb.QueryResult, len(req.Queries)),
+	}
+	for i, query := range req.Queries {
+		set, err := remoteReadSeriesSet(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		resp.Results[i], _, err = promRemote.ToQueryResult(set, RemoteReadSampleLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.Header("Content-Type", "application/x-protobuf")
+	c.Header("Content-Encoding", "snappy")
+	return promRemote.EncodeReadResponse(resp, c.Writer)
+}
+
+func remoteReadStreamedXORChunks(ctx context.Context, c *gin.Context, req *prompb.ReadRequest) error {
+	c.Header("Content-Type", "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse")
+
+	for i, query := range req.Queries {
+		set, err := remoteReadSeriesSet(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		_, err = promRemote.StreamChunkedReadResponses(
+			promRemote.NewChunkedWriter(c.Writer, c.Writer),
+			int64(i),
+			storage.NewSeriesSetToChunkSet(set),
+			nil,
+			RemoteReadMaxBytesInFrame,
+			remoteReadMarshalPool,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandlerRemoteRead
+// @Summary  prometheus remote read
+// @ID       remote_read
+// @Produce  application/x-protobuf
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param    data                  	body      string                        true   "snappy 压缩的 prompb.ReadRequest"
+// @Success  200                   	{string}  string
+// @Failure  400                   	{string}  string
+// @Router   /api/v1/read [post]
+func HandlerRemoteRead(c *gin.Context) {
+	var (
+		ctx  = c.Request.Context()
+		user = metadata.GetUser(ctx)
+
+		err error
+	)
+
+	ctx, span := trace.NewSpan(ctx, "handler-remote-read")
+	defer func() {
+		status := metric.StatusSuccess
+		if err != nil {
+			status = metric.StatusFailed
+		}
+		metric.APIRequestInc(ctx, c.Request.URL.Path, status, user.SpaceUID, user.Source)
+		span.End(&err)
+	}()
+
+	span.Set("query-source", user.Key)
+	span.Set("query-space-uid", user.SpaceUID)
+
+	req, err := promRemote.DecodeReadRequest(c.Request)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	span.Set("query-num", len(req.Queries))
+
+	responseType, err := promRemote.NegotiateResponseType(req.AcceptedResponseTypes)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	span.Set("response-type", responseType.String())
+
+	switch responseType {
+	case prompb.ReadRequest_STREAMED_XOR_CHUNKS:
+		err = remoteReadStreamedXORChunks(ctx, c, req)
+	default:
+		err = remoteReadSamples(ctx, c, req)
+	}
+	if err == nil {
+		return
+	}
+
+	// 流式返回时可能已经写出部分数据，此时只能中断
+	if c.Writer.Written() {
+		metadata.NewMessage(
+			metadata.MsgQueryTs,
+			"remote read stream error: %s", err,
+		).Warn(ctx)
+		return
+	}
+
+	code := http.StatusInternalServerError
+	var (
+		rErr    *remoteReadError
+		httpErr promRemote.HTTPError
+	)
+	if errors.As(err, &rErr) {
+		code = http.StatusBadRequest
+	} else if errors.As(err, &httpErr) {
+		// 超过 sample_limit 等
+		code = httpErr.Status()
+	}
+	c.String(code, err.Error())
+}
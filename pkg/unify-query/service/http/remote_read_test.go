@@ -0,0 +1,160 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	promRemote "github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/influxdb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/influxdb/decoder"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/mock"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+)
+
+func remoteReadRequest(t *testing.T, ctx context.Context, req *prompb.ReadRequest) *httptest.ResponseRecorder {
+	data, err := req.Marshal()
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/read", bytes.NewReader(snappy.Encode(nil, data))).WithContext(ctx)
+	c.Request.Header.Set("Content-Encoding", "snappy")
+
+	HandlerRemoteRead(c)
+	return w
+}
+
+func TestHandlerRemoteRead(t *testing.T) {
+	mock.Init()
+	ctx := metadata.InitHashID(context.Background())
+	influxdb.MockSpaceRouter(ctx)
+	promql.MockEngine()
+
+	mock.InfluxDB.Set(map[string]any{
+		`SELECT "value" AS _value, *::tag, "time" AS _time FROM cpu_summary WHERE time > 1677081600000000000 and time < 1677081780000000000 AND (host='127.0.0.1') LIMIT 100000005 SLIMIT 100005`: &decoder.Response{
+			Results: []decoder.Result{
+				{
+					Series: []*decoder.Row{
+						{
+							Tags:    map[string]string{"host": "127.0.0.1"},
+							Columns: []string{influxdb.ResultColumnName, influxdb.TimeColumnName},
+							Values: [][]any{
+								{30, 1677081600000000000},
+								{21, 1677081660000000000},
+								{7, 1677081720000000000},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	query := &prompb.Query{
+		StartTimestampMs: 1677081600000,
+		EndTimestampMs:   1677081780000,
+		Matchers: []*prompb.LabelMatcher{
+			{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "datasource:result_table:influxdb:cpu_summary"},
+			{Type: prompb.LabelMatcher_EQ, Name: "host", Value: "127.0.0.1"},
+		},
+	}
+	expected := []prompb.Sample{
+		{Value: 30, Timestamp: 1677081600000},
+		{Value: 21, Timestamp: 1677081660000},
+		{Value: 7, Timestamp: 1677081720000},
+	}
+
+	t.Run("samples", func(t *testing.T) {
+		ctx := metadata.InitHashID(ctx)
+		metadata.SetUser(ctx, &metadata.User{SpaceUID: influxdb.SpaceUid})
+
+		w := remoteReadRequest(t, ctx, &prompb.ReadRequest{Queries: []*prompb.Query{query}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "snappy", w.Header().Get("Content-Encoding"))
+
+		data, err := snappy.Decode(nil, w.Body.Bytes())
+		require.NoError(t, err)
+		var resp prompb.ReadResponse
+		require.NoError(t, resp.Unmarshal(data))
+
+		require.Len(t, resp.Results, 1)
+		require.Len(t, resp.Results[0].Timeseries, 1)
+		assert.Contains(t, resp.Results[0].Timeseries[0].Labels, prompb.Label{Name: "host", Value: "127.0.0.1"})
+		assert.Equal(t, expected, resp.Results[0].Timeseries[0].Samples)
+	})
+
+	t.Run("streamed xor chunks", func(t *testing.T) {
+		ctx := metadata.InitHashID(ctx)
+		metadata.SetUser(ctx, &metadata.User{SpaceUID: influxdb.SpaceUid})
+
+		w := remoteReadRequest(t, ctx, &prompb.ReadRequest{
+			Queries:               []*prompb.Query{query},
+			AcceptedResponseTypes: []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse", w.Header().Get("Content-Type"))
+
+		var (
+			samples []prompb.Sample
+			reader  = promRemote.NewChunkedReader(w.Body, promRemote.DefaultChunkedReadLimit, nil)
+		)
+		for {
+			var resp prompb.ChunkedReadResponse
+			err := reader.NextProto(&resp)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+
+			for _, series := range resp.ChunkedSeries {
+				for _, chk := range series.Chunks {
+					c, err := chunkenc.FromData(chunkenc.EncXOR, chk.Data)
+					require.NoError(t, err)
+					it := c.Iterator(nil)
+					for it.Next() == chunkenc.ValFloat {
+						ts, v := it.At()
+						samples = append(samples, prompb.Sample{Value: v, Timestamp: ts})
+					}
+				}
+			}
+		}
+		assert.Equal(t, expected, samples)
+	})
+
+	t.Run("without metric name", func(t *testing.T) {
+		ctx := metadata.InitHashID(ctx)
+		metadata.SetUser(ctx, &metadata.User{SpaceUID: influxdb.SpaceUid})
+
+		w := remoteReadRequest(t, ctx, &prompb.ReadRequest{Queries: []*prompb.Query{{
+			StartTimestampMs: 1677081600000,
+			EndTimestampMs:   1677081780000,
+			Matchers: []*prompb.LabelMatcher{
+				{Type: prompb.LabelMatcher_RE, Name: "__name__", Value: "cpu_.*"},
+			},
+		}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "remote read query must contain a __name__ equal matcher", w.Body.String())
+	})
+}
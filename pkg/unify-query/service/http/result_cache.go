@@ -0,0 +1,73 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	promPromql "github.com/prometheus/prometheus/promql"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/resultcache"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/tsdb"
+)
+
+// directQueryRange range 查询优先复用结果缓存，缺失的区间按配置拆分后并发查询
+func directQueryRange(
+	ctx context.Context, query *structured.QueryTs, instance tsdb.Instance, stmt string,
+) (promPromql.Matrix, bool, error) {
+	qb := metadata.GetQueryParams(ctx)
+	direct := func(ctx context.Context, start, end time.Time) (promPromql.Matrix, bool, error) {
+		return instance.DirectQueryRange(ctx, stmt, start, end, qb.Step)
+	}
+
+	// reference 查询按原始时间范围取数，不能按时间拆分
+	if qb.IsReference {
+		return direct(ctx, qb.AlignStart, qb.End)
+	}
+
+	fetch := func(ctx context.Context, start, end time.Time) (promPromql.Matrix, bool, error) {
+		return splitQueryRange(ctx, start, end, qb.Step, direct)
+	}
+
+	c := resultcache.Default()
+	if c == nil {
+		return fetch(ctx, qb.AlignStart, qb.End)
+	}
+
+	return c.QueryRange(ctx, resultCacheKey(ctx, query, instance, stmt), qb.AlignStart, qb.End, qb.Step, fetch)
+}
+
+// resultCacheKey 使用去掉时间范围后的查询、空间以及最终查询语句生成缓存 key
+func resultCacheKey(ctx context.Context, query *structured.QueryTs, instance tsdb.Instance, stmt string) string {
+	user := metadata.GetUser(ctx)
+
+	normalized := *query
+	normalized.Start = ""
+	normalized.End = ""
+	queryStr, _ := json.Marshal(normalized)
+	expandStr, _ := json.Marshal(metadata.GetExpand(ctx))
+
+	h := sha256.New()
+	for _, s := range []string{
+		user.TenantID, user.SpaceUID, user.SkipSpace,
+		instance.InstanceType(), stmt,
+		string(queryStr), string(expandStr),
+	} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return "result_cache:" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,48 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/tsdb"
+)
+
+type resultCacheTestInstance struct {
+	tsdb.DefaultInstance
+}
+
+func (i *resultCacheTestInstance) InstanceType() string {
+	return "test"
+}
+
+func TestResultCacheKey(t *testing.T) {
+	ctx := metadata.InitHashID(context.Background())
+	instance := &resultCacheTestInstance{}
+
+	key := func(spaceUID string, query *structured.QueryTs) string {
+		metadata.SetUser(ctx, &metadata.User{SpaceUID: spaceUID})
+		return resultCacheKey(ctx, query, instance, "sum(a)")
+	}
+
+	base := key("bkcc__2", &structured.QueryTs{Start: "1741056443", End: "1741060043", Step: "1m"})
+
+	// 时间范围不参与 key 计算
+	assert.Equal(t, base, key("bkcc__2", &structured.QueryTs{Start: "1741000000", End: "1741099999", Step: "1m"}))
+	// 空间不同不能复用
+	assert.NotEqual(t, base, key("bkcc__3", &structured.QueryTs{Start: "1741056443", End: "1741060043", Step: "1m"}))
+	// 查询参数不同不能复用
+	assert.NotEqual(t, base, key("bkcc__2", &structured.QueryTs{Start: "1741056443", End: "1741060043", Step: "1m", Timezone: "Asia/Shanghai"}))
+}
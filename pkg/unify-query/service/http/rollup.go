@@ -0,0 +1,75 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/rollup"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+// routeRollup step 足够大时将查询路由到降精度结果表，instant 查询不处理
+func routeRollup(ctx context.Context, queryTs *structured.QueryTs) {
+	router := rollup.Default()
+	if router == nil || queryTs.Instant || queryTs.SkipRollup {
+		return
+	}
+
+	var err error
+	ctx, span := trace.NewSpan(ctx, "route-rollup")
+	defer span.End(&err)
+
+	spaceUid := queryTs.SpaceUid
+	if spaceUid == "" {
+		spaceUid = metadata.GetUser(ctx).SpaceUID
+	}
+
+	for _, ql := range queryTs.QueryList {
+		stepStr := ql.Step
+		if stepStr == "" {
+			stepStr = queryTs.Step
+		}
+		step, parseErr := model.ParseDuration(stepStr)
+		if parseErr != nil {
+			continue
+		}
+
+		tableID := ql.TableID
+		if t, ok := router.Route(ctx, spaceUid, time.Duration(step), ql); ok {
+			span.Set("rollup-"+ql.ReferenceName, fmt.Sprintf("%s -> %s", tableID, t.TableID))
+		}
+	}
+}
+
+// rollupResolution 返回各查询使用的数据精度，都未命中降精度结果表时返回空
+func rollupResolution(queryTs *structured.QueryTs) map[string]string {
+	var isRollup bool
+	resolution := make(map[string]string, len(queryTs.QueryList))
+	for _, ql := range queryTs.QueryList {
+		if ql.Rollup == "" {
+			resolution[ql.ReferenceName] = rollup.Raw
+			continue
+		}
+		resolution[ql.ReferenceName] = ql.Rollup
+		isRollup = true
+	}
+
+	if !isRollup {
+		return nil
+	}
+	return resolution
+}
@@ -0,0 +1,81 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/rollup"
+	routerInfluxdb "github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/router/influxdb"
+)
+
+type rollupMetadata struct{}
+
+func (rollupMetadata) GetSpace(_ context.Context, _ string) routerInfluxdb.Space {
+	return routerInfluxdb.Space{"system.cpu": {}, "2_rollup_5m.cpu": {}}
+}
+
+func (rollupMetadata) GetResultTable(_ context.Context, tableID string, _ bool) *routerInfluxdb.ResultTableDetail {
+	switch tableID {
+	case "system.cpu":
+		return &routerInfluxdb.ResultTableDetail{Labels: map[string]string{rollup.DefaultLabel: "5m=2_rollup_5m.cpu"}}
+	case "2_rollup_5m.cpu":
+		return &routerInfluxdb.ResultTableDetail{Fields: []string{"usage_max"}}
+	}
+	return nil
+}
+
+func TestRouteRollup(t *testing.T) {
+	rollup.SetDefault(rollup.NewRouter("", rollupMetadata{}))
+	defer rollup.SetDefault(nil)
+
+	newQueryTs := func(step string, instant bool) *structured.QueryTs {
+		return &structured.QueryTs{
+			SpaceUid: "bkcc__2",
+			Step:     step,
+			Instant:  instant,
+			QueryList: []*structured.Query{
+				{
+					TableID: "system.cpu", FieldName: "usage", ReferenceName: "a",
+					TimeAggregation: structured.TimeAggregation{Function: structured.MaxOT, Window: "10m"},
+				},
+				{
+					TableID: "system.cpu", FieldName: "usage", ReferenceName: "b",
+					TimeAggregation: structured.TimeAggregation{Function: structured.AvgOT, Window: "10m"},
+				},
+			},
+		}
+	}
+
+	ctx := context.Background()
+
+	queryTs := newQueryTs("10m", false)
+	routeRollup(ctx, queryTs)
+	assert.Equal(t, structured.TableID("2_rollup_5m.cpu"), queryTs.QueryList[0].TableID)
+	assert.Equal(t, "usage_max", queryTs.QueryList[0].FieldName)
+	assert.Equal(t, map[string]string{"a": "5m", "b": rollup.Raw}, rollupResolution(queryTs))
+
+	queryTs = newQueryTs("1m", false)
+	routeRollup(ctx, queryTs)
+	assert.Nil(t, rollupResolution(queryTs))
+
+	queryTs = newQueryTs("10m", true)
+	routeRollup(ctx, queryTs)
+	assert.Nil(t, rollupResolution(queryTs))
+
+	queryTs = newQueryTs("10m", false)
+	queryTs.SkipRollup = true
+	routeRollup(ctx, queryTs)
+	assert.Nil(t, rollupResolution(queryTs))
+}
@@ -58,8 +58,11 @@ const (
 	ProxyConfigPath                               = "http.path.proxy"
 	TSQueryRawMAXLimitConfigPath                  = "http.query.raw.max_limit"
 	TSQueryRawQueryWithScrollHandlePathConfigPath = "http.path.ts_raw_with_scroll"
+	TSQueryRawExportHandlePathConfigPath          = "http.path.ts_raw_export"
+	TSQuerySQLHandlePathConfigPath                = "http.path.ts_sql"
 	CheckQueryTsConfigPath                        = "http.path.check_query_ts"
 	CheckQueryPromQLConfigPath                    = "http.path.check_query_promql"
+	PromAPIPathConfigPath                         = "http.path.prom_api"
 
 	// 查询配置
 	InfoDefaultLimit = "http.info.limit"
@@ -68,6 +71,15 @@ const (
 	SegmentedEnable      = "http.segmented.enable"
 	SegmentedMaxRoutines = "http.segmented.max_routines"
 	SegmentedMinInterval = "http.segmented.min_interval"
+	SegmentedInterval    = "http.segmented.interval"
+
+	// remote read 配置
+	RemoteReadSampleLimitConfigPath     = "http.remote_read.sample_limit"
+	RemoteReadMaxBytesInFrameConfigPath = "http.remote_read.max_bytes_in_frame"
+
+	// 原始数据导出配置
+	RawExportMaxRowsConfigPath             = "http.query.raw.export.max_rows"
+	RawExportParquetRowGroupSizeConfigPath = "http.query.raw.export.parquet_row_group_size"
 
 	// 滚动查询配置
 	ScrollSliceLimitConfigPath         = "scroll.slice_limit"
@@ -116,6 +128,16 @@ var (
 
 	QueryMaxRouting int
 
+	SegmentedQueryEnable      bool
+	SegmentedQueryMaxRoutines int
+	SegmentedQueryInterval    time.Duration
+
+	RemoteReadSampleLimit     int
+	RemoteReadMaxBytesInFrame int
+
+	RawExportMaxRows             int
+	RawExportParquetRowGroupSize int
+
 	ClusterMetricQueryPrefix  string
 	ClusterMetricQueryTimeout time.Duration
 
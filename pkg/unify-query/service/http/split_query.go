@@ -0,0 +1,87 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	ants "github.com/panjf2000/ants/v2"
+	promPromql "github.com/prometheus/prometheus/promql"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+type queryRangeFunc func(ctx context.Context, start, end time.Time) (promPromql.Matrix, bool, error)
+
+// splitQueryRange 长时间范围的 range 查询按 step 对齐拆分为多段，使用有界协程池并发查询后合并结果
+func splitQueryRange(ctx context.Context, start, end time.Time, step time.Duration, fetch queryRangeFunc) (res promPromql.Matrix, isPartial bool, err error) {
+	if !SegmentedQueryEnable {
+		return fetch(ctx, start, end)
+	}
+
+	ranges := promql.SplitStepRange(start, end, step, SegmentedQueryInterval)
+	if len(ranges) <= 1 {
+		return fetch(ctx, start, end)
+	}
+
+	ctx, span := trace.NewSpan(ctx, "split-query-range")
+	defer span.End(&err)
+
+	span.Set("split-interval", SegmentedQueryInterval.String())
+	span.Set("split-num", len(ranges))
+
+	var (
+		wg       sync.WaitGroup
+		results  = make([]promPromql.Matrix, len(ranges))
+		partials = make([]bool, len(ranges))
+		errs     = make([]error, len(ranges))
+	)
+
+	p, _ := ants.NewPool(max(SegmentedQueryMaxRoutines, 1))
+	defer p.Release()
+
+	for i, r := range ranges {
+		wg.Add(1)
+		submitErr := p.Submit(func() {
+			defer wg.Done()
+
+			var subErr error
+			subCtx, subSpan := trace.NewSpan(ctx, "split-query-range-sub")
+			defer subSpan.End(&subErr)
+
+			subSpan.Set("sub-index", i)
+			subSpan.Set("sub-start", r.Start.String())
+			subSpan.Set("sub-end", r.End.String())
+
+			results[i], partials[i], subErr = fetch(subCtx, r.Start, r.End)
+			errs[i] = subErr
+		})
+		if submitErr != nil {
+			wg.Done()
+			errs[i] = submitErr
+		}
+	}
+	wg.Wait()
+
+	for i := range ranges {
+		if errs[i] != nil {
+			err = errs[i]
+			return
+		}
+		isPartial = isPartial || partials[i]
+	}
+
+	res = promql.MergeMatrix(results...)
+	span.Set("resp-series-num", len(res))
+	return
+}
@@ -0,0 +1,76 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	promPromql "github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitQueryRange(t *testing.T) {
+	enable, interval, routines := SegmentedQueryEnable, SegmentedQueryInterval, SegmentedQueryMaxRoutines
+	defer func() {
+		SegmentedQueryEnable, SegmentedQueryInterval, SegmentedQueryMaxRoutines = enable, interval, routines
+	}()
+	SegmentedQueryEnable, SegmentedQueryInterval, SegmentedQueryMaxRoutines = true, 24*time.Hour, 2
+
+	ctx := context.Background()
+	step := time.Hour
+	start := time.Unix(1741056443, 0)
+	end := start.Add(72*time.Hour + 30*time.Minute)
+
+	var (
+		lock  sync.Mutex
+		calls [][2]time.Time
+	)
+	fetch := func(_ context.Context, s, e time.Time) (promPromql.Matrix, bool, error) {
+		lock.Lock()
+		calls = append(calls, [2]time.Time{s, e})
+		lock.Unlock()
+
+		series := promPromql.Series{Metric: labels.FromStrings("a", "1")}
+		for ts := s; !ts.After(e); ts = ts.Add(step) {
+			series.Points = append(series.Points, promPromql.Point{T: ts.UnixMilli(), V: 1})
+		}
+		return promPromql.Matrix{series}, s.Equal(start), nil
+	}
+
+	res, isPartial, err := splitQueryRange(ctx, start, end, step, fetch)
+	assert.NoError(t, err)
+	assert.True(t, isPartial)
+	assert.Len(t, calls, 4)
+	assert.Len(t, res, 1)
+	assert.Len(t, res[0].Points, 73)
+	assert.Equal(t, start.UnixMilli(), res[0].Points[0].T)
+	assert.Equal(t, start.Add(72*time.Hour).UnixMilli(), res[0].Points[72].T)
+
+	// 任意子查询失败则整体失败
+	_, _, err = splitQueryRange(ctx, start, end, step, func(_ context.Context, s, e time.Time) (promPromql.Matrix, bool, error) {
+		if s.Equal(start) {
+			return nil, false, errors.New("timeout")
+		}
+		return nil, false, nil
+	})
+	assert.EqualError(t, err, "timeout")
+
+	// 未开启时不拆分
+	SegmentedQueryEnable = false
+	calls = nil
+	_, _, err = splitQueryRange(ctx, start, end, step, fetch)
+	assert.NoError(t, err)
+	assert.Len(t, calls, 1)
+}
@@ -0,0 +1,262 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/set"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/trace"
+)
+
+// SQLData SQL 查询结果，list 中每行的 key 为 columns 中的输出列名
+type SQLData struct {
+	Columns       []string         `json:"columns"`
+	Total         int64            `json:"total"`
+	List          []map[string]any `json:"list"`
+	TraceID       string           `json:"trace_id,omitempty"`
+	Status        *metadata.Status `json:"status"`
+	ResultTableID []string         `json:"result_table_id"`
+}
+
+// querySQL 把 SQL 转换为结构化查询后执行：聚合查询走 reference 存储聚合，非聚合查询走原始数据查询
+func querySQL(ctx context.Context, q *structured.QuerySQL) (*SQLData, error) {
+	stmt, err := q.Statement()
+	if err != nil {
+		return nil, metadata.NewMessage(
+			metadata.MsgQuerySQL,
+			"SQL 解析异常",
+		).Error(ctx, err)
+	}
+
+	if stmt.IsAggregate() {
+		return querySQLAggregate(ctx, q, stmt)
+	}
+	return querySQLRaw(ctx, q, stmt)
+}
+
+func querySQLRaw(ctx context.Context, q *structured.QuerySQL, stmt *structured.SQLStatement) (*SQLData, error) {
+	queryTs := q.RawQueryTs(stmt)
+	if err := validateQueryTsDataSource(queryTs); err != nil {
+		return nil, err
+	}
+
+	total, list, _, routeInfo, err := queryRawWithInstance(ctx, queryTs)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SQLData{
+		Total:         total,
+		List:          make([]map[string]any, 0, len(list)),
+		ResultTableID: resultTableIDFromRouteInfo(routeInfo),
+	}
+	if stmt.SelectAll {
+		data.List = append(data.List, list...)
+		if len(list) > 0 {
+			data.Columns = rowColumns(list[0])
+		}
+		return data, nil
+	}
+
+	data.Columns = stmt.ColumnNames()
+	for _, row := range list {
+		nr := make(map[string]any, len(stmt.Columns))
+		for _, c := range stmt.Columns {
+			nr[c.Name] = row[c.Field]
+		}
+		data.List = append(data.List, nr)
+	}
+	return data, nil
+}
+
+// querySQLAggregate 每个聚合列单独查询，按维度和时间分桶合并为行，再统一排序分页
+func querySQLAggregate(ctx context.Context, q *structured.QuerySQL, stmt *structured.SQLStatement) (*SQLData, error) {
+	var (
+		rows          = make([]map[string]any, 0)
+		index         = make(map[string]map[string]any)
+		resultTableID = set.New[string]()
+	)
+
+	for _, agg := range stmt.Aggregations() {
+		res, err := queryReferenceWithPromEngine(ctx, q.AggregateQueryTs(stmt, agg))
+		if err != nil {
+			return nil, err
+		}
+		resultTableID.Add(res.ResultTableID...)
+
+		for _, table := range res.Tables {
+			dims := make(map[string]string, len(table.GroupKeys))
+			for i, k := range table.GroupKeys {
+				if i < len(table.GroupValues) {
+					dims[k] = table.GroupValues[i]
+				}
+			}
+
+			for _, value := range table.Values {
+				if len(value) < 2 {
+					continue
+				}
+
+				keys := make([]string, 0, len(stmt.Dimensions)+1)
+				for _, d := range stmt.Dimensions {
+					keys = append(keys, dims[d])
+				}
+				if stmt.Window != "" {
+					keys = append(keys, cast.ToString(value[0]))
+				}
+				key := strings.Join(keys, "\x00")
+
+				row, ok := index[key]
+				if !ok {
+					row = make(map[string]any, len(stmt.Columns))
+					for _, c := range stmt.Columns {
+						switch {
+						case c.IsTime:
+							row[c.Name] = value[0]
+						case c.Method == "":
+							row[c.Name] = dims[c.Field]
+						default:
+							row[c.Name] = nil
+						}
+					}
+					index[key] = row
+					rows = append(rows, row)
+				}
+				row[agg.Name] = value[1]
+			}
+		}
+	}
+
+	sortSQLRows(rows, stmt.OrderBy)
+
+	data := &SQLData{
+		Columns:       stmt.ColumnNames(),
+		Total:         int64(len(rows)),
+		ResultTableID: resultTableID.ToArray(),
+	}
+	sort.Strings(data.ResultTableID)
+
+	from := min(stmt.From, len(rows))
+	end := len(rows)
+	if stmt.Limit > 0 {
+		end = min(from+stmt.Limit, end)
+	}
+	data.List = rows[from:end]
+	return data, nil
+}
+
+func sortSQLRows(rows []map[string]any, orderBy structured.OrderBy) {
+	orders := orderBy.Orders()
+	if len(orders) == 0 {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, o := range orders {
+			c := compareSQLValue(rows[i][o.Name], rows[j][o.Name])
+			if c == 0 {
+				continue
+			}
+			if o.Ast {
+				return c < 0
+			}
+			return c > 0
+		}
+		return false
+	})
+}
+
+// compareSQLValue 数值按大小比较，其余按字符串比较，nil 最小
+func compareSQLValue(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	fa, errA := cast.ToFloat64E(a)
+	fb, errB := cast.ToFloat64E(b)
+	if errA == nil && errB == nil {
+		return cmp.Compare(fa, fb)
+	}
+	return strings.Compare(cast.ToString(a), cast.ToString(b))
+}
+
+// HandlerQuerySQL
+// @Summary query by sql, translate sql to storage query such as elasticsearch dsl
+// @ID query_sql
+// @Produce json
+// @Param    traceparent            header    string                        false  "TraceID" default(00-3967ac0f1648bf0216b27631730d7eb9-8e3c31d5109e78dd-01)
+// @Param    Bk-Query-Source   		header    string                        false  "来源" default(username:goodman)
+// @Param    X-Bk-Scope-Space-Uid   header    string                        false  "空间UID" default(bkcc__2)
+// @Param	 X-Bk-Scope-Skip-Space  header	  string						false  "是否跳过空间验证" default()
+// @Param    data                  	body      structured.QuerySQL  			true   "json data"
+// @Success  200                   	{object}  SQLData
+// @Failure  400                   	{object}  ErrResponse
+// @Router   /query/ts/sql [post]
+func HandlerQuerySQL(c *gin.Context) {
+	var (
+		ctx  = c.Request.Context()
+		resp = &response{c: c}
+		user = metadata.GetUser(ctx)
+		err  error
+		span *trace.Span
+	)
+
+	ctx, span = trace.NewSpan(ctx, "handler-query-sql")
+	defer func() {
+		span.End(&err)
+	}()
+
+	span.Set("request-url", c.Request.URL.String())
+	span.Set("request-header", c.Request.Header)
+
+	span.Set("query-source", user.Key)
+	span.Set("query-tenant-id", user.TenantID)
+	span.Set("query-space-uid", user.SpaceUID)
+
+	query := &structured.QuerySQL{}
+	err = json.NewDecoder(c.Request.Body).Decode(query)
+	if err != nil {
+		resp.failed(ctx, err)
+		return
+	}
+
+	// metadata 中的 spaceUid 是从 header 头信息中获取
+	if user.SpaceUID != "" {
+		query.SpaceUid = user.SpaceUID
+	}
+	span.Set("query-sql", query.SQL)
+
+	data, err := querySQL(ctx, query)
+	if err != nil {
+		resp.failed(ctx, err)
+		return
+	}
+
+	data.TraceID = span.TraceID()
+	data.Status = metadata.GetStatus(ctx)
+	if data.Columns == nil {
+		data.Columns = make([]string, 0)
+	}
+	resp.success(ctx, data)
+}
@@ -0,0 +1,80 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/influxdb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/metadata"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/mock"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/promql"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/query/structured"
+)
+
+func TestQuerySQL(t *testing.T) {
+	mock.Init()
+	promql.MockEngine()
+	ctx := metadata.InitHashID(context.Background())
+	influxdb.MockSpaceRouter(ctx)
+
+	mock.Es.Set(map[string]any{
+		`{"aggregations":{"_value":{"value_count":{"field":"_index"}}},"query":{"bool":{"filter":[{"match_phrase":{"level":{"query":"error"}}},{"range":{"dtEventTimeStamp":{"format":"epoch_second","from":1741154079,"include_lower":true,"include_upper":true,"to":1741155879}}}]}},"size":0}`:                                                                `{"took":5,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},"hits":{"total":{"value":3,"relation":"eq"},"max_score":null,"hits":[]},"aggregations":{"_value":{"value":12}}}`,
+		`{"aggregations":{"__ext.container_name":{"aggregations":{"_value":{"value_count":{"field":"_index"}}},"terms":{"field":"__ext.container_name","missing":" ","size":10000}}},"query":{"bool":{"filter":{"range":{"dtEventTimeStamp":{"format":"epoch_second","from":1741154079,"include_lower":true,"include_upper":true,"to":1741155879}}}}},"size":0}`: `{"took":5,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},"hits":{"total":{"value":3,"relation":"eq"},"max_score":null,"hits":[]},"aggregations":{"__ext.container_name":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,"buckets":[{"key":"unify-query","doc_count":3,"_value":{"value":3}},{"key":"bkmonitorbeat","doc_count":5,"_value":{"value":5}}]}}}`,
+		`{"aggregations":{"__ext.container_name":{"aggregations":{"_value":{"max":{"field":"gseIndex"}}},"terms":{"field":"__ext.container_name","missing":" ","size":10000}}},"query":{"bool":{"filter":{"range":{"dtEventTimeStamp":{"format":"epoch_second","from":1741154079,"include_lower":true,"include_upper":true,"to":1741155879}}}}},"size":0}`:       `{"took":5,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},"hits":{"total":{"value":3,"relation":"eq"},"max_score":null,"hits":[]},"aggregations":{"__ext.container_name":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,"buckets":[{"key":"unify-query","doc_count":3,"_value":{"value":100}},{"key":"bkmonitorbeat","doc_count":5,"_value":{"value":200}}]}}}`,
+		`{"_source":{"includes":["level"]},"from":0,"query":{"bool":{"filter":[{"range":{"gseIndex":{"from":"1","include_lower":false,"include_upper":true,"to":null}}},{"range":{"dtEventTimeStamp":{"format":"epoch_second","from":1741154079,"include_lower":true,"include_upper":true,"to":1741155879}}}]}},"size":2}`:                                       `{"took":5,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},"hits":{"total":{"value":2,"relation":"eq"},"max_score":null,"hits":[{"_index":"es_index","_id":"1","_score":null,"_source":{"level":"error"}},{"_index":"es_index","_id":"2","_score":null,"_source":{"level":"info"}}]}}`,
+	})
+
+	for name, c := range map[string]struct {
+		sql      string
+		expected string
+		err      string
+	}{
+		"count": {
+			sql:      "SELECT count(*) AS cnt FROM result_table.es WHERE level = 'error'",
+			expected: `{"columns":["cnt"],"total":1,"list":[{"cnt":12}],"status":null,"result_table_id":["result_table.es"]}`,
+		},
+		"group by": {
+			sql:      "SELECT __ext.container_name AS container, count(*) AS cnt, max(gseIndex) FROM result_table.es GROUP BY container ORDER BY cnt DESC",
+			expected: `{"columns":["container","cnt","max(gseIndex)"],"total":2,"list":[{"cnt":5,"container":"bkmonitorbeat","max(gseIndex)":200},{"cnt":3,"container":"unify-query","max(gseIndex)":100}],"status":null,"result_table_id":["result_table.es"]}`,
+		},
+		"raw": {
+			sql:      "SELECT level AS l FROM result_table.es WHERE gseIndex > 1 LIMIT 2",
+			expected: `{"columns":["l"],"total":2,"list":[{"l":"error"},{"l":"info"}],"status":null,"result_table_id":["result_table.es"]}`,
+		},
+		"parse error": {
+			sql: "SELECT level FROM result_table.es a JOIN result_table.bk_log b ON a.level = b.level",
+			err: "SQL 解析异常",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.InitHashID(ctx)
+			data, err := querySQL(ctx, &structured.QuerySQL{
+				SQL:      c.sql,
+				SpaceUid: influxdb.SpaceUid,
+				Start:    "1741154079",
+				End:      "1741155879",
+			})
+			if c.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.err)
+				return
+			}
+			require.NoError(t, err)
+			actual, _ := json.Marshal(data)
+			assert.JSONEq(t, c.expected, string(actual))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/eventbus"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+)
+
+// setDefaultConfig
+func setDefaultConfig() {
+	viper.SetDefault(EnabledConfigPath, false)
+	viper.SetDefault(DefaultIntervalConfigPath, "1m")
+	viper.SetDefault(EvalTimeoutConfigPath, "30s")
+
+	viper.SetDefault(SinkTypeConfigPath, SinkTypeRemoteWrite)
+	viper.SetDefault(RemoteWriteTimeoutConfigPath, "10s")
+	viper.SetDefault(FilePathConfigPath, "record_rule.ndjson")
+}
+
+// LoadConfig
+func LoadConfig() {
+	Enabled = viper.GetBool(EnabledConfigPath)
+	Spaces = viper.GetStringMapStringSlice(SpacesConfigPath)
+	DefaultInterval = viper.GetDuration(DefaultIntervalConfigPath)
+	EvalTimeout = viper.GetDuration(EvalTimeoutConfigPath)
+
+	SinkType = viper.GetString(SinkTypeConfigPath)
+	RemoteWriteURL = viper.GetString(RemoteWriteURLConfigPath)
+	RemoteWriteTimeout = viper.GetDuration(RemoteWriteTimeoutConfigPath)
+	RemoteWriteHeaders = viper.GetStringMapString(RemoteWriteHeadersConfigPath)
+	FilePath = viper.GetString(FilePathConfigPath)
+
+	log.Debugf(context.TODO(),
+		"reload success new config: enabled:%v,spaces:%d,default_interval:%s,sink_type:%s",
+		Enabled, len(Spaces), DefaultInterval, SinkType,
+	)
+}
+
+// init
+func init() {
+	if err := eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPreParse, setDefaultConfig); err != nil {
+		fmt.Printf(
+			"failed to subscribe event->[%s] for record rule module for default config, maybe record rule module won't working.",
+			eventbus.EventSignalConfigPreParse,
+		)
+	}
+
+	if err := eventbus.EventBus.Subscribe(eventbus.EventSignalConfigPostParse, LoadConfig); err != nil {
+		fmt.Printf(
+			"failed to subscribe event->[%s] for record rule module for new config, maybe record rule module won't working.",
+			eventbus.EventSignalConfigPostParse,
+		)
+	}
+}
@@ -0,0 +1,129 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	promPromql "github.com/prometheus/prometheus/promql"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/log"
+	inner "github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/recordrule"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/unify-query/service/http"
+)
+
+// Service recording rule 计算服务，每次 Reload 重新加载规则文件
+type Service struct {
+	cancelFunc context.CancelFunc
+	evaluator  *inner.Evaluator
+	sink       inner.Sink
+}
+
+// Type
+func (s *Service) Type() string {
+	return "record_rule"
+}
+
+// Start
+func (s *Service) Start(ctx context.Context) {
+	s.Reload(ctx)
+}
+
+// Reload
+func (s *Service) Reload(ctx context.Context) {
+	s.Close()
+	s.Wait()
+
+	if !Enabled {
+		log.Infof(ctx, "record rule service is disabled")
+		return
+	}
+
+	groups, err := loadGroups()
+	if err != nil {
+		log.Errorf(ctx, "record rule service load rules error: %s", err)
+		return
+	}
+
+	sink, err := newSink()
+	if err != nil {
+		log.Errorf(ctx, "record rule service init sink error: %s", err)
+		return
+	}
+
+	var runCtx context.Context
+	runCtx, s.cancelFunc = context.WithCancel(ctx)
+	s.sink = sink
+	s.evaluator = inner.NewEvaluator(groups, queryInstant, sink, EvalTimeout)
+	s.evaluator.Run(runCtx)
+
+	log.Infof(ctx, "record rule service start success, groups: %d", len(groups))
+}
+
+// Wait
+func (s *Service) Wait() {
+	if s.evaluator != nil {
+		s.evaluator.Wait()
+		s.evaluator = nil
+	}
+	if s.sink != nil {
+		if err := s.sink.Close(); err != nil {
+			log.Warnf(context.TODO(), "record rule service close sink error: %s", err)
+		}
+		s.sink = nil
+	}
+}
+
+// Close
+func (s *Service) Close() {
+	if s.cancelFunc != nil {
+		s.cancelFunc()
+		s.cancelFunc = nil
+	}
+}
+
+func queryInstant(ctx context.Context, _ string, expr string, ts time.Time) (promPromql.Vector, error) {
+	return http.QueryInstant(ctx, expr, ts)
+}
+
+func loadGroups() ([]*inner.Group, error) {
+	spaceUIDs := make([]string, 0, len(Spaces))
+	for spaceUID := range Spaces {
+		spaceUIDs = append(spaceUIDs, spaceUID)
+	}
+	sort.Strings(spaceUIDs)
+
+	var groups []*inner.Group
+	for _, spaceUID := range spaceUIDs {
+		g, err := inner.LoadGroups(spaceUID, DefaultInterval, Spaces[spaceUID]...)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g...)
+	}
+	return groups, nil
+}
+
+func newSink() (inner.Sink, error) {
+	switch SinkType {
+	case SinkTypeRemoteWrite:
+		if RemoteWriteURL == "" {
+			return nil, fmt.Errorf("%s is empty", RemoteWriteURLConfigPath)
+		}
+		return inner.NewRemoteWriteSink(RemoteWriteURL, RemoteWriteHeaders, RemoteWriteTimeout), nil
+	case SinkTypeFile:
+		return inner.NewFileSink(FilePath)
+	default:
+		return nil, fmt.Errorf("unknown record rule sink type: %s", SinkType)
+	}
+}
@@ -0,0 +1,47 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package recordrule
+
+import (
+	"time"
+)
+
+const (
+	EnabledConfigPath = "record_rule.enabled"
+	// SpacesConfigPath 空间对应的 Prometheus 规则文件，key 为 space_uid，value 为规则文件路径列表（支持通配符）
+	SpacesConfigPath          = "record_rule.spaces"
+	DefaultIntervalConfigPath = "record_rule.default_interval"
+	EvalTimeoutConfigPath     = "record_rule.eval_timeout"
+
+	// SinkTypeConfigPath 结果写入方式：remote_write 或 file
+	SinkTypeConfigPath           = "record_rule.sink.type"
+	RemoteWriteURLConfigPath     = "record_rule.sink.remote_write.url"
+	RemoteWriteTimeoutConfigPath = "record_rule.sink.remote_write.timeout"
+	RemoteWriteHeadersConfigPath = "record_rule.sink.remote_write.headers"
+	FilePathConfigPath           = "record_rule.sink.file.path"
+)
+
+const (
+	SinkTypeRemoteWrite = "remote_write"
+	SinkTypeFile        = "file"
+)
+
+var (
+	Enabled         bool
+	Spaces          map[string][]string
+	DefaultInterval time.Duration
+	EvalTimeout     time.Duration
+
+	SinkType           string
+	RemoteWriteURL     string
+	RemoteWriteTimeout time.Duration
+	RemoteWriteHeaders map[string]string
+	FilePath           string
+)